@@ -7,6 +7,7 @@ require (
 	github.com/disintegration/imaging v1.5.0
 	github.com/docker/docker v1.4.2-0.20190818020526-0c46a20f9471
 	github.com/dsnet/compress v0.0.0-20171208185109-cc9eb1d7ad76 // indirect
+	github.com/eclipse/paho.mqtt.golang v1.2.0
 	github.com/enr/go-commons v0.0.0-20150504121636-bcd3f40eeea8 // indirect
 	github.com/fatih/color v1.7.0
 	github.com/fortytw2/leaktest v1.2.0
@@ -33,6 +34,7 @@ require (
 	go-home.io/x/server/plugins v0.0.0-20181025030525-18e916b213bc
 	golang.org/x/crypto v0.0.0-20180904163835-0709b304e793
 	golang.org/x/image v0.0.0-20180708004352-c73c2afc3b81 // indirect
+	golang.org/x/net v0.0.0-20180906233101-161cd47e91fd // indirect
 	golang.org/x/sys v0.0.0-20180905080454-ebe1bf3edb33 // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/go-playground/validator.v9 v9.21.0
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"sync"
+
 	"github.com/jessevdk/go-flags"
 	"go-home.io/x/server/server"
 	"go-home.io/x/server/settings"
@@ -14,6 +16,11 @@ func main() {
 		panic(err)
 	}
 
+	if options.AllInOne {
+		startAllInOne(options)
+		return
+	}
+
 	s := settings.Load(options)
 	s.SystemLogger().Info("Starting go-home server")
 
@@ -33,3 +40,35 @@ func main() {
 		srv.Start()
 	}
 }
+
+// Starts master and worker in the same process.
+// Both are reading the same configs, so they should have master and worker definitions.
+func startAllInOne(options *settings.StartUpOptions) {
+	workerOptions := *options
+	workerOptions.IsWorker = true
+	options.IsWorker = false
+
+	ms := settings.Load(options)
+	ws := settings.Load(&workerOptions)
+	ms.SystemLogger().Info("Starting go-home in all-in-one mode")
+
+	srv, err := server.NewServer(ms)
+	if err != nil {
+		ms.SystemLogger().Fatal("Failed to start go-home server", err)
+	}
+
+	wkr, err := worker.NewWorker(ws)
+	if err != nil {
+		ws.SystemLogger().Fatal("Failed to start go-home worker", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wkr.Start()
+	}()
+
+	srv.Start()
+	wg.Wait()
+}
@@ -0,0 +1,40 @@
+//+build !release
+
+package mocks
+
+import (
+	"sync"
+
+	"go-home.io/x/server/plugins/audit"
+)
+
+// IFakeAudit adds additional capabilities to a fake audit provider.
+type IFakeAudit interface {
+	GetEntries() []*audit.Entry
+}
+
+type fakeAudit struct {
+	sync.Mutex
+	entries []*audit.Entry
+}
+
+func (f *fakeAudit) Record(e *audit.Entry) {
+	f.Lock()
+	defer f.Unlock()
+	f.entries = append(f.entries, e)
+}
+
+func (f *fakeAudit) Query(*audit.Query) ([]*audit.Entry, error) {
+	return f.GetEntries(), nil
+}
+
+func (f *fakeAudit) GetEntries() []*audit.Entry {
+	f.Lock()
+	defer f.Unlock()
+	return append([]*audit.Entry(nil), f.entries...)
+}
+
+// FakeNewAudit creates a new fake audit provider.
+func FakeNewAudit() *fakeAudit {
+	return &fakeAudit{entries: make([]*audit.Entry, 0)}
+}
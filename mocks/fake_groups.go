@@ -9,6 +9,7 @@ import (
 
 type fakeGroups struct {
 	groupID  string
+	parent   string
 	devices  []string
 	callback func()
 }
@@ -25,6 +26,17 @@ func (f *fakeGroups) Icon() string {
 	return ""
 }
 
+func (f *fakeGroups) Parent() string {
+	return f.parent
+}
+
+func (f *fakeGroups) Nests(string) bool {
+	return false
+}
+
+func (f *fakeGroups) ExcludeNested(string) {
+}
+
 func (f *fakeGroups) InvokeCommand(enums.Command, map[string]interface{}) {
 	if nil != f.callback {
 		f.callback()
@@ -48,3 +60,12 @@ func FakeNewLocationProvider(groupID string, devices []string, callback func())
 		callback: callback,
 	}
 }
+
+// FakeNewChildLocationProvider creates a new fake location provider with a parent.
+func FakeNewChildLocationProvider(groupID string, parent string, devices []string) providers.ILocationProvider {
+	return &fakeGroups{
+		devices: devices,
+		groupID: groupID,
+		parent:  parent,
+	}
+}
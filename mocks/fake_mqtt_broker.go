@@ -0,0 +1,311 @@
+//+build !release
+
+package mocks
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// FakeMQTTMessage has message, received by the fake broker.
+type FakeMQTTMessage struct {
+	Topic    string
+	Payload  []byte
+	QoS      byte
+	Retained bool
+}
+
+// IFakeMQTTBroker adds additional capabilities to a fake MQTT broker.
+type IFakeMQTTBroker interface {
+	Address() string
+	Publish(topic string, payload []byte, retained bool)
+	Retained(topic string) []byte
+	Messages(topic string) []*FakeMQTTMessage
+	Clients() int
+	DropClients()
+	Close()
+}
+
+// In-process MQTT 3.1.1 broker.
+// Messages are always delivered with QoS 0.
+type fakeMQTTBroker struct {
+	sync.Mutex
+	listener net.Listener
+	clients  map[*fakeMQTTClient]bool
+	retained map[string][]byte
+	messages []*FakeMQTTMessage
+}
+
+// Connected client.
+type fakeMQTTClient struct {
+	sync.Mutex
+	conn    net.Conn
+	filters map[string]bool
+	will    *FakeMQTTMessage
+}
+
+// FakeNewMQTTBroker starts a new in-process MQTT broker on a random local port.
+func FakeNewMQTTBroker() IFakeMQTTBroker {
+	return FakeNewMQTTBrokerAt("127.0.0.1:0")
+}
+
+// FakeNewMQTTBrokerAt starts a new in-process MQTT broker on the address.
+func FakeNewMQTTBrokerAt(address string) IFakeMQTTBroker {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		panic(err)
+	}
+
+	b := &fakeMQTTBroker{
+		listener: l,
+		clients:  make(map[*fakeMQTTClient]bool),
+		retained: make(map[string][]byte),
+		messages: make([]*FakeMQTTMessage, 0),
+	}
+
+	go b.accept()
+	return b
+}
+
+// Address returns broker URL.
+func (b *fakeMQTTBroker) Address() string {
+	return fmt.Sprintf("tcp://%s", b.listener.Addr().String())
+}
+
+// Publish sends message to subscribers.
+func (b *fakeMQTTBroker) Publish(topic string, payload []byte, retained bool) {
+	b.route(&FakeMQTTMessage{Topic: topic, Payload: payload, Retained: retained})
+}
+
+// Retained returns retained message payload.
+func (b *fakeMQTTBroker) Retained(topic string) []byte {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.retained[topic]
+}
+
+// Messages returns all messages, published to the topic filter.
+func (b *fakeMQTTBroker) Messages(filter string) []*FakeMQTTMessage {
+	b.Lock()
+	defer b.Unlock()
+
+	result := make([]*FakeMQTTMessage, 0)
+	for _, v := range b.messages {
+		if FakeMQTTTopicMatch(filter, v.Topic) {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// Clients returns number of connected clients.
+func (b *fakeMQTTBroker) Clients() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return len(b.clients)
+}
+
+// DropClients closes all client connections, simulating network failure.
+func (b *fakeMQTTBroker) DropClients() {
+	b.Lock()
+	defer b.Unlock()
+
+	for c := range b.clients {
+		c.conn.Close() // nolint: gosec, errcheck
+	}
+}
+
+// Close stops the broker.
+func (b *fakeMQTTBroker) Close() {
+	b.listener.Close() // nolint: gosec, errcheck
+	b.DropClients()
+}
+
+// Accepts new connections.
+func (b *fakeMQTTBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go b.serve(&fakeMQTTClient{conn: conn, filters: make(map[string]bool)})
+	}
+}
+
+// Processes client packets.
+// nolint: gocyclo
+func (b *fakeMQTTBroker) serve(c *fakeMQTTClient) {
+	defer func() {
+		c.conn.Close() // nolint: gosec, errcheck
+		b.Lock()
+		delete(b.clients, c)
+		b.Unlock()
+
+		if nil != c.will {
+			b.route(c.will)
+		}
+	}()
+
+	for {
+		p, err := packets.ReadPacket(c.conn)
+		if err != nil {
+			return
+		}
+
+		switch v := p.(type) {
+		case *packets.ConnectPacket:
+			if v.WillFlag {
+				c.will = &FakeMQTTMessage{Topic: v.WillTopic, Payload: v.WillMessage, QoS: v.WillQos,
+					Retained: v.WillRetain}
+			}
+
+			b.Lock()
+			b.clients[c] = true
+			b.Unlock()
+			c.write(packets.NewControlPacket(packets.Connack))
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = v.MessageID
+			c.Lock()
+			for i, t := range v.Topics {
+				c.filters[t] = true
+				ack.ReturnCodes = append(ack.ReturnCodes, v.Qoss[i])
+			}
+			c.Unlock()
+
+			c.write(ack)
+			b.sendRetained(c, v.Topics)
+		case *packets.UnsubscribePacket:
+			c.Lock()
+			for _, t := range v.Topics {
+				delete(c.filters, t)
+			}
+			c.Unlock()
+
+			ack := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			ack.MessageID = v.MessageID
+			c.write(ack)
+		case *packets.PublishPacket:
+			switch v.Qos {
+			case 1:
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = v.MessageID
+				c.write(ack)
+			case 2:
+				ack := packets.NewControlPacket(packets.Pubrec).(*packets.PubrecPacket)
+				ack.MessageID = v.MessageID
+				c.write(ack)
+			}
+
+			b.route(&FakeMQTTMessage{Topic: v.TopicName, Payload: v.Payload, QoS: v.Qos, Retained: v.Retain})
+		case *packets.PubrelPacket:
+			ack := packets.NewControlPacket(packets.Pubcomp).(*packets.PubcompPacket)
+			ack.MessageID = v.MessageID
+			c.write(ack)
+		case *packets.PingreqPacket:
+			c.write(packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			c.will = nil
+			return
+		}
+	}
+}
+
+// Stores retained message and delivers it to subscribers.
+func (b *fakeMQTTBroker) route(msg *FakeMQTTMessage) {
+	b.Lock()
+	b.messages = append(b.messages, msg)
+	if msg.Retained {
+		if 0 == len(msg.Payload) {
+			delete(b.retained, msg.Topic)
+		} else {
+			b.retained[msg.Topic] = msg.Payload
+		}
+	}
+
+	clients := make([]*fakeMQTTClient, 0, len(b.clients))
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+	b.Unlock()
+
+	for _, c := range clients {
+		if c.isSubscribed(msg.Topic) {
+			c.publish(msg.Topic, msg.Payload, false)
+		}
+	}
+}
+
+// Sends retained messages, matching new subscriptions.
+func (b *fakeMQTTBroker) sendRetained(c *fakeMQTTClient, filters []string) {
+	b.Lock()
+	matched := make(map[string][]byte)
+	for topic, payload := range b.retained {
+		for _, f := range filters {
+			if FakeMQTTTopicMatch(f, topic) {
+				matched[topic] = payload
+			}
+		}
+	}
+	b.Unlock()
+
+	for topic, payload := range matched {
+		c.publish(topic, payload, true)
+	}
+}
+
+// Checks whether client is subscribed to the topic.
+func (c *fakeMQTTClient) isSubscribed(topic string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	for f := range c.filters {
+		if FakeMQTTTopicMatch(f, topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sends message to the client.
+func (c *fakeMQTTClient) publish(topic string, payload []byte, retained bool) {
+	p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	p.TopicName = topic
+	p.Payload = payload
+	p.Retain = retained
+	c.write(p)
+}
+
+// Writes packet to the client connection.
+func (c *fakeMQTTClient) write(p packets.ControlPacket) {
+	c.Lock()
+	defer c.Unlock()
+
+	p.Write(c.conn) // nolint: gosec, errcheck
+}
+
+// FakeMQTTTopicMatch checks whether topic matches MQTT filter with + and # wildcards.
+func FakeMQTTTopicMatch(filter string, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, v := range f {
+		if "#" == v {
+			return true
+		}
+
+		if i >= len(t) || ("+" != v && v != t[i]) {
+			return false
+		}
+	}
+
+	return len(f) == len(t)
+}
@@ -0,0 +1,41 @@
+//+build !release
+
+package mocks
+
+import (
+	"sync"
+
+	"go-home.io/x/server/providers"
+	"gopkg.in/yaml.v2"
+)
+
+type fakePersistentStore struct {
+	sync.Mutex
+	data map[string][]byte
+}
+
+func (f *fakePersistentStore) Load(key string, target interface{}) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	v, ok := f.data[key]
+	if !ok {
+		return false
+	}
+
+	return nil == yaml.Unmarshal(v, target)
+}
+
+func (f *fakePersistentStore) Save(key string, data interface{}) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.data[key], _ = yaml.Marshal(data)
+}
+
+// FakeNewPersistentStore creates a new in-memory persistent store.
+func FakeNewPersistentStore() providers.IPersistentStoreProvider {
+	return &fakePersistentStore{
+		data: make(map[string][]byte),
+	}
+}
@@ -0,0 +1,43 @@
+//+build !release
+
+package mocks
+
+import (
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+type fakeScene struct {
+	sceneID  string
+	devices  []string
+	callback func(enums.Command)
+}
+
+func (f *fakeScene) ID() string {
+	return f.sceneID
+}
+
+func (f *fakeScene) Devices() []string {
+	return f.devices
+}
+
+func (f *fakeScene) InvokeCommand(cmd enums.Command, _ map[string]interface{}) {
+	if nil != f.callback {
+		f.callback(cmd)
+	}
+}
+
+func (f *fakeScene) Capture() {
+	if nil != f.callback {
+		f.callback(enums.CmdSnapshot)
+	}
+}
+
+// FakeNewSceneProvider creates a new fake scene provider.
+func FakeNewSceneProvider(sceneID string, devices []string, callback func(enums.Command)) providers.ISceneProvider {
+	return &fakeScene{
+		sceneID:  sceneID,
+		devices:  devices,
+		callback: callback,
+	}
+}
@@ -14,12 +14,33 @@ type fakeSecurity struct {
 
 func (f *fakeSecurity) GetUser(map[string][]string) (providers.IAuthenticatedUser, error) {
 	if f.allow {
-		return &fakeAuthenticatedUser{}, nil
+		return &fakeAuthenticatedUser{allow: true}, nil
 	}
 
 	return nil, errors.New("not found")
 }
 
+func (f *fakeSecurity) GetUserByName(name string) (providers.IAuthenticatedUser, error) {
+	if f.allow {
+		return &fakeAuthenticatedUser{name: name}, nil
+	}
+
+	return nil, errors.New("not found")
+}
+
+func (f *fakeSecurity) CreateToken(providers.IAuthenticatedUser,
+	*providers.APITokenRequest) (*providers.APIToken, string, error) {
+	return nil, "", errors.New("not supported")
+}
+
+func (f *fakeSecurity) GetTokens(providers.IAuthenticatedUser) ([]*providers.APIToken, error) {
+	return []*providers.APIToken{}, nil
+}
+
+func (f *fakeSecurity) RevokeToken(providers.IAuthenticatedUser, string) error {
+	return errors.New("not found")
+}
+
 // FakeNewSecurityProvider creates a fake security provider.
 func FakeNewSecurityProvider(allow bool) *fakeSecurity {
 	return &fakeSecurity{
@@ -34,6 +55,7 @@ type IFakeAuthenticatedUser interface {
 
 type fakeAuthenticatedUser struct {
 	allow bool
+	name  string
 }
 
 func (f *fakeAuthenticatedUser) TriggerGet(string) bool {
@@ -48,11 +70,19 @@ func (f *fakeAuthenticatedUser) Logs() bool {
 	return f.allow
 }
 
+func (f *fakeAuthenticatedUser) Audit() bool {
+	return f.allow
+}
+
 func (f *fakeAuthenticatedUser) SetAllow(allow bool) {
 	f.allow = allow
 }
 
-func (*fakeAuthenticatedUser) Name() string {
+func (f *fakeAuthenticatedUser) Name() string {
+	if "" != f.name {
+		return f.name
+	}
+
 	return "test"
 }
 
@@ -75,3 +105,19 @@ func (f *fakeAuthenticatedUser) Workers() bool {
 func (f *fakeAuthenticatedUser) Entities() bool {
 	return f.allow
 }
+
+func (f *fakeAuthenticatedUser) AlarmArm(string) bool {
+	return f.allow
+}
+
+func (f *fakeAuthenticatedUser) AlarmDisarm(string) bool {
+	return f.allow
+}
+
+func (f *fakeAuthenticatedUser) TokenID() string {
+	return ""
+}
+
+func (f *fakeAuthenticatedUser) Groups() []string {
+	return nil
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	"sync"
+
 	"github.com/gobwas/glob"
 	"go-home.io/x/server/plugins/device/enums"
 	"go-home.io/x/server/providers"
@@ -11,24 +13,57 @@ import (
 // IFakeServer adds additional capabilities to a fake server.
 type IFakeServer interface {
 	AddDevice(device *providers.KnownDevice)
+	AddDeviceWithID(id string, device *providers.KnownDevice)
+	GetLastMasterUpdate() *providers.MasterDeviceUpdate
+	GetInvokedCommands() []enums.Command
+	GetNotifications() []string
+	SetHomeMode(string)
+	SetPersonPresence(string, string)
 }
 
 type fakeServer struct {
-	callback func()
-	device   *providers.KnownDevice
+	sync.Mutex
+
+	callback   func()
+	device     *providers.KnownDevice
+	devices    map[string]*providers.KnownDevice
+	lastUpdate *providers.MasterDeviceUpdate
+	invoked    []enums.Command
+	homeMode   string
+	messages   []string
+	presence   map[string]string
 }
 
-func (f *fakeServer) SendNotificationCommand(glob.Glob, string) {
+func (f *fakeServer) SendNotificationCommand(_ glob.Glob, msg string) {
+	f.Lock()
+	f.messages = append(f.messages, msg)
+	f.Unlock()
+
 	if nil != f.callback {
 		f.callback()
 	}
 }
 
-func (f *fakeServer) GetDevice(string) *providers.KnownDevice {
+func (f *fakeServer) GetDevice(id string) *providers.KnownDevice {
+	if d, ok := f.devices[id]; ok {
+		return d
+	}
+
 	return f.device
 }
 
-func (f *fakeServer) PushMasterDeviceUpdate(*providers.MasterDeviceUpdate) {
+func (f *fakeServer) PushMasterDeviceUpdate(update *providers.MasterDeviceUpdate) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.lastUpdate = update
+}
+
+func (f *fakeServer) GetLastMasterUpdate() *providers.MasterDeviceUpdate {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.lastUpdate
 }
 
 func (f *fakeServer) Start() {
@@ -36,18 +71,72 @@ func (f *fakeServer) Start() {
 
 func (f *fakeServer) InternalCommandInvokeDeviceCommand(deviceRegexp glob.Glob, cmd enums.Command,
 	data map[string]interface{}) {
+	f.Lock()
+	f.invoked = append(f.invoked, cmd)
+	f.Unlock()
+
 	if nil != f.callback {
 		f.callback()
 	}
 }
 
+func (f *fakeServer) GetInvokedCommands() []enums.Command {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.invoked
+}
+
+func (f *fakeServer) GetNotifications() []string {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.messages
+}
+
+func (f *fakeServer) GetHomeMode() string {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.homeMode
+}
+
+func (f *fakeServer) SetHomeMode(mode string) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.homeMode = mode
+}
+
+func (f *fakeServer) GetPersonPresence(id string) string {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.presence[id]
+}
+
+func (f *fakeServer) SetPersonPresence(id string, presence string) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.presence[id] = presence
+}
+
 func (f *fakeServer) AddDevice(device *providers.KnownDevice) {
 	f.device = device
 }
 
+func (f *fakeServer) AddDeviceWithID(id string, device *providers.KnownDevice) {
+	f.devices[id] = device
+}
+
 // FakeNewServer creates a new fake server.
 func FakeNewServer(callback func()) IFakeServer {
 	return &fakeServer{
 		callback: callback,
+		devices:  make(map[string]*providers.KnownDevice),
+		invoked:  make([]enums.Command, 0),
+		messages: make([]string, 0),
+		presence: make(map[string]string),
 	}
 }
@@ -9,7 +9,7 @@ import (
 	"go-home.io/x/server/providers"
 )
 
-//IFakeSettings adds additional capabilities to a fake server provider.
+// IFakeSettings adds additional capabilities to a fake server provider.
 type IFakeSettings interface {
 	AddLoader(returnOj interface{})
 	AddSBCallback(func(...interface{}))
@@ -26,8 +26,10 @@ type fakeSettings struct {
 	security       providers.ISecurityProvider
 	fanOut         providers.IInternalFanOutProvider
 	storage        providers.IStorageProvider
+	audit          providers.IAuditProvider
 	loader         providers.IPluginLoaderProvider
 	groups         []*providers.RawMasterComponent
+	scenes         []*providers.RawMasterComponent
 	externalAPI    []*providers.RawMasterComponent
 	triggers       []*providers.RawMasterComponent
 	notifications  []*providers.RawMasterComponent
@@ -46,10 +48,38 @@ func (f *fakeSettings) Storage() providers.IStorageProvider {
 	return FakeNewStorage()
 }
 
+func (f *fakeSettings) Audit() providers.IAuditProvider {
+	if nil != f.audit {
+		return f.audit
+	}
+
+	return FakeNewAudit()
+}
+
 func (f *fakeSettings) Groups() []*providers.RawMasterComponent {
 	return f.groups
 }
 
+func (f *fakeSettings) Scenes() []*providers.RawMasterComponent {
+	return f.scenes
+}
+
+func (f *fakeSettings) HomeMode() *providers.RawMasterComponent {
+	return nil
+}
+
+func (f *fakeSettings) Helpers() []*providers.RawMasterComponent {
+	return nil
+}
+
+func (f *fakeSettings) Alarms() []*providers.RawMasterComponent {
+	return nil
+}
+
+func (f *fakeSettings) Persons() []*providers.RawMasterComponent {
+	return nil
+}
+
 func (f *fakeSettings) ExtendedAPIs() []*providers.RawMasterComponent {
 	return f.externalAPI
 }
@@ -157,6 +187,8 @@ func FakeNewSettings(sbPublish func(string, ...interface{}), isWorker bool,
 		cron:     FakeNewCron(),
 		devices:  devices,
 		fanOut:   FakeNewFanOut(),
+		security: FakeNewSecurityProvider(true),
+		audit:    FakeNewAudit(),
 	}
 }
 
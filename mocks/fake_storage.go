@@ -5,6 +5,7 @@ package mocks
 import (
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
 )
 
 type fakeStorage struct {
@@ -16,6 +17,13 @@ func (*fakeStorage) Heartbeat(string) {
 func (*fakeStorage) State(*common.MsgDeviceUpdate) {
 }
 
+func (*fakeStorage) Flush() {
+}
+
+func (*fakeStorage) GetSpecs() *providers.StorageSpecs {
+	return &providers.StorageSpecs{IsConfigured: true, IsLoaded: true}
+}
+
 func (*fakeStorage) History(string) map[enums.Property]map[int64]interface{} {
 	return nil
 }
@@ -0,0 +1,69 @@
+// Package audit contains audit log storage plugin definitions.
+package audit
+
+import (
+	"reflect"
+
+	"go-home.io/x/server/plugins/common"
+)
+
+// Known audit actions.
+const (
+	// ActionDeviceCommand describes device command invocation.
+	ActionDeviceCommand = "device_command"
+	// ActionGroupCommand describes group command invocation.
+	ActionGroupCommand = "group_command"
+	// ActionLocationCommand describes location command invocation.
+	ActionLocationCommand = "location_command"
+	// ActionSceneCapture describes scene state capture.
+	ActionSceneCapture = "scene_capture"
+	// ActionTokenCreate describes API token creation.
+	ActionTokenCreate = "token_create"
+	// ActionTokenRevoke describes API token revocation.
+	ActionTokenRevoke = "token_revoke"
+	// ActionTokenUse describes authentication with API token.
+	ActionTokenUse = "token_use"
+)
+
+// IAuditStorage defines audit log storage plugin interface.
+// Storage is append-only: entries are never updated or removed by go-home.
+// Appends are serialized, but Query might be called concurrently with them.
+type IAuditStorage interface {
+	Init(*InitDataAuditStorage) error
+	Append(*Entry) error
+	Query(*Query) ([]*Entry, error)
+}
+
+// InitDataAuditStorage has data required for initializing a new audit log storage.
+type InitDataAuditStorage struct {
+	Logger    common.ILoggerProvider
+	Secret    common.ISecretProvider
+	RawConfig []byte
+}
+
+// Entry describes single audit log record.
+type Entry struct {
+	Time      int64       `json:"time"`
+	User      string      `json:"user"`
+	IP        string      `json:"ip"`
+	UserAgent string      `json:"user_agent"`
+	Action    string      `json:"action"`
+	Target    string      `json:"target"`
+	Payload   interface{} `json:"payload,omitempty"`
+	Success   bool        `json:"success"`
+	Result    string      `json:"result,omitempty"`
+}
+
+// Query describes audit log filter.
+// Empty fields are ignored, From and To are unix timestamps in seconds, both inclusive.
+type Query struct {
+	From   int64  `json:"from"`
+	To     int64  `json:"to"`
+	User   string `json:"user"`
+	Target string `json:"target"`
+	Action string `json:"action"`
+	Limit  int    `json:"limit"`
+}
+
+// TypeAuditStorage is a syntax sugar around IAuditStorage type.
+var TypeAuditStorage = reflect.TypeOf((*IAuditStorage)(nil)).Elem()
@@ -28,6 +28,10 @@ const (
 	MsgDeviceCommand
 	// MsgEntityLoadStatus describes status of a config entity.
 	MsgEntityLoadStatus
+	// MsgDeviceBatchCommand describes multiple devices commands sent by master.
+	MsgDeviceBatchCommand
+	// MsgWorkerLeave describes graceful worker shutdown sent by worker.
+	MsgWorkerLeave
 )
 
 const (
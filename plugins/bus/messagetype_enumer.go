@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=MessageType -transform=snake -trimprefix=Msg -text -json -yaml"; DO NOT EDIT.
 
-//
 package bus
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _MessageTypeName = "pingdevice_assignmentdevice_updatedevice_commandentity_load_status"
+const _MessageTypeName = "pingdevice_assignmentdevice_updatedevice_commandentity_load_statusdevice_batch_commandworker_leave"
 
-var _MessageTypeIndex = [...]uint8{0, 4, 21, 34, 48, 66}
+var _MessageTypeIndex = [...]uint8{0, 4, 21, 34, 48, 66, 86, 98}
 
 func (i MessageType) String() string {
 	if i < 0 || i >= MessageType(len(_MessageTypeIndex)-1) {
@@ -19,7 +18,7 @@ func (i MessageType) String() string {
 	return _MessageTypeName[_MessageTypeIndex[i]:_MessageTypeIndex[i+1]]
 }
 
-var _MessageTypeValues = []MessageType{0, 1, 2, 3, 4}
+var _MessageTypeValues = []MessageType{0, 1, 2, 3, 4, 5, 6}
 
 var _MessageTypeNameToValueMap = map[string]MessageType{
 	_MessageTypeName[0:4]:   0,
@@ -27,6 +26,8 @@ var _MessageTypeNameToValueMap = map[string]MessageType{
 	_MessageTypeName[21:34]: 2,
 	_MessageTypeName[34:48]: 3,
 	_MessageTypeName[48:66]: 4,
+	_MessageTypeName[66:86]: 5,
+	_MessageTypeName[86:98]: 6,
 }
 
 // MessageTypeString retrieves an enum value from the enum constants string name.
@@ -79,3 +79,9 @@ type IFanOutProvider interface {
 	SubscribeDeviceUpdates() (int64, chan *MsgDeviceUpdate)
 	UnSubscribeDeviceUpdates(int64)
 }
+
+// IUnloadable defines optional plugin interface.
+// Triggers and notifications implementing it are unloaded on shutdown.
+type IUnloadable interface {
+	Unload()
+}
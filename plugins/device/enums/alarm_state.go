@@ -0,0 +1,21 @@
+//go:generate enumer -type=AlarmState -transform=snake -trimprefix=Alarm -json -text -yaml
+
+package enums
+
+// AlarmState defines alarm panel state.
+type AlarmState int
+
+const (
+	// AlarmDisarmed describes disarmed alarm.
+	AlarmDisarmed AlarmState = iota
+	// AlarmArmedHome describes alarm armed while someone is at home.
+	AlarmArmedHome
+	// AlarmArmedAway describes alarm armed while nobody is at home.
+	AlarmArmedAway
+	// AlarmArming describes alarm waiting for the exit delay.
+	AlarmArming
+	// AlarmPending describes alarm waiting for the entry delay.
+	AlarmPending
+	// AlarmTriggered describes triggered alarm.
+	AlarmTriggered
+)
@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=Command -transform=kebab -trimprefix=Cmd -json -text -yaml"; DO NOT EDIT.
 
-//
 package enums
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _CommandName = "inputonofftoggleset-colorset-sceneset-brightnessset-transition-timepausedockfind-meset-fan-speedtake-picture"
+const _CommandName = "inputonofftoggleset-colorset-sceneset-brightnessset-transition-timepausedockfind-meset-fan-speedtake-pictureactivatesnapshotrestoreset-modeset-valueincrementdecrementstartcancelarm-homearm-awaydisarmtriggerset-location"
 
-var _CommandIndex = [...]uint8{0, 5, 7, 10, 16, 25, 34, 48, 67, 72, 76, 83, 96, 108}
+var _CommandIndex = [...]uint8{0, 5, 7, 10, 16, 25, 34, 48, 67, 72, 76, 83, 96, 108, 116, 124, 131, 139, 148, 157, 166, 171, 177, 185, 193, 199, 206, 218}
 
 func (i Command) String() string {
 	if i < 0 || i >= Command(len(_CommandIndex)-1) {
@@ -19,22 +18,36 @@ func (i Command) String() string {
 	return _CommandName[_CommandIndex[i]:_CommandIndex[i+1]]
 }
 
-var _CommandValues = []Command{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+var _CommandValues = []Command{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26}
 
 var _CommandNameToValueMap = map[string]Command{
-	_CommandName[0:5]:    0,
-	_CommandName[5:7]:    1,
-	_CommandName[7:10]:   2,
-	_CommandName[10:16]:  3,
-	_CommandName[16:25]:  4,
-	_CommandName[25:34]:  5,
-	_CommandName[34:48]:  6,
-	_CommandName[48:67]:  7,
-	_CommandName[67:72]:  8,
-	_CommandName[72:76]:  9,
-	_CommandName[76:83]:  10,
-	_CommandName[83:96]:  11,
-	_CommandName[96:108]: 12,
+	_CommandName[0:5]:     0,
+	_CommandName[5:7]:     1,
+	_CommandName[7:10]:    2,
+	_CommandName[10:16]:   3,
+	_CommandName[16:25]:   4,
+	_CommandName[25:34]:   5,
+	_CommandName[34:48]:   6,
+	_CommandName[48:67]:   7,
+	_CommandName[67:72]:   8,
+	_CommandName[72:76]:   9,
+	_CommandName[76:83]:   10,
+	_CommandName[83:96]:   11,
+	_CommandName[96:108]:  12,
+	_CommandName[108:116]: 13,
+	_CommandName[116:124]: 14,
+	_CommandName[124:131]: 15,
+	_CommandName[131:139]: 16,
+	_CommandName[139:148]: 17,
+	_CommandName[148:157]: 18,
+	_CommandName[157:166]: 19,
+	_CommandName[166:171]: 20,
+	_CommandName[171:177]: 21,
+	_CommandName[177:185]: 22,
+	_CommandName[185:193]: 23,
+	_CommandName[193:199]: 24,
+	_CommandName[199:206]: 25,
+	_CommandName[206:218]: 26,
 }
 
 // CommandString retrieves an enum value from the enum constants string name.
@@ -35,6 +35,34 @@ const (
 	CmdSetFanSpeed
 	// CmdTakePicture describes taking a picture.
 	CmdTakePicture
+	// CmdActivate describes scene activation command.
+	CmdActivate
+	// CmdSnapshot describes capturing a temporary snapshot of devices state.
+	CmdSnapshot
+	// CmdRestore describes restoring previously captured snapshot.
+	CmdRestore
+	// CmdSetMode describes changing current mode.
+	CmdSetMode
+	// CmdSetValue describes setting a value.
+	CmdSetValue
+	// CmdIncrement describes incrementing a value.
+	CmdIncrement
+	// CmdDecrement describes decrementing a value.
+	CmdDecrement
+	// CmdStart describes starting the device.
+	CmdStart
+	// CmdCancel describes cancelling the device operation.
+	CmdCancel
+	// CmdArmHome describes arming alarm while someone is at home.
+	CmdArmHome
+	// CmdArmAway describes arming alarm while nobody is at home.
+	CmdArmAway
+	// CmdDisarm describes disarming alarm.
+	CmdDisarm
+	// CmdTrigger describes triggering alarm manually.
+	CmdTrigger
+	// CmdSetLocation describes reporting current location.
+	CmdSetLocation
 )
 
 // AllowedCommands contains set of all possible allowed commands per device type.
@@ -46,6 +74,11 @@ var AllowedCommands = map[DeviceType][]Command{
 	DevVacuum: {CmdOn, CmdOff, CmdPause, CmdDock, CmdFindMe, CmdSetFanSpeed},
 	DevCamera: {CmdTakePicture},
 	DevLock:   {CmdOn, CmdOff, CmdToggle},
+	DevScene:  {CmdActivate, CmdSnapshot, CmdRestore},
+	DevMode:   {CmdSetMode},
+	DevHelper: {CmdOn, CmdOff, CmdToggle, CmdSetValue, CmdIncrement, CmdDecrement, CmdStart, CmdPause, CmdCancel},
+	DevAlarm:  {CmdArmHome, CmdArmAway, CmdDisarm, CmdTrigger},
+	DevPerson: {CmdSetLocation},
 }
 
 // SliceContainsCommand checks whether slice contains certain command.
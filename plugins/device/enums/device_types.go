@@ -28,6 +28,16 @@ const (
 	DevLock
 	// DevTrigger describes a fake device for a trigger status updates.
 	DevTrigger
+	// DevScene describes server-side scene.
+	DevScene
+	// DevMode describes global home mode.
+	DevMode
+	// DevHelper describes input helper entity.
+	DevHelper
+	// DevAlarm describes alarm panel.
+	DevAlarm
+	// DevPerson describes tracked person.
+	DevPerson
 )
 
 // SliceContainsDeviceType is a helper Slice.contains.
@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=DeviceType -transform=kebab -trimprefix=Dev -json -text -yaml"; DO NOT EDIT.
 
-//
 package enums
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _DeviceTypeName = "unknownhublightswitchsensorgroupweathervacuumcameralocktrigger"
+const _DeviceTypeName = "unknownhublightswitchsensorgroupweathervacuumcameralocktriggerscenemodehelperalarmperson"
 
-var _DeviceTypeIndex = [...]uint8{0, 7, 10, 15, 21, 27, 32, 39, 45, 51, 55, 62}
+var _DeviceTypeIndex = [...]uint8{0, 7, 10, 15, 21, 27, 32, 39, 45, 51, 55, 62, 67, 71, 77, 82, 88}
 
 func (i DeviceType) String() string {
 	if i < 0 || i >= DeviceType(len(_DeviceTypeIndex)-1) {
@@ -19,7 +18,7 @@ func (i DeviceType) String() string {
 	return _DeviceTypeName[_DeviceTypeIndex[i]:_DeviceTypeIndex[i+1]]
 }
 
-var _DeviceTypeValues = []DeviceType{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+var _DeviceTypeValues = []DeviceType{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
 
 var _DeviceTypeNameToValueMap = map[string]DeviceType{
 	_DeviceTypeName[0:7]:   0,
@@ -33,6 +32,11 @@ var _DeviceTypeNameToValueMap = map[string]DeviceType{
 	_DeviceTypeName[45:51]: 8,
 	_DeviceTypeName[51:55]: 9,
 	_DeviceTypeName[55:62]: 10,
+	_DeviceTypeName[62:67]: 11,
+	_DeviceTypeName[67:71]: 12,
+	_DeviceTypeName[71:77]: 13,
+	_DeviceTypeName[77:82]: 14,
+	_DeviceTypeName[82:88]: 15,
 }
 
 // DeviceTypeString retrieves an enum value from the enum constants string name.
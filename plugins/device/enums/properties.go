@@ -64,6 +64,36 @@ const (
 	PropUser
 	// PropDescription describes generic text description.
 	PropDescription
+	// PropMode describes current mode.
+	PropMode
+	// PropModes describes list of available modes.
+	PropModes
+	// PropHelperType describes input helper type.
+	PropHelperType
+	// PropValue describes generic numeric value.
+	PropValue
+	// PropOption describes currently selected option.
+	PropOption
+	// PropOptions describes list of available options.
+	PropOptions
+	// PropText describes generic text value.
+	PropText
+	// PropTimerStatus describes timer status.
+	PropTimerStatus
+	// PropRemaining describes remaining timer duration.
+	PropRemaining
+	// PropAlarmState describes alarm panel state.
+	PropAlarmState
+	// PropPresence describes person presence: home, away or zone name.
+	PropPresence
+	// PropLastSeen describes last seen timestamp.
+	PropLastSeen
+	// PropLatitude describes latitude.
+	PropLatitude
+	// PropLongitude describes longitude.
+	PropLongitude
+	// PropFinishesAt describes timestamp when timer finishes.
+	PropFinishesAt
 )
 
 // AllowedProperties contains set of all possible allowed properties per device type.
@@ -78,6 +108,11 @@ var AllowedProperties = map[DeviceType][]Property{
 	DevVacuum: {PropVacStatus, PropBatteryLevel, PropArea, PropDuration, PropFanSpeed},
 	DevCamera: {PropPicture, PropDistance},
 	DevLock:   {PropOn, PropBatteryLevel},
+	DevMode:   {PropMode, PropModes},
+	DevHelper: {PropHelperType, PropOn, PropValue, PropOption, PropOptions, PropText, PropTimerStatus,
+		PropRemaining, PropFinishesAt, PropDuration},
+	DevAlarm:  {PropAlarmState, PropRemaining},
+	DevPerson: {PropPresence, PropLastSeen, PropLatitude, PropLongitude},
 }
 
 // SliceContainsProperty checks whether slice contains certain property.
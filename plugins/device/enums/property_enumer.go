@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=Property -transform=snake -trimprefix=Prop -json -text -yaml"; DO NOT EDIT.
 
-//
 package enums
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _PropertyName = "inputoncolornum_devicestransition_timebrightnessscenespowertemperaturebattery_levelsunrisesunsethumiditypressurevisibilitywind_directionwind_speedclickdouble_clickpresssensor_typevac_statusareadurationfan_speedpicturedistanceuserdescription"
+const _PropertyName = "inputoncolornum_devicestransition_timebrightnessscenespowertemperaturebattery_levelsunrisesunsethumiditypressurevisibilitywind_directionwind_speedclickdouble_clickpresssensor_typevac_statusareadurationfan_speedpicturedistanceuserdescriptionmodemodeshelper_typevalueoptionoptionstexttimer_statusremainingalarm_statepresencelast_seenlatitudelongitudefinishes_at"
 
-var _PropertyIndex = [...]uint8{0, 5, 7, 12, 23, 38, 48, 54, 59, 70, 83, 90, 96, 104, 112, 122, 136, 146, 151, 163, 168, 179, 189, 193, 201, 210, 217, 225, 229, 240}
+var _PropertyIndex = [...]uint16{0, 5, 7, 12, 23, 38, 48, 54, 59, 70, 83, 90, 96, 104, 112, 122, 136, 146, 151, 163, 168, 179, 189, 193, 201, 210, 217, 225, 229, 240, 244, 249, 260, 265, 271, 278, 282, 294, 303, 314, 322, 331, 339, 348, 359}
 
 func (i Property) String() string {
 	if i < 0 || i >= Property(len(_PropertyIndex)-1) {
@@ -19,7 +18,7 @@ func (i Property) String() string {
 	return _PropertyName[_PropertyIndex[i]:_PropertyIndex[i+1]]
 }
 
-var _PropertyValues = []Property{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28}
+var _PropertyValues = []Property{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43}
 
 var _PropertyNameToValueMap = map[string]Property{
 	_PropertyName[0:5]:     0,
@@ -51,6 +50,21 @@ var _PropertyNameToValueMap = map[string]Property{
 	_PropertyName[217:225]: 26,
 	_PropertyName[225:229]: 27,
 	_PropertyName[229:240]: 28,
+	_PropertyName[240:244]: 29,
+	_PropertyName[244:249]: 30,
+	_PropertyName[249:260]: 31,
+	_PropertyName[260:265]: 32,
+	_PropertyName[265:271]: 33,
+	_PropertyName[271:278]: 34,
+	_PropertyName[278:282]: 35,
+	_PropertyName[282:294]: 36,
+	_PropertyName[294:303]: 37,
+	_PropertyName[303:314]: 38,
+	_PropertyName[314:322]: 39,
+	_PropertyName[322:331]: 40,
+	_PropertyName[331:339]: 41,
+	_PropertyName[339:348]: 42,
+	_PropertyName[348:359]: 43,
 }
 
 // PropertyString retrieves an enum value from the enum constants string name.
@@ -18,4 +18,8 @@ const (
 	SenLock
 	// SenPresence describes presence sensor.
 	SenPresence
+	// SenContact describes door or window contact sensor.
+	SenContact
+	// SenOccupancy describes virtual location occupancy sensor.
+	SenOccupancy
 )
@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=SensorType -transform=kebab -trimprefix=Sen -json -text -yaml"; DO NOT EDIT.
 
-//
 package enums
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _SensorTypeName = "genericmotiontemperaturebuttonlockpresence"
+const _SensorTypeName = "genericmotiontemperaturebuttonlockpresencecontactoccupancy"
 
-var _SensorTypeIndex = [...]uint8{0, 7, 13, 24, 30, 34, 42}
+var _SensorTypeIndex = [...]uint8{0, 7, 13, 24, 30, 34, 42, 49, 58}
 
 func (i SensorType) String() string {
 	if i < 0 || i >= SensorType(len(_SensorTypeIndex)-1) {
@@ -19,7 +18,7 @@ func (i SensorType) String() string {
 	return _SensorTypeName[_SensorTypeIndex[i]:_SensorTypeIndex[i+1]]
 }
 
-var _SensorTypeValues = []SensorType{0, 1, 2, 3, 4, 5}
+var _SensorTypeValues = []SensorType{0, 1, 2, 3, 4, 5, 6, 7}
 
 var _SensorTypeNameToValueMap = map[string]SensorType{
 	_SensorTypeName[0:7]:   0,
@@ -28,6 +27,8 @@ var _SensorTypeNameToValueMap = map[string]SensorType{
 	_SensorTypeName[24:30]: 3,
 	_SensorTypeName[30:34]: 4,
 	_SensorTypeName[34:42]: 5,
+	_SensorTypeName[42:49]: 6,
+	_SensorTypeName[49:58]: 7,
 }
 
 // SensorTypeString retrieves an enum value from the enum constants string name.
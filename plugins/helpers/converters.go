@@ -42,17 +42,20 @@ func GetPropertyType(p enums.Property) PropertyType {
 		return PropInput
 	case enums.PropColor:
 		return PropColor
-	case enums.PropScenes:
+	case enums.PropScenes, enums.PropModes, enums.PropOptions:
 		return PropStringSlice
-	case enums.PropSensorType, enums.PropVacStatus:
+	case enums.PropSensorType, enums.PropVacStatus, enums.PropAlarmState:
 		return PropEnum
-	case enums.PropPicture, enums.PropUser, enums.PropSunrise, enums.PropSunset, enums.PropDescription:
+	case enums.PropPicture, enums.PropUser, enums.PropSunrise, enums.PropSunset, enums.PropDescription,
+		enums.PropMode, enums.PropHelperType, enums.PropOption, enums.PropText, enums.PropTimerStatus,
+		enums.PropPresence:
 		return PropString
 	case enums.PropOn, enums.PropClick, enums.PropDoubleClick, enums.PropPress:
 		return PropBool
 	case enums.PropBrightness, enums.PropBatteryLevel, enums.PropFanSpeed:
 		return PropPercent
-	case enums.PropDuration, enums.PropDistance, enums.PropNumDevices, enums.PropTransitionTime:
+	case enums.PropDuration, enums.PropDistance, enums.PropNumDevices, enums.PropTransitionTime,
+		enums.PropRemaining, enums.PropLastSeen, enums.PropFinishesAt:
 		return PropInt
 	}
 
@@ -94,9 +97,11 @@ func propertyFix(x interface{}, p enums.Property,
 // field	interface{}
 // with data
 // color:
-//   r : 120
-// 	 g : 120
-//   b : 120
+//
+//	  r : 120
+//		 g : 120
+//	  b : 120
+//
 // will be un-marshaled as map[interface{}] interface{}.
 // Which prevents normal deep compare.
 func PropertyFixYaml(x interface{}, p enums.Property) (interface{}, error) {
@@ -173,7 +178,8 @@ func CommandPropertyFixYaml(x interface{}, c enums.Command) (interface{}, error)
 	}
 
 	switch c {
-	case enums.CmdOn, enums.CmdOff, enums.CmdToggle, enums.CmdFindMe, enums.CmdDock, enums.CmdPause:
+	case enums.CmdOn, enums.CmdOff, enums.CmdToggle, enums.CmdFindMe, enums.CmdDock, enums.CmdPause,
+		enums.CmdCancel, enums.CmdTrigger:
 		return nil, nil
 	case enums.CmdSetBrightness, enums.CmdSetFanSpeed:
 		return convertValueProperty(x, &common.Percent{})
@@ -183,6 +189,15 @@ func CommandPropertyFixYaml(x interface{}, c enums.Command) (interface{}, error)
 		return convertProperty(x, &common.Color{})
 	case enums.CmdInput:
 		return convertProperty(x, &common.Input{})
+	case enums.CmdSetMode:
+		return convertValueProperty(x, &common.String{})
+	case enums.CmdSetValue, enums.CmdIncrement, enums.CmdDecrement, enums.CmdStart:
+		switch x.(type) {
+		case map[interface{}]interface{}, map[string]interface{}:
+			return x, nil
+		}
+
+		return map[string]interface{}{"value": x}, nil
 	}
 
 	return x, nil
@@ -199,7 +214,7 @@ func PropertyFixNum(x interface{}, p enums.Property) interface{} {
 		return uint8(x.(float64))
 	case enums.PropTransitionTime:
 		return uint16(x.(float64))
-	case enums.PropDuration, enums.PropDistance:
+	case enums.PropDuration, enums.PropDistance, enums.PropRemaining, enums.PropLastSeen, enums.PropFinishesAt:
 		return int(x.(float64))
 	}
 
@@ -14,6 +14,12 @@ type IStorage interface {
 	History(string, int) map[string]map[int64]interface{}
 }
 
+// IFlushableStorage defines optional storage plugin interface.
+// Flush is invoked on shutdown, after all pending entries were passed to the plugin.
+type IFlushableStorage interface {
+	Flush()
+}
+
 // InitDataStorage has data required for initializing of a new state storage provider.
 type InitDataStorage struct {
 	Logger common.ILoggerProvider
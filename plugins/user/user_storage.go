@@ -12,6 +12,12 @@ type IUserStorage interface {
 	Authorize(headers map[string][]string) (username string, err error)
 }
 
+// IGroupsUserStorage defines optional user store capability of returning user's groups.
+// Groups are matched against roles' groups.
+type IGroupsUserStorage interface {
+	AuthorizeWithGroups(headers map[string][]string) (username string, groups []string, err error)
+}
+
 // InitDataUserStorage has data required for initializing a new user storage.
 type InitDataUserStorage struct {
 	Logger    common.ILoggerProvider
@@ -0,0 +1,10 @@
+package providers
+
+import "go-home.io/x/server/plugins/device/enums"
+
+// IAlarmProvider describes alarm panel provider.
+type IAlarmProvider interface {
+	ID() string
+	State() enums.AlarmState
+	InvokeCommand(enums.Command, map[string]interface{}) error
+}
@@ -0,0 +1,11 @@
+package providers
+
+import (
+	"go-home.io/x/server/plugins/audit"
+)
+
+// IAuditProvider defines user actions audit log provider.
+type IAuditProvider interface {
+	Record(*audit.Entry)
+	Query(*audit.Query) ([]*audit.Entry, error)
+}
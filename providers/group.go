@@ -7,4 +7,6 @@ type IGroupProvider interface {
 	ID() string
 	Devices() []string
 	InvokeCommand(enums.Command, map[string]interface{})
+	Nests(string) bool
+	ExcludeNested(string)
 }
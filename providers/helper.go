@@ -0,0 +1,9 @@
+package providers
+
+import "go-home.io/x/server/plugins/device/enums"
+
+// IHelperProvider describes input helper entity provider.
+type IHelperProvider interface {
+	ID() string
+	InvokeCommand(enums.Command, map[string]interface{}) error
+}
@@ -0,0 +1,12 @@
+package providers
+
+import "go-home.io/x/server/plugins/device/enums"
+
+// IModeProvider describes global home mode provider.
+type IModeProvider interface {
+	ID() string
+	Current() string
+	States() []string
+	SetMode(string) error
+	InvokeCommand(enums.Command, map[string]interface{}) error
+}
@@ -2,6 +2,7 @@ package providers
 
 // INotificationProvider defines notification provider.
 type INotificationProvider interface {
+	ILoadedProvider
 	GetID() string
 	Message(string)
 }
@@ -0,0 +1,9 @@
+package providers
+
+// IOccupancyProvider defines location occupancy engine.
+type IOccupancyProvider interface {
+	ID() string
+	Location() string
+	IsOccupied() bool
+	Unload()
+}
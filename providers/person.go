@@ -0,0 +1,11 @@
+package providers
+
+import "go-home.io/x/server/plugins/device/enums"
+
+// IPersonProvider describes tracked person provider.
+type IPersonProvider interface {
+	ID() string
+	Presence() string
+	LastSeen() int64
+	InvokeCommand(enums.Command, map[string]interface{}) error
+}
@@ -0,0 +1,11 @@
+package providers
+
+import "go-home.io/x/server/plugins/device/enums"
+
+// ISceneProvider describes server-side scene provider.
+type ISceneProvider interface {
+	ID() string
+	Devices() []string
+	InvokeCommand(enums.Command, map[string]interface{})
+	Capture()
+}
@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=SecSystem -transform=kebab -trimprefix=SecSystem -json -text -yaml"; DO NOT EDIT.
 
-//
 package providers
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _SecSystemName = "alldevicecoretrigger"
+const _SecSystemName = "alldevicecoretriggeralarm"
 
-var _SecSystemIndex = [...]uint8{0, 3, 9, 13, 20}
+var _SecSystemIndex = [...]uint8{0, 3, 9, 13, 20, 25}
 
 func (i SecSystem) String() string {
 	if i < 0 || i >= SecSystem(len(_SecSystemIndex)-1) {
@@ -19,13 +18,14 @@ func (i SecSystem) String() string {
 	return _SecSystemName[_SecSystemIndex[i]:_SecSystemIndex[i+1]]
 }
 
-var _SecSystemValues = []SecSystem{0, 1, 2, 3}
+var _SecSystemValues = []SecSystem{0, 1, 2, 3, 4}
 
 var _SecSystemNameToValueMap = map[string]SecSystem{
 	_SecSystemName[0:3]:   0,
 	_SecSystemName[3:9]:   1,
 	_SecSystemName[9:13]:  2,
 	_SecSystemName[13:20]: 3,
+	_SecSystemName[20:25]: 4,
 }
 
 // SecSystemString retrieves an enum value from the enum constants string name.
@@ -10,6 +10,10 @@ import (
 // ISecurityProvider defines security provider.
 type ISecurityProvider interface {
 	GetUser(map[string][]string) (IAuthenticatedUser, error)
+	GetUserByName(string) (IAuthenticatedUser, error)
+	CreateToken(IAuthenticatedUser, *APITokenRequest) (*APIToken, string, error)
+	GetTokens(IAuthenticatedUser) ([]*APIToken, error)
+	RevokeToken(IAuthenticatedUser, string) error
 }
 
 // IAuthenticatedUser describes authenticated user.
@@ -23,6 +27,11 @@ type IAuthenticatedUser interface {
 	Workers() bool
 	Entities() bool
 	Logs() bool
+	Audit() bool
+	AlarmArm(string) bool
+	AlarmDisarm(string) bool
+	TokenID() string
+	Groups() []string
 }
 
 // SecVerb describes allowed rules for the role.
@@ -37,6 +46,10 @@ const (
 	SecVerbCommand
 	// SecVerbHistory describes get history command rule
 	SecVerbHistory
+	// SecVerbArm describes alarm arming rule.
+	SecVerbArm
+	// SecVerbDisarm describes alarm disarming rule.
+	SecVerbDisarm
 )
 
 // SecSystem describes possible role's rule system.
@@ -51,21 +64,24 @@ const (
 	SecSystemCore
 	// SecSystemTrigger describes triggers' system.
 	SecSystemTrigger
+	// SecSystemAlarm describes alarm panels' system.
+	SecSystemAlarm
 )
 
 // SecRoleRule has data, describing single security rule.
 type SecRoleRule struct {
-	System    string    `yaml:"system" validate:"required,oneof=* device core"`
-	Resources []string  `yaml:"resources" validate:"unique,min=1"`
-	Verbs     []SecVerb `yaml:"-"`
-	StrVerb   []string  `yaml:"verbs" validate:"unique,min=1,oneof=* get command history"`
+	System    string    `yaml:"system" json:"system" validate:"required,oneof=* device core alarm"`
+	Resources []string  `yaml:"resources" json:"resources" validate:"unique,min=1"`
+	Verbs     []SecVerb `yaml:"-" json:"-"`
+	StrVerb   []string  `yaml:"verbs" json:"verbs" validate:"unique,min=1,oneof=* get command history arm disarm"`
 }
 
 // SecRole has data, describing single security role.
 type SecRole struct {
-	Name  string        `yaml:"name" validate:"required"`
-	Users []string      `yaml:"users" validate:"unique,min=1"`
-	Rules []SecRoleRule `yaml:"rules" validate:"min=1"`
+	Name   string        `yaml:"name" validate:"required"`
+	Users  []string      `yaml:"users" validate:"unique"`
+	Groups []string      `yaml:"groups" validate:"unique"`
+	Rules  []SecRoleRule `yaml:"rules" validate:"min=1"`
 }
 
 // BakedRule is a helper type with pre-compiled regexps.
@@ -75,4 +91,28 @@ type BakedRule struct {
 	Get       bool
 	Command   bool
 	History   bool
+	Arm       bool
+	Disarm    bool
+}
+
+// APITokenRequest has data required for a new API token.
+// Empty rules mean that token has the same permissions as the user.
+type APITokenRequest struct {
+	Name    string        `json:"name"`
+	Expires int64         `json:"expires"`
+	Rules   []SecRoleRule `json:"rules"`
+}
+
+// APIToken has data describing long-lived API access token.
+// Token permissions are the intersection of the user's roles and token rules.
+type APIToken struct {
+	ID       string        `yaml:"id" json:"id"`
+	Name     string        `yaml:"name" json:"name"`
+	User     string        `yaml:"user" json:"user"`
+	Groups   []string      `yaml:"groups,omitempty" json:"-"`
+	Hash     string        `yaml:"hash" json:"-"`
+	Rules    []SecRoleRule `yaml:"rules" json:"rules"`
+	Created  int64         `yaml:"created" json:"created"`
+	Expires  int64         `yaml:"expires" json:"expires"`
+	LastUsed int64         `yaml:"last_used" json:"last_used"`
 }
@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=SecVerb -transform=kebab -trimprefix=SecVerb -json -text -yaml"; DO NOT EDIT.
 
-//
 package providers
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _SecVerbName = "allgetcommandhistory"
+const _SecVerbName = "allgetcommandhistoryarmdisarm"
 
-var _SecVerbIndex = [...]uint8{0, 3, 6, 13, 20}
+var _SecVerbIndex = [...]uint8{0, 3, 6, 13, 20, 23, 29}
 
 func (i SecVerb) String() string {
 	if i < 0 || i >= SecVerb(len(_SecVerbIndex)-1) {
@@ -19,13 +18,15 @@ func (i SecVerb) String() string {
 	return _SecVerbName[_SecVerbIndex[i]:_SecVerbIndex[i+1]]
 }
 
-var _SecVerbValues = []SecVerb{0, 1, 2, 3}
+var _SecVerbValues = []SecVerb{0, 1, 2, 3, 4, 5}
 
 var _SecVerbNameToValueMap = map[string]SecVerb{
 	_SecVerbName[0:3]:   0,
 	_SecVerbName[3:6]:   1,
 	_SecVerbName[6:13]:  2,
 	_SecVerbName[13:20]: 3,
+	_SecVerbName[20:23]: 4,
+	_SecVerbName[23:29]: 5,
 }
 
 // SecVerbString retrieves an enum value from the enum constants string name.
@@ -13,6 +13,8 @@ type IServerProvider interface {
 	SendNotificationCommand(glob.Glob, string)
 	GetDevice(string) *KnownDevice
 	PushMasterDeviceUpdate(*MasterDeviceUpdate)
+	GetHomeMode() string
+	GetPersonPresence(string) string
 }
 
 // MasterDeviceUpdate contains data required for pushing update for device running on master.
@@ -27,7 +29,9 @@ type MasterDeviceUpdate struct {
 
 // KnownDevice contains data about known device.
 type KnownDevice struct {
-	Worker   string
-	Type     enums.DeviceType
-	Commands []string
+	Worker     string
+	Type       enums.DeviceType
+	Commands   []string
+	Properties []string
+	Locations  []string
 }
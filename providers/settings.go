@@ -26,8 +26,14 @@ type ISettingsProvider interface {
 	ExtendedAPIs() []*RawMasterComponent
 	Notifications() []*RawMasterComponent
 	Groups() []*RawMasterComponent
+	Scenes() []*RawMasterComponent
+	HomeMode() *RawMasterComponent
+	Helpers() []*RawMasterComponent
+	Alarms() []*RawMasterComponent
+	Persons() []*RawMasterComponent
 	FanOut() IInternalFanOutProvider
 	Storage() IStorageProvider
+	Audit() IAuditProvider
 	Timezone() *time.Location
 }
 
@@ -55,15 +61,60 @@ type MasterSettings struct {
 	DelayedStart int                   `yaml:"delayedStart" validate:"gte=0"`
 	UOM          enums.UOM             `yaml:"units" default:"imperial"`
 	Timezone     string                `yaml:"timezone" default:"Local"`
+	Lockout      LockoutSettings       `yaml:"lockout"`
+	TLS          TLSSettings           `yaml:"tls"`
+	MQTT         MQTTBridgeSettings    `yaml:"mqtt"`
 	Locations    []*RawMasterComponent `yaml:"-"`
+	Occupancy    []*RawMasterComponent `yaml:"-"`
+	Webhooks     []*RawMasterComponent `yaml:"-"`
+}
+
+// TLSSettings has master HTTPS settings.
+// If certificate files are not set, they are located in configs directory.
+type TLSSettings struct {
+	Enabled      bool     `yaml:"enabled"`
+	CertFile     string   `yaml:"certFile"`
+	KeyFile      string   `yaml:"keyFile"`
+	SelfSigned   bool     `yaml:"selfSigned"`
+	Hosts        []string `yaml:"hosts"`
+	RedirectPort int      `yaml:"redirectPort" validate:"omitempty,port"`
+	ClientCA     string   `yaml:"clientCA"`
+}
+
+// MQTTBridgeSettings has master MQTT bridge settings.
+// Bridge is disabled if broker is not set.
+// Commands are accepted only if user is set and allowed to invoke them.
+type MQTTBridgeSettings struct {
+	Broker          string `yaml:"broker"`
+	ClientID        string `yaml:"clientID" default:"go-home-master"`
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	Prefix          string `yaml:"prefix" default:"go-home"`
+	QoS             int    `yaml:"qos" validate:"gte=0,lte=2"`
+	User            string `yaml:"user"`
+	Discovery       bool   `yaml:"discovery"`
+	DiscoveryPrefix string `yaml:"discoveryPrefix" default:"homeassistant"`
+}
+
+// LockoutSettings has login brute-force protection settings.
+// All durations are in seconds.
+type LockoutSettings struct {
+	Threshold int    `yaml:"threshold" validate:"gte=1" default:"5"`
+	Delay     int    `yaml:"delay" validate:"gte=1" default:"30"`
+	MaxDelay  int    `yaml:"maxDelay" validate:"gte=1" default:"3600"`
+	Window    int    `yaml:"window" validate:"gte=1" default:"900"`
+	Notify    string `yaml:"notify"`
+	// Proxies, IP addresses or CIDRs, allowed to set X-Forwarded-For and X-Real-Ip headers.
+	TrustedProxies []string `yaml:"trustedProxies"`
 }
 
 // WorkerSettings has configured data for worker node.
 type WorkerSettings struct {
-	Name       string            `yaml:"name"`
-	Properties map[string]string `yaml:"properties"`
-	MaxDevices int               `yaml:"maxDevices" validate:"gte=0,lte=1000" default:"99"`
-	Timezone   string            `yaml:"timezone" default:"Local"`
+	Name        string            `yaml:"name"`
+	Properties  map[string]string `yaml:"properties"`
+	MaxDevices  int               `yaml:"maxDevices" validate:"gte=0,lte=1000" default:"99"`
+	Timezone    string            `yaml:"timezone" default:"Local"`
+	MetricsPort int               `yaml:"metricsPort" validate:"omitempty,port"`
 }
 
 // RawMasterComponent has configuration for master component.
@@ -0,0 +1,11 @@
+package providers
+
+import "context"
+
+// IShutdownProvider defines graceful shutdown coordinator.
+type IShutdownProvider interface {
+	Context() context.Context
+	Add(name string, step func(context.Context))
+	Shutdown()
+	Wait()
+}
@@ -5,9 +5,24 @@ import (
 	"go-home.io/x/server/plugins/device/enums"
 )
 
+// StorageSpecs describes storage provider state.
+type StorageSpecs struct {
+	IsConfigured bool
+	IsLoaded     bool
+}
+
 // IStorageProvider defines state history storage provider.
 type IStorageProvider interface {
 	Heartbeat(string)
 	State(*common.MsgDeviceUpdate)
 	History(string) map[enums.Property]map[int64]interface{}
+	Flush()
+	GetSpecs() *StorageSpecs
+}
+
+// IPersistentStoreProvider defines key-value store used by master entities
+// for preserving their state between restarts.
+type IPersistentStoreProvider interface {
+	Load(key string, target interface{}) bool
+	Save(key string, data interface{})
 }
@@ -1,7 +1,18 @@
 package providers
 
+import "net/http"
+
 // ITriggerProvider defines events-trigger.
 type ITriggerProvider interface {
+	ILoadedProvider
 	GetID() string
 	GetLastTriggeredTime() int64
 }
+
+// IHookTriggerProvider defines trigger, fired by inbound HTTP calls.
+// Empty hook ID means that trigger doesn't accept calls.
+type IHookTriggerProvider interface {
+	ITriggerProvider
+	HookID() string
+	Hook(header http.Header, query map[string][]string, body []byte) error
+}
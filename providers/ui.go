@@ -4,5 +4,6 @@ package providers
 type ILocationProvider interface {
 	ID() string
 	Icon() string
+	Parent() string
 	Devices() []string
 }
@@ -0,0 +1,21 @@
+package providers
+
+// IWebhookProvider defines outbound webhook dispatcher.
+type IWebhookProvider interface {
+	ID() string
+	Status() *WebhookStatus
+	Unload()
+}
+
+// WebhookStatus has webhook delivery status.
+type WebhookStatus struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Queued      int    `json:"queued"`
+	Delivered   int64  `json:"delivered"`
+	Failed      int64  `json:"failed"`
+	Dropped     int64  `json:"dropped"`
+	LastSuccess int64  `json:"last_success"`
+	LastFailure int64  `json:"last_failure"`
+	LastError   string `json:"last_error,omitempty"`
+}
@@ -1,18 +1,19 @@
 package server
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"go-home.io/x/server/plugins/device/enums"
-	"go-home.io/x/server/providers"
 )
 
 // Contains data about known locations.
 type knownLocation struct {
 	Name    string   `json:"name"`
 	Icon    string   `json:"icon"`
+	Parent  string   `json:"parent"`
 	Devices []string `json:"devices"`
 }
 
@@ -23,10 +24,27 @@ type knownGroup struct {
 	ID      string   `json:"id"`
 }
 
+// Contains data about known scenes.
+type knownScene struct {
+	Name    string   `json:"name"`
+	Devices []string `json:"devices"`
+	ID      string   `json:"id"`
+}
+
+// Contains data about tracked persons.
+type knownPerson struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Presence string `json:"presence"`
+	LastSeen int64  `json:"last_seen"`
+}
+
 // Contains server state required UI to start.
 type currentState struct {
 	Devices       []*knownDevice   `json:"devices"`
 	Groups        []*knownGroup    `json:"groups"`
+	Scenes        []*knownScene    `json:"scenes"`
+	Persons       []*knownPerson   `json:"persons"`
 	Locations     []*knownLocation `json:"locations"`
 	Triggers      []*knownTrigger  `json:"triggers"`
 	UOM           enums.UOM        `json:"uom"`
@@ -53,6 +71,34 @@ type knownTrigger struct {
 	LastTriggered int64  `json:"last_triggered"`
 }
 
+// Batch command request.
+type batchCommandRequest struct {
+	Commands []*batchCommandItem `json:"commands"`
+}
+
+// Single batch command. Device can be a glob.
+type batchCommandItem struct {
+	Device  string      `json:"device"`
+	Command string      `json:"command"`
+	Value   interface{} `json:"value"`
+}
+
+// Result of a single device command from the batch.
+type batchCommandResult struct {
+	Index   int       `json:"index"`
+	Device  string    `json:"device"`
+	Command string    `json:"command"`
+	Status  string    `json:"status"`
+	Error   *apiError `json:"error,omitempty"`
+}
+
+// Result of a location command for a single device.
+type locationCommandResult struct {
+	Device string    `json:"device"`
+	Status string    `json:"status"`
+	Error  *apiError `json:"error,omitempty"`
+}
+
 // Returns all devices available for the user.
 func (s *GoHomeServer) getDevices(writer http.ResponseWriter, request *http.Request) {
 	respond(writer, s.commandGetAllDevices(getContextUser(request)))
@@ -65,18 +111,7 @@ func (s *GoHomeServer) getGroups(writer http.ResponseWriter, request *http.Reque
 
 // Returns server state required for UI to start.
 func (s *GoHomeServer) getCurrentState(writer http.ResponseWriter, request *http.Request) {
-	usr := getContextUser(request)
-	response := &currentState{
-		Devices:       s.commandGetAllDevices(usr),
-		Triggers:      s.commandGetAllTriggers(usr),
-		Groups:        s.commandGetAllGroups(usr),
-		Locations:     s.commandGetAllLocations(usr),
-		UOM:           s.Settings.MasterSettings().UOM,
-		Timezone:      s.Settings.Timezone().String(),
-		LogsAvailable: s.Logger.GetSpecs().IsHistorySupported && usr.Logs(),
-	}
-
-	respond(writer, response)
+	respond(writer, s.commandGetCurrentState(getContextUser(request)))
 }
 
 // Executes device command if it's allowed for the user.
@@ -91,48 +126,78 @@ func (s *GoHomeServer) deviceCommand(writer http.ResponseWriter, request *http.R
 		vars[string(urlDeviceID)], vars[string(urlCommandName)], b))
 }
 
-// Gets device state history.
-func (s *GoHomeServer) getDeviceStateHistory(writer http.ResponseWriter, request *http.Request) {
-	user := getContextUser(request)
-	vars := mux.Vars(request)
-	kd := s.state.GetDevice(vars[string(urlDeviceID)])
-
-	if nil == kd {
-		respondError(writer, "Unknown device")
+// Executes multiple devices commands.
+func (s *GoHomeServer) deviceBatchCommand(writer http.ResponseWriter, request *http.Request) {
+	req := &batchCommandRequest{}
+	err := json.NewDecoder(request.Body).Decode(req)
+	if err != nil {
+		respondError(writer, "Wrong request")
 		return
 	}
 
-	if !user.DeviceHistory(kd.ID) {
-		respondForbidden(writer)
+	respond(writer, s.commandBatchInvoke(getContextUser(request), req.Commands))
+}
+
+// Executes command against all devices in the location subtree.
+func (s *GoHomeServer) locationCommand(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	b, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		respondError(writer, "Failed to read body")
+		return
+	}
+	results, err := s.commandLocationCommand(getContextUser(request),
+		vars[string(urlLocationID)], vars[string(urlCommandName)], b)
+	if err != nil {
+		respondError(writer, err.Error())
 		return
 	}
 
-	respond(writer, s.Settings.Storage().History(kd.ID))
+	respond(writer, results)
 }
 
-// Gets trigger state history.
-func (s *GoHomeServer) getTriggerStateHistory(writer http.ResponseWriter, request *http.Request) {
-	user := getContextUser(request)
+// Processes location report, sent by a phone.
+func (s *GoHomeServer) personLocation(writer http.ResponseWriter, request *http.Request) {
 	vars := mux.Vars(request)
+	b, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		respondError(writer, "Failed to read body")
+		return
+	}
+	respondOkError(writer, s.commandInvokeDeviceCommand(getContextUser(request),
+		vars[string(urlPersonID)], enums.CmdSetLocation.String(), b))
+}
 
-	triggerID := vars[string(urlTriggerID)]
-	var kt *knownMasterComponent
+// Captures current devices state into the scene.
+func (s *GoHomeServer) captureScene(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	respondOkError(writer, s.commandCaptureScene(getContextUser(request), vars[string(urlSceneID)]))
+}
 
-	for _, v := range s.triggers {
-		if v.Interface.(providers.ITriggerProvider).GetID() == triggerID {
-			kt = v
-			break
-		}
-	}
+// Gets device state history.
+func (s *GoHomeServer) getDeviceStateHistory(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	history, err := s.commandGetDeviceHistory(getContextUser(request), vars[string(urlDeviceID)])
+	respondHistory(writer, history, err)
+}
 
-	if nil == kt {
-		respondError(writer, "Unknown trigger")
-		return
-	}
+// Gets trigger state history.
+func (s *GoHomeServer) getTriggerStateHistory(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	history, err := s.commandGetTriggerHistory(getContextUser(request), vars[string(urlTriggerID)])
+	respondHistory(writer, history, err)
+}
 
-	if !user.TriggerHistory(triggerID) {
+// Responds with state history or error.
+func respondHistory(writer http.ResponseWriter, history map[enums.Property]map[int64]interface{}, err error) {
+	switch err.(type) {
+	case nil:
+		respond(writer, history)
+	case *ErrForbidden:
 		respondForbidden(writer)
+	case *ErrUnknownDevice:
+		respondError(writer, "Unknown device")
+	default:
+		respondError(writer, "Unknown trigger")
 	}
-
-	respond(writer, s.Settings.Storage().History(triggerID))
 }
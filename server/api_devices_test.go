@@ -20,7 +20,12 @@ import (
 )
 
 type fakeTriggerWrapper struct {
-	id string
+	id       string
+	unloaded bool
+}
+
+func (f *fakeTriggerWrapper) Unload() {
+	f.unloaded = true
 }
 
 func (f *fakeTriggerWrapper) GetID() string {
@@ -188,6 +193,32 @@ func TestDeviceCommandAPI(t *testing.T) {
 	}
 }
 
+// Tests location command.
+func TestLocationCommandAPI(t *testing.T) {
+	input := map[string]int{
+		"upstairs": http.StatusOK,
+		"kitchen":  http.StatusOK,
+		"wrong":    http.StatusInternalServerError,
+	}
+
+	monkey.Patch(getContextUser, getFakeRootUser)
+	defer monkey.UnpatchAll()
+
+	srv := getLocationsServer(nil)
+	for k, v := range input {
+		req, err := http.NewRequest("POST", "/test", strings.NewReader(""))
+		require.NoError(t, err, "setup failed %s", k)
+		req = mux.SetURLVars(req, map[string]string{
+			string(urlLocationID):  k,
+			string(urlCommandName): enums.CmdOff.String(),
+		})
+
+		r := httptest.NewRecorder()
+		http.HandlerFunc(srv.locationCommand).ServeHTTP(r, req)
+		assert.Equal(t, v, r.Code, "response code %s", k)
+	}
+}
+
 // Test getting the history.
 func TestGetDeviceStateHistoryAPI(t *testing.T) {
 	input := map[string]int{
@@ -216,8 +247,8 @@ func TestGetDeviceStateHistoryAPI(t *testing.T) {
 func TestGetTriggerStateHistoryAPI(t *testing.T) {
 	input := map[string]int{
 		"trigger1test.trigger": http.StatusOK,
-		"trigger123.trigger": http.StatusForbidden,
-		"dev2": http.StatusInternalServerError,
+		"trigger123.trigger":   http.StatusForbidden,
+		"dev2":                 http.StatusInternalServerError,
 	}
 
 	monkey.Patch(getContextUser, getFakeRootUser)
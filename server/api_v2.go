@@ -0,0 +1,567 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/gorilla/mux"
+	"go-home.io/x/server/plugins/audit"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/alarm"
+	"go-home.io/x/server/systems/helper"
+	"go-home.io/x/server/systems/mode"
+	"go-home.io/x/server/systems/person"
+	"go-home.io/x/server/systems/security"
+	"go-home.io/x/server/systems/trigger"
+)
+
+const (
+	// errCodeBadRequest describes malformed request error code.
+	errCodeBadRequest = "bad_request"
+	// errCodeUnauthorized describes missing or wrong credentials error code.
+	errCodeUnauthorized = "unauthorized"
+	// errCodeForbidden describes access denied error code.
+	errCodeForbidden = "forbidden"
+	// errCodeTooManyRequests describes locked out client error code.
+	errCodeTooManyRequests = "too_many_requests"
+	// errCodeNotFound describes unknown entity error code.
+	errCodeNotFound = "not_found"
+	// errCodeInternal describes unexpected server error code.
+	errCodeInternal = "internal_error"
+)
+
+// API v2 error.
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// API v2 error response.
+type apiErrorResponse struct {
+	Error *apiError `json:"error"`
+}
+
+// API v2 generic success response.
+type apiStatusResponse struct {
+	Status string `json:"status"`
+}
+
+// API v2 created token response.
+// Token value is returned only once.
+type apiTokenResponse struct {
+	Token *providers.APIToken `json:"token"`
+	Value string              `json:"value"`
+}
+
+// API v2 handler, returns either response data or error.
+type apiV2Handler func(providers.IAuthenticatedUser, *http.Request) (interface{}, error)
+
+// API v2 query parameter.
+type apiV2Param struct {
+	Name        string
+	Description string
+}
+
+// API v2 route. Used for both registration and OpenAPI document generation.
+type apiV2Route struct {
+	ID       string
+	Method   string
+	Path     string
+	Summary  string
+	Query    []*apiV2Param
+	Body     interface{}
+	Response interface{}
+	Handler  apiV2Handler
+}
+
+// Filter for devices list.
+type deviceFilter struct {
+	Types    []enums.DeviceType
+	Location string
+	Worker   string
+	ID       glob.Glob
+}
+
+// Registers API v2 routes.
+func (s *GoHomeServer) registerAPIv2(router *mux.Router) {
+	routes := s.apiV2Routes()
+	spec := buildOpenAPISpec(routes)
+	routes = append(routes, &apiV2Route{
+		Method: http.MethodGet,
+		Path:   "/openapi.json",
+		Handler: func(_ providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+			return spec, nil
+		},
+	})
+
+	router.HandleFunc("/ws", s.handleWSv2)
+	router.HandleFunc("/events", s.handleEvents).Methods(http.MethodGet)
+	router.HandleFunc("/metrics", s.handleMetrics).Methods(http.MethodGet)
+	for _, v := range routes {
+		router.HandleFunc(v.Path, s.apiV2Wrap(v.Handler)).Methods(v.Method)
+	}
+
+	router.Use(s.logMiddleware)
+}
+
+// Wraps API v2 handler into http handler.
+func (s *GoHomeServer) apiV2Wrap(handler apiV2Handler) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		data, err := handler(getContextUser(request), request)
+		if err != nil {
+			respondAPIError(writer, newAPIError(err))
+			return
+		}
+
+		respondStatus(writer, http.StatusOK, data)
+	}
+}
+
+// Returns all API v2 routes.
+func (s *GoHomeServer) apiV2Routes() []*apiV2Route {
+	return []*apiV2Route{
+		{
+			ID:      "listDevices",
+			Method:  http.MethodGet,
+			Path:    "/device",
+			Summary: "Returns devices available for the user",
+			Query: []*apiV2Param{
+				{Name: "type", Description: "Device type, can be repeated"},
+				{Name: "location", Description: "Location, including nested locations"},
+				{Name: "worker", Description: "Worker ID"},
+				{Name: "id", Description: "Device ID glob"},
+			},
+			Response: []*knownDevice{},
+			Handler:  s.getDevicesV2,
+		},
+		{
+			ID:       "invokeBatchCommand",
+			Method:   http.MethodPost,
+			Path:     "/device/batch",
+			Summary:  "Invokes multiple devices commands, device can be a glob",
+			Body:     &batchCommandRequest{},
+			Response: []*batchCommandResult{},
+			Handler:  s.deviceBatchCommandV2,
+		},
+		{
+			ID:       "getDevice",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/device/{%s}", urlDeviceID),
+			Summary:  "Returns a single device",
+			Response: &knownDevice{},
+			Handler:  s.getDeviceV2,
+		},
+		{
+			ID:       "invokeDeviceCommand",
+			Method:   http.MethodPost,
+			Path:     fmt.Sprintf("/device/{%s}/command/{%s}", urlDeviceID, urlCommandName),
+			Summary:  "Invokes device command",
+			Body:     map[string]interface{}{},
+			Response: &apiStatusResponse{},
+			Handler:  s.deviceCommandV2,
+		},
+		{
+			ID:       "getDeviceHistory",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/device/{%s}/history", urlDeviceID),
+			Summary:  "Returns device state history",
+			Response: map[enums.Property]map[int64]interface{}{},
+			Handler:  s.getDeviceHistoryV2,
+		},
+		{
+			ID:       "listGroups",
+			Method:   http.MethodGet,
+			Path:     "/group",
+			Summary:  "Returns groups available for the user",
+			Response: []*knownGroup{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetAllGroups(user), nil
+			},
+		},
+		{
+			ID:       "listScenes",
+			Method:   http.MethodGet,
+			Path:     "/scene",
+			Summary:  "Returns scenes available for the user",
+			Response: []*knownScene{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetAllScenes(user), nil
+			},
+		},
+		{
+			ID:       "captureScene",
+			Method:   http.MethodPost,
+			Path:     fmt.Sprintf("/scene/{%s}/capture", urlSceneID),
+			Summary:  "Captures current devices state into the scene",
+			Response: &apiStatusResponse{},
+			Handler:  s.captureSceneV2,
+		},
+		{
+			ID:       "listPersons",
+			Method:   http.MethodGet,
+			Path:     "/person",
+			Summary:  "Returns tracked persons available for the user",
+			Response: []*knownPerson{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetAllPersons(user), nil
+			},
+		},
+		{
+			ID:       "reportPersonLocation",
+			Method:   http.MethodPost,
+			Path:     fmt.Sprintf("/person/{%s}/location", urlPersonID),
+			Summary:  "Reports person location",
+			Body:     map[string]interface{}{},
+			Response: &apiStatusResponse{},
+			Handler:  s.personLocationV2,
+		},
+		{
+			ID:       "listLocations",
+			Method:   http.MethodGet,
+			Path:     "/location",
+			Summary:  "Returns locations available for the user",
+			Response: []*knownLocation{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetAllLocations(user), nil
+			},
+		},
+		{
+			ID:       "invokeLocationCommand",
+			Method:   http.MethodPost,
+			Path:     fmt.Sprintf("/location/{%s}/command/{%s}", urlLocationID, urlCommandName),
+			Summary:  "Invokes command against all devices in the location subtree",
+			Body:     map[string]interface{}{},
+			Response: []*locationCommandResult{},
+			Handler:  s.locationCommandV2,
+		},
+		{
+			ID:       "listTriggers",
+			Method:   http.MethodGet,
+			Path:     "/trigger",
+			Summary:  "Returns triggers available for the user",
+			Response: []*knownTrigger{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetAllTriggers(user), nil
+			},
+		},
+		{
+			ID:       "getTriggerHistory",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/trigger/{%s}/history", urlTriggerID),
+			Summary:  "Returns trigger state history",
+			Response: map[enums.Property]map[int64]interface{}{},
+			Handler:  s.getTriggerHistoryV2,
+		},
+		{
+			ID:       "getState",
+			Method:   http.MethodGet,
+			Path:     "/state",
+			Summary:  "Returns server state required for UI to start",
+			Response: &currentState{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetCurrentState(user), nil
+			},
+		},
+		{
+			ID:       "listWorkers",
+			Method:   http.MethodGet,
+			Path:     "/worker",
+			Summary:  "Returns known workers",
+			Response: []*knownWorker{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetWorkers(user)
+			},
+		},
+		{
+			ID:       "getStatus",
+			Method:   http.MethodGet,
+			Path:     "/status",
+			Summary:  "Returns entities load status",
+			Response: []*knownEntity{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetStatus(user)
+			},
+		},
+		{
+			ID:       "getLockoutStatus",
+			Method:   http.MethodGet,
+			Path:     "/status/lockout",
+			Summary:  "Returns failed login attempts and lockouts",
+			Response: []*lockoutStatus{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetLockoutStatus(user)
+			},
+		},
+		{
+			ID:       "getWebhookStatus",
+			Method:   http.MethodGet,
+			Path:     "/status/webhook",
+			Summary:  "Returns outbound webhooks delivery status",
+			Response: []*providers.WebhookStatus{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.commandGetWebhooks(user)
+			},
+		},
+		{
+			ID:       "queryLogs",
+			Method:   http.MethodPost,
+			Path:     "/logs",
+			Summary:  "Queries logs history",
+			Body:     &common.LogHistoryRequest{},
+			Response: []*common.LogHistoryEntry{},
+			Handler: func(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+				return s.commandGetLogs(user, request.Body)
+			},
+		},
+		{
+			ID:       "listTokens",
+			Method:   http.MethodGet,
+			Path:     "/token",
+			Summary:  "Returns user's API tokens",
+			Response: []*providers.APIToken{},
+			Handler: func(user providers.IAuthenticatedUser, _ *http.Request) (interface{}, error) {
+				return s.Settings.Security().GetTokens(user)
+			},
+		},
+		{
+			ID:       "createToken",
+			Method:   http.MethodPost,
+			Path:     "/token",
+			Summary:  "Creates a new API token, optionally restricted by rules",
+			Body:     &providers.APITokenRequest{},
+			Response: &apiTokenResponse{},
+			Handler:  s.createTokenV2,
+		},
+		{
+			ID:       "revokeToken",
+			Method:   http.MethodDelete,
+			Path:     fmt.Sprintf("/token/{%s}", urlTokenID),
+			Summary:  "Revokes API token",
+			Response: &apiStatusResponse{},
+			Handler:  s.revokeTokenV2,
+		},
+		{
+			ID:      "queryAudit",
+			Method:  http.MethodGet,
+			Path:    "/audit",
+			Summary: "Queries user actions audit log, newest first",
+			Query: []*apiV2Param{
+				{Name: "from", Description: "Unix timestamp, inclusive"},
+				{Name: "to", Description: "Unix timestamp, inclusive"},
+				{Name: "user", Description: "User name"},
+				{Name: "target", Description: "Target ID glob"},
+				{Name: "action", Description: "Action"},
+				{Name: "limit", Description: "Max number of entries, defaults to 100"},
+			},
+			Response: []*audit.Entry{},
+			Handler:  s.getAuditV2,
+		},
+	}
+}
+
+// Returns filtered devices.
+func (s *GoHomeServer) getDevicesV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	query := request.URL.Query()
+	filter := &deviceFilter{
+		Types:    make([]enums.DeviceType, 0),
+		Location: query.Get("location"),
+		Worker:   query.Get("worker"),
+	}
+
+	for _, v := range query["type"] {
+		for _, t := range strings.Split(v, ",") {
+			devType, err := enums.DeviceTypeString(strings.TrimSpace(t))
+			if err != nil {
+				return nil, &ErrBadRequest{}
+			}
+
+			filter.Types = append(filter.Types, devType)
+		}
+	}
+
+	if id := query.Get("id"); "" != id {
+		exp, err := glob.Compile(id)
+		if err != nil {
+			return nil, &ErrBadRequest{}
+		}
+
+		filter.ID = exp
+	}
+
+	return s.commandGetFilteredDevices(user, filter)
+}
+
+// Returns a single device.
+func (s *GoHomeServer) getDeviceV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	deviceID := mux.Vars(request)[string(urlDeviceID)]
+	for _, v := range s.commandGetAllDevices(user) {
+		if v.ID == deviceID {
+			return v, nil
+		}
+	}
+
+	return nil, &ErrUnknownDevice{ID: deviceID}
+}
+
+// Executes device command.
+func (s *GoHomeServer) deviceCommandV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	vars := mux.Vars(request)
+	b, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, &ErrBadRequest{}
+	}
+
+	return statusOk(s.commandInvokeDeviceCommand(user, vars[string(urlDeviceID)], vars[string(urlCommandName)], b))
+}
+
+// Executes multiple devices commands.
+func (s *GoHomeServer) deviceBatchCommandV2(user providers.IAuthenticatedUser,
+	request *http.Request) (interface{}, error) {
+	req := &batchCommandRequest{}
+	err := json.NewDecoder(request.Body).Decode(req)
+	if err != nil {
+		return nil, &ErrBadRequest{}
+	}
+
+	return s.commandBatchInvoke(user, req.Commands), nil
+}
+
+// Returns device state history.
+func (s *GoHomeServer) getDeviceHistoryV2(user providers.IAuthenticatedUser,
+	request *http.Request) (interface{}, error) {
+	return s.commandGetDeviceHistory(user, mux.Vars(request)[string(urlDeviceID)])
+}
+
+// Returns trigger state history.
+func (s *GoHomeServer) getTriggerHistoryV2(user providers.IAuthenticatedUser,
+	request *http.Request) (interface{}, error) {
+	return s.commandGetTriggerHistory(user, mux.Vars(request)[string(urlTriggerID)])
+}
+
+// Captures scene.
+func (s *GoHomeServer) captureSceneV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	return statusOk(s.commandCaptureScene(user, mux.Vars(request)[string(urlSceneID)]))
+}
+
+// Processes person location report.
+func (s *GoHomeServer) personLocationV2(user providers.IAuthenticatedUser,
+	request *http.Request) (interface{}, error) {
+	b, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, &ErrBadRequest{}
+	}
+
+	return statusOk(s.commandInvokeDeviceCommand(user, mux.Vars(request)[string(urlPersonID)],
+		enums.CmdSetLocation.String(), b))
+}
+
+// Executes location command.
+func (s *GoHomeServer) locationCommandV2(user providers.IAuthenticatedUser,
+	request *http.Request) (interface{}, error) {
+	vars := mux.Vars(request)
+	b, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, &ErrBadRequest{}
+	}
+
+	return s.commandLocationCommand(user, vars[string(urlLocationID)], vars[string(urlCommandName)], b)
+}
+
+// Creates API token.
+func (s *GoHomeServer) createTokenV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	req := &providers.APITokenRequest{}
+	err := json.NewDecoder(request.Body).Decode(req)
+	if err != nil {
+		return nil, &ErrBadRequest{}
+	}
+
+	token, value, err := s.Settings.Security().CreateToken(user, req)
+	target := req.Name
+	if nil != token {
+		target = token.ID
+	}
+
+	s.audit(user, audit.ActionTokenCreate, target, req, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiTokenResponse{Token: token, Value: value}, nil
+}
+
+// Revokes API token.
+func (s *GoHomeServer) revokeTokenV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	tokenID := mux.Vars(request)[string(urlTokenID)]
+	err := s.Settings.Security().RevokeToken(user, tokenID)
+	s.audit(user, audit.ActionTokenRevoke, tokenID, nil, err)
+	return statusOk(err)
+}
+
+// Converts command result into API v2 response.
+func statusOk(err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiStatusResponse{Status: "OK"}, nil
+}
+
+// Converts error into API v2 error.
+func newAPIError(err error) *apiError {
+	e := &apiError{Message: err.Error()}
+	switch err.(type) {
+	case *ErrUnknownDevice, *ErrUnknownGroup, *ErrUnknownScene, *ErrUnknownLocation, *ErrUnknownTrigger,
+		*ErrUnknownSubscription, *security.ErrTokenNotFound:
+		e.Status = http.StatusNotFound
+		e.Code = errCodeNotFound
+	case *ErrForbidden, *alarm.ErrWrongCode, *mode.ErrForbiddenTransition, *security.ErrTokenManagement:
+		e.Status = http.StatusForbidden
+		e.Code = errCodeForbidden
+	case *ErrBadRequest, *ErrUnknownCommand, *ErrUnsupportedCommand,
+		*mode.ErrUnknownMode, *mode.ErrUnsupportedCommand,
+		*helper.ErrInvalidValue, *helper.ErrUnsupportedCommand,
+		*alarm.ErrUnsupportedCommand,
+		*person.ErrUnknownZone, *person.ErrInvalidLocation, *person.ErrUnsupportedCommand,
+		*security.ErrInvalidTokenRequest, *trigger.ErrHookBadPayload:
+		e.Status = http.StatusBadRequest
+		e.Code = errCodeBadRequest
+	case *trigger.ErrHookUnauthorized:
+		e.Status = http.StatusUnauthorized
+		e.Code = errCodeUnauthorized
+	case *alarm.ErrLocked:
+		e.Status = http.StatusTooManyRequests
+		e.Code = errCodeTooManyRequests
+	default:
+		e.Status = http.StatusInternalServerError
+		e.Code = errCodeInternal
+	}
+
+	return e
+}
+
+// Responds with API v2 error.
+func respondAPIError(writer http.ResponseWriter, err *apiError) {
+	respondStatus(writer, err.Status, &apiErrorResponse{Error: err})
+}
+
+// Responds with JSON data and status code.
+//noinspection GoUnhandledErrorResult
+func respondStatus(writer http.ResponseWriter, status int, data interface{}) {
+	d, err := json.Marshal(data)
+	if err != nil {
+		status = http.StatusInternalServerError
+		d = []byte(fmt.Sprintf(`{ "error": { "status": %d, "code": "%s", "message": "failed to marshal response" } }`,
+			status, errCodeInternal))
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	writer.Write(d) // nolint: gosec, errcheck
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/systems/alarm"
+	"go-home.io/x/server/systems/security"
+)
+
+// Returns router with registered API v2.
+func getAPIv2Router(srv *GoHomeServer) *mux.Router {
+	router := mux.NewRouter()
+	srv.registerAPIv2(router.PathPrefix(routeAPIv2).Subrouter())
+	return router
+}
+
+// Invokes API v2.
+func invokeAPIv2(router *mux.Router, method string, url string, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, routeAPIv2+url, strings.NewReader(body))
+	r := httptest.NewRecorder()
+	router.ServeHTTP(r, req)
+	return r
+}
+
+// Tests devices filtering.
+func TestGetDevicesAPIv2(t *testing.T) {
+	monkey.Patch(getContextUser, getFakeRootUser)
+	defer monkey.UnpatchAll()
+
+	srv := getLocationsServer(nil)
+	srv.state.(*serverState).KnownDevices["light1"] = &knownDevice{ID: "light1", Type: enums.DevLight, Worker: "3"}
+	router := getAPIv2Router(srv)
+
+	input := map[string][]string{
+		"":                                    {"dev1", "dev2", "dev3", "dev4", "light1", "sec1"},
+		"?type=light":                         {"light1"},
+		"?type=light,switch&id=d*":            {"dev1", "dev2", "dev3", "dev4"},
+		"?type=light&type=switch&worker=2":    {"dev3", "dev4", "sec1"},
+		"?location=upstairs":                  {"dev1", "dev2", "dev3", "sec1"},
+		"?location=upstairs&id=dev?&worker=1": {"dev1", "dev2"},
+		"?location=Default":                   {"light1"},
+		"?id=sec*":                            {"sec1"},
+		"?worker=5":                           {},
+	}
+
+	for k, v := range input {
+		r := invokeAPIv2(router, http.MethodGet, "/device"+k, "")
+		require.Equal(t, http.StatusOK, r.Code, k)
+
+		data := make([]*knownDevice, 0)
+		require.NoError(t, json.Unmarshal(r.Body.Bytes(), &data), k)
+
+		ids := make([]string, 0)
+		for _, d := range data {
+			ids = append(ids, d.ID)
+		}
+
+		assert.Equal(t, v, ids, k)
+	}
+
+	wrong := map[string]int{
+		"?type=wrong":     http.StatusBadRequest,
+		"?id=[":           http.StatusBadRequest,
+		"?location=wrong": http.StatusNotFound,
+	}
+
+	for k, v := range wrong {
+		r := invokeAPIv2(router, http.MethodGet, "/device"+k, "")
+		assert.Equal(t, v, r.Code, k)
+
+		resp := &apiErrorResponse{}
+		require.NoError(t, json.Unmarshal(r.Body.Bytes(), resp), k)
+		assert.Equal(t, v, resp.Error.Status, k)
+		assert.NotEmpty(t, resp.Error.Code, k)
+		assert.NotEmpty(t, resp.Error.Message, k)
+	}
+}
+
+// Tests API v2 status codes.
+func TestStatusCodesAPIv2(t *testing.T) {
+	monkey.Patch(getContextUser, getFakeRootUser)
+	defer monkey.UnpatchAll()
+
+	router := getAPIv2Router(getServer())
+	input := []struct {
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/device/dev1", "", http.StatusOK},
+		{http.MethodGet, "/device/dev2", "", http.StatusNotFound},
+		{http.MethodPost, "/device/dev1/command/on", "", http.StatusOK},
+		{http.MethodPost, "/device/g1/command/on", "", http.StatusOK},
+		{http.MethodPost, "/device/dev2/command/on", "", http.StatusNotFound},
+		{http.MethodPost, "/device/dev1/command/wrong", "", http.StatusBadRequest},
+		{http.MethodPost, "/device/device/command/set-brightness", "", http.StatusBadRequest},
+		{http.MethodPost, "/device/dev1/command/set-brightness", "{", http.StatusBadRequest},
+		{http.MethodPost, "/device/batch", `{"commands":[{"device":"dev1","command":"on"}]}`, http.StatusOK},
+		{http.MethodPost, "/device/batch", "[", http.StatusBadRequest},
+		{http.MethodGet, "/device/dev1/history", "", http.StatusOK},
+		{http.MethodGet, "/device/dev2/history", "", http.StatusNotFound},
+		{http.MethodGet, "/trigger/trigger1test.trigger/history", "", http.StatusOK},
+		{http.MethodGet, "/trigger/trigger123.trigger/history", "", http.StatusForbidden},
+		{http.MethodGet, "/trigger/wrong/history", "", http.StatusNotFound},
+		{http.MethodPost, "/location/wrong/command/on", "", http.StatusNotFound},
+		{http.MethodGet, "/worker", "", http.StatusOK},
+		{http.MethodPost, "/logs", "", http.StatusForbidden},
+		{http.MethodGet, "/state", "", http.StatusOK},
+		{http.MethodGet, "/group", "", http.StatusOK},
+		{http.MethodGet, "/token", "", http.StatusOK},
+		{http.MethodPost, "/token", "{", http.StatusBadRequest},
+		{http.MethodGet, "/openapi.json", "", http.StatusOK},
+	}
+
+	for _, v := range input {
+		r := invokeAPIv2(router, v.method, v.url, v.body)
+		assert.Equal(t, v.status, r.Code, "%s %s", v.method, v.url)
+		assert.Equal(t, "application/json", r.Header().Get("Content-Type"), v.url)
+	}
+}
+
+// Tests errors conversion.
+func TestNewAPIError(t *testing.T) {
+	input := map[error]int{
+		&ErrUnknownDevice{}:                http.StatusNotFound,
+		&ErrUnknownLocation{}:              http.StatusNotFound,
+		&ErrForbidden{}:                    http.StatusForbidden,
+		&alarm.ErrWrongCode{}:              http.StatusForbidden,
+		&alarm.ErrLocked{}:                 http.StatusTooManyRequests,
+		&ErrUnknownCommand{}:               http.StatusBadRequest,
+		&ErrBadRequest{}:                   http.StatusBadRequest,
+		&security.ErrTokenNotFound{}:       http.StatusNotFound,
+		&security.ErrTokenManagement{}:     http.StatusForbidden,
+		&security.ErrInvalidTokenRequest{}: http.StatusBadRequest,
+		errors.New("whatever"):             http.StatusInternalServerError,
+	}
+
+	for k, v := range input {
+		e := newAPIError(k)
+		assert.Equal(t, v, e.Status, k.Error())
+		assert.Equal(t, k.Error(), e.Message)
+	}
+}
+
+// Tests that OpenAPI document covers all routes and references are resolvable.
+func TestOpenAPISpec(t *testing.T) {
+	srv := getServer()
+	routes := srv.apiV2Routes()
+	spec := buildOpenAPISpec(routes)
+
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	doc := &struct {
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, doc))
+
+	ids := make(map[string]bool)
+	for _, v := range routes {
+		op, ok := doc.Paths[v.Path][strings.ToLower(v.Method)]
+		require.True(t, ok, "%s %s", v.Method, v.Path)
+		assert.Equal(t, v.ID, op.(map[string]interface{})["operationId"])
+		assert.False(t, ids[v.ID], "duplicated ID %s", v.ID)
+		ids[v.ID] = true
+	}
+
+	for _, v := range []string{"KnownDevice", "ApiErrorResponse", "ApiError", "CurrentState", "LogHistoryRequest"} {
+		assert.NotNil(t, doc.Components.Schemas[v], v)
+	}
+
+	for _, v := range strings.Split(string(data), `"$ref":"`)[1:] {
+		name := strings.TrimPrefix(v[:strings.Index(v, `"`)], openAPISchemas)
+		assert.NotNil(t, doc.Components.Schemas[name], name)
+	}
+
+	assert.Contains(t, string(data), `"worker_properties"`)
+	assert.NotContains(t, string(data), `"LastSeen"`)
+}
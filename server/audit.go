@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/glob"
+	"go-home.io/x/server/plugins/audit"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+)
+
+// Minimal period in seconds between audit records of the same API token usage.
+const tokenUseAuditPeriod = 60
+
+// Last audited usage of API tokens.
+type tokenUsage struct {
+	sync.Mutex
+	audited map[string]int64
+}
+
+// Authenticated user with request details, required for audit log.
+type requestUser struct {
+	providers.IAuthenticatedUser
+	ip        string
+	userAgent string
+}
+
+// Wraps authenticated user with request details.
+func newRequestUser(user providers.IAuthenticatedUser, r *http.Request) providers.IAuthenticatedUser {
+	return &requestUser{
+		IAuthenticatedUser: user,
+		ip:                 getRequestIP(r),
+		userAgent:          r.UserAgent(),
+	}
+}
+
+// Appends a new audit log entry.
+func (s *GoHomeServer) audit(user providers.IAuthenticatedUser, action string, target string,
+	payload interface{}, err error) {
+	entry := &audit.Entry{
+		User:    user.Name(),
+		Action:  action,
+		Target:  target,
+		Payload: payload,
+		Success: nil == err,
+	}
+
+	if ru, ok := user.(*requestUser); ok {
+		entry.IP = ru.ip
+		entry.UserAgent = ru.userAgent
+	}
+
+	if nil != err {
+		entry.Result = err.Error()
+	}
+
+	s.Settings.Audit().Record(entry)
+}
+
+// Records API token usage, at most once per period for every token.
+func (s *GoHomeServer) auditTokenUse(user providers.IAuthenticatedUser) {
+	tokenID := user.TokenID()
+	if "" == tokenID {
+		return
+	}
+
+	now := utils.TimeNow()
+	s.tokenUsage.Lock()
+	if nil == s.tokenUsage.audited {
+		s.tokenUsage.audited = make(map[string]int64)
+	}
+
+	last, ok := s.tokenUsage.audited[tokenID]
+	if ok && now-last < tokenUseAuditPeriod {
+		s.tokenUsage.Unlock()
+		return
+	}
+
+	s.tokenUsage.audited[tokenID] = now
+	s.tokenUsage.Unlock()
+
+	s.audit(user, audit.ActionTokenUse, tokenID, nil, nil)
+}
+
+// Returns audit action for the device command.
+func getAuditCommandAction(device *knownDevice) string {
+	if nil != device && device.Type == enums.DevGroup {
+		return audit.ActionGroupCommand
+	}
+
+	return audit.ActionDeviceCommand
+}
+
+// Returns audit payload for the command.
+// Command data is preserved as JSON if possible.
+func getAuditCommandPayload(cmdName string, data []byte) map[string]interface{} {
+	payload := map[string]interface{}{"command": cmdName}
+	if 0 == len(data) {
+		return payload
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		payload["data"] = string(data)
+	} else {
+		payload["data"] = redactAuditData(value)
+	}
+
+	return payload
+}
+
+// Replaces values of sensitive keys, e.g. alarm PIN, so they never reach audit storage.
+func redactAuditData(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if helpers.SliceContainsString(auditSensitiveKeys, strings.ToLower(key)) {
+				result[key] = auditRedacted
+				continue
+			}
+
+			result[key] = redactAuditData(item)
+		}
+
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactAuditData(item)
+		}
+
+		return result
+	}
+
+	return value
+}
+
+// Returns audit payload for the batch command item.
+func getAuditBatchPayload(item *batchCommandItem) map[string]interface{} {
+	payload := map[string]interface{}{"command": item.Command}
+	if nil != item.Value {
+		payload["data"] = redactAuditData(item.Value)
+	}
+
+	return payload
+}
+
+// Queries audit log.
+func (s *GoHomeServer) commandGetAudit(user providers.IAuthenticatedUser, query *audit.Query) ([]*audit.Entry, error) {
+	if !user.Audit() {
+		return nil, &ErrForbidden{}
+	}
+
+	return s.Settings.Audit().Query(query)
+}
+
+// Returns audit log entries.
+func (s *GoHomeServer) getAuditV2(user providers.IAuthenticatedUser, request *http.Request) (interface{}, error) {
+	values := request.URL.Query()
+	query := &audit.Query{
+		User:   values.Get("user"),
+		Target: values.Get("target"),
+		Action: values.Get("action"),
+	}
+
+	for k, v := range map[string]*int64{"from": &query.From, "to": &query.To} {
+		if "" == values.Get(k) {
+			continue
+		}
+
+		n, err := strconv.ParseInt(values.Get(k), 10, 64)
+		if err != nil {
+			return nil, &ErrBadRequest{}
+		}
+
+		*v = n
+	}
+
+	if "" != values.Get("limit") {
+		n, err := strconv.Atoi(values.Get("limit"))
+		if err != nil || n < 0 {
+			return nil, &ErrBadRequest{}
+		}
+
+		query.Limit = n
+	}
+
+	if "" != query.Target {
+		if _, err := glob.Compile(query.Target); err != nil {
+			return nil, &ErrBadRequest{}
+		}
+	}
+
+	return s.commandGetAudit(user, query)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/audit"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/security"
+)
+
+// Returns root user with request details.
+func getAuditUser() providers.IAuthenticatedUser {
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/device", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("User-Agent", "test-agent")
+	return newRequestUser(getFakeRootUser(r), r)
+}
+
+// Returns recorded audit entries.
+func getAuditEntries(srv *GoHomeServer) []*audit.Entry {
+	return srv.Settings.Audit().(mocks.IFakeAudit).GetEntries()
+}
+
+// Tests device and group commands audit.
+func TestAuditCommands(t *testing.T) {
+	srv := getServer()
+	user := getAuditUser()
+
+	assert.NoError(t, srv.commandInvokeDeviceCommand(user, "dev1", enums.CmdSetBrightness.String(),
+		[]byte(`{"value": 10}`)))
+	assert.NoError(t, srv.commandInvokeDeviceCommand(user, "g1", enums.CmdOn.String(), []byte("5")))
+	assert.Error(t, srv.commandInvokeDeviceCommand(user, "wrong", enums.CmdOn.String(), nil))
+
+	entries := getAuditEntries(srv)
+	require.Equal(t, 3, len(entries))
+
+	assert.Equal(t, &audit.Entry{
+		User:      "test",
+		IP:        "10.0.0.1",
+		UserAgent: "test-agent",
+		Action:    audit.ActionDeviceCommand,
+		Target:    "dev1",
+		Payload: map[string]interface{}{
+			"command": enums.CmdSetBrightness.String(),
+			"data":    map[string]interface{}{"value": 10.0},
+		},
+		Success: true,
+	}, entries[0])
+
+	assert.Equal(t, audit.ActionGroupCommand, entries[1].Action, "group")
+	assert.Equal(t, 5.0, entries[1].Payload.(map[string]interface{})["data"], "value data")
+	assert.Equal(t, "on", getAuditCommandPayload("cmd", []byte("on"))["data"], "raw data")
+
+	assert.False(t, entries[2].Success, "failed")
+	assert.Equal(t, (&ErrUnknownDevice{ID: "wrong"}).Error(), entries[2].Result, "result")
+}
+
+// Tests batch commands audit.
+func TestAuditBatch(t *testing.T) {
+	srv := getServer()
+	srv.commandBatchInvoke(getAuditUser(), []*batchCommandItem{
+		{Device: "dev1", Command: enums.CmdOn.String(), Value: true},
+		{Device: "wrong", Command: enums.CmdOn.String()},
+	})
+
+	entries := getAuditEntries(srv)
+	require.Equal(t, 2, len(entries))
+	assert.True(t, entries[0].Success, "dev1")
+	assert.Equal(t, map[string]interface{}{"command": "on", "data": true}, entries[0].Payload, "payload")
+	assert.Equal(t, "wrong", entries[1].Target, "target")
+	assert.False(t, entries[1].Success, "wrong")
+}
+
+// Tests that sensitive command data is not recorded.
+func TestAuditRedaction(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"command": enums.CmdDisarm.String(),
+		"data":    map[string]interface{}{"code": auditRedacted},
+	}, getAuditCommandPayload(enums.CmdDisarm.String(), []byte(`{"code": "1234"}`)), "alarm code")
+
+	payload := getAuditCommandPayload("cmd", []byte(`[{"Password": "p", "value": 1}]`))
+	assert.Equal(t, []interface{}{map[string]interface{}{"Password": auditRedacted, "value": 1.0}},
+		payload["data"], "nested")
+
+	payload = getAuditBatchPayload(&batchCommandItem{Command: enums.CmdArmAway.String(),
+		Value: map[string]interface{}{"pin": "1234", "mode": "away"}})
+	assert.Equal(t, map[string]interface{}{"pin": auditRedacted, "mode": "away"}, payload["data"], "batch")
+}
+
+// Tests tokens audit.
+func TestAuditTokens(t *testing.T) {
+	srv := getServer()
+	user := getAuditUser()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/token", strings.NewReader(`{"name": "test"}`))
+	_, err := srv.createTokenV2(user, r)
+	assert.Error(t, err)
+
+	r = mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v2/token/1", nil),
+		map[string]string{string(urlTokenID): "1"})
+	_, err = srv.revokeTokenV2(user, r)
+	assert.Error(t, err)
+
+	entries := getAuditEntries(srv)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, audit.ActionTokenCreate, entries[0].Action, "create")
+	assert.Equal(t, "test", entries[0].Target, "create target")
+	assert.Equal(t, "test", entries[0].Payload.(*providers.APITokenRequest).Name, "create payload")
+	assert.Equal(t, audit.ActionTokenRevoke, entries[1].Action, "revoke")
+	assert.Equal(t, "1", entries[1].Target, "revoke target")
+}
+
+// Tests API token usage audit.
+func TestAuditTokenUse(t *testing.T) {
+	srv := getServer()
+	user := getAuditUser()
+	srv.auditTokenUse(user)
+	assert.Equal(t, 0, len(getAuditEntries(srv)), "not a token")
+
+	user.(*requestUser).IAuthenticatedUser.(*security.AuthenticatedUser).Token = "t1"
+	srv.auditTokenUse(user)
+	srv.auditTokenUse(user)
+
+	entries := getAuditEntries(srv)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, audit.ActionTokenUse, entries[0].Action, "action")
+	assert.Equal(t, "t1", entries[0].Target, "target")
+	assert.Equal(t, "10.0.0.1", entries[0].IP, "ip")
+}
+
+// Tests audit log query.
+func TestAuditQuery(t *testing.T) {
+	defer monkey.UnpatchAll()
+	srv := getServer()
+	srv.commandInvokeDeviceCommand(getAuditUser(), "dev1", enums.CmdOn.String(), nil) // nolint: errcheck, gosec
+
+	_, err := srv.getAuditV2(&security.AuthenticatedUser{Username: "usr"},
+		httptest.NewRequest(http.MethodGet, "/api/v2/audit", nil))
+	assert.IsType(t, &ErrForbidden{}, err, "forbidden")
+
+	user := getFakeRootUser(nil)
+	for _, v := range []string{"from=a", "to=1.5", "limit=-1", "limit=a", "target=["} {
+		_, err := srv.getAuditV2(user, httptest.NewRequest(http.MethodGet, "/api/v2/audit?"+v, nil))
+		assert.IsType(t, &ErrBadRequest{}, err, v)
+	}
+
+	r, err := srv.getAuditV2(user,
+		httptest.NewRequest(http.MethodGet, "/api/v2/audit?from=1&to=2&user=test&target=dev*&limit=5", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(r.([]*audit.Entry)))
+}
+
+// Tests that authenticated user is wrapped with request details.
+func TestAuditRequestUser(t *testing.T) {
+	prepareCidrs()
+	s := &GoHomeServer{
+		Logger:   mocks.FakeNewLogger(nil),
+		Settings: mocks.FakeNewSettingsWithUserStorage(mocks.FakeNewSecurityProvider(true)),
+	}
+
+	var user providers.IAuthenticatedUser
+	handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user = getContextUser(req)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/device", nil)
+	r.Header.Set("User-Agent", "agent")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.NotNil(t, user)
+	ru, ok := user.(*requestUser)
+	require.True(t, ok, "wrapped")
+	assert.Equal(t, "agent", ru.userAgent, "agent")
+	assert.Equal(t, "192.0.2.1", ru.ip, "ip")
+	assert.Equal(t, "test", user.Name(), "name")
+}
@@ -3,14 +3,19 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
+	"strings"
 
 	"github.com/gobwas/glob"
+	"go-home.io/x/server/plugins/audit"
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/plugins/device/enums"
 	"go-home.io/x/server/plugins/helpers"
 	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
 	"go-home.io/x/server/systems/bus"
+	"go-home.io/x/server/utils"
 )
 
 const (
@@ -51,7 +56,8 @@ func (s *GoHomeServer) InternalCommandInvokeDeviceCommand(
 		s.Logger.Debug("Invoking device operation", common.LogSystemToken, logSystem,
 			common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
 
-		if v.Type == enums.DevGroup {
+		switch v.Type {
+		case enums.DevGroup:
 			g, ok := s.groups[v.ID]
 			if !ok {
 				s.Logger.Warn("Received unknown group", common.LogSystemToken, logSystem,
@@ -59,7 +65,60 @@ func (s *GoHomeServer) InternalCommandInvokeDeviceCommand(
 				continue
 			}
 			g.InvokeCommand(cmd, data)
-		} else {
+		case enums.DevScene:
+			sc, ok := s.scenes[v.ID]
+			if !ok {
+				s.Logger.Warn("Received unknown scene", common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+				continue
+			}
+			sc.InvokeCommand(cmd, data)
+		case enums.DevMode:
+			if nil == s.mode || s.mode.ID() != v.ID {
+				continue
+			}
+
+			if err := s.mode.InvokeCommand(cmd, data); err != nil {
+				s.Logger.Error("Failed to change home mode", err, common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+			}
+		case enums.DevHelper:
+			h, ok := s.helpers[v.ID]
+			if !ok {
+				s.Logger.Warn("Received unknown helper", common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+				continue
+			}
+
+			if err := h.InvokeCommand(cmd, data); err != nil {
+				s.Logger.Error("Failed to invoke helper command", err, common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+			}
+		case enums.DevAlarm:
+			a, ok := s.alarms[v.ID]
+			if !ok {
+				s.Logger.Warn("Received unknown alarm", common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+				continue
+			}
+
+			if err := a.InvokeCommand(cmd, data); err != nil {
+				s.Logger.Error("Failed to invoke alarm command", err, common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+			}
+		case enums.DevPerson:
+			pr, ok := s.persons[v.ID]
+			if !ok {
+				s.Logger.Warn("Received unknown person", common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+				continue
+			}
+
+			if err := pr.InvokeCommand(cmd, data); err != nil {
+				s.Logger.Error("Failed to invoke person command", err, common.LogSystemToken, logSystem,
+					common.LogIDToken, v.ID, common.LogDeviceCommandToken, cmd.String())
+			}
+		default:
 			s.Settings.ServiceBus().PublishToWorker(v.Worker,
 				bus.NewDeviceCommandMessage(v.ID, cmd, data))
 		}
@@ -69,18 +128,52 @@ func (s *GoHomeServer) InternalCommandInvokeDeviceCommand(
 // Invokes device command if it's allowed for the user.
 func (s *GoHomeServer) commandInvokeDeviceCommand(user providers.IAuthenticatedUser,
 	deviceID string, cmdName string, data []byte) error {
+	err := s.invokeDeviceCommand(user, deviceID, cmdName, data)
+	s.audit(user, getAuditCommandAction(s.state.GetDevice(deviceID)), deviceID,
+		getAuditCommandPayload(cmdName, data), err)
+	return err
+}
+
+// Validates and invokes device command.
+func (s *GoHomeServer) invokeDeviceCommand(user providers.IAuthenticatedUser,
+	deviceID string, cmdName string, data []byte) error {
+	knownDevice, command, err := s.commandValidateDeviceCommand(user, deviceID, cmdName)
+	if err != nil {
+		return err
+	}
+
+	inputData, err := s.parseCommandData(data)
+	if err != nil {
+		return err
+	}
+
+	if isMaster, err := s.commandInvokeMasterDevice(user, knownDevice, command, inputData); isMaster {
+		return err
+	}
+
+	s.Logger.Debug("Invoking device operation", common.LogSystemToken, logSystem,
+		common.LogIDToken, deviceID, common.LogDeviceCommandToken, cmdName,
+		common.LogUserNameToken, user.Name())
+	s.Settings.ServiceBus().PublishToWorker(knownDevice.Worker,
+		bus.NewDeviceCommandMessage(deviceID, command, inputData))
+	return nil
+}
+
+// Validates whether device command is allowed for the user and supported by the device.
+func (s *GoHomeServer) commandValidateDeviceCommand(user providers.IAuthenticatedUser,
+	deviceID string, cmdName string) (*knownDevice, enums.Command, error) {
 	knownDevice := s.state.GetDevice(deviceID)
 	if nil == knownDevice {
 		s.Logger.Warn("Failed to find device", common.LogSystemToken, logSystem,
 			common.LogIDToken, deviceID, common.LogUserNameToken, user.Name())
-		return &ErrUnknownDevice{ID: deviceID}
+		return nil, 0, &ErrUnknownDevice{ID: deviceID}
 	}
 
 	// We don't want to allow to brute-forth device names, so returning generic error
-	if !user.DeviceCommand(knownDevice.ID) {
+	if !isCommandAllowed(user, knownDevice, cmdName) {
 		s.Logger.Warn("User doesn't have access to this device", common.LogSystemToken, logSystem,
 			common.LogIDToken, deviceID, common.LogUserNameToken, user.Name())
-		return &ErrUnknownDevice{ID: deviceID}
+		return nil, 0, &ErrUnknownDevice{ID: deviceID}
 	}
 
 	command, err := enums.CommandString(cmdName)
@@ -88,40 +181,148 @@ func (s *GoHomeServer) commandInvokeDeviceCommand(user providers.IAuthenticatedU
 		s.Logger.Warn("Received unknown command", common.LogSystemToken, logSystem,
 			common.LogIDToken, deviceID, common.LogDeviceCommandToken, cmdName,
 			common.LogUserNameToken, user.Name())
-		return &ErrUnknownCommand{Name: cmdName}
+		return nil, 0, &ErrUnknownCommand{Name: cmdName}
 	}
 
 	if !helpers.SliceContainsString(knownDevice.Commands, cmdName) {
 		s.Logger.Warn("Received command is not supported", common.LogSystemToken, logSystem,
 			common.LogIDToken, deviceID, common.LogDeviceCommandToken, cmdName,
 			common.LogUserNameToken, user.Name())
-		return &ErrUnsupportedCommand{Name: cmdName}
+		return nil, 0, &ErrUnsupportedCommand{Name: cmdName}
 	}
 
-	inputData := make(map[string]interface{})
-	if len(data) > 0 {
-		err := json.Unmarshal(data, &inputData)
+	return knownDevice, command, nil
+}
+
+// Checks whether user is allowed to invoke the command.
+// Alarm panels are controlled by arm and disarm verbs instead of the generic command one.
+func isCommandAllowed(user providers.IAuthenticatedUser, knownDevice *knownDevice, cmdName string) bool {
+	if enums.DevAlarm != knownDevice.Type {
+		return user.DeviceCommand(knownDevice.ID)
+	}
+
+	if enums.CmdDisarm.String() == cmdName {
+		return user.AlarmDisarm(knownDevice.ID)
+	}
+
+	return user.AlarmArm(knownDevice.ID)
+}
+
+// Invokes command of the device hosted by master.
+// Returns false if device belongs to a worker.
+func (s *GoHomeServer) commandInvokeMasterDevice(user providers.IAuthenticatedUser, knownDevice *knownDevice,
+	command enums.Command, data map[string]interface{}) (bool, error) {
+	switch knownDevice.Type {
+	case enums.DevGroup:
+		return true, s.commandGroupCommand(user, knownDevice.ID, command, data)
+	case enums.DevScene:
+		return true, s.commandSceneCommand(user, knownDevice.ID, command, data)
+	case enums.DevMode:
+		return true, s.commandModeCommand(user, knownDevice.ID, command, data)
+	case enums.DevHelper:
+		return true, s.commandHelperCommand(user, knownDevice.ID, command, data)
+	case enums.DevAlarm:
+		return true, s.commandAlarmCommand(user, knownDevice.ID, command, data)
+	case enums.DevPerson:
+		return true, s.commandPersonCommand(user, knownDevice.ID, command, data)
+	}
+
+	return false, nil
+}
+
+// Invokes command against every capable device in the location subtree.
+func (s *GoHomeServer) commandLocationCommand(user providers.IAuthenticatedUser,
+	locationID string, cmdName string, data []byte) ([]*locationCommandResult, error) {
+	results, err := s.invokeLocationCommand(user, locationID, cmdName, data)
+	s.audit(user, audit.ActionLocationCommand, locationID, getAuditCommandPayload(cmdName, data), err)
+	return results, err
+}
+
+// Validates and invokes location command.
+// Groups are skipped, since their members might be outside of the location,
+// members within the subtree receive the command directly.
+func (s *GoHomeServer) invokeLocationCommand(user providers.IAuthenticatedUser,
+	locationID string, cmdName string, data []byte) ([]*locationCommandResult, error) {
+	if nil == s.getLocation(locationID) {
+		s.Logger.Warn("Failed to find location", common.LogSystemToken, logSystem,
+			common.LogIDToken, locationID, common.LogUserNameToken, user.Name())
+		return nil, &ErrUnknownLocation{Name: locationID}
+	}
+
+	command, err := enums.CommandString(cmdName)
+	if err != nil {
+		s.Logger.Warn("Received unknown command", common.LogSystemToken, logSystem,
+			common.LogIDToken, locationID, common.LogDeviceCommandToken, cmdName,
+			common.LogUserNameToken, user.Name())
+		return nil, &ErrUnknownCommand{Name: cmdName}
+	}
+
+	inputData, err := s.parseCommandData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*locationCommandResult, 0)
+	for _, v := range s.getLocationSubtreeDevices(locationID) {
+		kd := s.state.GetDevice(v)
+		if nil == kd || enums.DevGroup == kd.Type || !isCommandAllowed(user, kd, cmdName) ||
+			!helpers.SliceContainsString(kd.Commands, cmdName) {
+			continue
+		}
+
+		s.Logger.Debug("Invoking location device operation", common.LogSystemToken, logSystem,
+			common.LogIDToken, kd.ID, common.LogDeviceCommandToken, cmdName,
+			common.LogUserNameToken, user.Name())
+
+		result := &locationCommandResult{Device: kd.ID, Status: "OK"}
+		results = append(results, result)
+
+		isMaster, err := s.commandInvokeMasterDevice(user, kd, command, inputData)
 		if err != nil {
-			data = []byte(fmt.Sprintf(`{ "value" : %s  }`, string(data)))
-			err := json.Unmarshal(data, &inputData)
-			if err != nil {
-				s.Logger.Error("Failed to unmarshal input request", err,
-					common.LogSystemToken, logSystem)
-				return &ErrBadRequest{}
-			}
+			s.Logger.Error("Failed to invoke location device operation", err, common.LogSystemToken, logSystem,
+				common.LogIDToken, kd.ID, common.LogDeviceCommandToken, cmdName,
+				common.LogUserNameToken, user.Name())
+			result.Status = "ERROR"
+			result.Error = newAPIError(err)
+			continue
+		}
+
+		if !isMaster {
+			s.Settings.ServiceBus().PublishToWorker(kd.Worker,
+				bus.NewDeviceCommandMessage(kd.ID, command, inputData))
 		}
 	}
 
-	if knownDevice.Type == enums.DevGroup {
-		return s.commandGroupCommand(user, knownDevice.ID, command, inputData)
+	if 0 == len(results) {
+		s.Logger.Warn("No devices in location support the command", common.LogSystemToken, logSystem,
+			common.LogIDToken, locationID, common.LogDeviceCommandToken, cmdName,
+			common.LogUserNameToken, user.Name())
+		return nil, &ErrUnsupportedCommand{Name: cmdName}
 	}
 
-	s.Logger.Debug("Invoking device operation", common.LogSystemToken, logSystem,
-		common.LogIDToken, deviceID, common.LogDeviceCommandToken, cmdName,
-		common.LogUserNameToken, user.Name())
-	s.Settings.ServiceBus().PublishToWorker(knownDevice.Worker,
-		bus.NewDeviceCommandMessage(deviceID, command, inputData))
-	return nil
+	return results, nil
+}
+
+// Parses command input data.
+// Non-object input is wrapped into { "value": data }.
+func (s *GoHomeServer) parseCommandData(data []byte) (map[string]interface{}, error) {
+	inputData := make(map[string]interface{})
+	if 0 == len(data) {
+		return inputData, nil
+	}
+
+	err := json.Unmarshal(data, &inputData)
+	if err != nil {
+		data = []byte(fmt.Sprintf(`{ "value" : %s  }`, string(data)))
+		err := json.Unmarshal(data, &inputData)
+		if err != nil {
+			s.Logger.Error("Failed to unmarshal input request", err,
+				common.LogSystemToken, logSystem)
+			return nil, &ErrBadRequest{}
+		}
+	}
+
+	return inputData, nil
 }
 
 // Invokes group command
@@ -139,35 +340,236 @@ func (s *GoHomeServer) commandGroupCommand(user providers.IAuthenticatedUser,
 	return nil
 }
 
+// Invokes scene command.
+func (s *GoHomeServer) commandSceneCommand(user providers.IAuthenticatedUser,
+	sceneID string, cmd enums.Command, data map[string]interface{}) error {
+	sc, ok := s.scenes[sceneID]
+	if !ok {
+		s.Logger.Warn("Received unknown scene", common.LogSystemToken, logSystem,
+			common.LogIDToken, sceneID, common.LogDeviceCommandToken, cmd.String(),
+			common.LogUserNameToken, user.Name())
+		return &ErrUnknownScene{Name: sceneID}
+	}
+
+	sc.InvokeCommand(cmd, data)
+	return nil
+}
+
+// Invokes home mode command.
+func (s *GoHomeServer) commandModeCommand(user providers.IAuthenticatedUser,
+	modeID string, cmd enums.Command, data map[string]interface{}) error {
+	if nil == s.mode || s.mode.ID() != modeID {
+		s.Logger.Warn("Received unknown mode", common.LogSystemToken, logSystem,
+			common.LogIDToken, modeID, common.LogUserNameToken, user.Name())
+		return &ErrUnknownDevice{ID: modeID}
+	}
+
+	err := s.mode.InvokeCommand(cmd, data)
+	if err != nil {
+		s.Logger.Warn("Failed to change home mode", common.LogSystemToken, logSystem,
+			common.LogIDToken, modeID, common.LogDeviceCommandToken, cmd.String(),
+			common.LogUserNameToken, user.Name(), common.LogErrorToken, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Invokes input helper command.
+func (s *GoHomeServer) commandHelperCommand(user providers.IAuthenticatedUser,
+	helperID string, cmd enums.Command, data map[string]interface{}) error {
+	h, ok := s.helpers[helperID]
+	if !ok {
+		s.Logger.Warn("Received unknown helper", common.LogSystemToken, logSystem,
+			common.LogIDToken, helperID, common.LogUserNameToken, user.Name())
+		return &ErrUnknownDevice{ID: helperID}
+	}
+
+	err := h.InvokeCommand(cmd, data)
+	if err != nil {
+		s.Logger.Warn("Failed to invoke helper command", common.LogSystemToken, logSystem,
+			common.LogIDToken, helperID, common.LogDeviceCommandToken, cmd.String(),
+			common.LogUserNameToken, user.Name(), common.LogErrorToken, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Invokes alarm command if user has corresponding alarm verb.
+func (s *GoHomeServer) commandAlarmCommand(user providers.IAuthenticatedUser,
+	alarmID string, cmd enums.Command, data map[string]interface{}) error {
+	a, ok := s.alarms[alarmID]
+	if !ok {
+		s.Logger.Warn("Received unknown alarm", common.LogSystemToken, logSystem,
+			common.LogIDToken, alarmID, common.LogUserNameToken, user.Name())
+		return &ErrUnknownDevice{ID: alarmID}
+	}
+
+	allowed := user.AlarmArm(alarmID)
+	if enums.CmdDisarm == cmd {
+		allowed = user.AlarmDisarm(alarmID)
+	}
+
+	if !allowed {
+		s.Logger.Warn("User doesn't have access to this alarm", common.LogSystemToken, logSystem,
+			common.LogIDToken, alarmID, common.LogDeviceCommandToken, cmd.String(),
+			common.LogUserNameToken, user.Name())
+		return &ErrUnknownDevice{ID: alarmID}
+	}
+
+	err := a.InvokeCommand(cmd, data)
+	if err != nil {
+		s.Logger.Warn("Failed to invoke alarm command", common.LogSystemToken, logSystem,
+			common.LogIDToken, alarmID, common.LogDeviceCommandToken, cmd.String(),
+			common.LogUserNameToken, user.Name(), common.LogErrorToken, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Invokes person command.
+func (s *GoHomeServer) commandPersonCommand(user providers.IAuthenticatedUser,
+	personID string, cmd enums.Command, data map[string]interface{}) error {
+	pr, ok := s.persons[personID]
+	if !ok {
+		s.Logger.Warn("Received unknown person", common.LogSystemToken, logSystem,
+			common.LogIDToken, personID, common.LogUserNameToken, user.Name())
+		return &ErrUnknownDevice{ID: personID}
+	}
+
+	err := pr.InvokeCommand(cmd, data)
+	if err != nil {
+		s.Logger.Warn("Failed to invoke person command", common.LogSystemToken, logSystem,
+			common.LogIDToken, personID, common.LogDeviceCommandToken, cmd.String(),
+			common.LogUserNameToken, user.Name(), common.LogErrorToken, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Captures current devices state into the scene if it's allowed for the user.
+func (s *GoHomeServer) commandCaptureScene(user providers.IAuthenticatedUser, sceneID string) error {
+	err := s.invokeCaptureScene(user, sceneID)
+	s.audit(user, audit.ActionSceneCapture, sceneID, nil, err)
+	return err
+}
+
+// Validates access and captures the scene.
+func (s *GoHomeServer) invokeCaptureScene(user providers.IAuthenticatedUser, sceneID string) error {
+	sc, ok := s.scenes[sceneID]
+	if !ok || !user.DeviceCommand(sceneID) {
+		s.Logger.Warn("Failed to find scene", common.LogSystemToken, logSystem,
+			common.LogIDToken, sceneID, common.LogUserNameToken, user.Name())
+		return &ErrUnknownScene{Name: sceneID}
+	}
+
+	for _, v := range sc.Devices() {
+		if !user.DeviceGet(v) {
+			s.Logger.Warn("User doesn't have access to scene device", common.LogSystemToken, logSystem,
+				common.LogIDToken, v, common.LogUserNameToken, user.Name())
+			return &ErrUnknownScene{Name: sceneID}
+		}
+	}
+
+	sc.Capture()
+	return nil
+}
+
+// Returns all allowed for the user persons.
+func (s *GoHomeServer) commandGetAllPersons(user providers.IAuthenticatedUser) []*knownPerson {
+	response := make([]*knownPerson, 0)
+	for _, v := range s.commandGetAllDevices(user) {
+		if v.Type != enums.DevPerson {
+			continue
+		}
+
+		pr, ok := s.persons[v.ID]
+		if !ok {
+			continue
+		}
+
+		response = append(response, &knownPerson{
+			ID:       v.ID,
+			Name:     v.Name,
+			Presence: pr.Presence(),
+			LastSeen: pr.LastSeen(),
+		})
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Name < response[j].Name
+	})
+	return response
+}
+
+// Returns all allowed for the user scenes.
+func (s *GoHomeServer) commandGetAllScenes(user providers.IAuthenticatedUser) []*knownScene {
+	response := make([]*knownScene, 0)
+	for _, v := range s.commandGetAllDevices(user) {
+		if v.Type != enums.DevScene {
+			continue
+		}
+
+		sc, ok := s.scenes[v.ID]
+		if !ok {
+			continue
+		}
+
+		scene := &knownScene{
+			ID:      v.ID,
+			Name:    v.Name,
+			Devices: make([]string, 0),
+		}
+
+		for _, dev := range sc.Devices() {
+			if user.DeviceGet(dev) {
+				scene.Devices = append(scene.Devices, dev)
+			}
+		}
+
+		response = append(response, scene)
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Name < response[j].Name
+	})
+	return response
+}
+
 // Returns all allowed for the user devices.
 func (s *GoHomeServer) commandGetAllDevices(user providers.IAuthenticatedUser) []*knownDevice {
 	allowedDevices := make([]*knownDevice, 0)
-	isWorkerAllowed := user.Workers()
-
 	for _, v := range s.state.GetAllDevices() {
-		worker := v.Worker
-		if !isWorkerAllowed {
-			worker = ""
-		}
-
 		if user.DeviceGet(v.ID) {
-			d := &knownDevice{
-				ID:         v.ID,
-				Type:       v.Type,
-				State:      v.State,
-				Name:       v.Name,
-				Worker:     worker,
-				Commands:   v.Commands,
-				LastSeen:   v.LastSeen,
-				IsReadOnly: !user.DeviceCommand(v.ID),
-			}
-			allowedDevices = append(allowedDevices, d)
+			allowedDevices = append(allowedDevices, newUserDevice(user, v))
 		}
 	}
 
 	return allowedDevices
 }
 
+// Returns device as it's visible for the user.
+func newUserDevice(user providers.IAuthenticatedUser, device *knownDevice) *knownDevice {
+	worker := device.Worker
+	if !user.Workers() {
+		worker = ""
+	}
+
+	return &knownDevice{
+		ID:         device.ID,
+		Type:       device.Type,
+		State:      device.State,
+		Name:       device.Name,
+		Worker:     worker,
+		Commands:   device.Commands,
+		LastSeen:   device.LastSeen,
+		IsReadOnly: !user.DeviceCommand(device.ID),
+	}
+}
+
 // Returns all allowed triggers.
 func (s *GoHomeServer) commandGetAllTriggers(user providers.IAuthenticatedUser) []*knownTrigger {
 	allowedTriggers := make([]*knownTrigger, 0)
@@ -241,6 +643,7 @@ func (s *GoHomeServer) commandGetAllLocations(user providers.IAuthenticatedUser)
 		location := &knownLocation{
 			Name:    v.ID(),
 			Icon:    v.Icon(),
+			Parent:  v.Parent(),
 			Devices: make([]string, 0),
 		}
 
@@ -254,7 +657,8 @@ func (s *GoHomeServer) commandGetAllLocations(user providers.IAuthenticatedUser)
 			devicesProcessed = append(devicesProcessed, dev)
 		}
 
-		if 0 != len(location.Devices) {
+		// Parent locations are kept even without own devices.
+		if 0 != len(location.Devices) || s.hasAllowedLocationDevices(user, v.ID()) {
 			sort.Strings(location.Devices)
 			response = append(response, location)
 		}
@@ -303,6 +707,17 @@ func (s *GoHomeServer) commandGetAllLocations(user providers.IAuthenticatedUser)
 	return response
 }
 
+// Checks whether user is allowed to see any device in the location subtree.
+func (s *GoHomeServer) hasAllowedLocationDevices(user providers.IAuthenticatedUser, locationID string) bool {
+	for _, v := range s.getLocationSubtreeDevices(locationID) {
+		if nil != s.state.GetDevice(v) && user.DeviceGet(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Checks whether this device has been claimed as a part of a group.
 func groupsHasDevice(groups []*knownGroup, deviceID string) bool {
 	for _, g := range groups {
@@ -313,3 +728,315 @@ func groupsHasDevice(groups []*knownGroup, deviceID string) bool {
 
 	return false
 }
+
+// Returns server state required for UI to start.
+func (s *GoHomeServer) commandGetCurrentState(user providers.IAuthenticatedUser) *currentState {
+	return &currentState{
+		Devices:       s.commandGetAllDevices(user),
+		Triggers:      s.commandGetAllTriggers(user),
+		Groups:        s.commandGetAllGroups(user),
+		Scenes:        s.commandGetAllScenes(user),
+		Persons:       s.commandGetAllPersons(user),
+		Locations:     s.commandGetAllLocations(user),
+		UOM:           s.Settings.MasterSettings().UOM,
+		Timezone:      s.Settings.Timezone().String(),
+		LogsAvailable: s.Logger.GetSpecs().IsHistorySupported && user.Logs(),
+	}
+}
+
+// Returns device state history if it's allowed for the user.
+func (s *GoHomeServer) commandGetDeviceHistory(user providers.IAuthenticatedUser,
+	deviceID string) (map[enums.Property]map[int64]interface{}, error) {
+	kd := s.state.GetDevice(deviceID)
+	if nil == kd {
+		return nil, &ErrUnknownDevice{ID: deviceID}
+	}
+
+	if !user.DeviceHistory(kd.ID) {
+		return nil, &ErrForbidden{}
+	}
+
+	return s.Settings.Storage().History(kd.ID), nil
+}
+
+// Returns trigger state history if it's allowed for the user.
+func (s *GoHomeServer) commandGetTriggerHistory(user providers.IAuthenticatedUser,
+	triggerID string) (map[enums.Property]map[int64]interface{}, error) {
+	found := false
+	for _, v := range s.triggers {
+		if v.Interface.(providers.ITriggerProvider).GetID() == triggerID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, &ErrUnknownTrigger{ID: triggerID}
+	}
+
+	if !user.TriggerHistory(triggerID) {
+		return nil, &ErrForbidden{}
+	}
+
+	return s.Settings.Storage().History(triggerID), nil
+}
+
+// Returns known workers, including master.
+func (s *GoHomeServer) commandGetWorkers(user providers.IAuthenticatedUser) ([]*knownWorker, error) {
+	if !user.Workers() {
+		return nil, &ErrForbidden{}
+	}
+
+	now := utils.TimeNow()
+	workers := s.state.GetWorkers()
+	workers = append(workers, &knownWorker{
+		ID:         "master",
+		LastSeen:   now,
+		MaxDevices: 0,
+	})
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].ID < workers[j].ID
+	})
+
+	// Setting LastSeen property to represent number of seconds from the last event.
+
+	for _, v := range workers {
+		v.LastSeenSec = now - v.LastSeen
+	}
+
+	return workers, nil
+}
+
+// Returns entities status.
+func (s *GoHomeServer) commandGetStatus(user providers.IAuthenticatedUser) ([]*knownEntity, error) {
+	if !user.Entities() {
+		return nil, &ErrForbidden{}
+	}
+
+	entities := s.state.GetEntities()
+	entities = append(entities, addMasterComponents(s.triggers, systems.SysTrigger)...)
+	entities = append(entities, addMasterComponents(s.extendedAPIs, systems.SysAPI)...)
+	entities = append(entities, addMasterComponents(s.notifications, systems.SysNotification)...)
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Name < entities[j].Name
+	})
+	return entities, nil
+}
+
+// Queries logs.
+func (s *GoHomeServer) commandGetLogs(user providers.IAuthenticatedUser,
+	body io.Reader) ([]*common.LogHistoryEntry, error) {
+	if !user.Logs() || !s.Logger.GetSpecs().IsHistorySupported {
+		return nil, &ErrForbidden{}
+	}
+
+	req := &common.LogHistoryRequest{}
+	err := json.NewDecoder(body).Decode(req)
+	if err != nil {
+		s.Logger.Error("Failed to decode Logs Request", err, common.LogSystemToken, logSystem,
+			common.LogUserNameToken, user.Name())
+		return nil, &ErrBadRequest{}
+	}
+
+	return s.Logger.Query(req), nil
+}
+
+// Returns all allowed for the user devices matching the filter.
+func (s *GoHomeServer) commandGetFilteredDevices(user providers.IAuthenticatedUser,
+	filter *deviceFilter) ([]*knownDevice, error) {
+	var locationDevices []string
+	switch {
+	case "" == filter.Location:
+	case nil != s.getLocation(filter.Location):
+		locationDevices = s.getLocationSubtreeDevices(filter.Location)
+	case defaultLocationName == filter.Location:
+		// Default location holds devices which are not assigned anywhere else.
+		locationDevices = make([]string, 0)
+		for _, v := range s.commandGetAllLocations(user) {
+			if defaultLocationName == v.Name {
+				locationDevices = v.Devices
+			}
+		}
+	default:
+		return nil, &ErrUnknownLocation{Name: filter.Location}
+	}
+
+	response := make([]*knownDevice, 0)
+	for _, v := range s.commandGetAllDevices(user) {
+		if 0 != len(filter.Types) && !deviceTypesContain(filter.Types, v.Type) {
+			continue
+		}
+
+		if nil != locationDevices && !helpers.SliceContainsString(locationDevices, v.ID) {
+			continue
+		}
+
+		if "" != filter.Worker && v.Worker != filter.Worker {
+			continue
+		}
+
+		if nil != filter.ID && !filter.ID.Match(v.ID) {
+			continue
+		}
+
+		response = append(response, v)
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+	return response, nil
+}
+
+// Checks whether device type is in the list.
+func deviceTypesContain(types []enums.DeviceType, devType enums.DeviceType) bool {
+	for _, v := range types {
+		if v == devType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Invokes multiple devices commands.
+// Commands for worker devices are grouped into a single message per worker.
+func (s *GoHomeServer) commandBatchInvoke(user providers.IAuthenticatedUser,
+	items []*batchCommandItem) []*batchCommandResult {
+	results := make([]*batchCommandResult, 0)
+	workers := make(map[string][]*bus.DeviceCommand)
+
+	for ii, item := range items {
+		data := batchCommandData(item.Value)
+		devices, err := s.getBatchDevices(user, item)
+		if err != nil {
+			results = append(results, &batchCommandResult{
+				Index:   ii,
+				Device:  item.Device,
+				Command: item.Command,
+				Status:  "ERROR",
+				Error:   newAPIError(err),
+			})
+			s.audit(user, audit.ActionDeviceCommand, item.Device, getAuditBatchPayload(item), err)
+			continue
+		}
+
+		for _, id := range devices {
+			result := &batchCommandResult{
+				Index:   ii,
+				Device:  id,
+				Command: item.Command,
+				Status:  "OK",
+			}
+			results = append(results, result)
+
+			kd, command, err := s.commandValidateDeviceCommand(user, id, item.Command)
+			if err != nil {
+				result.Status = "ERROR"
+				result.Error = newAPIError(err)
+				s.audit(user, audit.ActionDeviceCommand, id, getAuditBatchPayload(item), err)
+				continue
+			}
+
+			isMaster, err := s.commandInvokeMasterDevice(user, kd, command, data)
+			s.audit(user, getAuditCommandAction(kd), id, getAuditBatchPayload(item), err)
+			if err != nil {
+				result.Status = "ERROR"
+				result.Error = newAPIError(err)
+				continue
+			}
+
+			if !isMaster {
+				workers[kd.Worker] = append(workers[kd.Worker], &bus.DeviceCommand{
+					DeviceID: kd.ID,
+					Command:  command,
+					Payload:  data,
+				})
+			}
+		}
+	}
+
+	for k, v := range workers {
+		s.Logger.Debug("Invoking batch device operation", common.LogSystemToken, logSystem,
+			common.LogWorkerToken, k, common.LogUserNameToken, user.Name())
+		s.Settings.ServiceBus().PublishToWorker(k, bus.NewDeviceBatchCommandMessage(v))
+	}
+
+	return results
+}
+
+// Returns batch command devices.
+// Glob is expanded into allowed for the user devices which support the command.
+// Invalid glob or glob without matches is a bad request.
+func (s *GoHomeServer) getBatchDevices(user providers.IAuthenticatedUser, item *batchCommandItem) ([]string, error) {
+	if !strings.ContainsAny(item.Device, "*?[{") {
+		return []string{item.Device}, nil
+	}
+
+	exp, err := glob.Compile(item.Device)
+	if err != nil {
+		s.Logger.Warn("Received invalid batch devices glob", common.LogSystemToken, logSystem,
+			common.LogIDToken, item.Device, common.LogUserNameToken, user.Name())
+		return nil, &ErrBadRequest{}
+	}
+
+	result := make([]string, 0)
+	for _, v := range s.commandGetAllDevices(user) {
+		if exp.Match(v.ID) && user.DeviceCommand(v.ID) && helpers.SliceContainsString(v.Commands, item.Command) {
+			result = append(result, v.ID)
+		}
+	}
+
+	if 0 == len(result) {
+		s.Logger.Warn("No devices match batch glob", common.LogSystemToken, logSystem,
+			common.LogIDToken, item.Device, common.LogDeviceCommandToken, item.Command,
+			common.LogUserNameToken, user.Name())
+		return nil, &ErrBadRequest{}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// Converts batch command value into command data.
+// Non-object value is wrapped into { "value": data }.
+func batchCommandData(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case nil:
+		return make(map[string]interface{})
+	case map[string]interface{}:
+		return v
+	}
+
+	return map[string]interface{}{"value": value}
+}
+
+// Returns trigger data.
+func (s *GoHomeServer) getKnownTrigger(triggerID string) *knownTrigger {
+	kt := &knownTrigger{ID: triggerID}
+	for _, v := range s.triggers {
+		tr := v.Interface.(providers.ITriggerProvider)
+		if tr.GetID() == triggerID {
+			kt.Name = v.Name
+			kt.LastTriggered = tr.GetLastTriggeredTime()
+			break
+		}
+	}
+
+	return kt
+}
+
+// Returns failed login attempts and lockouts.
+func (s *GoHomeServer) commandGetLockoutStatus(user providers.IAuthenticatedUser) ([]*lockoutStatus, error) {
+	if !user.Entities() {
+		return nil, &ErrForbidden{}
+	}
+
+	if nil == s.lockout {
+		return []*lockoutStatus{}, nil
+	}
+
+	return s.lockout.status(), nil
+}
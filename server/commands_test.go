@@ -1,6 +1,7 @@
 package server
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/gobwas/glob"
@@ -9,6 +10,11 @@ import (
 	"go-home.io/x/server/mocks"
 	"go-home.io/x/server/plugins/device/enums"
 	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/alarm"
+	"go-home.io/x/server/systems/bus"
+	"go-home.io/x/server/systems/helper"
+	"go-home.io/x/server/systems/mode"
+	"go-home.io/x/server/systems/person"
 	"go-home.io/x/server/systems/security"
 )
 
@@ -449,9 +455,174 @@ func TestGetLocations(t *testing.T) {
 	assert.True(t, found, "group")
 }
 
+// Returns server with locations tree.
+func getLocationsServer(publishCallback func(name string, msg ...interface{})) *GoHomeServer {
+	s := getFakeSettings(publishCallback, nil, nil)
+	state := newServerState(s)
+	state.KnownDevices = map[string]*knownDevice{
+		"dev1": {ID: "dev1", Type: enums.DevSwitch, Commands: []string{enums.CmdOff.String()}, Worker: "1"},
+		"dev2": {ID: "dev2", Type: enums.DevSwitch, Commands: []string{enums.CmdOff.String()}, Worker: "1"},
+		"dev3": {ID: "dev3", Type: enums.DevSwitch, Commands: []string{enums.CmdOn.String()}, Worker: "2"},
+		"sec1": {ID: "sec1", Type: enums.DevSwitch, Commands: []string{enums.CmdOff.String()}, Worker: "2"},
+		"dev4": {ID: "dev4", Type: enums.DevSwitch, Commands: []string{enums.CmdOff.String()}, Worker: "2"},
+	}
+
+	return &GoHomeServer{
+		state:    state,
+		Logger:   mocks.FakeNewLogger(nil),
+		Settings: s,
+		groups:   map[string]providers.IGroupProvider{},
+		locations: []providers.ILocationProvider{
+			mocks.FakeNewChildLocationProvider("home", "", []string{}),
+			mocks.FakeNewChildLocationProvider("upstairs", "home", []string{"dev1"}),
+			mocks.FakeNewChildLocationProvider("bedroom", "upstairs", []string{"dev2", "dev3", "sec1"}),
+			mocks.FakeNewChildLocationProvider("kitchen", "unknown", []string{"dev4"}),
+			mocks.FakeNewChildLocationProvider("loop1", "loop2", []string{}),
+			mocks.FakeNewChildLocationProvider("loop2", "loop1", []string{}),
+		},
+	}
+}
+
+// Tests that devices inherit location ancestors.
+func TestDeviceLocationsInheritance(t *testing.T) {
+	srv := getLocationsServer(nil)
+	input := map[string][]string{
+		"dev1": {"upstairs", "home"},
+		"dev2": {"bedroom", "upstairs", "home"},
+		"dev4": {"kitchen"},
+		"dev5": {},
+	}
+
+	for k, v := range input {
+		assert.Equal(t, v, srv.getDeviceLocations(k), "locations of %s", k)
+	}
+
+	assert.Equal(t, []string{"loop2"}, srv.getLocationAncestors("loop1"), "cycle")
+	assert.Equal(t, []string{"dev1", "dev2", "dev3", "sec1"}, srv.getLocationSubtreeDevices("home"))
+}
+
+// Tests that parent locations without own devices are returned.
+func TestGetLocationsTree(t *testing.T) {
+	srv := getLocationsServer(nil)
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Resources: []glob.Glob{compileRegexp("dev?")},
+				},
+			},
+		},
+	}
+
+	locations := srv.commandGetAllLocations(user)
+	names := make([]string, 0)
+	for _, v := range locations {
+		names = append(names, v.Name)
+		if v.Name == "bedroom" {
+			assert.Equal(t, "upstairs", v.Parent, "parent")
+			assert.Equal(t, []string{"dev2", "dev3"}, v.Devices, "devices")
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"home", "upstairs", "bedroom", "kitchen"}, names)
+}
+
+// Tests location commands.
+func TestLocationCommand(t *testing.T) {
+	published := make([]string, 0)
+	srv := getLocationsServer(func(_ string, msg ...interface{}) {
+		published = append(published, msg[0].(*bus.DeviceCommandMessage).DeviceID)
+	})
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("dev?")},
+				},
+			},
+		},
+	}
+
+	results, err := srv.commandLocationCommand(user, "upstairs", enums.CmdOff.String(), nil)
+	assert.NoError(t, err, "upstairs")
+	assert.Equal(t, 2, len(results), "upstairs results")
+	assert.ElementsMatch(t, []string{"dev1", "dev2"}, published, "upstairs devices")
+
+	published = make([]string, 0)
+	_, err = srv.commandLocationCommand(user, "bedroom", enums.CmdOn.String(), []byte("1"))
+	assert.NoError(t, err, "bedroom")
+	assert.Equal(t, []string{"dev3"}, published, "bedroom devices")
+
+	_, err = srv.commandLocationCommand(user, "wrong", enums.CmdOff.String(), nil)
+	assert.IsType(t, &ErrUnknownLocation{}, err, "unknown location")
+	_, err = srv.commandLocationCommand(user, "home", "wrong", nil)
+	assert.IsType(t, &ErrUnknownCommand{}, err, "unknown command")
+	_, err = srv.commandLocationCommand(user, "kitchen", enums.CmdOn.String(), nil)
+	assert.IsType(t, &ErrUnsupportedCommand{}, err, "unsupported command")
+	_, err = srv.commandLocationCommand(user, "home", enums.CmdOff.String(), []byte("{"))
+	assert.IsType(t, &ErrBadRequest{}, err, "bad data")
+}
+
+// Tests that groups are skipped and failures are reported per device.
+func TestLocationCommandGroups(t *testing.T) {
+	published := make([]string, 0)
+	srv := getLocationsServer(func(_ string, msg ...interface{}) {
+		published = append(published, msg[0].(*bus.DeviceCommandMessage).DeviceID)
+	})
+
+	groupCalled := 0
+	srv.groups["devg"] = mocks.FakeNewGroupProvider("devg", []string{"dev1", "dev4"}, func() { groupCalled++ })
+	srv.state.(*serverState).KnownDevices["devg"] = &knownDevice{ID: "devg", Type: enums.DevGroup,
+		Commands: []string{enums.CmdOff.String()}, Worker: "1"}
+	srv.state.(*serverState).KnownDevices["devs"] = &knownDevice{ID: "devs", Type: enums.DevScene,
+		Commands: []string{enums.CmdOff.String()}, Worker: "1"}
+	srv.locations = append(srv.locations, mocks.FakeNewChildLocationProvider("attic", "upstairs",
+		[]string{"devg", "devs"}))
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("dev?")},
+				},
+			},
+		},
+	}
+
+	results, err := srv.commandLocationCommand(user, "upstairs", enums.CmdOff.String(), nil)
+	require.NoError(t, err, "partial failure")
+
+	failed := make([]string, 0)
+	for _, v := range results {
+		if "ERROR" == v.Status {
+			failed = append(failed, v.Device)
+			assert.Equal(t, http.StatusNotFound, v.Error.Status, "error status")
+		}
+	}
+
+	assert.Equal(t, 3, len(results), "results")
+	assert.Equal(t, []string{"devs"}, failed, "failed devices")
+	assert.Equal(t, 0, groupCalled, "group")
+	assert.ElementsMatch(t, []string{"dev1", "dev2"}, published, "members outside are skipped")
+}
+
 type fakeNotificationProvider struct {
-	called int
-	name   string
+	called   int
+	name     string
+	unloaded bool
+}
+
+func (f *fakeNotificationProvider) Unload() {
+	f.unloaded = true
 }
 
 func (f *fakeNotificationProvider) GetID() string {
@@ -495,3 +666,296 @@ func TestNotificationsCommand(t *testing.T) {
 	assert.Equal(t, 1, f1.called, "correct provider was not invoked")
 	assert.Equal(t, 0, f2.called, "incorrect provider was invoked")
 }
+
+// Tests scene commands.
+func TestSceneCommands(t *testing.T) {
+	invoked := make([]enums.Command, 0)
+	srv := getLocationsServer(nil)
+	srv.state.(*serverState).KnownDevices["scene.s1"] = &knownDevice{ID: "scene.s1", Type: enums.DevScene,
+		Commands: []string{enums.CmdActivate.String()}, Worker: "master"}
+	srv.scenes = map[string]providers.ISceneProvider{
+		"scene.s1": mocks.FakeNewSceneProvider("scene.s1", []string{"dev1", "sec1"}, func(c enums.Command) {
+			invoked = append(invoked, c)
+		}),
+	}
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("dev?"), compileRegexp("scene.*")},
+				},
+			},
+		},
+	}
+
+	err := srv.commandInvokeDeviceCommand(user, "scene.s1", enums.CmdActivate.String(), nil)
+	assert.NoError(t, err, "activate")
+	srv.InternalCommandInvokeDeviceCommand(compileRegexp("scene.*"), enums.CmdActivate, nil)
+	assert.Equal(t, []enums.Command{enums.CmdActivate, enums.CmdActivate}, invoked, "invoked")
+
+	scenes := srv.commandGetAllScenes(user)
+	require.Equal(t, 1, len(scenes), "scenes")
+	assert.Equal(t, []string{"dev1"}, scenes[0].Devices, "scene devices")
+
+	_, ok := srv.commandCaptureScene(user, "scene.s1").(*ErrUnknownScene)
+	assert.True(t, ok, "capture with forbidden device")
+	_, ok = srv.commandCaptureScene(user, "scene.s2").(*ErrUnknownScene)
+	assert.True(t, ok, "unknown scene")
+
+	srv.scenes["scene.s1"] = mocks.FakeNewSceneProvider("scene.s1", []string{"dev1"}, func(c enums.Command) {
+		invoked = append(invoked, c)
+	})
+	assert.NoError(t, srv.commandCaptureScene(user, "scene.s1"), "capture")
+	assert.Equal(t, 3, len(invoked), "captured")
+}
+
+// Tests home mode commands.
+func TestModeCommands(t *testing.T) {
+	srv := getLocationsServer(nil)
+	md, err := mode.NewModeProvider(&mode.ConstructMode{
+		Settings: srv.Settings,
+		Server:   srv,
+		Store:    mocks.FakeNewPersistentStore(),
+	})
+	require.NoError(t, err)
+	srv.mode = md
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("mode.*")},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "home", srv.GetHomeMode())
+	err = srv.commandInvokeDeviceCommand(user, "mode.home", enums.CmdSetMode.String(), []byte(`"away"`))
+	assert.NoError(t, err, "set mode")
+	assert.Equal(t, "away", srv.GetHomeMode())
+
+	err = srv.commandInvokeDeviceCommand(user, "mode.home", enums.CmdSetMode.String(), []byte(`"wrong"`))
+	assert.Error(t, err, "unknown mode")
+	assert.Equal(t, "away", srv.GetHomeMode())
+
+	srv.InternalCommandInvokeDeviceCommand(compileRegexp("mode.*"), enums.CmdSetMode,
+		map[string]interface{}{"value": "night"})
+	assert.Equal(t, "night", srv.GetHomeMode(), "internal")
+}
+
+// Tests input helper commands.
+func TestHelperCommands(t *testing.T) {
+	srv := getLocationsServer(nil)
+	h, err := helper.NewHelperProvider(&helper.ConstructHelper{
+		RawConfig: []byte("name: guest\ntype: boolean"),
+		Settings:  srv.Settings,
+		Server:    srv,
+		Store:     mocks.FakeNewPersistentStore(),
+	})
+	require.NoError(t, err)
+	srv.helpers = map[string]providers.IHelperProvider{h.ID(): h}
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("helper.*")},
+				},
+			},
+		},
+	}
+
+	err = srv.commandInvokeDeviceCommand(user, "helper.guest", enums.CmdOn.String(), nil)
+	assert.NoError(t, err, "on")
+	assert.Equal(t, true, srv.state.GetDevice("helper.guest").State[enums.PropOn.String()])
+
+	err = srv.commandInvokeDeviceCommand(user, "helper.guest", enums.CmdSetValue.String(), []byte(`"wrong"`))
+	assert.Error(t, err, "wrong value")
+
+	srv.InternalCommandInvokeDeviceCommand(compileRegexp("helper.*"), enums.CmdToggle, nil)
+	assert.Equal(t, false, srv.state.GetDevice("helper.guest").State[enums.PropOn.String()], "internal")
+}
+
+// Tests alarm commands and verbs.
+func TestAlarmCommands(t *testing.T) {
+	srv := getLocationsServer(nil)
+	a, err := alarm.NewAlarmProvider(&alarm.ConstructAlarm{
+		RawConfig: []byte("name: house\ncode: 1234\nnotifications: \"*\""),
+		Settings:  srv.Settings,
+		Server:    srv,
+		Store:     mocks.FakeNewPersistentStore(),
+	})
+	require.NoError(t, err)
+	srv.alarms = map[string]providers.IAlarmProvider{a.ID(): a}
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Resources: []glob.Glob{compileRegexp("alarm.*")},
+				},
+			},
+		},
+	}
+
+	err = srv.commandInvokeDeviceCommand(user, "alarm.house", enums.CmdArmAway.String(), nil)
+	_, ok := err.(*ErrUnknownDevice)
+	assert.True(t, ok, "no arm verb")
+	assert.Equal(t, enums.AlarmDisarmed, a.State())
+
+	user.Rules[providers.SecSystemAlarm] = []*providers.BakedRule{
+		{
+			Arm:       true,
+			Resources: []glob.Glob{compileRegexp("alarm.*")},
+		},
+	}
+
+	err = srv.commandInvokeDeviceCommand(user, "alarm.house", enums.CmdArmAway.String(), nil)
+	assert.NoError(t, err, "arm")
+	assert.Equal(t, enums.AlarmArmedAway, a.State())
+
+	err = srv.commandInvokeDeviceCommand(user, "alarm.house", enums.CmdDisarm.String(), []byte(`{"code":"1234"}`))
+	_, ok = err.(*ErrUnknownDevice)
+	assert.True(t, ok, "no disarm verb")
+
+	user.Rules[providers.SecSystemAlarm][0].Disarm = true
+	err = srv.commandInvokeDeviceCommand(user, "alarm.house", enums.CmdDisarm.String(), []byte(`{"code":"1"}`))
+	_, ok = err.(*alarm.ErrWrongCode)
+	assert.True(t, ok, "wrong code")
+
+	err = srv.commandInvokeDeviceCommand(user, "alarm.house", enums.CmdDisarm.String(), []byte(`{"code":"1234"}`))
+	assert.NoError(t, err, "disarm")
+	assert.Equal(t, enums.AlarmDisarmed, a.State())
+
+	user.Rules[providers.SecSystemAlarm][0].Disarm = false
+	user.Rules[providers.SecSystemDevice][0].Command = true
+	err = srv.commandInvokeDeviceCommand(user, "alarm.house", enums.CmdDisarm.String(), []byte(`{"code":"1234"}`))
+	_, ok = err.(*ErrUnknownDevice)
+	assert.True(t, ok, "generic command verb")
+}
+
+// Tests person location reports.
+func TestPersonCommands(t *testing.T) {
+	srv := getLocationsServer(nil)
+	p, err := person.NewPersonProvider(&person.ConstructPerson{
+		RawConfig: []byte("name: John"),
+		Settings:  srv.Settings,
+		Server:    srv,
+		Store:     mocks.FakeNewPersistentStore(),
+	})
+	require.NoError(t, err)
+	srv.persons = map[string]providers.IPersonProvider{p.ID(): p}
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("person.*")},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "away", srv.GetPersonPresence("person.john"))
+	assert.Equal(t, "", srv.GetPersonPresence("person.jane"))
+
+	err = srv.commandInvokeDeviceCommand(user, "person.john", enums.CmdSetLocation.String(), []byte(`{"zone":"home"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "home", srv.GetPersonPresence("person.john"))
+
+	err = srv.commandInvokeDeviceCommand(user, "person.john", enums.CmdSetLocation.String(), []byte(`{"zone":"gym"}`))
+	_, ok := err.(*person.ErrUnknownZone)
+	assert.True(t, ok, "unknown zone")
+
+	persons := srv.commandGetAllPersons(user)
+	require.Equal(t, 1, len(persons))
+	assert.Equal(t, "John", persons[0].Name)
+	assert.Equal(t, "home", persons[0].Presence)
+
+	user.Rules[providers.SecSystemDevice][0].Resources = []glob.Glob{compileRegexp("alarm.*")}
+	assert.Equal(t, 0, len(srv.commandGetAllPersons(user)))
+}
+
+// Tests batch commands grouping and per-item results.
+func TestBatchCommands(t *testing.T) {
+	published := make(map[string][]*bus.DeviceCommand)
+	calls := 0
+	srv := getLocationsServer(func(name string, msg ...interface{}) {
+		calls++
+		published[name] = append(published[name], msg[0].(*bus.DeviceBatchCommandMessage).Commands...)
+	})
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("dev?")},
+				},
+			},
+		},
+	}
+
+	results := srv.commandBatchInvoke(user, []*batchCommandItem{
+		{Device: "dev*", Command: enums.CmdOff.String()},
+		{Device: "sec1", Command: enums.CmdOff.String()},
+		{Device: "dev3", Command: enums.CmdOn.String(), Value: 10.0},
+		{Device: "dev1", Command: "wrong"},
+		{Device: "x*", Command: enums.CmdOff.String()},
+		{Device: "dev[", Command: enums.CmdOff.String()},
+	})
+
+	expected := []struct {
+		index  int
+		device string
+		status int
+	}{
+		{0, "dev1", 0},
+		{0, "dev2", 0},
+		{0, "dev4", 0},
+		{1, "sec1", http.StatusNotFound},
+		{2, "dev3", 0},
+		{3, "dev1", http.StatusBadRequest},
+		{4, "x*", http.StatusBadRequest},
+		{5, "dev[", http.StatusBadRequest},
+	}
+
+	require.Equal(t, len(expected), len(results))
+	for ii, v := range expected {
+		assert.Equal(t, v.index, results[ii].Index, "index %d", ii)
+		assert.Equal(t, v.device, results[ii].Device, "device %d", ii)
+		if 0 == v.status {
+			assert.Equal(t, "OK", results[ii].Status, "status %d", ii)
+			assert.Nil(t, results[ii].Error, "error %d", ii)
+			continue
+		}
+
+		assert.Equal(t, "ERROR", results[ii].Status, "status %d", ii)
+		assert.Equal(t, v.status, results[ii].Error.Status, "error %d", ii)
+	}
+
+	assert.Equal(t, 2, calls, "one message per worker")
+	require.Equal(t, 2, len(published["1"]))
+	require.Equal(t, 2, len(published["2"]))
+	assert.Equal(t, "dev4", published["2"][0].DeviceID)
+	assert.Equal(t, enums.CmdOn, published["2"][1].Command)
+	assert.Equal(t, 10.0, published["2"][1].Payload["value"])
+}
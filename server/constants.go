@@ -1,4 +1,6 @@
 //go:generate enumer -type=entityStatus -transform=snake -trimprefix=entity -json -text -yaml
+//go:generate enumer -type=healthStatus -transform=snake -trimprefix=health -json -text -yaml
+//go:generate enumer -type=wsMessageType -transform=snake -trimprefix=ws -json -text -yaml
 
 package server
 
@@ -8,14 +10,26 @@ type muxKeys string
 const (
 	// urlDeviceID describes device ID URL param.
 	urlDeviceID muxKeys = "deviceID"
+	// urlSceneID describes scene ID URL param.
+	urlSceneID muxKeys = "sceneID"
+	// urlPersonID describes person ID URL param.
+	urlPersonID muxKeys = "personID"
+	// urlLocationID describes location ID URL param.
+	urlLocationID muxKeys = "locationID"
 	//urlTriggerID describes trigger ID URL param.
 	urlTriggerID muxKeys = "triggerID"
+	// urlHookID describes inbound webhook ID URL param.
+	urlHookID muxKeys = "hookID"
+	// urlTokenID describes API token ID URL param.
+	urlTokenID muxKeys = "tokenID"
 	// urlCommandName describes device command name URL param.
 	urlCommandName muxKeys = "commandName"
 	// ctxtUserName describes user in the context.
 	ctxtUserName muxKeys = "user"
 	// routeAPI describes base api prefix.
 	routeAPI = "/api/v1"
+	// routeAPIv2 describes base api v2 prefix.
+	routeAPIv2 = "/api/v2"
 )
 
 // entityStatus describes enum with entity load status.
@@ -33,3 +47,45 @@ const (
 	// entityLoadFailed describes error while loading status.
 	entityLoadFailed
 )
+
+// healthStatus describes enum with subsystem health status.
+type healthStatus int
+
+const (
+	// healthOk describes healthy subsystem.
+	healthOk healthStatus = iota
+	// healthDegraded describes partially working subsystem.
+	healthDegraded
+	// healthFailed describes non-working subsystem.
+	healthFailed
+)
+
+// wsMessageType describes enum with known WS v2 messages.
+type wsMessageType int
+
+const (
+	// wsSubscribe describes subscription request sent by client.
+	wsSubscribe wsMessageType = iota
+	// wsUnsubscribe describes subscription removal request sent by client.
+	wsUnsubscribe
+	// wsCommand describes device command request sent by client.
+	wsCommand
+	// wsBatch describes batch device command request sent by client.
+	wsBatch
+	// wsPing describes ping request sent by client.
+	wsPing
+	// wsPong describes ping reply sent by server.
+	wsPong
+	// wsResult describes request reply sent by server.
+	wsResult
+	// wsDeviceUpdate describes device update sent by server.
+	wsDeviceUpdate
+	// wsTriggerUpdate describes trigger update sent by server.
+	wsTriggerUpdate
+)
+
+// auditRedacted describes replacement for sensitive values in audit payloads.
+const auditRedacted = "***"
+
+// auditSensitiveKeys describes command data keys which are never recorded to audit.
+var auditSensitiveKeys = []string{"code", "pin", "password", "secret", "token"}
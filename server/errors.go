@@ -1,6 +1,8 @@
 package server
 
-import "fmt"
+import (
+	"fmt"
+)
 
 // ErrUnknownDevice defines unknown device error.
 type ErrUnknownDevice struct {
@@ -32,6 +34,26 @@ func (e *ErrUnknownGroup) Error() string {
 	return fmt.Sprintf("group %s is unknown", e.Name)
 }
 
+// ErrUnknownScene defines unknown scene error.
+type ErrUnknownScene struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnknownScene) Error() string {
+	return fmt.Sprintf("scene %s is unknown", e.Name)
+}
+
+// ErrUnknownLocation defines unknown location error.
+type ErrUnknownLocation struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnknownLocation) Error() string {
+	return fmt.Sprintf("location %s is unknown", e.Name)
+}
+
 // ErrUnsupportedCommand defines unsupported on device command error.
 type ErrUnsupportedCommand struct {
 	Name string
@@ -50,3 +72,32 @@ type ErrBadRequest struct {
 func (e *ErrBadRequest) Error() string {
 	return "bad request"
 }
+
+// ErrUnknownTrigger defines unknown trigger error.
+type ErrUnknownTrigger struct {
+	ID string
+}
+
+// Error formats output.
+func (e *ErrUnknownTrigger) Error() string {
+	return fmt.Sprintf("trigger %s is unknown", e.ID)
+}
+
+// ErrForbidden defines access denied error.
+type ErrForbidden struct {
+}
+
+// Error formats output.
+func (e *ErrForbidden) Error() string {
+	return "access denied"
+}
+
+// ErrUnknownSubscription defines unknown WS subscription error.
+type ErrUnknownSubscription struct {
+	ID string
+}
+
+// Error formats output.
+func (e *ErrUnknownSubscription) Error() string {
+	return fmt.Sprintf("subscription %s is unknown", e.ID)
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+)
+
+const (
+	// Number of the latest updates kept for streams resume.
+	eventsHistorySize = 512
+	// Keep-alive comment period, prevents proxies from closing idle streams.
+	eventsKeepAlive = 30 * time.Second
+	// Event sent when client missed updates and should reload the state.
+	eventsReset = "reset"
+)
+
+// Single stored update.
+type streamEvent struct {
+	ID      uint64
+	Type    wsMessageType
+	Device  *knownDevice
+	Trigger *knownTrigger
+}
+
+// Ring buffer with the latest updates.
+type eventsHistory struct {
+	sync.Mutex
+
+	events []*streamEvent
+	next   int
+	lastID uint64
+
+	lastListener int64
+	listeners    map[int64]chan bool
+}
+
+// Constructs a new events history.
+func newEventsHistory(size int) *eventsHistory {
+	return &eventsHistory{
+		events:    make([]*streamEvent, size),
+		listeners: make(map[int64]chan bool),
+	}
+}
+
+// Stores a new event and notifies listeners.
+func (h *eventsHistory) add(e *streamEvent) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.lastID++
+	e.ID = h.lastID
+	h.events[h.next] = e
+	h.next = (h.next + 1) % len(h.events)
+
+	for _, v := range h.listeners {
+		select {
+		case v <- true:
+		default:
+		}
+	}
+}
+
+// Returns ID of the latest event.
+func (h *eventsHistory) last() uint64 {
+	h.Lock()
+	defer h.Unlock()
+
+	return h.lastID
+}
+
+// Returns events after the ID and ID of the latest event.
+// False is returned if some of them were already overwritten or ID is unknown.
+func (h *eventsHistory) since(id uint64) ([]*streamEvent, uint64, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	if id > h.lastID {
+		return []*streamEvent{}, h.lastID, false
+	}
+
+	size := uint64(len(h.events))
+	count := h.lastID
+	if count > size {
+		count = size
+	}
+
+	result := make([]*streamEvent, 0, h.lastID-id)
+	for ii := 0; ii < len(h.events); ii++ {
+		e := h.events[(h.next+ii)%len(h.events)]
+		if nil != e && e.ID > id {
+			result = append(result, e)
+		}
+	}
+
+	return result, h.lastID, id+count >= h.lastID
+}
+
+// Subscribes for new events notifications.
+func (h *eventsHistory) subscribe() (int64, chan bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.lastListener++
+	ch := make(chan bool, 1)
+	h.listeners[h.lastListener] = ch
+	return h.lastListener, ch
+}
+
+// Removes notifications subscription.
+func (h *eventsHistory) unsubscribe(id int64) {
+	h.Lock()
+	defer h.Unlock()
+
+	delete(h.listeners, id)
+}
+
+// Collects devices and triggers updates into the history.
+func (s *GoHomeServer) collectEvents() {
+	_, deviceUpd := s.Settings.FanOut().SubscribeDeviceUpdates()
+	_, triggerUpd := s.Settings.FanOut().SubscribeTriggerUpdates()
+
+	for {
+		select {
+		case msg, ok := <-deviceUpd:
+			if !ok {
+				return
+			}
+
+			kd := s.state.GetDevice(msg.ID)
+			if nil == kd {
+				continue
+			}
+
+			// Device state is updated in place, so keeping a snapshot.
+			snapshot := *kd
+			snapshot.State = make(map[string]interface{}, len(kd.State))
+			for k, v := range kd.State {
+				snapshot.State[k] = v
+			}
+
+			s.events.add(&streamEvent{Type: wsDeviceUpdate, Device: &snapshot})
+		case msg, ok := <-triggerUpd:
+			if !ok {
+				return
+			}
+
+			s.events.add(&streamEvent{Type: wsTriggerUpdate, Trigger: s.getKnownTrigger(msg)})
+		}
+	}
+}
+
+// Streams updates as server-sent events.
+// Client can resume the stream with Last-Event-ID header or last_event_id query param.
+//noinspection GoUnhandledErrorResult
+func (s *GoHomeServer) handleEvents(writer http.ResponseWriter, request *http.Request) {
+	usr := getContextUser(request)
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		respondAPIError(writer, &apiError{
+			Status:  http.StatusInternalServerError,
+			Code:    errCodeInternal,
+			Message: "streaming is not supported",
+		})
+		return
+	}
+
+	req, err := parseEventsQuery(request.URL.Query())
+	if err != nil {
+		respondAPIError(writer, newAPIError(err))
+		return
+	}
+
+	sub, err := s.newSubscription(req)
+	if err != nil {
+		respondAPIError(writer, newAPIError(err))
+		return
+	}
+
+	lastID := s.events.last()
+	resume := request.Header.Get("Last-Event-ID")
+	if "" == resume {
+		resume = request.URL.Query().Get("last_event_id")
+	}
+
+	if "" != resume {
+		lastID, err = strconv.ParseUint(resume, 10, 64)
+		if err != nil {
+			respondAPIError(writer, newAPIError(&ErrBadRequest{}))
+			return
+		}
+	}
+
+	listenerID, notify := s.events.subscribe()
+	defer s.events.unsubscribe(listenerID)
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	s.Logger.Debug("Started events stream", common.LogSystemToken, logSystem,
+		common.LogUserNameToken, usr.Name())
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		events, latestID, complete := s.events.since(lastID)
+		lastID = latestID
+		if !complete {
+			if _, err := fmt.Fprintf(writer, "event: %s\ndata: {}\n\n", eventsReset); err != nil {
+				return
+			}
+		}
+
+		for _, v := range events {
+			if nil != s.writeEvent(writer, usr, sub, v) {
+				return
+			}
+		}
+
+		flusher.Flush()
+
+		select {
+		case <-request.Context().Done():
+			s.Logger.Debug("Closed events stream", common.LogSystemToken, logSystem,
+				common.LogUserNameToken, usr.Name())
+			return
+		case <-s.shutdownStarted():
+			return
+		case <-notify:
+		case <-ticker.C:
+			if _, err := fmt.Fprint(writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Writes event if it's allowed for the user and matches the subscription.
+func (s *GoHomeServer) writeEvent(writer http.ResponseWriter, usr providers.IAuthenticatedUser,
+	sub *wsSubscription, e *streamEvent) error {
+	var data interface{}
+	switch e.Type {
+	case wsDeviceUpdate:
+		locations := func() []string {
+			return s.getDeviceLocations(e.Device.ID)
+		}
+
+		if !usr.DeviceGet(e.Device.ID) || !sub.matches(e.Type, e.Device.ID, locations) {
+			return nil
+		}
+
+		data = newUserDevice(usr, e.Device)
+	case wsTriggerUpdate:
+		locations := func() []string {
+			return []string{}
+		}
+
+		if !usr.TriggerGet(e.Trigger.ID) || !sub.matches(e.Type, e.Trigger.ID, locations) {
+			return nil
+		}
+
+		data = e.Trigger
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+
+	_, err = fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type.String(), b)
+	return err
+}
+
+// Parses events stream filter.
+func parseEventsQuery(query url.Values) (*wsSubscriptionRequest, error) {
+	req := &wsSubscriptionRequest{
+		Devices:   query["device"],
+		Locations: query["location"],
+		Types:     make([]wsMessageType, 0),
+	}
+
+	for _, v := range query["type"] {
+		t, err := wsMessageTypeString(v)
+		if err != nil {
+			return nil, &ErrBadRequest{}
+		}
+
+		req.Types = append(req.Types, t)
+	}
+
+	return req, nil
+}
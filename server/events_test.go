@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/gobwas/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/security"
+)
+
+// Returns IDs of events.
+func getEventIDs(events []*streamEvent) []uint64 {
+	result := make([]uint64, 0)
+	for _, v := range events {
+		result = append(result, v.ID)
+	}
+
+	return result
+}
+
+// Tests events ring buffer.
+func TestEventsHistory(t *testing.T) {
+	h := newEventsHistory(3)
+	events, _, complete := h.since(0)
+	assert.True(t, complete, "empty")
+	assert.Equal(t, 0, len(events), "empty")
+
+	h.add(&streamEvent{})
+	h.add(&streamEvent{})
+	events, _, complete = h.since(0)
+	assert.True(t, complete, "not full")
+	assert.Equal(t, []uint64{1, 2}, getEventIDs(events), "not full")
+
+	h.add(&streamEvent{})
+	h.add(&streamEvent{})
+	h.add(&streamEvent{})
+	assert.Equal(t, uint64(5), h.last())
+
+	events, _, complete = h.since(2)
+	assert.True(t, complete, "full")
+	assert.Equal(t, []uint64{3, 4, 5}, getEventIDs(events), "full")
+
+	events, _, complete = h.since(4)
+	assert.True(t, complete, "latest")
+	assert.Equal(t, []uint64{5}, getEventIDs(events), "latest")
+
+	events, last, complete := h.since(1)
+	assert.False(t, complete, "lost")
+	assert.Equal(t, uint64(5), last, "lost")
+	assert.Equal(t, []uint64{3, 4, 5}, getEventIDs(events), "lost")
+
+	events, last, complete = h.since(10)
+	assert.False(t, complete, "unknown")
+	assert.Equal(t, uint64(5), last, "unknown")
+	assert.Equal(t, 0, len(events), "unknown")
+}
+
+// Tests events notifications.
+func TestEventsHistoryListeners(t *testing.T) {
+	h := newEventsHistory(3)
+	id, ch := h.subscribe()
+	h.add(&streamEvent{})
+	h.add(&streamEvent{})
+	assert.Equal(t, 1, len(ch))
+
+	h.unsubscribe(id)
+	<-ch
+	h.add(&streamEvent{})
+	assert.Equal(t, 0, len(ch))
+}
+
+// Single parsed server-sent event.
+type sseTestEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// Reads events stream until keep-alive or EOF.
+func readEvents(t *testing.T, srv *GoHomeServer, url string, lastID string) []*sseTestEvent {
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Resources: []glob.Glob{compileRegexp("dev?")},
+				},
+			},
+		},
+	}
+
+	monkey.Patch(getContextUser, func(request *http.Request) providers.IAuthenticatedUser {
+		return user
+	})
+	defer monkey.UnpatchAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, url, nil).WithContext(ctx)
+	if "" != lastID {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	r := httptest.NewRecorder()
+	srv.handleEvents(r, req)
+
+	require.Equal(t, http.StatusOK, r.Code, url)
+	assert.Equal(t, "text/event-stream", r.Header().Get("Content-Type"), url)
+
+	result := make([]*sseTestEvent, 0)
+	scanner := bufio.NewScanner(r.Body)
+	current := &sseTestEvent{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case "" == line:
+			result = append(result, current)
+			current = &sseTestEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+
+	return result
+}
+
+// Tests events stream resume and filtering.
+func TestEventsStream(t *testing.T) {
+	srv := getLocationsServer(nil)
+	srv.events = newEventsHistory(4)
+	go srv.collectEvents()
+
+	for _, v := range []string{"dev1", "sec1", "dev4", "dev2"} {
+		srv.Settings.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: v}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	events := readEvents(t, srv, "/events", "")
+	assert.Equal(t, 0, len(events), "new stream")
+
+	events = readEvents(t, srv, "/events", "0")
+	require.Equal(t, 3, len(events), "resume")
+	assert.Equal(t, "1", events[0].id, "resume")
+	assert.Equal(t, "device_update", events[0].event, "resume")
+	assert.Contains(t, events[0].data, `"id":"dev1"`, "resume")
+	assert.Equal(t, "4", events[2].id, "resume")
+
+	events = readEvents(t, srv, "/events?location=upstairs", "1")
+	require.Equal(t, 1, len(events), "location")
+	assert.Equal(t, "4", events[0].id, "location")
+
+	events = readEvents(t, srv, "/events?type=trigger_update", "0")
+	assert.Equal(t, 0, len(events), "type")
+
+	srv.Settings.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: "dev1"}
+	time.Sleep(100 * time.Millisecond)
+
+	events = readEvents(t, srv, "/events", "0")
+	require.Equal(t, 4, len(events), "lost")
+	assert.Equal(t, eventsReset, events[0].event, "lost")
+	assert.Equal(t, "3", events[1].id, "lost")
+	assert.Equal(t, "5", events[3].id, "lost")
+
+	events = readEvents(t, srv, "/events", "42")
+	require.Equal(t, 1, len(events), "unknown")
+	assert.Equal(t, eventsReset, events[0].event, "unknown")
+}
+
+// Tests events stream wrong requests.
+func TestEventsStreamWrongRequests(t *testing.T) {
+	monkey.Patch(getContextUser, getFakeRootUser)
+	defer monkey.UnpatchAll()
+
+	srv := getLocationsServer(nil)
+	srv.events = newEventsHistory(4)
+	input := map[string]int{
+		"/events?type=command":    http.StatusBadRequest,
+		"/events?type=wrong":      http.StatusBadRequest,
+		"/events?device=[":        http.StatusBadRequest,
+		"/events?location=wrong":  http.StatusNotFound,
+		"/events?last_event_id=a": http.StatusBadRequest,
+	}
+
+	for k, v := range input {
+		r := httptest.NewRecorder()
+		srv.handleEvents(r, httptest.NewRequest(http.MethodGet, k, nil))
+		assert.Equal(t, v, r.Code, k)
+		assert.Equal(t, "application/json", r.Header().Get("Content-Type"), k)
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync/atomic"
+)
+
+// Single subsystem health check.
+type healthCheck struct {
+	Status  healthStatus           `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+
+	// Critical checks fail liveness, others only readiness.
+	critical bool
+}
+
+// Health report.
+type healthReport struct {
+	Status healthStatus            `json:"status"`
+	Checks map[string]*healthCheck `json:"checks"`
+}
+
+// Responds with liveness report.
+// Fails only if master can't function at all, so orchestrators don't restart it
+// because of a misconfigured plugin or missing workers.
+func (s *GoHomeServer) getHealth(writer http.ResponseWriter, request *http.Request) {
+	report := s.getHealthReport()
+	status := http.StatusOK
+	for _, v := range report.Checks {
+		if v.critical && healthFailed == v.Status {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	s.hideHealthDetails(request, report)
+	respondStatus(writer, status, report)
+}
+
+// Responds with readiness report.
+// Fails if any subsystem failed, bus is not started yet or master is shutting down.
+func (s *GoHomeServer) getReady(writer http.ResponseWriter, request *http.Request) {
+	report := s.getHealthReport()
+	status := http.StatusOK
+	if healthFailed == report.Status {
+		status = http.StatusServiceUnavailable
+	}
+
+	select {
+	case <-s.shutdownStarted():
+		status = http.StatusServiceUnavailable
+		report.Status = healthFailed
+	default:
+	}
+
+	s.hideHealthDetails(request, report)
+	respondStatus(writer, status, report)
+}
+
+// Removes checks' messages and details, unless request is made by a user allowed to see workers.
+// Endpoints are public, so only statuses are exposed to anonymous callers.
+func (s *GoHomeServer) hideHealthDetails(request *http.Request, report *healthReport) {
+	if s.isHealthDetailsAllowed(request) {
+		return
+	}
+
+	for _, v := range report.Checks {
+		v.Message = ""
+		v.Details = nil
+	}
+}
+
+// Checks whether request is authenticated and allowed to see health details.
+// Failed attempts are counted by the lockout, same as for API.
+func (s *GoHomeServer) isHealthDetailsAllowed(request *http.Request) bool {
+	if "" == request.Header.Get("Authorization") && (nil == request.TLS || 0 == len(request.TLS.VerifiedChains)) {
+		return false
+	}
+
+	keys := getLockoutKeys(request)
+	if nil != s.lockout && s.lockout.locked(keys...) > 0 {
+		return false
+	}
+
+	user, err := s.getRequestUser(request)
+	if err != nil {
+		if nil != s.lockout {
+			s.lockoutFailure(keys)
+		}
+
+		return false
+	}
+
+	if nil != s.lockout {
+		s.lockout.success(keys...)
+	}
+
+	return user.Workers()
+}
+
+// Collects all subsystems checks.
+func (s *GoHomeServer) getHealthReport() *healthReport {
+	report := &healthReport{
+		Status: healthOk,
+		Checks: map[string]*healthCheck{
+			"bus":           s.checkBus(),
+			"storage":       s.checkStorage(),
+			"logger":        s.checkLogger(),
+			"workers":       s.checkWorkers(),
+			"entities":      s.checkEntities(),
+			"triggers":      checkMasterComponents(s.triggers),
+			"apis":          checkMasterComponents(s.extendedAPIs),
+			"notifications": checkMasterComponents(s.notifications),
+		},
+	}
+
+	if nil != s.mqtt {
+		report.Checks["mqtt"] = s.mqtt.check()
+	}
+
+	for _, v := range report.Checks {
+		if v.Status > report.Status {
+			report.Status = v.Status
+		}
+	}
+
+	return report
+}
+
+// Checks service bus connectivity.
+func (s *GoHomeServer) checkBus() *healthCheck {
+	if err := s.Settings.ServiceBus().Ping(); err != nil {
+		return &healthCheck{Status: healthFailed, Message: err.Error(), critical: true}
+	}
+
+	if 0 == atomic.LoadInt32(&s.busStarted) {
+		return &healthCheck{Status: healthFailed, Message: "not subscribed yet"}
+	}
+
+	return &healthCheck{Status: healthOk}
+}
+
+// Checks storage plugin state.
+func (s *GoHomeServer) checkStorage() *healthCheck {
+	specs := s.Settings.Storage().GetSpecs()
+	c := &healthCheck{
+		Status:  healthOk,
+		Details: map[string]interface{}{"configured": specs.IsConfigured, "loaded": specs.IsLoaded},
+	}
+
+	if specs.IsConfigured && !specs.IsLoaded {
+		c.Status = healthFailed
+		c.Message = "plugin is not loaded"
+	}
+
+	return c
+}
+
+// Checks logger capabilities.
+func (s *GoHomeServer) checkLogger() *healthCheck {
+	return &healthCheck{
+		Status:  healthOk,
+		Details: map[string]interface{}{"history": s.Logger.GetSpecs().IsHistorySupported},
+	}
+}
+
+// Checks live workers against configured device selectors.
+func (s *GoHomeServer) checkWorkers() *healthCheck {
+	workers := len(s.state.GetWorkers())
+	all, unmatched := s.state.GetSelectors()
+	c := &healthCheck{
+		Status: healthOk,
+		Details: map[string]interface{}{
+			"workers":   workers,
+			"selectors": len(all),
+			"unmatched": unmatched,
+		},
+	}
+
+	switch {
+	case 0 == len(all):
+	case 0 == workers:
+		c.Status = healthFailed
+		c.Message = "no live workers"
+	case 0 != len(unmatched):
+		c.Status = healthDegraded
+		c.Message = "some selectors don't match any live worker"
+	}
+
+	return c
+}
+
+// Checks entities load reports from workers.
+func (s *GoHomeServer) checkEntities() *healthCheck {
+	failed := make([]string, 0)
+	for _, v := range s.state.GetEntities() {
+		if entityLoadFailed == v.Status || entityWrongWorker == v.Status {
+			failed = append(failed, v.Name)
+		}
+	}
+
+	return checkFailedNames(failed)
+}
+
+// Checks master components load state.
+func checkMasterComponents(components []*knownMasterComponent) *healthCheck {
+	failed := make([]string, 0)
+	for _, v := range components {
+		if !v.Loaded {
+			failed = append(failed, v.Name)
+		}
+	}
+
+	return checkFailedNames(failed)
+}
+
+// Returns degraded check if some entities failed.
+func checkFailedNames(failed []string) *healthCheck {
+	sort.Strings(failed)
+	c := &healthCheck{
+		Status:  healthOk,
+		Details: map[string]interface{}{"failed": failed},
+	}
+
+	if 0 != len(failed) {
+		c.Status = healthDegraded
+		c.Message = "some entities failed to load"
+	}
+
+	return c
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/shutdown"
+)
+
+// Invokes health handler on behalf of authenticated user and parses the report.
+func getHealthResponse(t *testing.T, handler http.HandlerFunc) (int, *healthReport) {
+	req := httptest.NewRequest(http.MethodGet, "/pub/health", nil)
+	req.SetBasicAuth("test", "test")
+	return getHealthRequestResponse(t, handler, req)
+}
+
+// Invokes health handler with the request and parses the report.
+func getHealthRequestResponse(t *testing.T, handler http.HandlerFunc, req *http.Request) (int, *healthReport) {
+	r := httptest.NewRecorder()
+	handler(r, req)
+
+	report := &healthReport{}
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), report))
+	return r.Code, report
+}
+
+// Returns server with configured devices.
+func getHealthServer() *GoHomeServer {
+	srv := getServer()
+	srv.Settings = getFakeSettings(nil, []*providers.RawDevice{
+		{
+			Name:       "dev1",
+			DeviceType: enums.DevSwitch,
+			Selector:   &providers.RawDeviceSelector{Name: "dev1", Selectors: map[string]string{"name": "worker-1"}},
+		},
+		{
+			Name:       "dev2",
+			DeviceType: enums.DevSwitch,
+			Selector:   &providers.RawDeviceSelector{Name: "dev2", Selectors: map[string]string{"Name": "worker-1"}},
+		},
+		{
+			Name:       "dev3",
+			DeviceType: enums.DevSwitch,
+			Selector:   &providers.RawDeviceSelector{Name: "dev3"},
+		},
+	}, nil)
+	srv.state = newServerState(srv.Settings)
+	srv.busStarted = 1
+	return srv
+}
+
+// Tests healthy system.
+func TestHealthOk(t *testing.T) {
+	srv := getServer()
+	srv.busStarted = 1
+
+	code, report := getHealthResponse(t, srv.getHealth)
+	assert.Equal(t, http.StatusOK, code, "health")
+	assert.Equal(t, healthOk, report.Status, "status")
+	assert.Equal(t, 8, len(report.Checks), "checks")
+	assert.Equal(t, true, report.Checks["storage"].Details["loaded"], "storage")
+
+	code, _ = getHealthResponse(t, srv.getReady)
+	assert.Equal(t, http.StatusOK, code, "ready")
+}
+
+// Tests workers and selectors check.
+func TestHealthWorkers(t *testing.T) {
+	srv := getHealthServer()
+
+	code, report := getHealthResponse(t, srv.getHealth)
+	assert.Equal(t, http.StatusOK, code, "health without workers")
+	assert.Equal(t, healthFailed, report.Checks["workers"].Status, "no workers")
+	assert.Equal(t, float64(2), report.Checks["workers"].Details["selectors"], "selectors")
+
+	code, _ = getHealthResponse(t, srv.getReady)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "ready without workers")
+
+	srv.state.(*serverState).KnownWorkers["1"] = &knownWorker{
+		ID:               "1",
+		WorkerProperties: map[string]string{"name": "worker-2"},
+	}
+
+	code, report = getHealthResponse(t, srv.getReady)
+	assert.Equal(t, http.StatusOK, code, "ready with degraded workers")
+	assert.Equal(t, healthDegraded, report.Status, "degraded")
+	assert.Equal(t, []interface{}{"name=worker-1"}, report.Checks["workers"].Details["unmatched"], "unmatched")
+}
+
+// Tests entities and components failures.
+func TestHealthFailedEntities(t *testing.T) {
+	srv := getHealthServer()
+	srv.state.(*serverState).KnownEntities["dev1"].Status = entityLoadFailed
+	srv.triggers[0].Loaded = false
+
+	_, report := getHealthResponse(t, srv.getHealth)
+	assert.Equal(t, healthDegraded, report.Checks["entities"].Status, "entities")
+	assert.Equal(t, []interface{}{"dev1"}, report.Checks["entities"].Details["failed"], "failed entities")
+	assert.Equal(t, healthDegraded, report.Checks["triggers"].Status, "triggers")
+	assert.Equal(t, []interface{}{"trigger1"}, report.Checks["triggers"].Details["failed"], "failed triggers")
+	assert.Equal(t, healthOk, report.Checks["notifications"].Status, "notifications")
+}
+
+// Tests bus failures.
+func TestHealthBus(t *testing.T) {
+	srv := getServer()
+
+	code, report := getHealthResponse(t, srv.getReady)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "ready before bus start")
+	assert.Equal(t, healthFailed, report.Checks["bus"].Status, "not started")
+
+	code, _ = getHealthResponse(t, srv.getHealth)
+	assert.Equal(t, http.StatusOK, code, "health before bus start")
+
+	srv.Settings.ServiceBus().(mocks.IFakeServiceBus).SetPingError(errors.New("test"))
+	code, report = getHealthResponse(t, srv.getHealth)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "health")
+	assert.Equal(t, "test", report.Checks["bus"].Message, "message")
+}
+
+// Tests that health details are exposed only to allowed users.
+func TestHealthDetails(t *testing.T) {
+	srv := getServer()
+	srv.Settings.ServiceBus().(mocks.IFakeServiceBus).SetPingError(errors.New("test"))
+
+	code, report := getHealthRequestResponse(t, srv.getHealth, httptest.NewRequest(http.MethodGet, "/pub/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, code, "anonymous")
+	assert.Equal(t, healthFailed, report.Checks["bus"].Status, "anonymous status")
+	assert.Equal(t, "", report.Checks["bus"].Message, "anonymous message")
+	assert.Nil(t, report.Checks["storage"].Details, "anonymous details")
+
+	srv.Settings = mocks.FakeNewSettingsWithUserStorage(mocks.FakeNewSecurityProvider(false))
+	srv.lockout = newAuthLockout(providers.LockoutSettings{Threshold: 1, Delay: 60})
+	req := httptest.NewRequest(http.MethodGet, "/pub/ready", nil)
+	req.SetBasicAuth("test", "wrong")
+	assert.False(t, srv.isHealthDetailsAllowed(req), "unauthorized")
+	assert.NotEqual(t, 0, len(srv.lockout.status()), "lockout")
+}
+
+// Tests readiness during shutdown.
+func TestReadyShutdown(t *testing.T) {
+	srv := getServer()
+	srv.busStarted = 1
+	srv.shutdown = shutdown.NewShutdownProvider(&shutdown.ConstructShutdown{Logger: mocks.FakeNewLogger(nil)})
+	srv.shutdown.Shutdown()
+
+	code, _ := getHealthResponse(t, srv.getReady)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "ready")
+
+	code, _ = getHealthResponse(t, srv.getHealth)
+	assert.Equal(t, http.StatusOK, code, "health")
+}
+
+// Tests selectors formatting.
+func TestGetSelectors(t *testing.T) {
+	srv := getHealthServer()
+	all, unmatched := srv.state.GetSelectors()
+	assert.Equal(t, []string{"*", "name=worker-1"}, all, "all")
+	assert.Equal(t, []string{"*", "name=worker-1"}, unmatched, "unmatched")
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/metrics"
+	"go-home.io/x/server/systems/trigger"
+)
+
+// Max inbound webhook body size.
+const maxHookBodySize = 1 << 20
+
+// Fires webhook trigger.
+// Path is not authenticated: hook ID itself is unguessable and trigger
+// optionally validates shared secret or body signature.
+func (s *GoHomeServer) handleHook(writer http.ResponseWriter, request *http.Request) {
+	id := mux.Vars(request)[string(urlHookID)]
+	hook := s.getHookTrigger(id)
+	if nil == hook {
+		respondAPIError(writer, newAPIError(&ErrUnknownTrigger{ID: id}))
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(writer, request.Body, maxHookBodySize))
+	if err != nil {
+		respondAPIError(writer, newAPIError(&ErrBadRequest{}))
+		return
+	}
+
+	err = hook.Hook(request.Header, request.URL.Query(), body)
+	if err != nil {
+		if _, ok := err.(*trigger.ErrHookUnauthorized); ok {
+			metrics.AuthFailures.Inc("hook")
+		}
+
+		respondAPIError(writer, newAPIError(err))
+		return
+	}
+
+	respondStatus(writer, http.StatusOK, &apiStatusResponse{Status: "OK"})
+}
+
+// Returns loaded webhook trigger by its hook ID.
+func (s *GoHomeServer) getHookTrigger(id string) providers.IHookTriggerProvider {
+	if "" == id {
+		return nil
+	}
+
+	for _, v := range s.triggers {
+		hook, ok := v.Interface.(providers.IHookTriggerProvider)
+		if !ok || !v.Loaded {
+			continue
+		}
+
+		if 1 == subtle.ConstantTimeCompare([]byte(hook.HookID()), []byte(id)) {
+			return hook
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go-home.io/x/server/systems/trigger"
+)
+
+// Fake webhook trigger.
+type fakeHookTrigger struct {
+	id   string
+	body []byte
+}
+
+func (f *fakeHookTrigger) Unload() {
+}
+
+func (f *fakeHookTrigger) GetID() string {
+	return "hook.trigger"
+}
+
+func (f *fakeHookTrigger) GetLastTriggeredTime() int64 {
+	return 0
+}
+
+func (f *fakeHookTrigger) HookID() string {
+	return f.id
+}
+
+func (f *fakeHookTrigger) Hook(header http.Header, _ map[string][]string, body []byte) error {
+	if "secret" != header.Get("X-Go-Home-Secret") {
+		return &trigger.ErrHookUnauthorized{}
+	}
+
+	if 0 == len(body) {
+		return &trigger.ErrHookBadPayload{}
+	}
+
+	f.body = body
+	return nil
+}
+
+// Tests inbound webhook calls.
+func TestHandleHook(t *testing.T) {
+	srv := getServer()
+	hook := &fakeHookTrigger{id: "0123456789abcdef"}
+	srv.triggers = append(srv.triggers,
+		&knownMasterComponent{Name: "failed", Loaded: false},
+		&knownMasterComponent{Name: "not loaded", Loaded: false, Interface: &fakeHookTrigger{id: "fedcba9876543210"}},
+		&knownMasterComponent{Name: "hook", Loaded: true, Interface: hook})
+
+	data := []struct {
+		id     string
+		secret string
+		body   string
+		status int
+	}{
+		{id: "", secret: "secret", body: "{}", status: http.StatusNotFound},
+		{id: "fedcba9876543210", secret: "secret", body: "{}", status: http.StatusNotFound},
+		{id: "0123456789abcdef", secret: "wrong", body: "{}", status: http.StatusUnauthorized},
+		{id: "0123456789abcdef", secret: "secret", body: "", status: http.StatusBadRequest},
+		{id: "0123456789abcdef", secret: "secret", body: `{"a": 1}`, status: http.StatusOK},
+	}
+
+	for _, v := range data {
+		req := httptest.NewRequest(http.MethodPost, "/pub/hook/"+v.id, bytes.NewBufferString(v.body))
+		req.Header.Set("X-Go-Home-Secret", v.secret)
+		req = mux.SetURLVars(req, map[string]string{string(urlHookID): v.id})
+
+		r := httptest.NewRecorder()
+		srv.handleHook(r, req)
+		assert.Equal(t, v.status, r.Code, "id %s, secret %s, body %s", v.id, v.secret, v.body)
+	}
+
+	assert.Equal(t, `{"a": 1}`, string(hook.body))
+}
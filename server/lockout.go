@@ -0,0 +1,299 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/gobwas/glob"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+)
+
+const (
+	// Lockout key prefix for IP addresses.
+	lockoutIP = "ip"
+	// Lockout key prefix for user names.
+	lockoutUser = "user"
+)
+
+// Proxies allowed to set client IP headers.
+var trustedProxies []*net.IPNet
+
+// Failed authentication attempts of a single IP address or user.
+type lockoutEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Lockout status of a single IP address or user.
+type lockoutStatus struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Failures    int    `json:"failures"`
+	LastFailure int64  `json:"last_failure"`
+	LockedUntil int64  `json:"locked_until"`
+}
+
+// Login brute-force protection.
+// Failures are counted per IP address and per user name, after reaching
+// threshold every next failure doubles lockout delay.
+type authLockout struct {
+	sync.Mutex
+
+	threshold int
+	delay     time.Duration
+	maxDelay  time.Duration
+	window    time.Duration
+	entries   map[string]*lockoutEntry
+	now       func() time.Time
+}
+
+// Constructs a new lockout tracker.
+func newAuthLockout(settings providers.LockoutSettings) *authLockout {
+	// Settings might be created without validation.
+	defaults.Set(&settings) // nolint: gosec, errcheck
+	return &authLockout{
+		threshold: settings.Threshold,
+		delay:     time.Duration(settings.Delay) * time.Second,
+		maxDelay:  time.Duration(settings.MaxDelay) * time.Second,
+		window:    time.Duration(settings.Window) * time.Second,
+		entries:   make(map[string]*lockoutEntry),
+		now:       time.Now,
+	}
+}
+
+// Returns the longest remaining lockout of the keys.
+func (l *authLockout) locked(keys ...string) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	result := time.Duration(0)
+	for _, v := range keys {
+		e, ok := l.entries[v]
+		if ok && e.lockedUntil.After(now) && e.lockedUntil.Sub(now) > result {
+			result = e.lockedUntil.Sub(now)
+		}
+	}
+
+	return result
+}
+
+// Registers failed attempt.
+// Returns keys which became locked.
+func (l *authLockout) failure(keys ...string) map[string]time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	result := make(map[string]time.Duration)
+	for _, v := range keys {
+		e, ok := l.entries[v]
+		if !ok {
+			e = &lockoutEntry{}
+			l.entries[v] = e
+		}
+
+		e.failures++
+		e.lastFailure = now
+		if e.failures < l.threshold {
+			continue
+		}
+
+		delay := l.maxDelay
+		if shift := uint(e.failures - l.threshold); shift < 32 {
+			delay = l.delay << shift
+			if delay > l.maxDelay || delay <= 0 {
+				delay = l.maxDelay
+			}
+		}
+
+		e.lockedUntil = now.Add(delay)
+		result[v] = delay
+	}
+
+	return result
+}
+
+// Resets counters after successful attempt.
+func (l *authLockout) success(keys ...string) {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, v := range keys {
+		delete(l.entries, v)
+	}
+}
+
+// Returns current failures and lockouts.
+func (l *authLockout) status() []*lockoutStatus {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	result := make([]*lockoutStatus, 0, len(l.entries))
+	for k, v := range l.entries {
+		parts := strings.SplitN(k, ":", 2)
+		st := &lockoutStatus{
+			Type:        parts[0],
+			Name:        parts[1],
+			Failures:    v.failures,
+			LastFailure: v.lastFailure.Unix(),
+		}
+
+		if v.lockedUntil.After(now) {
+			st.LockedUntil = v.lockedUntil.Unix()
+		}
+
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type == result[j].Type {
+			return result[i].Name < result[j].Name
+		}
+
+		return result[i].Type < result[j].Type
+	})
+
+	return result
+}
+
+// Removes outdated entries.
+func (l *authLockout) cleanup(now time.Time) {
+	for k, v := range l.entries {
+		if now.Sub(v.lastFailure) > l.window && !v.lockedUntil.After(now) {
+			delete(l.entries, k)
+		}
+	}
+}
+
+// Starts login brute-force protection.
+func (s *GoHomeServer) startLockout() {
+	settings := s.Settings.MasterSettings().Lockout
+	s.lockout = newAuthLockout(settings)
+	trustedProxies = parseTrustedProxies(settings.TrustedProxies, s.Logger)
+	if "" == settings.Notify {
+		return
+	}
+
+	exp, err := glob.Compile(settings.Notify)
+	if err != nil {
+		s.Logger.Error("Failed to compile lockout notifications regexp", err, common.LogSystemToken, logSystem)
+		return
+	}
+
+	s.lockoutNotify = exp
+}
+
+// Registers failed authentication and notifies about new lockouts.
+func (s *GoHomeServer) lockoutFailure(keys []string) {
+	for k, v := range s.lockout.failure(keys...) {
+		msg := fmt.Sprintf("Too many failed login attempts from %s, locked for %s", strings.Replace(k, ":", " ", 1), v)
+		s.Logger.Warn(msg, common.LogSystemToken, logSystem)
+		if nil != s.lockoutNotify {
+			s.SendNotificationCommand(s.lockoutNotify, msg)
+		}
+	}
+}
+
+// Returns lockout keys of the request.
+func getLockoutKeys(r *http.Request) []string {
+	keys := []string{fmt.Sprintf("%s:%s", lockoutIP, getRequestIP(r))}
+	if usr, _, ok := r.BasicAuth(); ok && "" != usr {
+		keys = append(keys, fmt.Sprintf("%s:%s", lockoutUser, usr))
+	}
+
+	return keys
+}
+
+// Parses trusted proxies list, skipping invalid entries.
+// Single IP addresses are converted into a host-only network.
+func parseTrustedProxies(proxies []string, logger common.ILoggerProvider) []*net.IPNet {
+	result := make([]*net.IPNet, 0, len(proxies))
+	for _, v := range proxies {
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); nil != ip {
+				if nil != ip.To4() {
+					v += "/32"
+				} else {
+					v += "/128"
+				}
+			}
+		}
+
+		_, network, err := net.ParseCIDR(v)
+		if err != nil {
+			logger.Error("Failed to parse trusted proxy, skipping", err, common.LogSystemToken, logSystem)
+			continue
+		}
+
+		result = append(result, network)
+	}
+
+	return result
+}
+
+// Checks whether IP address belongs to one of trusted proxies.
+func isProxyTrusted(address string) bool {
+	ip := net.ParseIP(address)
+	if nil == ip {
+		return false
+	}
+
+	for _, v := range trustedProxies {
+		if v.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns client IP address.
+// Proxy headers are accepted only from trusted proxies. X-Forwarded-For chain
+// is walked from the right and the first hop which is not a trusted proxy is used.
+func getRequestIP(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+
+	if !isProxyTrusted(remoteIP) {
+		return remoteIP
+	}
+
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); "" != forwardedFor {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if nil == net.ParseIP(hop) {
+				break
+			}
+
+			remoteIP = hop
+			if !isProxyTrusted(hop) {
+				return hop
+			}
+		}
+
+		return remoteIP
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-Ip")); nil != net.ParseIP(realIP) {
+		return realIP
+	}
+
+	return remoteIP
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/providers"
+)
+
+// Tests lockout delays.
+func TestLockoutBackoff(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newAuthLockout(providers.LockoutSettings{Threshold: 3, Delay: 10, MaxDelay: 60})
+	l.now = func() time.Time {
+		return now
+	}
+
+	assert.Equal(t, 0, len(l.failure("ip:1", "user:a")))
+	assert.Equal(t, 0, len(l.failure("ip:1")))
+	assert.Equal(t, time.Duration(0), l.locked("ip:1", "user:a"))
+
+	assert.Equal(t, map[string]time.Duration{"ip:1": 10 * time.Second}, l.failure("ip:1"))
+	assert.Equal(t, 10*time.Second, l.locked("ip:1", "user:a"))
+	assert.Equal(t, time.Duration(0), l.locked("ip:2", "user:a"))
+
+	assert.Equal(t, 20*time.Second, l.failure("ip:1")["ip:1"])
+	assert.Equal(t, 40*time.Second, l.failure("ip:1")["ip:1"])
+	assert.Equal(t, 60*time.Second, l.failure("ip:1")["ip:1"], "max")
+
+	now = now.Add(61 * time.Second)
+	assert.Equal(t, time.Duration(0), l.locked("ip:1"), "expired")
+
+	status := l.status()
+	require.Equal(t, 2, len(status))
+	assert.Equal(t, "ip", status[0].Type)
+	assert.Equal(t, "1", status[0].Name)
+	assert.Equal(t, 6, status[0].Failures)
+	assert.Equal(t, int64(0), status[0].LockedUntil)
+	assert.Equal(t, "user", status[1].Type)
+
+	l.success("ip:1")
+	now = now.Add(time.Hour)
+	assert.Equal(t, 0, len(l.status()), "cleaned up")
+}
+
+// Tests that auth middleware locks out clients.
+func TestAuthMiddlewareLockout(t *testing.T) {
+	prepareCidrs()
+	logs := make([]string, 0)
+	s := &GoHomeServer{
+		Logger:   mocks.FakeNewLogger(func(s string) { logs = append(logs, s) }),
+		Settings: mocks.FakeNewSettingsWithUserStorage(mocks.FakeNewSecurityProvider(false)),
+		lockout:  newAuthLockout(providers.LockoutSettings{Threshold: 2, Delay: 60}),
+	}
+
+	nextCalled := false
+	handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		nextCalled = true
+	}))
+
+	invoke := func(url string, usr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.RemoteAddr = "245.0.0.1:1234"
+		req.SetBasicAuth(usr, "pwd")
+		r := httptest.NewRecorder()
+		handler.ServeHTTP(r, req)
+		return r
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, invoke("/api/v2/device", "usr1").Code)
+	assert.Equal(t, http.StatusUnauthorized, invoke("/api/v2/device", "usr2").Code)
+	assert.Contains(t, logs, "Too many failed login attempts from ip 245.0.0.1, locked for 1m0s")
+
+	s.Settings = mocks.FakeNewSettingsWithUserStorage(mocks.FakeNewSecurityProvider(true))
+	r := invoke("/api/v2/device", "usr3")
+	assert.Equal(t, http.StatusTooManyRequests, r.Code)
+	assert.Equal(t, "60", r.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusTooManyRequests, invoke("/api/v1/device", "usr3").Code)
+	assert.False(t, nextCalled)
+
+	s.lockout.success("ip:245.0.0.1")
+	assert.Equal(t, http.StatusOK, invoke("/api/v2/device", "usr2").Code)
+	assert.True(t, nextCalled)
+}
+
+// Tests client IP detection.
+func TestGetRequestIP(t *testing.T) {
+	trustedProxies = parseTrustedProxies([]string{"10.0.0.1", "10.1.0.0/16", "wrong"}, mocks.FakeNewLogger(nil))
+	defer func() { trustedProxies = nil }()
+
+	input := []struct {
+		remote  string
+		headers map[string]string
+		ip      string
+	}{
+		{"245.0.0.1:80", map[string]string{"X-Real-Ip": "1.1.1.1"}, "245.0.0.1"},
+		{"10.0.0.2:80", map[string]string{"X-Real-Ip": "1.1.1.1"}, "10.0.0.2"},
+		{"10.0.0.1:80", map[string]string{"X-Real-Ip": "1.1.1.1"}, "1.1.1.1"},
+		{"10.0.0.1:80", map[string]string{"X-Forwarded-For": "2.2.2.2, 10.1.0.2"}, "2.2.2.2"},
+		{"10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, 2.2.2.2, 10.1.0.2"}, "2.2.2.2"},
+		{"10.0.0.1:80", map[string]string{"X-Forwarded-For": "wrong, 10.1.0.2"}, "10.1.0.2"},
+		{"10.0.0.1:80", map[string]string{"X-Forwarded-For": "10.1.0.3, 10.1.0.2"}, "10.1.0.3"},
+		{"10.0.0.1:80", map[string]string{}, "10.0.0.1"},
+	}
+
+	for _, v := range input {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = v.remote
+		for k, h := range v.headers {
+			req.Header.Set(k, h)
+		}
+
+		assert.Equal(t, v.ip, getRequestIP(req), v.remote, v.headers)
+	}
+}
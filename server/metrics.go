@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+
+	"go-home.io/x/server/systems/metrics"
+)
+
+// Exports metrics in Prometheus text format.
+// Requires workers permission since metrics expose system internals.
+func (s *GoHomeServer) handleMetrics(writer http.ResponseWriter, request *http.Request) {
+	if !getContextUser(request).Workers() {
+		respondAPIError(writer, newAPIError(&ErrForbidden{}))
+		return
+	}
+
+	metrics.Handler().ServeHTTP(writer, request)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/metrics"
+	"go-home.io/x/server/systems/security"
+)
+
+// Tests metrics endpoint permissions and output.
+func TestMetricsEndpoint(t *testing.T) {
+	defer monkey.UnpatchAll()
+	srv := getServer()
+
+	monkey.Patch(getContextUser, func(_ *http.Request) providers.IAuthenticatedUser {
+		return &security.AuthenticatedUser{Username: "usr"}
+	})
+
+	r := httptest.NewRecorder()
+	srv.handleMetrics(r, httptest.NewRequest(http.MethodGet, "/api/v2/metrics", nil))
+	assert.Equal(t, http.StatusForbidden, r.Code, "forbidden")
+
+	monkey.Patch(getContextUser, getFakeRootUser)
+	metrics.AuthFailures.Inc("unauthorized")
+
+	r = httptest.NewRecorder()
+	srv.handleMetrics(r, httptest.NewRequest(http.MethodGet, "/api/v2/metrics", nil))
+	assert.Equal(t, http.StatusOK, r.Code, "ok")
+	assert.Equal(t, metrics.ContentType, r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `gohome_auth_failures_total{reason="unauthorized"}`)
+}
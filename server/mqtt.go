@@ -0,0 +1,383 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+)
+
+const (
+	// Published to the status topic while bridge is connected.
+	mqttOnline = "online"
+	// Published to the status topic by broker once bridge disconnects.
+	mqttOffline = "offline"
+	// Timeout for a single broker operation.
+	mqttTimeout = 5 * time.Second
+	// Delay between initial connection attempts.
+	mqttRetryDelay = 10 * time.Second
+	// Simple command payloads.
+	mqttPayloadOn     = "ON"
+	mqttPayloadOff    = "OFF"
+	mqttPayloadToggle = "TOGGLE"
+)
+
+// Characters, not allowed in discovery object IDs.
+var mqttDiscoveryIDRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// Discovery config payload.
+type mqttDiscoveryConfig struct {
+	Name                string               `json:"name"`
+	UniqueID            string               `json:"unique_id"`
+	StateTopic          string               `json:"state_topic"`
+	ValueTemplate       string               `json:"value_template"`
+	JSONAttributesTopic string               `json:"json_attributes_topic"`
+	AvailabilityTopic   string               `json:"availability_topic"`
+	CommandTopic        string               `json:"command_topic,omitempty"`
+	PayloadOn           string               `json:"payload_on"`
+	PayloadOff          string               `json:"payload_off"`
+	Device              *mqttDiscoveryDevice `json:"device"`
+}
+
+// Discovery device description.
+type mqttDiscoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// Bridge between known devices and MQTT broker.
+// Device states are published as retained JSON to <prefix>/<id>/state.
+// Commands are accepted from <prefix>/<id>/set (ON, OFF or TOGGLE)
+// and <prefix>/<id>/set/<command> with optional JSON arguments.
+type mqttBridge struct {
+	sync.Mutex
+	server   *GoHomeServer
+	settings *providers.MQTTBridgeSettings
+	client   mqtt.Client
+	user     providers.IAuthenticatedUser
+	logger   common.ILoggerProvider
+
+	subID     int64
+	published map[string]bool
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// Starts MQTT bridge, if broker is configured.
+func (s *GoHomeServer) startMQTT() {
+	settings := &s.Settings.MasterSettings().MQTT
+	if "" == settings.Broker {
+		return
+	}
+
+	s.mqtt = newMQTTBridge(s, settings)
+	go s.mqtt.connect()
+}
+
+// Creates a new MQTT bridge.
+// Bridge is read-only if command user is not set or unknown.
+func newMQTTBridge(s *GoHomeServer, settings *providers.MQTTBridgeSettings) *mqttBridge {
+	b := &mqttBridge{
+		server:    s,
+		settings:  settings,
+		logger:    s.Logger,
+		published: make(map[string]bool),
+		stop:      make(chan struct{}),
+	}
+
+	if "" != settings.User {
+		user, err := s.Settings.Security().GetUserByName(settings.User)
+		if err != nil {
+			b.logger.Error("Failed to find MQTT bridge user, commands are disabled", err,
+				common.LogSystemToken, logSystem, common.LogUserNameToken, settings.User)
+		} else {
+			b.user = user
+		}
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(settings.Broker).
+		SetClientID(settings.ClientID).
+		SetUsername(settings.Username).
+		SetPassword(settings.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(mqttTimeout).
+		SetWill(b.topic("status"), mqttOffline, b.qos(), true).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(b.onConnectionLost)
+	b.client = mqtt.NewClient(opts)
+
+	var updates chan *common.MsgDeviceUpdate
+	b.subID, updates = s.Settings.FanOut().SubscribeDeviceUpdates()
+	go b.deviceUpdates(updates)
+
+	return b
+}
+
+// Connects to the broker.
+// Client re-connects automatically only after the first successful connection,
+// so initial attempts are retried here.
+func (b *mqttBridge) connect() {
+	for {
+		token := b.client.Connect()
+		token.Wait()
+		if nil == token.Error() {
+			return
+		}
+
+		b.logger.Error("Failed to connect to MQTT broker", token.Error(),
+			common.LogSystemToken, logSystem)
+
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(mqttRetryDelay):
+		}
+	}
+}
+
+// Announces bridge, subscribes to commands and publishes all known devices.
+// Called on every (re)connect, since broker might have lost retained messages.
+func (b *mqttBridge) onConnect(client mqtt.Client) {
+	b.logger.Info("Connected to MQTT broker", common.LogSystemToken, logSystem)
+	b.publish(b.topic("status"), []byte(mqttOnline))
+
+	if nil != b.user {
+		filters := map[string]byte{
+			b.topic("+", "set"):      b.qos(),
+			b.topic("+", "set", "+"): b.qos(),
+		}
+
+		token := client.SubscribeMultiple(filters, b.onCommand)
+		if token.WaitTimeout(mqttTimeout) && nil != token.Error() {
+			b.logger.Error("Failed to subscribe to MQTT commands", token.Error(),
+				common.LogSystemToken, logSystem)
+		}
+	}
+
+	b.Lock()
+	b.published = make(map[string]bool)
+	b.Unlock()
+
+	for _, v := range b.server.state.GetAllDevices() {
+		b.publishDevice(v.ID)
+	}
+}
+
+// Logs lost connection, client re-connects automatically.
+func (b *mqttBridge) onConnectionLost(_ mqtt.Client, err error) {
+	b.logger.Warn("Lost connection to MQTT broker", common.LogSystemToken, logSystem,
+		common.LogErrorToken, err.Error())
+}
+
+// Publishes device state updates.
+func (b *mqttBridge) deviceUpdates(updates chan *common.MsgDeviceUpdate) {
+	for {
+		select {
+		case <-b.stop:
+			return
+		case msg, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			if b.client.IsConnected() {
+				b.publishDevice(msg.ID)
+			}
+		}
+	}
+}
+
+// Publishes device state and discovery config.
+// Discovery config is published only once per connection.
+func (b *mqttBridge) publishDevice(deviceID string) {
+	kd := b.server.state.GetDevice(deviceID)
+	state := b.server.state.GetDeviceState(deviceID)
+	if nil == kd || nil == state {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		b.logger.Error("Failed to marshal device state", err, common.LogSystemToken, logSystem,
+			common.LogIDToken, deviceID)
+		return
+	}
+
+	b.publish(b.topic(deviceID, "state"), data)
+
+	if !b.settings.Discovery {
+		return
+	}
+
+	b.Lock()
+	published := b.published[deviceID]
+	b.published[deviceID] = true
+	b.Unlock()
+
+	if published {
+		return
+	}
+
+	topic, config := b.getDiscoveryConfig(kd)
+	if nil == config {
+		return
+	}
+
+	data, err = json.Marshal(config)
+	if err != nil {
+		b.logger.Error("Failed to marshal discovery config", err, common.LogSystemToken, logSystem,
+			common.LogIDToken, deviceID)
+		return
+	}
+
+	b.publish(topic, data)
+}
+
+// Returns discovery topic and config.
+// Nil is returned for device types without a matching component.
+func (b *mqttBridge) getDiscoveryConfig(kd *knownDevice) (string, *mqttDiscoveryConfig) {
+	component := ""
+	switch kd.Type {
+	case enums.DevLight:
+		component = "light"
+	case enums.DevSwitch:
+		component = "switch"
+	case enums.DevSensor:
+		component = "binary_sensor"
+	default:
+		return "", nil
+	}
+
+	objectID := mqttDiscoveryIDRegexp.ReplaceAllString(kd.ID, "_")
+	nodeID := mqttDiscoveryIDRegexp.ReplaceAllString(b.settings.Prefix, "_")
+	name := kd.Name
+	if "" == name {
+		name = kd.ID
+	}
+
+	config := &mqttDiscoveryConfig{
+		Name:                name,
+		UniqueID:            fmt.Sprintf("%s_%s", nodeID, objectID),
+		StateTopic:          b.topic(kd.ID, "state"),
+		ValueTemplate:       "{{ 'ON' if value_json.on else 'OFF' }}",
+		JSONAttributesTopic: b.topic(kd.ID, "state"),
+		AvailabilityTopic:   b.topic("status"),
+		PayloadOn:           mqttPayloadOn,
+		PayloadOff:          mqttPayloadOff,
+		Device: &mqttDiscoveryDevice{
+			Identifiers:  []string{kd.ID},
+			Name:         name,
+			Manufacturer: "go-home",
+			Model:        kd.Type.String(),
+		},
+	}
+
+	if nil != b.user && !kd.IsReadOnly && helpers.SliceContainsString(kd.Commands, enums.CmdOn.String()) {
+		config.CommandTopic = b.topic(kd.ID, "set")
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s/config", b.settings.DiscoveryPrefix, component, nodeID, objectID), config
+}
+
+// Processes inbound command message.
+func (b *mqttBridge) onCommand(_ mqtt.Client, msg mqtt.Message) {
+	deviceID, cmdName, data, ok := b.parseCommandTopic(msg.Topic(), msg.Payload())
+	if !ok {
+		b.logger.Warn("Received malformed MQTT command", common.LogSystemToken, logSystem,
+			"topic", msg.Topic())
+		return
+	}
+
+	err := b.invoke(deviceID, cmdName, data)
+	if err != nil {
+		b.logger.Warn("Failed to invoke MQTT command", common.LogSystemToken, logSystem,
+			common.LogIDToken, deviceID, common.LogDeviceCommandToken, cmdName,
+			common.LogErrorToken, err.Error())
+	}
+}
+
+// Invokes device command on behalf of the bridge user.
+// Same permission checks and audit as for API calls are applied.
+func (b *mqttBridge) invoke(deviceID string, cmdName string, data []byte) error {
+	if nil == b.user {
+		return &ErrForbidden{}
+	}
+
+	return b.server.commandInvokeDeviceCommand(b.user, deviceID, cmdName, data)
+}
+
+// Parses command topic and payload.
+// Plain set topic accepts only ON, OFF and TOGGLE payloads.
+func (b *mqttBridge) parseCommandTopic(topic string, payload []byte) (string, string, []byte, bool) {
+	parts := strings.Split(strings.TrimPrefix(topic, b.settings.Prefix+"/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || "set" != parts[1] || "" == parts[0] {
+		return "", "", nil, false
+	}
+
+	if 3 == len(parts) {
+		return parts[0], parts[2], payload, true
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(string(payload))) {
+	case mqttPayloadOn:
+		return parts[0], enums.CmdOn.String(), nil, true
+	case mqttPayloadOff:
+		return parts[0], enums.CmdOff.String(), nil, true
+	case mqttPayloadToggle:
+		return parts[0], enums.CmdToggle.String(), nil, true
+	}
+
+	return "", "", nil, false
+}
+
+// Publishes retained message.
+func (b *mqttBridge) publish(topic string, payload []byte) {
+	token := b.client.Publish(topic, b.qos(), true, payload)
+	if token.WaitTimeout(mqttTimeout) && nil != token.Error() {
+		b.logger.Error("Failed to publish MQTT message", token.Error(), common.LogSystemToken, logSystem,
+			"topic", topic)
+	}
+}
+
+// Returns topic under configured prefix.
+func (b *mqttBridge) topic(parts ...string) string {
+	return strings.Join(append([]string{b.settings.Prefix}, parts...), "/")
+}
+
+// Returns configured QoS.
+func (b *mqttBridge) qos() byte {
+	return byte(b.settings.QoS)
+}
+
+// Returns health check.
+// Bridge is not critical, so disconnect only degrades master.
+func (b *mqttBridge) check() *healthCheck {
+	if b.client.IsConnected() {
+		return &healthCheck{Status: healthOk}
+	}
+
+	return &healthCheck{Status: healthDegraded, Message: "not connected"}
+}
+
+// Marks bridge as offline and disconnects from the broker.
+func (b *mqttBridge) Unload() {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+		b.server.Settings.FanOut().UnSubscribeDeviceUpdates(b.subID)
+		if b.client.IsConnected() {
+			b.publish(b.topic("status"), []byte(mqttOffline))
+			b.client.Disconnect(uint(mqttTimeout / time.Millisecond))
+		}
+	})
+}
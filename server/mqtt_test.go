@@ -0,0 +1,226 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/alarm"
+	"go-home.io/x/server/systems/bus"
+	"go-home.io/x/server/systems/security"
+)
+
+// Captures commands, sent to workers.
+type mqttCommands struct {
+	sync.Mutex
+	commands []*bus.DeviceCommandMessage
+}
+
+func (m *mqttCommands) publish(_ string, msg ...interface{}) {
+	m.Lock()
+	defer m.Unlock()
+	m.commands = append(m.commands, msg[0].(*bus.DeviceCommandMessage))
+}
+
+func (m *mqttCommands) get() []*bus.DeviceCommandMessage {
+	m.Lock()
+	defer m.Unlock()
+	return append([]*bus.DeviceCommandMessage(nil), m.commands...)
+}
+
+// Waits until condition is met.
+func waitMQTT(cond func() bool) bool {
+	for i := 0; i < 50; i++ {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+// Returns connected MQTT bridge.
+func getMQTTBridge(t *testing.T, broker mocks.IFakeMQTTBroker, discovery bool) (*mqttBridge, *mqttCommands) {
+	commands := &mqttCommands{}
+	srv := getServer()
+	srv.Settings = getFakeSettings(commands.publish, nil, nil)
+	srv.state.GetDevice("dev1").Type = enums.DevLight
+	srv.state.GetDevice("dev1").Name = "Kitchen light"
+	srv.state.GetDevice("dev1").State = map[string]interface{}{"on": false}
+	srv.state.GetDevice("device").State = map[string]interface{}{"on": true}
+
+	b := newMQTTBridge(srv, &providers.MQTTBridgeSettings{
+		Broker:          broker.Address(),
+		ClientID:        "test",
+		Prefix:          "go-home",
+		User:            "mqtt",
+		Discovery:       discovery,
+		DiscoveryPrefix: "homeassistant",
+	})
+
+	b.user = &security.AuthenticatedUser{
+		Username: "mqtt",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("dev1")},
+				},
+			},
+		},
+	}
+
+	b.connect()
+	require.True(t, waitMQTT(func() bool {
+		return "online" == string(broker.Retained("go-home/status"))
+	}), "not connected")
+
+	return b, commands
+}
+
+// Tests device states publishing.
+func TestMQTTStates(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	defer broker.Close()
+
+	b, _ := getMQTTBridge(t, broker, false)
+	require.True(t, waitMQTT(func() bool {
+		return nil != broker.Retained("go-home/device/state")
+	}), "initial state")
+	assert.JSONEq(t, `{"on": false}`, string(broker.Retained("go-home/dev1/state")))
+	assert.Equal(t, 0, len(broker.Messages("homeassistant/#")), "discovery")
+
+	b.server.state.GetDevice("dev1").State["on"] = true
+	b.server.Settings.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: "dev1"}
+	assert.True(t, waitMQTT(func() bool {
+		return `{"on":true}` == string(broker.Retained("go-home/dev1/state"))
+	}), "update")
+
+	b.Unload()
+	assert.True(t, waitMQTT(func() bool {
+		return 0 == broker.Clients() && "offline" == string(broker.Retained("go-home/status"))
+	}), "not disconnected")
+}
+
+// Tests commands routing and permissions.
+func TestMQTTCommands(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	defer broker.Close()
+
+	b, commands := getMQTTBridge(t, broker, false)
+	defer b.Unload()
+
+	broker.Publish("go-home/dev1/set", []byte("ON"), false)
+	broker.Publish("go-home/dev1/set/set-brightness", []byte(`{"value": 50}`), false)
+	broker.Publish("go-home/dev1/set", []byte("wrong"), false)
+	broker.Publish("go-home/device/set", []byte("ON"), false)
+	require.True(t, waitMQTT(func() bool {
+		return 3 == len(getAuditEntries(b.server))
+	}), "not processed")
+
+	cmds := commands.get()
+	require.Equal(t, 2, len(cmds))
+	assert.Equal(t, enums.CmdOn, cmds[0].Command)
+	assert.Equal(t, "dev1", cmds[0].DeviceID)
+	assert.Equal(t, enums.CmdSetBrightness, cmds[1].Command)
+	assert.Equal(t, 50.0, cmds[1].Payload["value"])
+
+	entries := getAuditEntries(b.server)
+	assert.Equal(t, "mqtt", entries[0].User)
+	assert.False(t, entries[2].Success, "forbidden device")
+}
+
+// Tests discovery configs.
+func TestMQTTDiscovery(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	defer broker.Close()
+
+	b, _ := getMQTTBridge(t, broker, true)
+	defer b.Unload()
+
+	require.True(t, waitMQTT(func() bool {
+		return nil != broker.Retained("homeassistant/light/go-home/dev1/config")
+	}), "discovery")
+
+	config := &mqttDiscoveryConfig{}
+	require.NoError(t, json.Unmarshal(broker.Retained("homeassistant/light/go-home/dev1/config"), config))
+	assert.Equal(t, "Kitchen light", config.Name)
+	assert.Equal(t, "go-home/dev1/state", config.StateTopic)
+	assert.Equal(t, "go-home/dev1/set", config.CommandTopic)
+	assert.Equal(t, "go-home/status", config.AvailabilityTopic)
+
+	b.server.Settings.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: "dev1"}
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, len(broker.Messages("homeassistant/#")), "published twice")
+}
+
+// Tests command topics parsing.
+func TestMQTTCommandTopic(t *testing.T) {
+	b := &mqttBridge{settings: &providers.MQTTBridgeSettings{Prefix: "go-home"}}
+	data := []struct {
+		topic   string
+		payload string
+		id      string
+		cmd     string
+		ok      bool
+	}{
+		{topic: "go-home/light/set", payload: "on", id: "light", cmd: "on", ok: true},
+		{topic: "go-home/light/set", payload: "TOGGLE", id: "light", cmd: "toggle", ok: true},
+		{topic: "go-home/light/set", payload: "50"},
+		{topic: "go-home/light/set/set-brightness", payload: "50", id: "light", cmd: "set-brightness", ok: true},
+		{topic: "go-home/light/state", payload: "ON"},
+		{topic: "go-home/light/set/a/b", payload: "ON"},
+		{topic: "go-home//set", payload: "ON"},
+	}
+
+	for _, v := range data {
+		id, cmd, _, ok := b.parseCommandTopic(v.topic, []byte(v.payload))
+		assert.Equal(t, v.ok, ok, v.topic)
+		assert.Equal(t, v.id, id, v.topic)
+		assert.Equal(t, v.cmd, cmd, v.topic)
+	}
+}
+
+// Tests that bridge commands are subject to per-type permissions.
+func TestMQTTAlarmPermissions(t *testing.T) {
+	srv := getLocationsServer(nil)
+	a, err := alarm.NewAlarmProvider(&alarm.ConstructAlarm{
+		RawConfig: []byte("name: house\ncode: 1234"),
+		Settings:  srv.Settings,
+		Server:    srv,
+		Store:     mocks.FakeNewPersistentStore(),
+	})
+	require.NoError(t, err)
+	srv.alarms = map[string]providers.IAlarmProvider{a.ID(): a}
+
+	b := &mqttBridge{server: srv}
+	assert.IsType(t, &ErrForbidden{}, b.invoke("alarm.house", enums.CmdArmAway.String(), nil), "read-only")
+
+	b.user = &security.AuthenticatedUser{
+		Username: "mqtt",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("*")},
+				},
+			},
+		},
+	}
+
+	assert.IsType(t, &ErrUnknownDevice{}, b.invoke("alarm.house", enums.CmdArmAway.String(), nil), "no arm verb")
+	assert.Equal(t, enums.AlarmDisarmed, a.State())
+	assert.False(t, getAuditEntries(srv)[0].Success, "audit")
+}
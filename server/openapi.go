@@ -0,0 +1,225 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/systems"
+)
+
+const (
+	// openAPIVersion describes version of the generated document.
+	openAPIVersion = "3.0.3"
+	// openAPISchemas describes components schemas reference prefix.
+	openAPISchemas = "#/components/schemas/"
+)
+
+// Matches path parameters.
+var pathParamRegexp = regexp.MustCompile(`{([^}]+)}`)
+
+// Generates OpenAPI schemas out of go types.
+type openAPIBuilder struct {
+	schemas map[string]interface{}
+	enums   map[reflect.Type][]string
+}
+
+// Builds OpenAPI document out of API v2 routes.
+func buildOpenAPISpec(routes []*apiV2Route) map[string]interface{} {
+	b := &openAPIBuilder{
+		schemas: make(map[string]interface{}),
+		enums:   openAPIEnums(),
+	}
+
+	paths := make(map[string]map[string]interface{})
+	for _, v := range routes {
+		p, ok := paths[v.Path]
+		if !ok {
+			p = make(map[string]interface{})
+			paths[v.Path] = p
+		}
+
+		p[strings.ToLower(v.Method)] = b.operation(v)
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "go-home API",
+			"version": "2",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": routeAPIv2},
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]interface{}{
+				"basicAuth": map[string]interface{}{"type": "http", "scheme": "basic"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"basicAuth": []string{}},
+		},
+	}
+}
+
+// Describes a single route.
+func (b *openAPIBuilder) operation(route *apiV2Route) map[string]interface{} {
+	params := make([]interface{}, 0)
+	for _, v := range pathParamRegexp.FindAllStringSubmatch(route.Path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     v[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+
+	for _, v := range route.Query {
+		params = append(params, map[string]interface{}{
+			"name":        v.Name,
+			"in":          "query",
+			"description": v.Description,
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+
+	errResponse := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": b.schema(reflect.TypeOf(&apiErrorResponse{})),
+			},
+		},
+	}
+
+	op := map[string]interface{}{
+		"operationId": route.ID,
+		"summary":     route.Summary,
+		"parameters":  params,
+		"responses": map[string]interface{}{
+			fmt.Sprint(http.StatusOK): map[string]interface{}{
+				"description": "Success",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": b.schema(reflect.TypeOf(route.Response)),
+					},
+				},
+			},
+			"default": errResponse,
+		},
+	}
+
+	if nil != route.Body {
+		op["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": b.schema(reflect.TypeOf(route.Body)),
+				},
+			},
+		}
+	}
+
+	return op
+}
+
+// Returns schema of the type.
+// Structures are placed into components and referenced.
+func (b *openAPIBuilder) schema(t reflect.Type) map[string]interface{} {
+	if nil == t {
+		return map[string]interface{}{}
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if values, ok := b.enums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	if t.Kind() != reflect.Struct && t.Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		return b.reference(t)
+	}
+
+	return map[string]interface{}{}
+}
+
+// Registers structure schema and returns reference to it.
+func (b *openAPIBuilder) reference(t reflect.Type) map[string]interface{} {
+	name := strings.Title(t.Name())
+	ref := map[string]interface{}{"$ref": openAPISchemas + name}
+	if _, ok := b.schemas[name]; ok {
+		return ref
+	}
+
+	// Placeholder prevents endless recursion on self-referencing types.
+	b.schemas[name] = nil
+	props := make(map[string]interface{})
+	for ii := 0; ii < t.NumField(); ii++ {
+		f := t.Field(ii)
+		if "" != f.PkgPath || f.Anonymous {
+			continue
+		}
+
+		field := strings.Split(f.Tag.Get("json"), ",")[0]
+		if "-" == field {
+			continue
+		}
+
+		if "" == field {
+			field = f.Name
+		}
+
+		props[field] = b.schema(f.Type)
+	}
+
+	b.schemas[name] = map[string]interface{}{"type": "object", "properties": props}
+	return ref
+}
+
+// Returns known enums values.
+func openAPIEnums() map[reflect.Type][]string {
+	result := make(map[reflect.Type][]string)
+	add := func(values interface{}) {
+		v := reflect.ValueOf(values)
+		strs := make([]string, v.Len())
+		for ii := 0; ii < v.Len(); ii++ {
+			strs[ii] = fmt.Sprint(v.Index(ii).Interface())
+		}
+
+		result[v.Type().Elem()] = strs
+	}
+
+	add(enums.DeviceTypeValues())
+	add(enums.PropertyValues())
+	add(enums.UOMValues())
+	add(systems.SystemTypeValues())
+	add(entityStatusValues())
+	return result
+}
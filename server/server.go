@@ -6,23 +6,32 @@ package server
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"sort"
+	"sync/atomic"
 	"time"
 
+	"github.com/gobwas/glob"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/rakyll/statik/fs"
 	busPlugin "go-home.io/x/server/plugins/bus"
 	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/helpers"
 	"go-home.io/x/server/providers"
 	_ "go-home.io/x/server/server/statik" // Importing statik auto-generated files.
+	"go-home.io/x/server/systems/alarm"
 	"go-home.io/x/server/systems/api"
 	"go-home.io/x/server/systems/bus"
 	"go-home.io/x/server/systems/group"
+	"go-home.io/x/server/systems/helper"
+	"go-home.io/x/server/systems/mode"
 	"go-home.io/x/server/systems/notification"
+	"go-home.io/x/server/systems/occupancy"
+	"go-home.io/x/server/systems/person"
+	"go-home.io/x/server/systems/scene"
+	"go-home.io/x/server/systems/shutdown"
+	"go-home.io/x/server/systems/storage"
 	"go-home.io/x/server/systems/trigger"
 	"go-home.io/x/server/systems/ui"
 )
@@ -51,7 +60,25 @@ type GoHomeServer struct {
 	extendedAPIs  []*knownMasterComponent
 	notifications []*knownMasterComponent
 	groups        map[string]providers.IGroupProvider
+	scenes        map[string]providers.ISceneProvider
 	locations     []providers.ILocationProvider
+	occupancy     []providers.IOccupancyProvider
+	webhooks      []providers.IWebhookProvider
+	mqtt          *mqttBridge
+	mode          providers.IModeProvider
+	helpers       map[string]providers.IHelperProvider
+	alarms        map[string]providers.IAlarmProvider
+	persons       map[string]providers.IPersonProvider
+	store         providers.IPersistentStoreProvider
+	events        *eventsHistory
+	lockout       *authLockout
+	lockoutNotify glob.Glob
+	tokenUsage    tokenUsage
+
+	shutdown       providers.IShutdownProvider
+	httpServer     *http.Server
+	redirectServer *http.Server
+	busStarted     int32
 
 	wsSettings websocket.Upgrader
 }
@@ -68,6 +95,8 @@ func NewServer(settings providers.ISettingsProvider) (providers.IServerProvider,
 	}
 
 	server.state = newServerState(settings)
+	server.store = storage.NewPersistentStore(settings.SystemLogger())
+	server.shutdown = shutdown.NewShutdownProvider(&shutdown.ConstructShutdown{Logger: settings.SystemLogger()})
 
 	return &server, nil
 }
@@ -78,24 +107,41 @@ func (s *GoHomeServer) Start() {
 
 	s.startTriggers()
 	s.startGroups()
+	s.startScenes()
+	s.startMode()
+	s.startHelpers()
+	s.startAlarms()
+	s.startPersons()
 	s.startLocations()
+	s.startOccupancy()
 	s.startNotifications()
+	s.startWebhooks()
+	s.startMQTT()
 
 	s.wsSettings = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
 		return true
 	}}
+	s.startLockout()
+	s.events = newEventsHistory(eventsHistorySize)
+	go s.collectEvents()
+
 	router := mux.NewRouter()
 	s.registerAPI(router)
+	err := s.prepareHTTP(handlers.CORS(
+		handlers.AllowedOrigins([]string{"*"}),
+		handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost, http.MethodDelete}),
+		handlers.AllowedHeaders([]string{"Accept-Encoding", "Content-Type", "Connection",
+			"Host", "Origin", "User-Agent", "Referer", "Authorization"}),
+		handlers.AllowCredentials(),
+	)(router))
+	if err != nil {
+		s.Logger.Fatal("Failed to prepare server", err, common.LogSystemToken, logSystem)
+	}
+
+	s.registerShutdown()
 	go func() {
-		err := http.ListenAndServe(fmt.Sprintf(":%d", s.Settings.MasterSettings().Port),
-			handlers.CORS(
-				handlers.AllowedOrigins([]string{"*"}),
-				handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost}),
-				handlers.AllowedHeaders([]string{"Accept-Encoding", "Content-Type", "Connection",
-					"Host", "Origin", "User-Agent", "Referer", "Authorization"}),
-				handlers.AllowCredentials(),
-			)(router))
-		if err != nil {
+		err := s.listen()
+		if err != nil && http.ErrServerClosed != err {
 			s.Logger.Fatal("Failed to start server", err, common.LogSystemToken, logSystem)
 		}
 	}()
@@ -108,15 +154,14 @@ func (s *GoHomeServer) Start() {
 			time.Sleep(time.Duration(sl) * time.Second)
 		}
 
+		if nil != s.shutdown.Context().Err() {
+			return
+		}
+
 		s.busStart()
 	}()
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	for range c {
-		s.Logger.Info("Received stop command, exiting", common.LogSystemToken, logSystem)
-		os.Exit(0)
-	}
+	s.shutdown.Wait()
 }
 
 // GetDevice returns known device.
@@ -127,9 +172,11 @@ func (s *GoHomeServer) GetDevice(id string) *providers.KnownDevice {
 	}
 
 	return &providers.KnownDevice{
-		Commands: kd.Commands,
-		Worker:   kd.Worker,
-		Type:     kd.Type,
+		Commands:   kd.Commands,
+		Worker:     kd.Worker,
+		Type:       kd.Type,
+		Properties: s.state.GetDeviceProperties(id),
+		Locations:  s.getDeviceLocations(id),
 	}
 }
 
@@ -156,6 +203,10 @@ func (s *GoHomeServer) registerAPI(router *mux.Router) {
 
 	publicRouter := router.PathPrefix("/pub").Subrouter()
 	publicRouter.HandleFunc("/ping", s.ping).Methods(http.MethodGet)
+	publicRouter.HandleFunc("/health", s.getHealth).Methods(http.MethodGet)
+	publicRouter.HandleFunc("/ready", s.getReady).Methods(http.MethodGet)
+	publicRouter.HandleFunc(fmt.Sprintf("/hook/{%s}", urlHookID), s.handleHook).
+		Methods(http.MethodGet, http.MethodPost)
 
 	apiRouter := router.PathPrefix(routeAPI).Subrouter()
 	apiRouter.HandleFunc("/ws", s.handleWS)
@@ -164,8 +215,15 @@ func (s *GoHomeServer) registerAPI(router *mux.Router) {
 		s.getDeviceStateHistory).Methods(http.MethodGet)
 	apiRouter.HandleFunc(fmt.Sprintf("/state/trigger/{%s}", urlTriggerID),
 		s.getTriggerStateHistory).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/device/batch", s.deviceBatchCommand).Methods(http.MethodPost)
 	apiRouter.HandleFunc(fmt.Sprintf("/device/{%s}/{%s}", urlDeviceID, urlCommandName),
 		s.deviceCommand).Methods(http.MethodPost)
+	apiRouter.HandleFunc(fmt.Sprintf("/location/{%s}/{%s}", urlLocationID, urlCommandName),
+		s.locationCommand).Methods(http.MethodPost)
+	apiRouter.HandleFunc(fmt.Sprintf("/scene/{%s}/capture", urlSceneID),
+		s.captureScene).Methods(http.MethodPost)
+	apiRouter.HandleFunc(fmt.Sprintf("/person/{%s}/location", urlPersonID),
+		s.personLocation).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/group", s.getGroups).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/state", s.getCurrentState).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/worker", s.getWorkers).Methods(http.MethodGet)
@@ -173,6 +231,7 @@ func (s *GoHomeServer) registerAPI(router *mux.Router) {
 	apiRouter.HandleFunc("/logs", s.getLogs).Methods(http.MethodPost)
 
 	apiRouter.Use(s.logMiddleware)
+	s.registerAPIv2(router.PathPrefix(routeAPIv2).Subrouter())
 	router.Use(s.authMiddleware)
 
 	router.PathPrefix("/").Handler(http.FileServer(sFS))
@@ -194,6 +253,7 @@ func (s *GoHomeServer) busStart() {
 	}
 
 	s.Logger.Debug("Successfully subscribed to bus channels", common.LogSystemToken, logSystem)
+	atomic.StoreInt32(&s.busStarted, 1)
 	s.busCycle()
 }
 
@@ -209,6 +269,10 @@ func (s *GoHomeServer) busCycle() {
 			s.state.Update(dup)
 		case load := <-s.MessageParser.GetEntityLoadStatueMessageChan():
 			s.state.EntityLoad(load)
+		case leave := <-s.MessageParser.GetWorkerLeaveMessageChan():
+			s.state.WorkerLeave(leave)
+		case <-s.shutdown.Context().Done():
+			return
 		}
 	}
 }
@@ -227,6 +291,7 @@ func (s *GoHomeServer) startTriggers() {
 			Secret:    s.Settings.Secrets(),
 			Validator: s.Settings.Validator(),
 			Storage:   s.Settings.Storage(),
+			Store:     s.store,
 			Server:    s,
 			Timezone:  s.Settings.Timezone(),
 		}
@@ -298,6 +363,140 @@ func (s *GoHomeServer) startGroups() {
 
 		s.groups[g.ID()] = g
 	}
+
+	group.BreakNestingCycles(s.groups, s.Logger)
+}
+
+// Starts scenes.
+func (s *GoHomeServer) startScenes() {
+	s.scenes = make(map[string]providers.ISceneProvider)
+
+	for _, v := range s.Settings.Scenes() {
+		ctor := &scene.ConstructScene{
+			RawConfig: v.RawConfig,
+			Settings:  s.Settings,
+			Server:    s,
+			Store:     s.store,
+		}
+
+		sc, err := scene.NewSceneProvider(ctor)
+		if err != nil {
+			continue
+		}
+
+		s.scenes[sc.ID()] = sc
+	}
+}
+
+// Starts home mode.
+func (s *GoHomeServer) startMode() {
+	ctor := &mode.ConstructMode{
+		Settings: s.Settings,
+		Server:   s,
+		Store:    s.store,
+	}
+
+	if raw := s.Settings.HomeMode(); nil != raw {
+		ctor.RawConfig = raw.RawConfig
+	}
+
+	m, err := mode.NewModeProvider(ctor)
+	if err != nil {
+		s.Logger.Error("Failed to start home mode", err, common.LogSystemToken, logSystem)
+		return
+	}
+
+	s.mode = m
+}
+
+// Starts input helpers.
+func (s *GoHomeServer) startHelpers() {
+	s.helpers = make(map[string]providers.IHelperProvider)
+
+	for _, v := range s.Settings.Helpers() {
+		ctor := &helper.ConstructHelper{
+			RawConfig: v.RawConfig,
+			Settings:  s.Settings,
+			Server:    s,
+			Store:     s.store,
+		}
+
+		h, err := helper.NewHelperProvider(ctor)
+		if err != nil {
+			s.Logger.Error("Failed to start helper", err, common.LogSystemToken, logSystem,
+				common.LogNameToken, v.Name)
+			continue
+		}
+
+		s.helpers[h.ID()] = h
+	}
+}
+
+// Starts alarm panels.
+func (s *GoHomeServer) startAlarms() {
+	s.alarms = make(map[string]providers.IAlarmProvider)
+
+	for _, v := range s.Settings.Alarms() {
+		ctor := &alarm.ConstructAlarm{
+			RawConfig: v.RawConfig,
+			Settings:  s.Settings,
+			Server:    s,
+			Store:     s.store,
+		}
+
+		a, err := alarm.NewAlarmProvider(ctor)
+		if err != nil {
+			s.Logger.Error("Failed to start alarm", err, common.LogSystemToken, logSystem,
+				common.LogNameToken, v.Name)
+			continue
+		}
+
+		s.alarms[a.ID()] = a
+	}
+}
+
+// Starts persons tracking.
+func (s *GoHomeServer) startPersons() {
+	s.persons = make(map[string]providers.IPersonProvider)
+
+	for _, v := range s.Settings.Persons() {
+		ctor := &person.ConstructPerson{
+			RawConfig: v.RawConfig,
+			Settings:  s.Settings,
+			Server:    s,
+			Store:     s.store,
+		}
+
+		pr, err := person.NewPersonProvider(ctor)
+		if err != nil {
+			s.Logger.Error("Failed to start person", err, common.LogSystemToken, logSystem,
+				common.LogNameToken, v.Name)
+			continue
+		}
+
+		s.persons[pr.ID()] = pr
+	}
+}
+
+// GetPersonPresence returns person presence.
+// Empty string is returned if person is unknown.
+func (s *GoHomeServer) GetPersonPresence(personID string) string {
+	pr, ok := s.persons[personID]
+	if !ok {
+		return ""
+	}
+
+	return pr.Presence()
+}
+
+// GetHomeMode returns current home mode.
+// Empty string is returned if mode is not available.
+func (s *GoHomeServer) GetHomeMode() string {
+	if nil == s.mode {
+		return ""
+	}
+
+	return s.mode.Current()
 }
 
 // Starts locations.
@@ -320,6 +519,98 @@ func (s *GoHomeServer) startLocations() {
 	}
 }
 
+// Starts locations occupancy.
+func (s *GoHomeServer) startOccupancy() {
+	s.occupancy = make([]providers.IOccupancyProvider, 0)
+
+	for _, v := range s.Settings.MasterSettings().Occupancy {
+		ctor := &occupancy.ConstructOccupancy{
+			RawConfig: v.RawConfig,
+			Settings:  s.Settings,
+			Server:    s,
+		}
+
+		o, err := occupancy.NewOccupancyProvider(ctor)
+		if err != nil {
+			continue
+		}
+
+		s.occupancy = append(s.occupancy, o)
+	}
+}
+
+// Returns names of locations, containing the device.
+// Device inherits all ancestors of the location it belongs to.
+func (s *GoHomeServer) getDeviceLocations(deviceID string) []string {
+	result := make([]string, 0)
+	for _, v := range s.locations {
+		if !helpers.SliceContainsString(v.Devices(), deviceID) {
+			continue
+		}
+
+		for _, l := range append([]string{v.ID()}, s.getLocationAncestors(v.ID())...) {
+			if !helpers.SliceContainsString(result, l) {
+				result = append(result, l)
+			}
+		}
+	}
+
+	return result
+}
+
+// Returns location by its name.
+func (s *GoHomeServer) getLocation(name string) providers.ILocationProvider {
+	for _, v := range s.locations {
+		if v.ID() == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Returns names of all location ancestors, starting from the direct parent.
+// Unknown parents are treated as a top-level, cycles are cut.
+func (s *GoHomeServer) getLocationAncestors(name string) []string {
+	result := make([]string, 0)
+	l := s.getLocation(name)
+	for nil != l && "" != l.Parent() {
+		if l.Parent() == name || helpers.SliceContainsString(result, l.Parent()) {
+			s.Logger.Warn("Detected locations cycle", common.LogSystemToken, logSystem,
+				common.LogIDToken, name)
+			break
+		}
+
+		l = s.getLocation(l.Parent())
+		if nil == l {
+			break
+		}
+
+		result = append(result, l.ID())
+	}
+
+	return result
+}
+
+// Returns devices of the location and all its descendants.
+func (s *GoHomeServer) getLocationSubtreeDevices(name string) []string {
+	result := make([]string, 0)
+	for _, v := range s.locations {
+		if v.ID() != name && !helpers.SliceContainsString(s.getLocationAncestors(v.ID()), name) {
+			continue
+		}
+
+		for _, d := range v.Devices() {
+			if !helpers.SliceContainsString(result, d) {
+				result = append(result, d)
+			}
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
 // Starts notification systems.
 func (s *GoHomeServer) startNotifications() {
 	s.notifications = make([]*knownMasterComponent, 0)
@@ -332,6 +623,7 @@ func (s *GoHomeServer) startNotifications() {
 			RawConfig: v.RawConfig,
 			Logger:    s.Settings.SystemLogger(),
 			Secret:    s.Settings.Secrets(),
+			Server:    s,
 		}
 
 		n, err := notification.NewNotificationProvider(ctor)
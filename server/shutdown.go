@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+
+	busPlugin "go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+)
+
+// Registers master shutdown steps.
+// Order is reverse to the start: incoming requests are stopped first,
+// notifications are unloaded last, so other components can still use them.
+func (s *GoHomeServer) registerShutdown() {
+	s.shutdown.Add("http", s.stopHTTP)
+	s.shutdown.Add("mqtt", func(context.Context) {
+		if nil != s.mqtt {
+			s.mqtt.Unload()
+		}
+	})
+	s.shutdown.Add("bus", s.stopBus)
+	s.shutdown.Add("occupancy", func(context.Context) {
+		for _, v := range s.occupancy {
+			v.Unload()
+		}
+	})
+	s.shutdown.Add("triggers", func(context.Context) {
+		s.unloadComponents(s.triggers)
+	})
+	s.shutdown.Add("apis", func(context.Context) {
+		s.unloadComponents(s.extendedAPIs)
+	})
+	s.shutdown.Add("notifications", func(context.Context) {
+		s.unloadComponents(s.notifications)
+	})
+	s.shutdown.Add("webhooks", func(context.Context) {
+		for _, v := range s.webhooks {
+			v.Unload()
+		}
+	})
+	s.shutdown.Add("storage", func(context.Context) {
+		s.Settings.Storage().Flush()
+	})
+}
+
+// Stops HTTP servers, waiting for in-flight requests.
+func (s *GoHomeServer) stopHTTP(ctx context.Context) {
+	if nil != s.redirectServer {
+		s.redirectServer.Shutdown(ctx) // nolint: gosec, errcheck
+	}
+
+	if nil == s.httpServer {
+		return
+	}
+
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.Logger.Error("Failed to gracefully stop http server", err, common.LogSystemToken, logSystem)
+	}
+}
+
+// Releases service bus subscriptions.
+func (s *GoHomeServer) stopBus(context.Context) {
+	s.Settings.ServiceBus().Unsubscribe(busPlugin.ChDiscovery.String())
+	s.Settings.ServiceBus().Unsubscribe(busPlugin.ChDeviceUpdates.String())
+}
+
+// Unloads successfully loaded components.
+func (s *GoHomeServer) unloadComponents(components []*knownMasterComponent) {
+	for _, v := range components {
+		if !v.Loaded {
+			continue
+		}
+
+		if l, ok := v.Interface.(providers.ILoadedProvider); ok {
+			s.Logger.Debug("Unloading component", common.LogSystemToken, logSystem, common.LogNameToken, v.Name)
+			l.Unload()
+		}
+	}
+}
+
+// Returns channel which is closed once shutdown starts.
+func (s *GoHomeServer) shutdownStarted() <-chan struct{} {
+	if nil == s.shutdown {
+		return nil
+	}
+
+	return s.shutdown.Context().Done()
+}
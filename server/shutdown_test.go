@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+)
+
+// Tests master shutdown steps.
+func TestServerShutdown(t *testing.T) {
+	srv, _ := NewServer(mocks.FakeNewSettings(nil, false, nil, nil))
+	s := srv.(*GoHomeServer)
+
+	tr := &fakeTriggerWrapper{id: "trigger"}
+	failed := &fakeTriggerWrapper{id: "failed"}
+	n := &fakeNotificationProvider{name: "notification"}
+	s.triggers = []*knownMasterComponent{
+		{Name: "trigger", Interface: tr, Loaded: true},
+		{Name: "failed", Interface: failed, Loaded: false},
+		{Name: "empty", Loaded: false},
+	}
+	s.notifications = []*knownMasterComponent{{Name: "notification", Interface: n, Loaded: true}}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s.httpServer = &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		writer.WriteHeader(http.StatusOK)
+	})}
+	go s.httpServer.Serve(l) // nolint: errcheck
+
+	status := make(chan int)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+
+		resp.Body.Close() // nolint: errcheck
+		status <- resp.StatusCode
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	s.registerShutdown()
+	s.shutdown.Shutdown()
+
+	assert.Equal(t, http.StatusOK, <-status, "in-flight request")
+	assert.True(t, tr.unloaded, "trigger")
+	assert.False(t, failed.unloaded, "failed trigger")
+	assert.True(t, n.unloaded, "notification")
+
+	select {
+	case <-s.shutdownStarted():
+	default:
+		assert.Fail(t, "shutdown is not started")
+	}
+
+	assert.Nil(t, (&GoHomeServer{}).shutdownStarted())
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"go-home.io/x/server/settings"
 	"go-home.io/x/server/systems"
 	"go-home.io/x/server/systems/bus"
+	"go-home.io/x/server/systems/metrics"
 	"go-home.io/x/server/utils"
 )
 
@@ -22,10 +24,14 @@ type IServerStateProvider interface {
 	Discovery(msg *bus.DiscoveryMessage)
 	Update(msg *bus.DeviceUpdateMessage)
 	EntityLoad(msg *bus.EntityLoadStatusMessage)
+	WorkerLeave(msg *bus.WorkerLeaveMessage)
 	GetAllDevices() []*knownDevice
 	GetDevice(string) *knownDevice
+	GetDeviceProperties(string) []string
+	GetDeviceState(string) map[string]interface{}
 	GetWorkers() []*knownWorker
 	GetEntities() []*knownEntity
+	GetSelectors() (all []string, unmatched []string)
 }
 
 // Worker properties.
@@ -151,10 +157,27 @@ func (s *serverState) Discovery(msg *bus.DiscoveryMessage) {
 	}
 }
 
+// WorkerLeave removes gracefully stopped worker and re-balances its devices.
+func (s *serverState) WorkerLeave(msg *bus.WorkerLeaveMessage) {
+	s.workerMutex.Lock()
+	_, ok := s.KnownWorkers[msg.NodeID]
+	delete(s.KnownWorkers, msg.NodeID)
+	s.workerMutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	s.Logger.Info("Worker is shutting down, re-balance needed",
+		common.LogWorkerToken, msg.NodeID, common.LogSystemToken, logSystem)
+	s.reBalance("")
+}
+
 // Update processes incoming device update message.
 func (s *serverState) Update(msg *bus.DeviceUpdateMessage) {
 	s.Logger.Debug("Received update for the device", common.LogDeviceTypeToken, msg.DeviceType.String(),
 		common.LogSystemToken, logSystem, common.LogIDToken, msg.DeviceID)
+	metrics.DeviceUpdates.Inc(msg.DeviceType.String())
 
 	s.deviceMutex.Lock()
 	defer s.deviceMutex.Unlock()
@@ -232,6 +255,43 @@ func (s *serverState) GetDevice(deviceID string) *knownDevice {
 	return s.KnownDevices[deviceID]
 }
 
+// GetDeviceState returns a copy of the device state.
+// Nil is returned if device is unknown.
+func (s *serverState) GetDeviceState(deviceID string) map[string]interface{} {
+	s.deviceMutex.Lock()
+	defer s.deviceMutex.Unlock()
+
+	kd, ok := s.KnownDevices[deviceID]
+	if !ok {
+		return nil
+	}
+
+	state := make(map[string]interface{}, len(kd.State))
+	for k, v := range kd.State {
+		state[k] = v
+	}
+
+	return state
+}
+
+// GetDeviceProperties returns list of properties, reported by the device.
+func (s *serverState) GetDeviceProperties(deviceID string) []string {
+	s.deviceMutex.Lock()
+	defer s.deviceMutex.Unlock()
+
+	props := make([]string, 0)
+	kd, ok := s.KnownDevices[deviceID]
+	if !ok {
+		return props
+	}
+
+	for k := range kd.State {
+		props = append(props, k)
+	}
+
+	return props
+}
+
 // GetWorkers returns known workers.
 // nolint: dupl
 func (s *serverState) GetWorkers() []*knownWorker {
@@ -246,6 +306,45 @@ func (s *serverState) GetWorkers() []*knownWorker {
 	return workers
 }
 
+// GetSelectors returns configured worker selectors and ones which don't match any live worker.
+func (s *serverState) GetSelectors() (all []string, unmatched []string) {
+	s.workerMutex.Lock()
+	defer s.workerMutex.Unlock()
+
+	all = make([]string, 0)
+	unmatched = make([]string, 0)
+	for _, v := range s.Settings.DevicesConfig() {
+		sel := formatSelector(v.Selector)
+		if helpers.SliceContainsString(all, sel) {
+			continue
+		}
+
+		all = append(all, sel)
+		if 0 == len(s.pickWorker(v)) {
+			unmatched = append(unmatched, sel)
+		}
+	}
+
+	sort.Strings(all)
+	sort.Strings(unmatched)
+	return all, unmatched
+}
+
+// Formats worker selectors as sorted key=value pairs.
+func formatSelector(selector *providers.RawDeviceSelector) string {
+	pairs := make([]string, 0, len(selector.Selectors))
+	for k, v := range selector.Selectors {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", strings.ToLower(k), v))
+	}
+
+	if 0 == len(pairs) {
+		return "*"
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 // GetEntities returns known entities.
 // nolint: dupl
 func (s *serverState) GetEntities() []*knownEntity {
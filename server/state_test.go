@@ -967,3 +967,40 @@ func TestEntityLoad(t *testing.T) {
 	require.Equal(t, 1, len(state.KnownEntities), "didn't receive entity third time")
 	assert.Equal(t, entityLoadFailed, state.KnownEntities["test"].Status, "wrong status third time")
 }
+
+// Tests whether re-balance is triggered immediately when worker leaves.
+func TestWorkerLeaveReBalance(t *testing.T) {
+	devices := []*providers.RawDevice{
+		{
+			StrConfig: "d1",
+			Name:      "d1",
+			Selector:  &providers.RawDeviceSelector{Selectors: map[string]string{}},
+		},
+		{
+			StrConfig: "d2",
+			Name:      "d2",
+			Selector:  &providers.RawDeviceSelector{Selectors: map[string]string{}},
+		},
+	}
+
+	published := make(map[string][]string)
+	s := getFakeSettings(getSbPatch(published, t), devices, nil)
+	state := newServerState(s)
+
+	for _, v := range []string{"1", "2"} {
+		state.KnownWorkers[v] = &knownWorker{
+			ID:         v,
+			MaxDevices: 999,
+			LastSeen:   utils.TimeNow(),
+			Devices:    []*bus.DeviceAssignment{{Config: "d" + v}},
+		}
+	}
+
+	state.WorkerLeave(bus.NewWorkerLeaveMessage("3"))
+	assert.Equal(t, 0, len(published), "unknown worker")
+
+	state.WorkerLeave(bus.NewWorkerLeaveMessage("1"))
+	require.Equal(t, 1, len(published), "count")
+	assert.Equal(t, 2, len(published["2"]), "calls")
+	assert.Equal(t, 1, len(state.GetWorkers()), "workers")
+}
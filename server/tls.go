@@ -0,0 +1,349 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+)
+
+const (
+	// Minimal period between certificate files modification checks.
+	tlsReloadCheckPeriod = 10 * time.Second
+	// Generated CA validity period.
+	tlsCAValidity = 10 * 365 * 24 * time.Hour
+	// Generated server certificate validity period.
+	tlsCertValidity = 2 * 365 * 24 * time.Hour
+)
+
+// Loads certificate and reloads it once files are changed.
+type certificateReloader struct {
+	sync.Mutex
+
+	certFile  string
+	keyFile   string
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+	logger    common.ILoggerProvider
+}
+
+// Constructs a new certificate reloader and loads certificate.
+func newCertificateReloader(certFile string, keyFile string,
+	logger common.ILoggerProvider) (*certificateReloader, error) {
+	r := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate returns current certificate, reloading it if files were changed.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if time.Since(r.lastCheck) < tlsReloadCheckPeriod {
+		return r.cert, nil
+	}
+
+	r.lastCheck = time.Now()
+	if r.getModTime().Equal(r.modTime) {
+		return r.cert, nil
+	}
+
+	err := r.load()
+	if err != nil {
+		r.logger.Error("Failed to reload TLS certificate, using the previous one", err,
+			common.LogSystemToken, logSystem, common.LogFileToken, r.certFile)
+	} else {
+		r.logger.Info("Reloaded TLS certificate", common.LogSystemToken, logSystem,
+			common.LogFileToken, r.certFile)
+	}
+
+	return r.cert, nil
+}
+
+// Loads certificate pair.
+func (r *certificateReloader) load() error {
+	modTime := r.getModTime()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "load certificate failed")
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// Returns the latest modification time of certificate files.
+func (r *certificateReloader) getModTime() time.Time {
+	result := time.Time{}
+	for _, v := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(v)
+		if err == nil && info.ModTime().After(result) {
+			result = info.ModTime()
+		}
+	}
+
+	return result
+}
+
+// Prepares TLS configuration.
+func newTLSConfig(settings *providers.TLSSettings, logger common.ILoggerProvider) (*tls.Config, error) {
+	certFile, keyFile := getTLSFiles(settings)
+	if settings.SelfSigned {
+		err := ensureSelfSigned(certFile, keyFile, settings.Hosts, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reloader, err := newCertificateReloader(certFile, keyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if "" == settings.ClientCA {
+		return cfg, nil
+	}
+
+	data, err := ioutil.ReadFile(settings.ClientCA)
+	if err != nil {
+		return nil, errors.Wrap(err, "read client CA failed")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("client CA doesn't have certificates")
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg, nil
+}
+
+// Returns certificate and key files.
+func getTLSFiles(settings *providers.TLSSettings) (string, string) {
+	certFile := settings.CertFile
+	if "" == certFile {
+		certFile = fmt.Sprintf("%s/_server.crt", utils.GetDefaultConfigsDir())
+	}
+
+	keyFile := settings.KeyFile
+	if "" == keyFile {
+		keyFile = fmt.Sprintf("%s/_server.key", utils.GetDefaultConfigsDir())
+	}
+
+	return certFile, keyFile
+}
+
+// Generates CA and server certificate if they don't exist.
+// CA is persisted in configs directory and re-used for new server certificates.
+func ensureSelfSigned(certFile string, keyFile string, hosts []string, logger common.ILoggerProvider) error {
+	if fileExists(certFile) && fileExists(keyFile) {
+		return nil
+	}
+
+	caCertFile := fmt.Sprintf("%s/_ca.crt", utils.GetDefaultConfigsDir())
+	caKeyFile := fmt.Sprintf("%s/_ca.key", utils.GetDefaultConfigsDir())
+	if !fileExists(caCertFile) || !fileExists(caKeyFile) {
+		logger.Info("Generating self-signed CA", common.LogSystemToken, logSystem, common.LogFileToken, caCertFile)
+		err := generateCertificate(caCertFile, caKeyFile, nil, nil)
+		if err != nil {
+			return errors.Wrap(err, "generate CA failed")
+		}
+	}
+
+	ca, err := tls.LoadX509KeyPair(caCertFile, caKeyFile)
+	if err != nil {
+		return errors.Wrap(err, "load CA failed")
+	}
+
+	ca.Leaf, err = x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return errors.Wrap(err, "parse CA failed")
+	}
+
+	logger.Info("Generating server certificate", common.LogSystemToken, logSystem, common.LogFileToken, certFile)
+	return generateCertificate(certFile, keyFile, &ca, hosts)
+}
+
+// Generates certificate, signed by CA. If CA is nil, generates a new CA.
+func generateCertificate(certFile string, keyFile string, ca *tls.Certificate, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    time.Now().Add(-time.Hour),
+	}
+
+	parent := template
+	var signer interface{} = key
+	if nil == ca {
+		template.Subject = pkix.Name{CommonName: "go-home CA", Organization: []string{"go-home"}}
+		template.NotAfter = time.Now().Add(tlsCAValidity)
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	} else {
+		template.Subject = pkix.Name{CommonName: "go-home", Organization: []string{"go-home"}}
+		template.NotAfter = time.Now().Add(tlsCertValidity)
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		addCertificateHosts(template, hosts)
+		parent = ca.Leaf
+		signer = ca.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return err
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+// Adds DNS names and IP addresses to the certificate.
+// Local host names are always added.
+func addCertificateHosts(template *x509.Certificate, hosts []string) {
+	all := append([]string{"localhost", "127.0.0.1", "::1"}, hosts...)
+	if name, err := os.Hostname(); err == nil {
+		all = append(all, name)
+	}
+
+	for _, v := range all {
+		if ip := net.ParseIP(v); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, v)
+		}
+	}
+}
+
+// Checks whether file exists.
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// Returns handler redirecting HTTP requests to HTTPS port.
+func redirectToHTTPS(port int) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		host, _, err := net.SplitHostPort(request.Host)
+		if err != nil {
+			host = request.Host
+		}
+
+		target := "https://" + net.JoinHostPort(host, strconv.Itoa(port)) + request.URL.RequestURI()
+		http.Redirect(writer, request, target, http.StatusPermanentRedirect)
+	})
+}
+
+// Prepares HTTP server and, if TLS is enabled, HTTPS redirect server.
+func (s *GoHomeServer) prepareHTTP(handler http.Handler) error {
+	settings := s.Settings.MasterSettings()
+	s.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", settings.Port),
+		Handler: handler,
+	}
+
+	if !settings.TLS.Enabled {
+		return nil
+	}
+
+	cfg, err := newTLSConfig(&settings.TLS, s.Logger)
+	if err != nil {
+		return err
+	}
+
+	s.httpServer.TLSConfig = cfg
+	if settings.TLS.RedirectPort > 0 {
+		s.redirectServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", settings.TLS.RedirectPort),
+			Handler: redirectToHTTPS(settings.Port),
+		}
+	}
+
+	return nil
+}
+
+// Starts HTTP server, with TLS if it's enabled.
+func (s *GoHomeServer) listen() error {
+	if nil == s.httpServer.TLSConfig {
+		return s.httpServer.ListenAndServe()
+	}
+
+	if nil != s.redirectServer {
+		go func() {
+			err := s.redirectServer.ListenAndServe()
+			if err != nil && http.ErrServerClosed != err {
+				s.Logger.Error("Failed to start HTTPS redirect server", err, common.LogSystemToken, logSystem)
+			}
+		}()
+	}
+
+	return s.httpServer.ListenAndServeTLS("", "")
+}
+
+// Authenticates request either with headers or with verified client certificate.
+// Certificate's common name is used as user name.
+func (s *GoHomeServer) getRequestUser(r *http.Request) (providers.IAuthenticatedUser, error) {
+	if "" == r.Header.Get("Authorization") && nil != r.TLS && 0 != len(r.TLS.VerifiedChains) {
+		name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if "" != name {
+			return s.Settings.Security().GetUserByName(name)
+		}
+	}
+
+	return s.Settings.Security().GetUser(r.Header)
+}
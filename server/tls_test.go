@@ -0,0 +1,179 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+)
+
+// Switches configs directory to a temporary one.
+func prepareTLSDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+
+	prev := utils.ConfigDir
+	utils.ConfigDir = dir
+	return func() {
+		utils.ConfigDir = prev
+		os.RemoveAll(dir) // nolint: errcheck
+	}
+}
+
+// Tests self-signed certificates generation and TLS handshake.
+func TestSelfSignedTLS(t *testing.T) {
+	defer prepareTLSDir(t)()
+
+	settings := &providers.TLSSettings{SelfSigned: true, Hosts: []string{"home.local", "10.0.0.1"}}
+	cfg, err := newTLSConfig(settings, mocks.FakeNewLogger(nil))
+	require.NoError(t, err)
+
+	for _, v := range []string{"_ca.crt", "_ca.key", "_server.crt", "_server.key"} {
+		assert.True(t, fileExists(filepath.Join(utils.ConfigDir, v)), v)
+	}
+
+	caData, err := ioutil.ReadFile(filepath.Join(utils.ConfigDir, "_ca.crt"))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caData))
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	ts.Listener = tls.NewListener(ts.Listener, cfg)
+	ts.Start()
+	defer ts.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(strings.Replace(ts.URL, "http://", "https://", 1))
+	require.NoError(t, err)
+	resp.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cert, err := cfg.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Contains(t, leaf.DNSNames, "home.local")
+	assert.Equal(t, 3, len(leaf.IPAddresses))
+
+	serial := leaf.SerialNumber
+	cfg, err = newTLSConfig(settings, mocks.FakeNewLogger(nil))
+	require.NoError(t, err)
+	cert, _ = cfg.GetCertificate(nil)
+	leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+	assert.Equal(t, serial, leaf.SerialNumber, "existing certificate is re-used")
+}
+
+// Tests certificate hot reload.
+func TestCertificateReload(t *testing.T) {
+	defer prepareTLSDir(t)()
+
+	certFile, keyFile := getTLSFiles(&providers.TLSSettings{})
+	require.NoError(t, ensureSelfSigned(certFile, keyFile, nil, mocks.FakeNewLogger(nil)))
+
+	logs := make([]string, 0)
+	r, err := newCertificateReloader(certFile, keyFile, mocks.FakeNewLogger(func(s string) { logs = append(logs, s) }))
+	require.NoError(t, err)
+	first, _ := r.GetCertificate(nil)
+
+	require.NoError(t, os.Remove(certFile))
+	require.NoError(t, ensureSelfSigned(certFile, keyFile, nil, mocks.FakeNewLogger(nil)))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	cert, _ := r.GetCertificate(nil)
+	assert.Equal(t, first, cert, "throttled")
+
+	r.lastCheck = time.Now().Add(-2 * tlsReloadCheckPeriod)
+	cert, _ = r.GetCertificate(nil)
+	assert.NotEqual(t, first, cert, "reloaded")
+	assert.Contains(t, logs, "Reloaded TLS certificate")
+
+	require.NoError(t, ioutil.WriteFile(certFile, []byte("wrong"), 0600))
+	require.NoError(t, os.Chtimes(certFile, future.Add(time.Minute), future.Add(time.Minute)))
+	r.lastCheck = time.Now().Add(-2 * tlsReloadCheckPeriod)
+	prev := cert
+	cert, _ = r.GetCertificate(nil)
+	assert.Equal(t, prev, cert, "previous certificate is kept")
+	assert.Contains(t, logs, "Failed to reload TLS certificate, using the previous one")
+}
+
+// Tests wrong TLS settings.
+func TestWrongTLSSettings(t *testing.T) {
+	defer prepareTLSDir(t)()
+
+	_, err := newTLSConfig(&providers.TLSSettings{}, mocks.FakeNewLogger(nil))
+	assert.Error(t, err, "no certificate")
+
+	settings := &providers.TLSSettings{SelfSigned: true, ClientCA: "/wrong/ca.crt"}
+	_, err = newTLSConfig(settings, mocks.FakeNewLogger(nil))
+	assert.Error(t, err, "no client CA")
+
+	settings.ClientCA = filepath.Join(utils.ConfigDir, "_server.key")
+	_, err = newTLSConfig(settings, mocks.FakeNewLogger(nil))
+	assert.Error(t, err, "wrong client CA")
+
+	settings.ClientCA = filepath.Join(utils.ConfigDir, "_ca.crt")
+	cfg, err := newTLSConfig(settings, mocks.FakeNewLogger(nil))
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, cfg.ClientAuth)
+}
+
+// Tests HTTPS redirect.
+func TestRedirectToHTTPS(t *testing.T) {
+	data := map[string]string{
+		"home.local:80":  "https://home.local:8443/api/v1/device?a=1",
+		"home.local":     "https://home.local:8443/api/v1/device?a=1",
+		"10.0.0.1:10080": "https://10.0.0.1:8443/api/v1/device?a=1",
+	}
+
+	for k, v := range data {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/device?a=1", nil)
+		req.Host = k
+		rr := httptest.NewRecorder()
+		redirectToHTTPS(8443).ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusPermanentRedirect, rr.Code, k)
+		assert.Equal(t, v, rr.Header().Get("Location"), k)
+	}
+}
+
+// Tests client certificate user mapping.
+func TestClientCertificateUser(t *testing.T) {
+	s := &GoHomeServer{
+		Logger:   mocks.FakeNewLogger(nil),
+		Settings: mocks.FakeNewSettingsWithUserStorage(mocks.FakeNewSecurityProvider(true)),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "sensor"}}}},
+	}
+
+	usr, err := s.getRequestUser(req)
+	require.NoError(t, err)
+	assert.Equal(t, "sensor", usr.Name())
+
+	req.SetBasicAuth("usr", "pwd")
+	usr, err = s.getRequestUser(req)
+	require.NoError(t, err)
+	assert.Equal(t, "test", usr.Name(), "authorization header has priority")
+
+	s.Settings = mocks.FakeNewSettingsWithUserStorage(mocks.FakeNewSecurityProvider(false))
+	req.Header.Del("Authorization")
+	_, err = s.getRequestUser(req)
+	assert.Error(t, err, "unknown user")
+}
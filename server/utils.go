@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/metrics"
 )
 
 // Plain HTTP_200 API response.
@@ -48,6 +52,21 @@ func respondUnAuth(writer http.ResponseWriter) {
 	http.Error(writer, "Unauthorized", http.StatusUnauthorized)
 }
 
+// Return HTTP_TOO_MANY_REQUESTS status.
+func respondLocked(writer http.ResponseWriter, wait time.Duration, isV2 bool) {
+	writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	if isV2 {
+		respondAPIError(writer, &apiError{
+			Status:  http.StatusTooManyRequests,
+			Code:    errCodeTooManyRequests,
+			Message: "too many failed login attempts",
+		})
+		return
+	}
+
+	http.Error(writer, "Too Many Requests", http.StatusTooManyRequests)
+}
+
 // Return HTTP_FORBIDDEN status.
 func respondForbidden(writer http.ResponseWriter) {
 	http.Error(writer, "Forbidden", http.StatusForbidden)
@@ -72,7 +91,8 @@ func (s *GoHomeServer) logMiddleware(next http.Handler) http.Handler {
 // Authz middleware.
 func (s *GoHomeServer) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.HasPrefix(r.RequestURI, routeAPI) {
+		isV2 := strings.HasPrefix(r.RequestURI, routeAPIv2)
+		if !isV2 && !strings.HasPrefix(r.RequestURI, routeAPI) {
 			if !isRequestInternal(r) {
 				respondUnAuth(w)
 				return
@@ -82,13 +102,43 @@ func (s *GoHomeServer) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		user, err := s.Settings.Security().GetUser(r.Header)
+		keys := getLockoutKeys(r)
+		if nil != s.lockout {
+			if wait := s.lockout.locked(keys...); wait > 0 {
+				s.Logger.Warn("Locked out access attempt", "url", r.RequestURI, "ip", getRequestIP(r))
+				metrics.AuthFailures.Inc("locked")
+				respondLocked(w, wait, isV2)
+				return
+			}
+		}
+
+		user, err := s.getRequestUser(r)
 		if err != nil {
 			s.Logger.Warn("Unauthorized access attempt", "url", r.RequestURI)
+			metrics.AuthFailures.Inc("unauthorized")
+			if nil != s.lockout {
+				s.lockoutFailure(keys)
+			}
+
+			if isV2 {
+				respondAPIError(w, &apiError{
+					Status:  http.StatusUnauthorized,
+					Code:    errCodeUnauthorized,
+					Message: "unauthorized",
+				})
+				return
+			}
+
 			respondUnAuth(w)
 			return
 		}
 
+		if nil != s.lockout {
+			s.lockout.success(keys...)
+		}
+
+		user = newRequestUser(user, r)
+		s.auditTokenUse(user)
 		ctx := context.WithValue(r.Context(), ctxtUserName, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -126,38 +176,9 @@ func prepareCidrs() {
 }
 
 // Checks whether request came from internal subnet.
+// Proxy headers are taken into account only if they are set by trusted proxies.
 func isRequestInternal(r *http.Request) bool {
-	realIP := r.Header.Get("X-Real-Ip")
-	forwardedFor := r.Header.Get("X-Forwarded-For")
-	if realIP == "" && forwardedFor == "" {
-		var remoteIP string
-		var err error
-		if strings.ContainsRune(r.RemoteAddr, ':') {
-			remoteIP, _, err = net.SplitHostPort(r.RemoteAddr)
-			if err != nil {
-				return false
-			}
-		} else {
-			remoteIP = r.RemoteAddr
-		}
-
-		return isAddressPrivate(remoteIP)
-	}
-
-	found := false
-	for _, address := range strings.Split(forwardedFor, ",") {
-		address = strings.TrimSpace(address)
-		if !isAddressPrivate(address) {
-			return false
-		}
-		found = true
-	}
-
-	if "" == realIP && found {
-		return true
-	}
-
-	return isAddressPrivate(realIP)
+	return isAddressPrivate(getRequestIP(r))
 }
 
 // Checks whether IP address belongs to private subnet.
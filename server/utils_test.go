@@ -53,6 +53,9 @@ func TestLogMiddleware(t *testing.T) {
 // Tests authorization middleware.
 func TestAuthMiddleware(t *testing.T) {
 	prepareCidrs()
+	trustedProxies = parseTrustedProxies([]string{"127.0.0.1"}, mocks.FakeNewLogger(nil))
+	defer func() { trustedProxies = nil }()
+
 	in := []struct {
 		url          string
 		nextExpected bool
@@ -60,31 +63,31 @@ func TestAuthMiddleware(t *testing.T) {
 		headers      map[string]string
 	}{
 		{
-			url:          "/api/v2/test/1",
+			url:          "/internal/test/1",
 			security:     mocks.FakeNewSecurityProvider(true),
-			headers:      map[string]string{"X-Real-Ip": "512.0.0.0"},
+			headers:      map[string]string{"X-Real-Ip": "245.0.0.0"},
 			nextExpected: false,
 		},
 		{
-			url:          "/api/v2/test/2",
+			url:          "/internal/test/2",
 			security:     mocks.FakeNewSecurityProvider(true),
 			headers:      map[string]string{"X-Forwarded-For": "245.0.0.0"},
 			nextExpected: false,
 		},
 		{
-			url:          "/api/v2/test/3",
+			url:          "/internal/test/3",
 			security:     mocks.FakeNewSecurityProvider(true),
-			headers:      map[string]string{"X-Forwarded-For": "10.0.0.0", "X-Real-IP": "245.0.0.0"},
+			headers:      map[string]string{"X-Forwarded-For": "10.0.0.0, 245.0.0.0", "X-Real-IP": "10.0.0.1"},
 			nextExpected: false,
 		},
 		{
-			url:          "/api/v2/test/4",
+			url:          "/internal/test/4",
 			security:     mocks.FakeNewSecurityProvider(true),
 			headers:      map[string]string{"X-Forwarded-For": "10.0.0.1"},
 			nextExpected: true,
 		},
 		{
-			url:          "/api/v2/test/5",
+			url:          "/internal/test/5",
 			security:     mocks.FakeNewSecurityProvider(true),
 			headers:      map[string]string{},
 			nextExpected: true,
@@ -101,6 +104,18 @@ func TestAuthMiddleware(t *testing.T) {
 			headers:      map[string]string{},
 			nextExpected: false,
 		},
+		{
+			url:          "/api/v2/test/8",
+			security:     mocks.FakeNewSecurityProvider(true),
+			headers:      map[string]string{"X-Real-Ip": "245.0.0.0"},
+			nextExpected: true,
+		},
+		{
+			url:          "/api/v2/test/9",
+			security:     mocks.FakeNewSecurityProvider(false),
+			headers:      map[string]string{},
+			nextExpected: false,
+		},
 	}
 
 	nextCalled := false
@@ -132,3 +147,17 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, v.nextExpected, nextCalled, "call %s", v.url)
 	}
 }
+
+// Tests that proxy headers are ignored unless they are set by trusted proxies.
+func TestIsRequestInternal(t *testing.T) {
+	prepareCidrs()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "245.0.0.1:80"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("X-Real-Ip", "10.0.0.1")
+	assert.False(t, isRequestInternal(req), "untrusted peer")
+
+	trustedProxies = parseTrustedProxies([]string{"245.0.0.1"}, mocks.FakeNewLogger(nil))
+	defer func() { trustedProxies = nil }()
+	assert.True(t, isRequestInternal(req), "trusted proxy")
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/storage"
+	"go-home.io/x/server/systems/webhook"
+	"go-home.io/x/server/utils"
+)
+
+// Starts outbound webhooks.
+// Undelivered events are kept in a separate file, so frequent updates
+// don't rewrite the main state.
+func (s *GoHomeServer) startWebhooks() {
+	s.webhooks = make([]providers.IWebhookProvider, 0)
+	if 0 == len(s.Settings.MasterSettings().Webhooks) {
+		return
+	}
+
+	store := storage.NewFilePersistentStore(
+		fmt.Sprintf("%s/_webhooks.yaml", utils.GetDefaultConfigsDir()), s.Settings.SystemLogger())
+	for _, v := range s.Settings.MasterSettings().Webhooks {
+		ctor := &webhook.ConstructWebhook{
+			RawConfig: v.RawConfig,
+			Logger:    s.Settings.SystemLogger(),
+			Validator: s.Settings.Validator(),
+			FanOut:    s.Settings.FanOut(),
+			Store:     store,
+		}
+
+		w, err := webhook.NewWebhookProvider(ctor)
+		if err != nil {
+			continue
+		}
+
+		s.webhooks = append(s.webhooks, w)
+	}
+}
+
+// Returns webhooks delivery status.
+func (s *GoHomeServer) commandGetWebhooks(user providers.IAuthenticatedUser) ([]*providers.WebhookStatus, error) {
+	if !user.Entities() {
+		return nil, &ErrForbidden{}
+	}
+
+	result := make([]*providers.WebhookStatus, 0)
+	for _, v := range s.webhooks {
+		result = append(result, v.Status())
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/security"
+)
+
+// Fake webhook.
+type fakeWebhook struct {
+	id string
+}
+
+func (f *fakeWebhook) ID() string {
+	return f.id
+}
+
+func (f *fakeWebhook) Status() *providers.WebhookStatus {
+	return &providers.WebhookStatus{ID: f.id, Delivered: 1}
+}
+
+func (f *fakeWebhook) Unload() {
+}
+
+// Tests webhooks status permissions and order.
+func TestWebhooksStatus(t *testing.T) {
+	srv := getServer()
+	srv.webhooks = []providers.IWebhookProvider{&fakeWebhook{id: "webhook.b"}, &fakeWebhook{id: "webhook.a"}}
+
+	_, err := srv.commandGetWebhooks(&security.AuthenticatedUser{Username: "usr"})
+	assert.IsType(t, &ErrForbidden{}, err, "forbidden")
+
+	status, err := srv.commandGetWebhooks(getFakeRootUser(nil))
+	assert.NoError(t, err)
+	assert.Len(t, status, 2)
+	assert.Equal(t, "webhook.a", status[0].ID)
+	assert.Equal(t, int64(1), status[1].Delivered)
+}
@@ -0,0 +1,490 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/gorilla/websocket"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+)
+
+const (
+	// Time allowed to write a message to the client.
+	wsWriteWait = 10 * time.Second
+	// Time allowed to read the next message or pong from the client.
+	wsPongWait = 60 * time.Second
+	// Maximum incoming message size.
+	wsMaxMessageSize = 64 * 1024
+	// Maximum number of not yet sent replies.
+	wsRepliesQueueSize = 32
+)
+
+// Period of server pings, should be less than pong wait.
+var wsPingPeriod = wsPongWait * 9 / 10
+
+// WS v2 incoming envelope.
+type wsRequest struct {
+	Type      wsMessageType   `json:"type"`
+	RequestID string          `json:"request_id"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WS v2 outgoing envelope.
+type wsResponse struct {
+	Type      wsMessageType `json:"type"`
+	RequestID string        `json:"request_id,omitempty"`
+	Data      interface{}   `json:"data,omitempty"`
+	Error     *apiError     `json:"error,omitempty"`
+}
+
+// WS v2 subscription request.
+// Empty filter matches everything.
+type wsSubscriptionRequest struct {
+	Devices   []string        `json:"devices"`
+	Locations []string        `json:"locations"`
+	Types     []wsMessageType `json:"types"`
+}
+
+// WS v2 subscription reference.
+type wsSubscriptionID struct {
+	Subscription string `json:"subscription"`
+}
+
+// Active WS v2 subscription.
+type wsSubscription struct {
+	devices   []glob.Glob
+	locations []string
+	types     []wsMessageType
+}
+
+// WS v2 connection.
+type wsConnection struct {
+	sync.Mutex
+
+	server *GoHomeServer
+	conn   *websocket.Conn
+	user   providers.IAuthenticatedUser
+
+	lastSubscription int
+	subscriptions    map[string]*wsSubscription
+
+	// Updates are coalesced by entity, so slow client receives only the latest state.
+	pending      map[string]*wsResponse
+	pendingOrder []string
+	notify       chan bool
+	replies      chan *wsResponse
+	done         chan bool
+	closeOnce    sync.Once
+}
+
+// Handles WS v2 upgrade request.
+func (s *GoHomeServer) handleWSv2(writer http.ResponseWriter, request *http.Request) {
+	usr := getContextUser(request)
+	c, err := s.wsSettings.Upgrade(writer, request, nil)
+	if err != nil {
+		s.Logger.Error("Failed to establish a WS connection", err, common.LogUserNameToken, usr.Name())
+		return
+	}
+
+	w := &wsConnection{
+		server:        s,
+		conn:          c,
+		user:          usr,
+		subscriptions: make(map[string]*wsSubscription),
+		pending:       make(map[string]*wsResponse),
+		pendingOrder:  make([]string, 0),
+		notify:        make(chan bool, 1),
+		replies:       make(chan *wsResponse, wsRepliesQueueSize),
+		done:          make(chan bool),
+	}
+
+	go w.writeLoop()
+	go w.updatesLoop()
+	go w.readLoop()
+}
+
+// Closes connection.
+//noinspection GoUnhandledErrorResult
+func (w *wsConnection) close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.conn.Close() // nolint: gosec, errcheck
+	})
+}
+
+// Processes incoming messages.
+//noinspection GoUnhandledErrorResult
+func (w *wsConnection) readLoop() {
+	defer w.close()
+
+	w.conn.SetReadLimit(wsMaxMessageSize)
+	w.conn.SetReadDeadline(time.Now().Add(wsPongWait)) // nolint: gosec, errcheck
+	w.conn.SetPongHandler(func(string) error {
+		return w.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, message, err := w.conn.ReadMessage()
+		if err != nil {
+			w.server.Logger.Info("Closing WS connection for user", common.LogSystemToken, logSystem,
+				common.LogUserNameToken, w.user.Name())
+			return
+		}
+
+		w.conn.SetReadDeadline(time.Now().Add(wsPongWait)) // nolint: gosec, errcheck
+		w.processRequest(message)
+	}
+}
+
+// Sends replies, updates and pings.
+//noinspection GoUnhandledErrorResult
+func (w *wsConnection) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	defer w.close()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case msg := <-w.replies:
+			if nil != w.write(msg) {
+				return
+			}
+		case <-w.notify:
+			for _, msg := range w.takePending() {
+				if nil != w.write(msg) {
+					return
+				}
+			}
+		case <-ticker.C:
+			w.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)) // nolint: gosec, errcheck
+			if nil != w.conn.WriteMessage(websocket.PingMessage, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Listens for devices and triggers updates.
+func (w *wsConnection) updatesLoop() {
+	defer w.close()
+
+	fanOut := w.server.Settings.FanOut()
+	deviceSubID, deviceUpd := fanOut.SubscribeDeviceUpdates()
+	defer fanOut.UnSubscribeDeviceUpdates(deviceSubID)
+
+	triggerSubID, triggerUpd := fanOut.SubscribeTriggerUpdates()
+	defer fanOut.UnSubscribeTriggerUpdates(triggerSubID)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case msg, ok := <-deviceUpd:
+			if !ok {
+				return
+			}
+
+			w.deviceUpdate(msg.ID)
+		case msg, ok := <-triggerUpd:
+			if !ok {
+				return
+			}
+
+			w.triggerUpdate(msg)
+		}
+	}
+}
+
+// Writes a single message.
+func (w *wsConnection) write(msg *wsResponse) error {
+	err := w.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err != nil {
+		return err
+	}
+
+	err = w.conn.WriteJSON(msg)
+	if err != nil {
+		w.server.Logger.Warn("Failed to write WS message, closing connection", common.LogSystemToken, logSystem,
+			common.LogUserNameToken, w.user.Name())
+	}
+
+	return err
+}
+
+// Processes a single client request.
+func (w *wsConnection) processRequest(message []byte) {
+	req := &wsRequest{}
+	var data interface{}
+	err := json.Unmarshal(message, req)
+
+	if err != nil {
+		err = &ErrBadRequest{}
+	} else {
+		switch req.Type {
+		case wsPing:
+			w.reply(&wsResponse{Type: wsPong, RequestID: req.RequestID})
+			return
+		case wsSubscribe:
+			data, err = w.subscribe(req.Data)
+		case wsUnsubscribe:
+			data, err = w.unsubscribe(req.Data)
+		case wsCommand:
+			data, err = w.command(req.Data)
+		case wsBatch:
+			data, err = w.batch(req.Data)
+		default:
+			err = &ErrBadRequest{}
+		}
+	}
+
+	resp := &wsResponse{Type: wsResult, RequestID: req.RequestID, Data: data}
+	if err != nil {
+		resp.Data = nil
+		resp.Error = newAPIError(err)
+	}
+
+	w.reply(resp)
+}
+
+// Adds a new subscription.
+func (w *wsConnection) subscribe(data json.RawMessage) (interface{}, error) {
+	req := &wsSubscriptionRequest{}
+	if 0 != len(data) && nil != json.Unmarshal(data, req) {
+		return nil, &ErrBadRequest{}
+	}
+
+	sub, err := w.server.newSubscription(req)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	w.lastSubscription++
+	id := strconv.Itoa(w.lastSubscription)
+	w.subscriptions[id] = sub
+	return &wsSubscriptionID{Subscription: id}, nil
+}
+
+// Removes subscription.
+func (w *wsConnection) unsubscribe(data json.RawMessage) (interface{}, error) {
+	req := &wsSubscriptionID{}
+	if nil != json.Unmarshal(data, req) {
+		return nil, &ErrBadRequest{}
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.subscriptions[req.Subscription]; !ok {
+		return nil, &ErrUnknownSubscription{ID: req.Subscription}
+	}
+
+	delete(w.subscriptions, req.Subscription)
+	return statusOk(nil)
+}
+
+// Invokes device command.
+func (w *wsConnection) command(data json.RawMessage) (interface{}, error) {
+	req := &batchCommandItem{}
+	if nil != json.Unmarshal(data, req) {
+		return nil, &ErrBadRequest{}
+	}
+
+	var value []byte
+	if nil != req.Value {
+		value, _ = json.Marshal(req.Value) // nolint: gosec
+	}
+
+	return statusOk(w.server.commandInvokeDeviceCommand(w.user, req.Device, req.Command, value))
+}
+
+// Invokes batch device command.
+func (w *wsConnection) batch(data json.RawMessage) (interface{}, error) {
+	req := &batchCommandRequest{}
+	if nil != json.Unmarshal(data, req) {
+		return nil, &ErrBadRequest{}
+	}
+
+	return w.server.commandBatchInvoke(w.user, req.Commands), nil
+}
+
+// Queues reply. Client which doesn't read replies is disconnected.
+func (w *wsConnection) reply(msg *wsResponse) {
+	select {
+	case w.replies <- msg:
+	default:
+		w.server.Logger.Warn("WS client is too slow, closing connection", common.LogSystemToken, logSystem,
+			common.LogUserNameToken, w.user.Name())
+		w.close()
+	}
+}
+
+// Processes device update.
+func (w *wsConnection) deviceUpdate(deviceID string) {
+	kd := w.server.state.GetDevice(deviceID)
+	if nil == kd || !w.user.DeviceGet(kd.ID) {
+		return
+	}
+
+	locations := func() []string {
+		return w.server.getDeviceLocations(kd.ID)
+	}
+
+	if !w.isSubscribed(wsDeviceUpdate, kd.ID, locations) {
+		return
+	}
+
+	w.enqueueUpdate("device:"+kd.ID, &wsResponse{Type: wsDeviceUpdate, Data: newUserDevice(w.user, kd)})
+}
+
+// Processes trigger update.
+func (w *wsConnection) triggerUpdate(triggerID string) {
+	if !w.user.TriggerGet(triggerID) {
+		return
+	}
+
+	locations := func() []string {
+		return []string{}
+	}
+
+	if !w.isSubscribed(wsTriggerUpdate, triggerID, locations) {
+		return
+	}
+
+	w.enqueueUpdate("trigger:"+triggerID, &wsResponse{Type: wsTriggerUpdate, Data: w.server.getKnownTrigger(triggerID)})
+}
+
+// Checks whether any of subscriptions matches the update.
+func (w *wsConnection) isSubscribed(msgType wsMessageType, id string, locations func() []string) bool {
+	w.Lock()
+	defer w.Unlock()
+
+	for _, v := range w.subscriptions {
+		if v.matches(msgType, id, locations) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Queues update, replacing not yet sent update of the same entity.
+func (w *wsConnection) enqueueUpdate(key string, msg *wsResponse) {
+	w.Lock()
+	if _, ok := w.pending[key]; !ok {
+		w.pendingOrder = append(w.pendingOrder, key)
+	}
+
+	w.pending[key] = msg
+	w.Unlock()
+
+	select {
+	case w.notify <- true:
+	default:
+	}
+}
+
+// Returns and clears queued updates.
+func (w *wsConnection) takePending() []*wsResponse {
+	w.Lock()
+	defer w.Unlock()
+
+	result := make([]*wsResponse, 0, len(w.pendingOrder))
+	for _, v := range w.pendingOrder {
+		result = append(result, w.pending[v])
+	}
+
+	w.pending = make(map[string]*wsResponse)
+	w.pendingOrder = make([]string, 0)
+	return result
+}
+
+// Validates subscription request and creates a new subscription.
+// Shared by WS v2 and events stream.
+func (s *GoHomeServer) newSubscription(req *wsSubscriptionRequest) (*wsSubscription, error) {
+	sub := &wsSubscription{
+		devices:   make([]glob.Glob, 0),
+		locations: req.Locations,
+		types:     req.Types,
+	}
+
+	for _, v := range req.Types {
+		if v != wsDeviceUpdate && v != wsTriggerUpdate {
+			return nil, &ErrBadRequest{}
+		}
+	}
+
+	for _, v := range req.Devices {
+		exp, err := glob.Compile(v)
+		if err != nil {
+			return nil, &ErrBadRequest{}
+		}
+
+		sub.devices = append(sub.devices, exp)
+	}
+
+	for _, v := range req.Locations {
+		if nil == s.getLocation(v) {
+			return nil, &ErrUnknownLocation{Name: v}
+		}
+	}
+
+	return sub, nil
+}
+
+// Checks whether subscription matches the update.
+func (s *wsSubscription) matches(msgType wsMessageType, id string, locations func() []string) bool {
+	if 0 != len(s.types) {
+		found := false
+		for _, v := range s.types {
+			if v == msgType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if 0 != len(s.devices) {
+		found := false
+		for _, v := range s.devices {
+			if v.Match(id) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if 0 != len(s.locations) {
+		found := false
+		for _, v := range locations() {
+			if helpers.SliceContainsString(s.locations, v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/gobwas/glob"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/security"
+)
+
+// Parsed WS v2 message.
+type wsTestMessage struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id"`
+	Data      json.RawMessage `json:"data"`
+	Error     *apiError       `json:"error"`
+}
+
+type wsV2Suite struct {
+	suite.Suite
+
+	srv       *GoHomeServer
+	published []string
+
+	ts *httptest.Server
+	ws *websocket.Conn
+}
+
+func (w *wsV2Suite) SetupTest() {
+	w.published = make([]string, 0)
+	w.srv = getLocationsServer(func(name string, msg ...interface{}) {
+		w.published = append(w.published, name)
+	})
+
+	user := &security.AuthenticatedUser{
+		Username: "usr1",
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemDevice: {
+				{
+					Get:       true,
+					Command:   true,
+					Resources: []glob.Glob{compileRegexp("dev?")},
+				},
+			},
+			providers.SecSystemTrigger: {
+				{
+					Get:       true,
+					Resources: []glob.Glob{compileRegexp("trigger1*")},
+				},
+			},
+		},
+	}
+
+	w.ts = httptest.NewServer(http.HandlerFunc(w.srv.handleWSv2))
+
+	monkey.Patch(getContextUser, func(request *http.Request) providers.IAuthenticatedUser {
+		return user
+	})
+	defer monkey.UnpatchAll()
+
+	u := "ws" + strings.TrimPrefix(w.ts.URL, "http")
+	ws, _, err := websocket.DefaultDialer.Dial(u, nil)
+	require.NoError(w.T(), err, "dial")
+	w.ws = ws
+}
+
+//noinspection GoUnhandledErrorResult
+func (w *wsV2Suite) TearDownTest() {
+	if nil != w.ts {
+		w.ts.Close()
+	}
+	if nil != w.ws {
+		w.ws.Close()
+	}
+}
+
+// Sends request.
+//noinspection GoUnhandledErrorResult
+func (w *wsV2Suite) send(msg string) {
+	w.ws.WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+// Reads the next message.
+//noinspection GoUnhandledErrorResult
+func (w *wsV2Suite) read() (*wsTestMessage, error) {
+	w.ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	msg := &wsTestMessage{}
+	err := w.ws.ReadJSON(msg)
+	return msg, err
+}
+
+// Sends device update.
+func (w *wsV2Suite) update(id string) {
+	w.srv.Settings.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: id}
+}
+
+// Reads device IDs of all updates.
+// Ping is used as a marker of the end.
+func (w *wsV2Suite) readUpdates() []string {
+	time.Sleep(200 * time.Millisecond)
+	w.send(`{"type": "ping", "request_id": "end"}`)
+
+	result := make([]string, 0)
+	for {
+		msg, err := w.read()
+		require.NoError(w.T(), err)
+		if "pong" == msg.Type {
+			return result
+		}
+
+		d := &knownDevice{}
+		require.NoError(w.T(), json.Unmarshal(msg.Data, d))
+		result = append(result, d.ID)
+	}
+}
+
+// Subscribes and returns subscription ID.
+func (w *wsV2Suite) subscribe(filter string) string {
+	w.send(`{"type": "subscribe", "request_id": "s", "data": ` + filter + `}`)
+	msg, err := w.read()
+	require.NoError(w.T(), err)
+	require.Nil(w.T(), msg.Error)
+
+	id := &wsSubscriptionID{}
+	require.NoError(w.T(), json.Unmarshal(msg.Data, id))
+	return id.Subscription
+}
+
+// Tests ping.
+func (w *wsV2Suite) TestPing() {
+	w.send(`{"type": "ping", "request_id": "42"}`)
+	msg, err := w.read()
+	require.NoError(w.T(), err)
+	assert.Equal(w.T(), "pong", msg.Type)
+	assert.Equal(w.T(), "42", msg.RequestID)
+}
+
+// Tests commands replies.
+func (w *wsV2Suite) TestCommands() {
+	data := []struct {
+		msg    string
+		status int
+	}{
+		{`{"type": "command", "request_id": "1", "data": {"device": "dev1", "command": "off"}}`, 0},
+		{`{"type": "command", "request_id": "2", "data": {"device": "sec1", "command": "off"}}`, http.StatusNotFound},
+		{`{"type": "command", "request_id": "3", "data": {"device": "dev3", "command": "off"}}`, http.StatusBadRequest},
+		{`{"type": "command", "request_id": "4", "data": "wrong"}`, http.StatusBadRequest},
+		{`{"type": "wrong", "request_id": "5"}`, http.StatusBadRequest},
+		{`{"type": "pong", "request_id": "6"}`, http.StatusBadRequest},
+		{`{"type": "unsubscribe", "request_id": "7", "data": {"subscription": "1"}}`, http.StatusNotFound},
+		{`{"type": "subscribe", "request_id": "8", "data": {"devices": ["["]}}`, http.StatusBadRequest},
+		{`{"type": "subscribe", "request_id": "9", "data": {"locations": ["wrong"]}}`, http.StatusNotFound},
+		{`{"type": "subscribe", "request_id": "10", "data": {"types": ["command"]}}`, http.StatusBadRequest},
+	}
+
+	for _, v := range data {
+		w.send(v.msg)
+		msg, err := w.read()
+		require.NoError(w.T(), err, v.msg)
+		assert.Equal(w.T(), "result", msg.Type, v.msg)
+		assert.NotEmpty(w.T(), msg.RequestID, v.msg)
+
+		if 0 == v.status {
+			assert.Nil(w.T(), msg.Error, v.msg)
+			continue
+		}
+
+		require.NotNil(w.T(), msg.Error, v.msg)
+		assert.Equal(w.T(), v.status, msg.Error.Status, v.msg)
+	}
+
+	assert.Equal(w.T(), []string{"1"}, w.published)
+
+	w.send(`{"type": "batch", "request_id": "b", "data": {"commands": [{"device": "dev*", "command": "off"}]}}`)
+	msg, err := w.read()
+	require.NoError(w.T(), err)
+	results := make([]*batchCommandResult, 0)
+	require.NoError(w.T(), json.Unmarshal(msg.Data, &results))
+	assert.Equal(w.T(), 3, len(results))
+}
+
+// Tests that updates are sent only for subscriptions.
+func (w *wsV2Suite) TestSubscriptions() {
+	w.update("dev1")
+	assert.Equal(w.T(), []string{}, w.readUpdates(), "no subscription")
+
+	id := w.subscribe(`{"devices": ["dev1", "sec*"]}`)
+	w.update("dev1")
+	w.update("dev2")
+	w.update("sec1")
+	assert.Equal(w.T(), []string{"dev1"}, w.readUpdates(), "devices")
+
+	w.send(`{"type": "unsubscribe", "request_id": "u", "data": {"subscription": "` + id + `"}}`)
+	msg, err := w.read()
+	require.NoError(w.T(), err)
+	assert.Nil(w.T(), msg.Error)
+
+	w.update("dev1")
+	assert.Equal(w.T(), []string{}, w.readUpdates(), "unsubscribed")
+
+	w.subscribe(`{"locations": ["upstairs"], "types": ["device_update"]}`)
+	w.update("dev4")
+	w.update("dev2")
+	w.srv.Settings.FanOut().ChannelInTriggerUpdates() <- "trigger1"
+	assert.Equal(w.T(), []string{"dev2"}, w.readUpdates(), "locations")
+}
+
+// Tests triggers subscription.
+func (w *wsV2Suite) TestTriggers() {
+	w.subscribe(`{"types": ["trigger_update"]}`)
+	w.update("dev1")
+	w.srv.Settings.FanOut().ChannelInTriggerUpdates() <- "trigger2"
+	w.srv.Settings.FanOut().ChannelInTriggerUpdates() <- "trigger1"
+
+	msg, err := w.read()
+	require.NoError(w.T(), err)
+	assert.Equal(w.T(), "trigger_update", msg.Type)
+
+	kt := &knownTrigger{}
+	require.NoError(w.T(), json.Unmarshal(msg.Data, kt))
+	assert.Equal(w.T(), "trigger1", kt.ID)
+
+	_, err = w.read()
+	assert.Error(w.T(), err, "nothing else")
+}
+
+// Tests WS v2 connection.
+func TestWsV2(t *testing.T) {
+	suite.Run(t, new(wsV2Suite))
+}
+
+// Tests that not yet sent updates of the same entity are coalesced.
+func TestWsUpdatesCoalescing(t *testing.T) {
+	w := &wsConnection{
+		pending:      make(map[string]*wsResponse),
+		pendingOrder: make([]string, 0),
+		notify:       make(chan bool, 1),
+	}
+
+	w.enqueueUpdate("device:1", &wsResponse{Data: 1})
+	w.enqueueUpdate("device:2", &wsResponse{Data: 2})
+	w.enqueueUpdate("device:1", &wsResponse{Data: 3})
+
+	pending := w.takePending()
+	require.Equal(t, 2, len(pending))
+	assert.Equal(t, 3, pending[0].Data)
+	assert.Equal(t, 2, pending[1].Data)
+	assert.Equal(t, 0, len(w.takePending()))
+	assert.Equal(t, 1, len(w.notify))
+}
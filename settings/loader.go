@@ -13,6 +13,7 @@ import (
 	"go-home.io/x/server/plugins/device/enums"
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/audit"
 	"go-home.io/x/server/systems/bus"
 	"go-home.io/x/server/systems/config"
 	"go-home.io/x/server/systems/fanout"
@@ -43,6 +44,7 @@ type StartUpOptions struct {
 	PluginsFolder string `short:"p" long:"plugins" description:"Plugins location."`
 	PluginsProxy  string `short:"x" long:"pluginsProxy" description:"Plugins download proxy"`
 	IsWorker      bool   `short:"w" long:"worker" description:"Flag indicating working instance."`
+	AllInOne      bool   `short:"a" long:"all-in-one" description:"Run master and worker in a single process."`
 
 	Config map[string]string `short:"c" long:"config" description:"Config files provider. Defaults to local FS."`
 	Secret map[string]string `short:"s" long:"secret" description:"Secrets provider. Defaults to local FS."`
@@ -68,10 +70,12 @@ type settingsProvider struct {
 	validator    providers.IValidatorProvider
 	secrets      common.ISecretProvider
 	storage      providers.IStorageProvider
+	audit        providers.IAuditProvider
 
 	wSettings *providers.WorkerSettings
 	mSettings *providers.MasterSettings
 	isWorker  bool
+	allInOne  bool
 	timezone  *time.Location
 
 	devicesConfig []*providers.RawDevice
@@ -84,6 +88,11 @@ type settingsProvider struct {
 	triggers      []*providers.RawMasterComponent
 	extendedAPIs  []*providers.RawMasterComponent
 	groups        []*providers.RawMasterComponent
+	scenes        []*providers.RawMasterComponent
+	homeMode      *providers.RawMasterComponent
+	helpers       []*providers.RawMasterComponent
+	alarms        []*providers.RawMasterComponent
+	persons       []*providers.RawMasterComponent
 	notifications []*providers.RawMasterComponent
 }
 
@@ -93,6 +102,7 @@ func Load(options *StartUpOptions) providers.ISettingsProvider {
 
 	settings := settingsProvider{
 		isWorker:      options.IsWorker,
+		allInOne:      options.AllInOne,
 		devicesConfig: make([]*providers.RawDevice, 0),
 		logger:        consoleLogger,
 		pluginLogger:  consoleLogger,
@@ -101,6 +111,10 @@ func Load(options *StartUpOptions) providers.ISettingsProvider {
 		extendedAPIs:  make([]*providers.RawMasterComponent, 0),
 		fanOut:        fanout.NewFanOut(),
 		groups:        make([]*providers.RawMasterComponent, 0),
+		scenes:        make([]*providers.RawMasterComponent, 0),
+		helpers:       make([]*providers.RawMasterComponent, 0),
+		alarms:        make([]*providers.RawMasterComponent, 0),
+		persons:       make([]*providers.RawMasterComponent, 0),
 		notifications: make([]*providers.RawMasterComponent, 0),
 	}
 
@@ -180,6 +194,11 @@ func Load(options *StartUpOptions) providers.ISettingsProvider {
 		secConstruct.UserRawConfig = settings.rawUsersProvider.Config
 	}
 
+	if !settings.isWorker {
+		secConstruct.Store = storage.NewFilePersistentStore(
+			fmt.Sprintf("%s/_tokens.yaml", utils.GetDefaultConfigsDir()), settings.pluginLogger)
+	}
+
 	settings.securityProvider = security.NewSecurityProvider(secConstruct)
 
 	settings.validate()
@@ -187,7 +206,17 @@ func Load(options *StartUpOptions) providers.ISettingsProvider {
 }
 
 // Validates whether all necessary settings are present.
+// In all-in-one mode in-memory bus is used, unless another one is configured.
 func (s *settingsProvider) validate() {
+	if s.bus == nil && s.allInOne {
+		s.logger.Info("Service bus is not configured, using in-memory one", common.LogSystemToken, logSystem)
+		s.bus, _ = bus.NewServiceBusProvider(&bus.ConstructBus{
+			Provider: bus.MemoryProvider,
+			Logger:   s.getPluginLogger(systems.SysBus, bus.MemoryProvider),
+			NodeID:   s.nodeID,
+		})
+	}
+
 	if s.bus == nil {
 		panic("Service bus is not configured")
 	}
@@ -250,6 +279,14 @@ func (s *settingsProvider) validateMasterSettings() {
 		s.logger.Warn("Storage provider is not defined", common.LogSystemToken, logSystem)
 		s.storage = storage.NewEmptyStorageProvider()
 	}
+
+	if nil == s.audit {
+		s.audit = audit.NewAuditProvider(&audit.ConstructAudit{
+			PluginLogger: s.pluginLogger,
+			Secret:       s.secrets,
+			Loader:       s.pluginLoader,
+		})
+	}
 }
 
 // Processes single yaml file.
@@ -364,6 +401,7 @@ func (s *settingsProvider) loadGoHomeDefinition(provider *rawProvider) {
 	} else if !s.isWorker && provider.Provider == configGoHomeMaster {
 		set := &providers.MasterSettings{
 			Locations: make([]*providers.RawMasterComponent, 0),
+			Occupancy: make([]*providers.RawMasterComponent, 0),
 		}
 		if err := yaml.Unmarshal(provider.Config, &set); err != nil {
 			panic("Failed to unmarshal server config")
@@ -419,6 +457,61 @@ func (s *settingsProvider) loadDeviceProvider(provider *rawProvider) (*providers
 		return nil, nil
 	}
 
+	if provider.Provider == enums.DevScene.String() {
+		s.scenes = append(s.scenes, &providers.RawMasterComponent{
+			Provider:  provider.Provider,
+			Name:      selector.Name,
+			RawConfig: provider.Config,
+		})
+
+		return nil, nil
+	}
+
+	if provider.Provider == enums.DevHelper.String() {
+		s.helpers = append(s.helpers, &providers.RawMasterComponent{
+			Provider:  provider.Provider,
+			Name:      selector.Name,
+			RawConfig: provider.Config,
+		})
+
+		return nil, nil
+	}
+
+	if provider.Provider == enums.DevAlarm.String() {
+		s.alarms = append(s.alarms, &providers.RawMasterComponent{
+			Provider:  provider.Provider,
+			Name:      selector.Name,
+			RawConfig: provider.Config,
+		})
+
+		return nil, nil
+	}
+
+	if provider.Provider == enums.DevPerson.String() {
+		s.persons = append(s.persons, &providers.RawMasterComponent{
+			Provider:  provider.Provider,
+			Name:      selector.Name,
+			RawConfig: provider.Config,
+		})
+
+		return nil, nil
+	}
+
+	if provider.Provider == enums.DevMode.String() {
+		if nil != s.homeMode {
+			s.logger.Warn("Duplicated home mode, ignoring", common.LogNameToken, selector.Name)
+			return nil, nil
+		}
+
+		s.homeMode = &providers.RawMasterComponent{
+			Provider:  provider.Provider,
+			Name:      selector.Name,
+			RawConfig: provider.Config,
+		}
+
+		return nil, nil
+	}
+
 	deviceType := utils.VerifyDeviceProvider(provider.Provider)
 	if deviceType == enums.DevUnknown && provider.System != systems.SysAPI.String() {
 		s.logger.Warn("Ignoring device since type is unknown", common.LogDeviceTypeToken, provider.Provider,
@@ -530,6 +623,7 @@ func (s *settingsProvider) parseProvider(provider *rawProvider) {
 			Loader:    s.pluginLoader,
 			NodeID:    s.nodeID,
 			Secret:    s.secrets,
+			Validator: s.validator,
 		}
 		s.bus, err = bus.NewServiceBusProvider(ctor)
 		if err != nil {
@@ -547,6 +641,10 @@ func (s *settingsProvider) parseProvider(provider *rawProvider) {
 		s.processStorage(provider)
 	case systems.SysNotification:
 		s.loadNotification(provider)
+	case systems.SysAudit:
+		s.processAudit(provider)
+	case systems.SysWebhook:
+		s.loadWebhook(provider)
 	}
 
 	if err != nil {
@@ -589,6 +687,27 @@ func (s *settingsProvider) processStorage(provider *rawProvider) {
 	s.storage = storage.NewStorageProvider(ctor)
 }
 
+// Processes audit log storage.
+func (s *settingsProvider) processAudit(provider *rawProvider) {
+	if s.isWorker {
+		return
+	}
+
+	if s.audit != nil {
+		s.logger.Warn("Duplicated audit provider",
+			common.LogProviderToken, provider.Provider, common.LogSystemToken, provider.System)
+		return
+	}
+
+	s.audit = audit.NewAuditProvider(&audit.ConstructAudit{
+		PluginLogger: s.pluginLogger,
+		Secret:       s.secrets,
+		Provider:     provider.Provider,
+		RawConfig:    provider.Config,
+		Loader:       s.pluginLoader,
+	})
+}
+
 // Processes security groups.
 func (s *settingsProvider) processSecurity(provider *rawProvider) {
 	if s.isWorker {
@@ -710,16 +829,47 @@ func (s *settingsProvider) loadUIProviders(provider *rawProvider) {
 		return
 	}
 
-	switch provider.Provider { // nolint: gocritic
+	switch provider.Provider {
 	case "location":
 		s.mSettings.Locations = append(s.mSettings.Locations, &providers.RawMasterComponent{
 			Provider:  provider.Provider,
 			Name:      c.Name,
 			RawConfig: provider.Config,
 		})
+	case "occupancy":
+		s.mSettings.Occupancy = append(s.mSettings.Occupancy, &providers.RawMasterComponent{
+			Provider:  provider.Provider,
+			Name:      c.Name,
+			RawConfig: provider.Config,
+		})
 	}
 }
 
+// Loads outbound webhook configuration.
+func (s *settingsProvider) loadWebhook(provider *rawProvider) {
+	if s.isWorker {
+		return
+	}
+
+	c := &providers.RawDeviceSelector{}
+	err := yaml.Unmarshal(provider.Config, c)
+	if err != nil {
+		s.logger.Error("Failed to unmarshal webhook", err)
+		return
+	}
+
+	if "" == c.Name {
+		s.logger.Warn("Skipping webhook since name is nul", common.LogProviderToken, provider.Provider)
+		return
+	}
+
+	s.mSettings.Webhooks = append(s.mSettings.Webhooks, &providers.RawMasterComponent{
+		Provider:  provider.Provider,
+		Name:      c.Name,
+		RawConfig: provider.Config,
+	})
+}
+
 // Performs master component load.
 func (s *settingsProvider) loadMasterComponent(existingProviders []*providers.RawMasterComponent,
 	provider *rawProvider) []*providers.RawMasterComponent {
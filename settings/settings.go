@@ -91,11 +91,43 @@ func (s *settingsProvider) Groups() []*providers.RawMasterComponent {
 	return s.groups
 }
 
+// Scenes returns a list of known scenes.
+func (s *settingsProvider) Scenes() []*providers.RawMasterComponent {
+	return s.scenes
+}
+
+// HomeMode returns home mode configuration.
+// Nil is returned if mode is not configured.
+func (s *settingsProvider) HomeMode() *providers.RawMasterComponent {
+	return s.homeMode
+}
+
+// Helpers returns a list of known input helpers.
+func (s *settingsProvider) Helpers() []*providers.RawMasterComponent {
+	return s.helpers
+}
+
+// Alarms returns a list of known alarm panels.
+func (s *settingsProvider) Alarms() []*providers.RawMasterComponent {
+	return s.alarms
+}
+
+// Persons returns a list of tracked persons.
+func (s *settingsProvider) Persons() []*providers.RawMasterComponent {
+	return s.persons
+}
+
 // Storage returns a storage provider.
 func (s *settingsProvider) Storage() providers.IStorageProvider {
 	return s.storage
 }
 
+// Audit returns an audit log provider.
+// Nil is returned on worker nodes.
+func (s *settingsProvider) Audit() providers.IAuditProvider {
+	return s.audit
+}
+
 // Timezone returns configured timezone.
 func (s *settingsProvider) Timezone() *time.Location {
 	return s.timezone
@@ -0,0 +1,406 @@
+// Package alarm contains alarm panel provider.
+package alarm
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Implements alarm panel provider.
+type provider struct {
+	sync.Mutex
+
+	internalID string
+	name       string
+	settings   *settings
+	sensorsExp map[enums.AlarmState][]glob.Glob
+	notifyExp  glob.Glob
+
+	state      enums.AlarmState
+	target     enums.AlarmState
+	delay      int
+	timer      *time.Timer
+	generation int
+
+	failures    int
+	lockedUntil time.Time
+
+	updatesChan chan *common.MsgDeviceUpdate
+	logger      common.ILoggerProvider
+	server      providers.IServerProvider
+	store       providers.IPersistentStoreProvider
+}
+
+// Alarm settings.
+type settings struct {
+	Name          string              `yaml:"name" validate:"required"`
+	Code          string              `yaml:"code" validate:"required"`
+	CodeArm       bool                `yaml:"codeArm"`
+	MaxAttempts   int                 `yaml:"maxAttempts" validate:"gte=0" default:"5"`
+	LockoutTime   int                 `yaml:"lockoutTime" validate:"gte=0" default:"300"`
+	ExitDelay     int                 `yaml:"exitDelay" validate:"gte=0" default:"30"`
+	EntryDelay    int                 `yaml:"entryDelay" validate:"gte=0" default:"30"`
+	TriggerTime   int                 `yaml:"triggerTime" validate:"gte=0" default:"300"`
+	Sensors       map[string][]string `yaml:"sensors"`
+	Notifications string              `yaml:"notifications" default:"*"`
+}
+
+// Persisted alarm state.
+type storedState struct {
+	State  string `yaml:"state"`
+	Target string `yaml:"target"`
+}
+
+// ConstructAlarm has data required for instantiating a new alarm panel.
+type ConstructAlarm struct {
+	RawConfig []byte
+	Settings  providers.ISettingsProvider
+	Server    providers.IServerProvider
+	Store     providers.IPersistentStoreProvider
+}
+
+// NewAlarmProvider creates a new alarm panel provider.
+func NewAlarmProvider(ctor *ConstructAlarm) (providers.IAlarmProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Settings.SystemLogger().Error("Failed to load alarm", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !ctor.Settings.Validator().Validate(settings) {
+		return nil, &ErrInvalidSettings{Name: settings.Name}
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Settings.PluginLogger(),
+		Provider:     systems.SysDevice.String(),
+		System:       "alarm",
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   getID(settings.Name),
+		},
+	}
+
+	p := &provider{
+		internalID: getID(settings.Name),
+		name:       settings.Name,
+		settings:   settings,
+		sensorsExp: make(map[enums.AlarmState][]glob.Glob),
+		state:      enums.AlarmDisarmed,
+		target:     enums.AlarmDisarmed,
+		logger:     logger.NewPluginLogger(logCtor),
+		server:     ctor.Server,
+		store:      ctor.Store,
+	}
+
+	p.notifyExp, err = glob.Compile(settings.Notifications)
+	if err != nil {
+		return nil, &ErrInvalidSettings{Name: settings.Name}
+	}
+
+	for k, v := range settings.Sensors {
+		mode, err := enums.AlarmStateString(k)
+		if err != nil || !isArmed(mode) {
+			return nil, &ErrUnknownArmMode{Name: k}
+		}
+
+		p.sensorsExp[mode] = make([]glob.Glob, 0)
+		for _, s := range v {
+			exp, err := glob.Compile(s)
+			if err != nil {
+				p.logger.Error("Failed to compile alarm sensor regexp, skipping", err)
+				continue
+			}
+
+			p.sensorsExp[mode] = append(p.sensorsExp[mode], exp)
+		}
+	}
+
+	p.restore()
+	p.pushState()
+
+	_, p.updatesChan = ctor.Settings.FanOut().SubscribeDeviceUpdates()
+	go p.deviceUpdates()
+
+	return p, nil
+}
+
+// ID returns alarm device ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// State returns current alarm state.
+func (p *provider) State() enums.AlarmState {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.state
+}
+
+// InvokeCommand invokes alarm command.
+// Disarm always requires PIN code, arming requires it only if configured.
+// Wrong codes are counted for both, so the code can't be brute-forced.
+func (p *provider) InvokeCommand(cmd enums.Command, data map[string]interface{}) error {
+	p.Lock()
+	defer p.Unlock()
+
+	switch cmd {
+	case enums.CmdArmHome, enums.CmdArmAway:
+		if p.settings.CodeArm {
+			if err := p.checkCode(data); err != nil {
+				p.logger.Warn("Wrong code received while arming")
+				return err
+			}
+		}
+
+		target := enums.AlarmArmedHome
+		if enums.CmdArmAway == cmd {
+			target = enums.AlarmArmedAway
+		}
+
+		p.arm(target)
+	case enums.CmdDisarm:
+		if err := p.checkCode(data); err != nil {
+			p.logger.Warn("Wrong code received while disarming")
+			return err
+		}
+
+		p.setState(enums.AlarmDisarmed, enums.AlarmDisarmed, 0, "")
+	case enums.CmdTrigger:
+		if enums.AlarmTriggered != p.state {
+			p.setState(enums.AlarmTriggered, p.target, p.settings.TriggerTime, "manual trigger")
+		}
+	default:
+		return &ErrUnsupportedCommand{Name: cmd.String()}
+	}
+
+	return nil
+}
+
+// Arms the alarm, respecting exit delay.
+func (p *provider) arm(target enums.AlarmState) {
+	if target == p.state || (enums.AlarmArming == p.state && target == p.target) {
+		return
+	}
+
+	if p.settings.ExitDelay > 0 {
+		p.setState(enums.AlarmArming, target, p.settings.ExitDelay, "")
+		return
+	}
+
+	p.setState(target, target, 0, "")
+}
+
+// Subscribes for devices updates.
+func (p *provider) deviceUpdates() {
+	for msg := range p.updatesChan {
+		go p.processDeviceUpdates(msg)
+	}
+}
+
+// Checks whether sensor trips the alarm.
+// First seen updates are ignored, since they report already existing state after restarts.
+func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
+	if msg.Type != enums.DevSensor || msg.FirstSeen {
+		return
+	}
+
+	on, ok := msg.State[enums.PropOn].(bool)
+	if !ok || !on {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if !isArmed(p.state) || !p.isMatched(p.state, msg.ID) {
+		return
+	}
+
+	p.logger.Info("Alarm sensor tripped", common.LogIDToken, msg.ID)
+	if p.settings.EntryDelay > 0 {
+		p.setState(enums.AlarmPending, p.state, p.settings.EntryDelay, msg.ID)
+		return
+	}
+
+	p.setState(enums.AlarmTriggered, p.state, p.settings.TriggerTime, msg.ID)
+}
+
+// Checks whether sensor is monitored in the arming mode.
+func (p *provider) isMatched(mode enums.AlarmState, deviceID string) bool {
+	for _, v := range p.sensorsExp[mode] {
+		if v.Match(deviceID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validates PIN code, counting failed attempts.
+// Panel is locked for the lockout time once attempts are exceeded.
+func (p *provider) checkCode(data map[string]interface{}) error {
+	if wait := time.Until(p.lockedUntil); wait > 0 {
+		return &ErrLocked{Wait: wait}
+	}
+
+	if p.isCodeValid(data) {
+		p.failures = 0
+		return nil
+	}
+
+	p.failures++
+	if p.settings.MaxAttempts > 0 && p.failures >= p.settings.MaxAttempts {
+		p.failures = 0
+		lockout := time.Duration(p.settings.LockoutTime) * time.Second
+		p.lockedUntil = time.Now().Add(lockout)
+
+		message := fmt.Sprintf("Alarm %s is locked for %s after too many wrong codes", p.name, lockout)
+		p.logger.Warn(message)
+		p.server.SendNotificationCommand(p.notifyExp, message)
+	}
+
+	return &ErrWrongCode{}
+}
+
+// Validates PIN code.
+// Missing code never matches, even if settings validation was bypassed.
+func (p *provider) isCodeValid(data map[string]interface{}) bool {
+	if "" == p.settings.Code {
+		return false
+	}
+
+	code, ok := data["code"]
+	if !ok || nil == code {
+		return false
+	}
+
+	return 1 == subtle.ConstantTimeCompare([]byte(fmt.Sprintf("%v", code)), []byte(p.settings.Code))
+}
+
+// Changes alarm state, persists it and sends notifications.
+func (p *provider) setState(state enums.AlarmState, target enums.AlarmState, delay int, reason string) {
+	p.apply(state, target, delay)
+	p.store.Save(p.internalID, &storedState{State: state.String(), Target: target.String()})
+	p.pushState()
+
+	message := fmt.Sprintf("Alarm %s is %s", p.name, state.String())
+	if "" != reason {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+
+	p.logger.Info(message)
+	p.server.SendNotificationCommand(p.notifyExp, message)
+}
+
+// Applies state and schedules delayed transition.
+func (p *provider) apply(state enums.AlarmState, target enums.AlarmState, delay int) {
+	p.generation++
+	if nil != p.timer {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	p.state = state
+	p.target = target
+	p.delay = delay
+
+	if delay <= 0 {
+		return
+	}
+
+	generation := p.generation
+	p.timer = time.AfterFunc(time.Duration(delay)*time.Second, func() {
+		p.delayFinished(generation)
+	})
+}
+
+// Processes delayed transitions.
+// Generation prevents outdated callbacks from changing the state.
+func (p *provider) delayFinished(generation int) {
+	p.Lock()
+	defer p.Unlock()
+
+	if generation != p.generation {
+		return
+	}
+
+	p.timer = nil
+	switch p.state {
+	case enums.AlarmArming:
+		p.setState(p.target, p.target, 0, "")
+	case enums.AlarmPending:
+		p.setState(enums.AlarmTriggered, p.target, p.settings.TriggerTime, "entry delay expired")
+	case enums.AlarmTriggered:
+		p.setState(p.target, p.target, 0, "trigger time expired")
+	}
+}
+
+// Restores persisted state.
+// Delays are restarted from scratch.
+func (p *provider) restore() {
+	stored := &storedState{}
+	if !p.store.Load(p.internalID, stored) {
+		return
+	}
+
+	state, err := enums.AlarmStateString(stored.State)
+	if err != nil {
+		return
+	}
+
+	target, err := enums.AlarmStateString(stored.Target)
+	if err != nil {
+		target = enums.AlarmDisarmed
+	}
+
+	switch state {
+	case enums.AlarmArming:
+		p.apply(state, target, p.settings.ExitDelay)
+	case enums.AlarmPending:
+		p.apply(state, target, p.settings.EntryDelay)
+	case enums.AlarmTriggered:
+		p.apply(state, target, p.settings.TriggerTime)
+	default:
+		p.apply(state, state, 0)
+	}
+}
+
+// Pushes alarm device state.
+func (p *provider) pushState() {
+	p.server.PushMasterDeviceUpdate(&providers.MasterDeviceUpdate{
+		Type: enums.DevAlarm,
+		Name: p.name,
+		ID:   p.internalID,
+		Commands: []string{enums.CmdArmHome.String(), enums.CmdArmAway.String(),
+			enums.CmdDisarm.String(), enums.CmdTrigger.String()},
+		State: map[string]interface{}{
+			enums.PropAlarmState.String(): p.state.String(),
+			enums.PropRemaining.String():  p.delay,
+		},
+	})
+}
+
+// Checks whether state is one of the armed modes.
+func isArmed(state enums.AlarmState) bool {
+	return enums.AlarmArmedHome == state || enums.AlarmArmedAway == state
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("alarm.%s", utils.NormalizeDeviceName(name))
+}
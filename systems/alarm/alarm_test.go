@@ -0,0 +1,227 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+type alSuite struct {
+	suite.Suite
+
+	f     providers.IInternalFanOutProvider
+	srv   mocks.IFakeServer
+	store providers.IPersistentStoreProvider
+}
+
+func (s *alSuite) SetupTest() {
+	s.srv = mocks.FakeNewServer(nil)
+	s.store = mocks.FakeNewPersistentStore()
+}
+
+// Creates a new alarm.
+func (s *alSuite) getAlarm(config string) providers.IAlarmProvider {
+	settings := mocks.FakeNewSettings(nil, false, nil, nil)
+	s.f = settings.FanOut()
+
+	ctor := &ConstructAlarm{
+		Settings:  settings,
+		Server:    s.srv.(providers.IServerProvider),
+		Store:     s.store,
+		RawConfig: []byte(config),
+	}
+
+	a, err := NewAlarmProvider(ctor)
+	require.NoError(s.T(), err)
+	return a
+}
+
+// Sends sensor update.
+func (s *alSuite) send(id string, on bool) {
+	s.f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:    id,
+		Type:  enums.DevSensor,
+		State: map[enums.Property]interface{}{enums.PropOn: on},
+	}
+
+	time.Sleep(200 * time.Millisecond)
+}
+
+// Returns last pushed alarm state.
+func (s *alSuite) lastState() interface{} {
+	return s.srv.GetLastMasterUpdate().State[enums.PropAlarmState.String()]
+}
+
+// Tests arming without delays.
+func (s *alSuite) TestInstant() {
+	a := s.getAlarm(`
+name: house
+code: 1234
+notifications: "*"
+sensors:
+  armed_home: [ door* ]
+  armed_away: [ door*, motion* ]
+`)
+	assert.Equal(s.T(), "alarm.house", a.ID())
+	assert.Equal(s.T(), "disarmed", s.lastState())
+
+	require.NoError(s.T(), a.InvokeCommand(enums.CmdArmHome, nil))
+	assert.Equal(s.T(), enums.AlarmArmedHome, a.State())
+
+	s.send("motion1", true)
+	assert.Equal(s.T(), enums.AlarmArmedHome, a.State(), "not monitored at home")
+	s.send("door1", false)
+	assert.Equal(s.T(), enums.AlarmArmedHome, a.State(), "closed")
+
+	s.send("door1", true)
+	assert.Equal(s.T(), enums.AlarmTriggered, a.State())
+	assert.Equal(s.T(), "triggered", s.lastState())
+	assert.Equal(s.T(), "Alarm house is triggered: door1", s.srv.GetNotifications()[1])
+
+	_, ok := a.InvokeCommand(enums.CmdDisarm, nil).(*ErrWrongCode)
+	assert.True(s.T(), ok, "no code")
+	_, ok = a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": "4321"}).(*ErrWrongCode)
+	assert.True(s.T(), ok, "wrong code")
+	assert.NoError(s.T(), a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": 1234.0}))
+	assert.Equal(s.T(), enums.AlarmDisarmed, a.State())
+
+	s.send("door1", true)
+	assert.Equal(s.T(), enums.AlarmDisarmed, a.State(), "disarmed")
+	assert.Equal(s.T(), 3, len(s.srv.GetNotifications()))
+}
+
+// Tests entry and exit delays.
+func (s *alSuite) TestDelays() {
+	a := s.getAlarm(`
+name: house
+code: 1234
+exitDelay: 1
+entryDelay: 1
+triggerTime: 1
+notifications: "*"
+sensors:
+  armed_away: [ motion* ]
+`)
+	require.NoError(s.T(), a.InvokeCommand(enums.CmdArmAway, nil))
+	assert.Equal(s.T(), enums.AlarmArming, a.State())
+	assert.Equal(s.T(), 1, s.srv.GetLastMasterUpdate().State[enums.PropRemaining.String()])
+
+	s.send("motion1", true)
+	assert.Equal(s.T(), enums.AlarmArming, a.State(), "exit delay")
+
+	time.Sleep(1 * time.Second)
+	assert.Equal(s.T(), enums.AlarmArmedAway, a.State())
+
+	s.send("motion1", true)
+	assert.Equal(s.T(), enums.AlarmPending, a.State())
+	time.Sleep(1 * time.Second)
+	assert.Equal(s.T(), enums.AlarmTriggered, a.State())
+	time.Sleep(1 * time.Second)
+	assert.Equal(s.T(), enums.AlarmArmedAway, a.State(), "trigger time expired")
+
+	s.send("motion1", true)
+	assert.Equal(s.T(), enums.AlarmPending, a.State())
+	assert.NoError(s.T(), a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": "1234"}))
+	time.Sleep(1 * time.Second)
+	assert.Equal(s.T(), enums.AlarmDisarmed, a.State(), "disarmed during entry delay")
+}
+
+// Tests PIN protected arming and manual trigger.
+func (s *alSuite) TestCodeArm() {
+	a := s.getAlarm(`
+name: house
+code: "0042"
+codeArm: true
+notifications: "*"
+`)
+	_, ok := a.InvokeCommand(enums.CmdArmAway, map[string]interface{}{"code": 42}).(*ErrWrongCode)
+	assert.True(s.T(), ok, "wrong code")
+	assert.NoError(s.T(), a.InvokeCommand(enums.CmdArmAway, map[string]interface{}{"code": "0042"}))
+
+	assert.NoError(s.T(), a.InvokeCommand(enums.CmdTrigger, nil))
+	assert.Equal(s.T(), enums.AlarmTriggered, a.State())
+
+	_, ok = a.InvokeCommand(enums.CmdOn, nil).(*ErrUnsupportedCommand)
+	assert.True(s.T(), ok, "unsupported")
+}
+
+// Tests that panel is locked after too many wrong codes.
+func (s *alSuite) TestLockout() {
+	a := s.getAlarm(`
+name: house
+code: 1234
+maxAttempts: 2
+lockoutTime: 1
+notifications: "*"
+`)
+	require.NoError(s.T(), a.InvokeCommand(enums.CmdArmAway, nil))
+
+	for i := 0; i < 2; i++ {
+		_, ok := a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": "1"}).(*ErrWrongCode)
+		assert.True(s.T(), ok, "wrong code %d", i)
+	}
+
+	_, ok := a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": "1234"}).(*ErrLocked)
+	assert.True(s.T(), ok, "locked")
+	assert.Equal(s.T(), enums.AlarmArmedAway, a.State())
+	assert.Equal(s.T(), "Alarm house is locked for 1s after too many wrong codes",
+		s.srv.GetNotifications()[len(s.srv.GetNotifications())-1])
+
+	time.Sleep(1 * time.Second)
+	assert.NoError(s.T(), a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": "1234"}))
+	assert.Equal(s.T(), enums.AlarmDisarmed, a.State())
+}
+
+// Tests that alarm without configured code can't be disarmed.
+func (s *alSuite) TestNoCode() {
+	a := s.getAlarm("name: house\nnotifications: \"*\"")
+	require.NoError(s.T(), a.InvokeCommand(enums.CmdArmAway, nil))
+
+	_, ok := a.InvokeCommand(enums.CmdDisarm, map[string]interface{}{"code": ""}).(*ErrWrongCode)
+	assert.True(s.T(), ok, "empty code")
+	assert.Equal(s.T(), enums.AlarmArmedAway, a.State())
+}
+
+// Tests persisted state.
+func (s *alSuite) TestPersistence() {
+	a := s.getAlarm("name: house\ncode: 1234\nnotifications: \"*\"")
+	require.NoError(s.T(), a.InvokeCommand(enums.CmdArmHome, nil))
+
+	a = s.getAlarm("name: house\ncode: 1234\nnotifications: \"*\"")
+	assert.Equal(s.T(), enums.AlarmArmedHome, a.State())
+	assert.Equal(s.T(), "armed_home", s.lastState())
+}
+
+// Tests alarm provider.
+func TestAlarmProvider(t *testing.T) {
+	suite.Run(t, new(alSuite))
+}
+
+// Tests wrong config.
+func TestWrongSettings(t *testing.T) {
+	data := []string{
+		"ad",
+		"name: a1\ncode: 1\nnotifications: \"[\"",
+		"name: a1\ncode: 1\nsensors: { triggered: [ door ] }",
+		"name: a1\ncode: 1\nsensors: { wrong: [ door ] }",
+	}
+
+	for _, v := range data {
+		ctor := &ConstructAlarm{
+			RawConfig: []byte(v),
+			Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+			Server:    mocks.FakeNewServer(nil).(providers.IServerProvider),
+			Store:     mocks.FakeNewPersistentStore(),
+		}
+
+		_, err := NewAlarmProvider(ctor)
+		assert.Error(t, err, v)
+	}
+}
@@ -0,0 +1,55 @@
+package alarm
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrInvalidSettings defines invalid alarm settings error.
+type ErrInvalidSettings struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrInvalidSettings) Error() string {
+	return fmt.Sprintf("alarm %s has invalid settings", e.Name)
+}
+
+// ErrUnknownArmMode defines unknown arming mode error.
+type ErrUnknownArmMode struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnknownArmMode) Error() string {
+	return fmt.Sprintf("arming mode %s is unknown", e.Name)
+}
+
+// ErrWrongCode defines wrong PIN code error.
+type ErrWrongCode struct {
+}
+
+// Error formats output.
+func (e *ErrWrongCode) Error() string {
+	return "code is wrong"
+}
+
+// ErrLocked defines locked alarm panel error.
+type ErrLocked struct {
+	Wait time.Duration
+}
+
+// Error formats output.
+func (e *ErrLocked) Error() string {
+	return fmt.Sprintf("too many wrong codes, locked for %s", e.Wait.Round(time.Second))
+}
+
+// ErrUnsupportedCommand defines unsupported command error.
+type ErrUnsupportedCommand struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnsupportedCommand) Error() string {
+	return fmt.Sprintf("command %s is not supported", e.Name)
+}
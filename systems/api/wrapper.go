@@ -17,6 +17,7 @@ import (
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
 	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/systems/metrics"
 	"go-home.io/x/server/utils"
 )
 
@@ -129,7 +130,7 @@ func (p *provider) Routes() []string {
 	return p.plugin.Routes()
 }
 
-// Unload helps to unload plugin. Called only on worker.
+// Unload helps to unload plugin. Called on worker re-balance and on shutdown.
 func (p *provider) Unload() {
 	if nil != p.pluginQueue {
 		p.serviceBus.Unsubscribe(p.inChannelName)
@@ -178,6 +179,7 @@ func (p *provider) busCycle() {
 		}
 
 		if utils.TimeNow()-pluginMsg.SendTime > bus.MsgTTLSeconds {
+			metrics.BusMessagesDiscarded.Inc("api")
 			p.logger.Debug("Received API message is too old")
 			continue
 		}
@@ -0,0 +1,130 @@
+// Package audit contains user actions audit log.
+package audit
+
+import (
+	"sync"
+
+	"go-home.io/x/server/plugins/audit"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+)
+
+const (
+	// Built-in file storage provider name.
+	fileProvider = "file"
+	// Default number of returned entries.
+	defaultQueryLimit = 100
+	// Max number of returned entries.
+	maxQueryLimit = 1000
+)
+
+// Audit log provider.
+type provider struct {
+	sync.Mutex
+	storage audit.IAuditStorage
+	logger  common.ILoggerProvider
+}
+
+// ConstructAudit has data required for a new audit log provider.
+type ConstructAudit struct {
+	PluginLogger common.ILoggerProvider
+	Secret       common.ISecretProvider
+	Loader       providers.IPluginLoaderProvider
+	RawConfig    []byte
+	Provider     string
+}
+
+// NewAuditProvider returns a new audit log provider.
+// If storage plugin fails to load, falls back to the built-in file storage.
+func NewAuditProvider(ctor *ConstructAudit) providers.IAuditProvider {
+	if "" == ctor.Provider || fileProvider == ctor.Provider {
+		return loadFileStorage(ctor)
+	}
+
+	log := getLogger(ctor, ctor.Provider)
+	pluginRequest := &providers.PluginLoadRequest{
+		ExpectedType: audit.TypeAuditStorage,
+		SystemType:   systems.SysAudit,
+		InitData: &audit.InitDataAuditStorage{
+			Logger:    log,
+			Secret:    ctor.Secret,
+			RawConfig: ctor.RawConfig,
+		},
+		RawConfig:      ctor.RawConfig,
+		PluginProvider: ctor.Provider,
+	}
+
+	storage, err := ctor.Loader.LoadPlugin(pluginRequest)
+	if err != nil {
+		log.Error("Failed to load audit storage, defaulting to file", err)
+		return loadFileStorage(&ConstructAudit{PluginLogger: ctor.PluginLogger, Secret: ctor.Secret})
+	}
+
+	return &provider{
+		storage: storage.(audit.IAuditStorage),
+		logger:  log,
+	}
+}
+
+// Loads built-in file storage.
+func loadFileStorage(ctor *ConstructAudit) providers.IAuditProvider {
+	log := getLogger(ctor, fileProvider)
+	storage := &fileStorage{}
+	err := storage.Init(&audit.InitDataAuditStorage{
+		Logger:    log,
+		Secret:    ctor.Secret,
+		RawConfig: ctor.RawConfig,
+	})
+	if err != nil {
+		log.Error("Failed to read audit storage settings, using defaults", err)
+		storage.Init(&audit.InitDataAuditStorage{Logger: log, Secret: ctor.Secret}) // nolint: gosec, errcheck
+	}
+
+	return &provider{
+		storage: storage,
+		logger:  log,
+	}
+}
+
+// Returns audit storage logger.
+func getLogger(ctor *ConstructAudit, providerName string) common.ILoggerProvider {
+	return logger.NewPluginLogger(&logger.ConstructPluginLogger{
+		SystemLogger: ctor.PluginLogger,
+		Provider:     providerName,
+		System:       systems.SysAudit.String(),
+	})
+}
+
+// Record appends a new entry.
+// Audit failures are logged, but never block user's action.
+func (p *provider) Record(entry *audit.Entry) {
+	if 0 == entry.Time {
+		entry.Time = utils.TimeNow()
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	err := p.storage.Append(entry)
+	if err != nil {
+		p.logger.Error("Failed to write audit entry", err, common.LogUserNameToken, entry.User,
+			common.LogIDToken, entry.Target)
+	}
+}
+
+// Query returns entries matching the filter, newest first.
+// Storage is queried without the lock, so long scans don't block recording.
+func (p *provider) Query(query *audit.Query) ([]*audit.Entry, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultQueryLimit
+	}
+
+	if query.Limit > maxQueryLimit {
+		query.Limit = maxQueryLimit
+	}
+
+	return p.storage.Query(query)
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/audit"
+	"go-home.io/x/server/utils"
+)
+
+// Fake audit storage plugin.
+type fakePlugin struct {
+	entries []*audit.Entry
+	query   *audit.Query
+}
+
+func (f *fakePlugin) Init(*audit.InitDataAuditStorage) error {
+	return nil
+}
+
+func (f *fakePlugin) Append(e *audit.Entry) error {
+	f.entries = append(f.entries, e)
+	if "fail" == e.Target {
+		return errors.New("test")
+	}
+
+	return nil
+}
+
+func (f *fakePlugin) Query(q *audit.Query) ([]*audit.Entry, error) {
+	f.query = q
+	return f.entries, nil
+}
+
+// Returns temp audit file location.
+func getTempLocation(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	return fmt.Sprintf("%s/audit.log", dir)
+}
+
+// Tests plugin storage and query limits.
+func TestPluginStorage(t *testing.T) {
+	pl := &fakePlugin{}
+	logs := make([]string, 0)
+	p := NewAuditProvider(&ConstructAudit{
+		PluginLogger: mocks.FakeNewLogger(func(s string) {
+			logs = append(logs, s)
+		}),
+		Loader:   mocks.FakeNewPluginLoader(pl),
+		Provider: "test",
+	})
+
+	p.Record(&audit.Entry{User: "usr", Target: "dev1"})
+	p.Record(&audit.Entry{User: "usr", Target: "fail", Time: 10})
+	require.Equal(t, 2, len(pl.entries))
+	assert.True(t, pl.entries[0].Time > 0, "time")
+	assert.Equal(t, int64(10), pl.entries[1].Time, "preserved time")
+	assert.Contains(t, logs, "Failed to write audit entry")
+
+	_, err := p.Query(&audit.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultQueryLimit, pl.query.Limit, "default limit")
+
+	p.Query(&audit.Query{Limit: 100000}) // nolint: errcheck, gosec
+	assert.Equal(t, maxQueryLimit, pl.query.Limit, "max limit")
+}
+
+// Tests fallback to file storage.
+func TestPluginFallback(t *testing.T) {
+	utils.ConfigDir = os.TempDir()
+	defer func() {
+		utils.ConfigDir = ""
+	}()
+
+	p := NewAuditProvider(&ConstructAudit{
+		PluginLogger: mocks.FakeNewLogger(nil),
+		Loader:       mocks.FakeNewPluginLoader(nil),
+		Provider:     "test",
+		RawConfig:    []byte("location: /wrong"),
+	})
+
+	s := p.(*provider).storage.(*fileStorage)
+	assert.Equal(t, fmt.Sprintf("%s/_audit.log", os.TempDir()), s.settings.Location)
+}
+
+// Tests file storage append and filters.
+func TestFileStorage(t *testing.T) {
+	location := getTempLocation(t)
+	defer os.Remove(location) // nolint: errcheck
+
+	p := NewAuditProvider(&ConstructAudit{
+		PluginLogger: mocks.FakeNewLogger(nil),
+		RawConfig:    []byte(fmt.Sprintf("location: %s", location)),
+	})
+
+	r, err := p.Query(&audit.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(r), "no file")
+
+	p.Record(&audit.Entry{Time: 1, User: "usr1", Action: audit.ActionDeviceCommand, Target: "light.1",
+		Payload: map[string]interface{}{"command": "on"}, Success: true})
+	p.Record(&audit.Entry{Time: 2, User: "usr2", Action: audit.ActionGroupCommand, Target: "group.1"})
+	p.Record(&audit.Entry{Time: 3, User: "usr1", Action: audit.ActionDeviceCommand, Target: "light.2"})
+	p.Record(&audit.Entry{Time: 4, User: "usr1", Action: audit.ActionTokenCreate, Target: "token"})
+
+	f, err := os.OpenFile(location, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	f.WriteString("corrupted\n") // nolint: errcheck, gosec
+	f.Close()                    // nolint: errcheck, gosec
+
+	data := map[*audit.Query][]int64{
+		{}:                                 {4, 3, 2, 1},
+		{Limit: 2}:                         {4, 3},
+		{From: 2, To: 3}:                   {3, 2},
+		{User: "usr1"}:                     {4, 3, 1},
+		{Target: "light.*"}:                {3, 1},
+		{Action: audit.ActionGroupCommand}: {2},
+		{User: "usr3"}:                     {},
+	}
+
+	for k, v := range data {
+		r, err := p.Query(k)
+		require.NoError(t, err)
+		times := make([]int64, 0)
+		for _, e := range r {
+			times = append(times, e.Time)
+		}
+
+		assert.Equal(t, v, times, "%+v", k)
+	}
+
+	r, _ = p.Query(&audit.Query{To: 1})
+	require.Equal(t, 1, len(r))
+	assert.Equal(t, map[string]interface{}{"command": "on"}, r[0].Payload, "payload")
+	assert.True(t, r[0].Success, "success")
+
+	_, err = p.Query(&audit.Query{Target: "["})
+	assert.Error(t, err, "wrong glob")
+}
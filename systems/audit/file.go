@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gobwas/glob"
+	"go-home.io/x/server/plugins/audit"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Max size of a single audit entry in the file.
+const maxEntrySize = 1024 * 1024
+
+// File storage settings.
+type fileSettings struct {
+	Location string `yaml:"location"`
+}
+
+// Built-in audit storage.
+// Every entry is appended to the file as a single JSON line.
+type fileStorage struct {
+	logger   common.ILoggerProvider
+	settings *fileSettings
+}
+
+// Init reads settings.
+// Default location is _audit.log in configs directory.
+func (f *fileStorage) Init(data *audit.InitDataAuditStorage) error {
+	f.logger = data.Logger
+	f.settings = &fileSettings{}
+
+	if err := yaml.Unmarshal(data.RawConfig, f.settings); err != nil {
+		return err
+	}
+
+	if "" == f.settings.Location {
+		f.settings.Location = fmt.Sprintf("%s/_audit.log", utils.GetDefaultConfigsDir())
+	}
+
+	return nil
+}
+
+// Append writes a new entry to the end of the file.
+//noinspection GoUnhandledErrorResult
+func (f *fileStorage) Append(entry *audit.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.settings.Location, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Query scans the file and returns the latest matching entries, newest first.
+//noinspection GoUnhandledErrorResult
+func (f *fileStorage) Query(query *audit.Query) ([]*audit.Entry, error) {
+	var target glob.Glob
+	if "" != query.Target {
+		exp, err := glob.Compile(query.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		target = exp
+	}
+
+	result := make([]*audit.Entry, 0)
+	file, err := os.Open(f.settings.Location)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+
+		return nil, err
+	}
+
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntrySize)
+	for scanner.Scan() {
+		entry := &audit.Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			f.logger.Warn("Skipping corrupted audit entry", common.LogFileToken, f.settings.Location)
+			continue
+		}
+
+		if !matchEntry(query, target, entry) {
+			continue
+		}
+
+		result = append(result, entry)
+		if query.Limit > 0 && len(result) > query.Limit {
+			result = result[1:]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for ii, jj := 0, len(result)-1; ii < jj; ii, jj = ii+1, jj-1 {
+		result[ii], result[jj] = result[jj], result[ii]
+	}
+
+	return result, nil
+}
+
+// Checks whether entry matches the query.
+func matchEntry(query *audit.Query, target glob.Glob, entry *audit.Entry) bool {
+	switch {
+	case 0 != query.From && entry.Time < query.From:
+		return false
+	case 0 != query.To && entry.Time > query.To:
+		return false
+	case "" != query.User && query.User != entry.User:
+		return false
+	case "" != query.Action && query.Action != entry.Action:
+		return false
+	case nil != target && !target.Match(entry.Target):
+		return false
+	}
+
+	return true
+}
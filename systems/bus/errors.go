@@ -26,3 +26,21 @@ type ErrCorruptedMessage struct {
 func (*ErrCorruptedMessage) Error() string {
 	return "failed to unmarshal bus message"
 }
+
+// ErrInvalidMQTTConfig defines an invalid MQTT bus config error.
+type ErrInvalidMQTTConfig struct {
+}
+
+// Error formats output.
+func (*ErrInvalidMQTTConfig) Error() string {
+	return "invalid mqtt bus config"
+}
+
+// ErrMQTTNotConnected defines a broker connection error.
+type ErrMQTTNotConnected struct {
+}
+
+// Error formats output.
+func (*ErrMQTTNotConnected) Error() string {
+	return "not connected to mqtt broker"
+}
@@ -0,0 +1,137 @@
+package bus
+
+import (
+	"encoding/json"
+	"sync"
+
+	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/plugins/common"
+)
+
+const (
+	// MemoryProvider is a built-in in-process service bus provider name.
+	MemoryProvider = "memory"
+	// Max number of undelivered messages per subscription.
+	memoryQueueSize = 1000
+)
+
+// Process-wide hub, shared by all in-memory buses,
+// so master and worker, started in the same process, can talk to each other.
+var memory = &memoryHub{
+	subscriptions: make(map[string]map[*memoryBus]*memorySubscription),
+}
+
+// In-memory channels registry.
+type memoryHub struct {
+	sync.Mutex
+	subscriptions map[string]map[*memoryBus]*memorySubscription
+}
+
+// Built-in in-process service bus.
+// Every published message is delivered to all subscribers of the channel.
+type memoryBus struct {
+	hub    *memoryHub
+	logger common.ILoggerProvider
+}
+
+// Active channel subscription.
+// Messages are buffered, so slow subscriber doesn't block publishers.
+type memorySubscription struct {
+	pending chan bus.RawMessage
+	stop    chan bool
+}
+
+// Creates a new in-memory service bus.
+func newMemoryBus(logger common.ILoggerProvider) *memoryBus {
+	return &memoryBus{
+		hub:    memory,
+		logger: logger,
+	}
+}
+
+// Init saves logger.
+func (m *memoryBus) Init(data *bus.InitDataServiceBus) error {
+	m.logger = data.Logger
+	return nil
+}
+
+// Subscribe allows to subscribe to the channel.
+// Previous subscription of this bus to the same channel is replaced.
+func (m *memoryBus) Subscribe(channel string, queue chan bus.RawMessage) error {
+	sub := &memorySubscription{
+		pending: make(chan bus.RawMessage, memoryQueueSize),
+		stop:    make(chan bool),
+	}
+
+	m.hub.Lock()
+	defer m.hub.Unlock()
+
+	if _, ok := m.hub.subscriptions[channel]; !ok {
+		m.hub.subscriptions[channel] = make(map[*memoryBus]*memorySubscription)
+	}
+
+	if old, ok := m.hub.subscriptions[channel][m]; ok {
+		close(old.stop)
+	}
+
+	m.hub.subscriptions[channel][m] = sub
+	go sub.forward(queue)
+	return nil
+}
+
+// Unsubscribe removes channel subscription.
+func (m *memoryBus) Unsubscribe(channel string) {
+	m.hub.Lock()
+	defer m.hub.Unlock()
+
+	sub, ok := m.hub.subscriptions[channel][m]
+	if !ok {
+		return
+	}
+
+	close(sub.stop)
+	delete(m.hub.subscriptions[channel], m)
+}
+
+// Publish sends messages to all channel subscribers.
+// Messages are dropped if subscriber's buffer is full.
+func (m *memoryBus) Publish(channel string, messages ...interface{}) {
+	for _, v := range messages {
+		data, err := json.Marshal(v)
+		if err != nil {
+			m.logger.Error("Failed to marshal message", err, common.LogChannelToken, channel)
+			continue
+		}
+
+		m.hub.Lock()
+		for _, sub := range m.hub.subscriptions[channel] {
+			select {
+			case sub.pending <- bus.RawMessage{Body: data}:
+			default:
+				m.logger.Warn("Subscriber queue is full, dropping message", common.LogChannelToken, channel)
+			}
+		}
+		m.hub.Unlock()
+	}
+}
+
+// Ping always succeeds, since there is nothing to connect to.
+func (m *memoryBus) Ping() error {
+	return nil
+}
+
+// Forwards buffered messages to the subscriber's queue.
+func (sub *memorySubscription) forward(queue chan bus.RawMessage) {
+	for {
+		select {
+		case msg := <-sub.pending:
+			select {
+			case queue <- msg:
+			case <-sub.stop:
+				return
+			}
+		case <-sub.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+// Returns in-memory bus.
+func getMemoryBus(t *testing.T) providers.IBusProvider {
+	b, err := NewServiceBusProvider(&ConstructBus{
+		Provider: MemoryProvider,
+		Logger:   mocks.FakeNewLogger(nil),
+	})
+	require.NoError(t, err)
+	return b
+}
+
+// Tests messages fan-out between buses in the same process.
+func TestMemoryBusFanOut(t *testing.T) {
+	master := getMemoryBus(t)
+	worker1 := getMemoryBus(t)
+	worker2 := getMemoryBus(t)
+	assert.NoError(t, master.Ping())
+
+	q1 := make(chan bus.RawMessage, 10)
+	q2 := make(chan bus.RawMessage, 10)
+	require.NoError(t, worker1.SubscribeStr("memory-test", q1))
+	require.NoError(t, worker2.SubscribeStr("memory-test", q2))
+
+	master.PublishStr("memory-test", NewDeviceCommandMessage("dev1", enums.CmdOn, nil),
+		NewDeviceCommandMessage("dev1", enums.CmdOff, nil))
+	for _, q := range []chan bus.RawMessage{q1, q2} {
+		msg := receive(q)
+		require.NotNil(t, msg)
+		assert.Equal(t, enums.CmdOn, msg.Command)
+		msg = receive(q)
+		require.NotNil(t, msg)
+		assert.Equal(t, enums.CmdOff, msg.Command)
+	}
+
+	worker1.Unsubscribe("memory-test")
+	master.PublishStr("memory-test", NewDeviceCommandMessage("dev1", enums.CmdToggle, nil))
+	msg := receive(q2)
+	require.NotNil(t, msg)
+	assert.Equal(t, enums.CmdToggle, msg.Command)
+
+	select {
+	case <-q1:
+		assert.Fail(t, "unsubscribed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	worker2.Unsubscribe("memory-test")
+}
+
+// Tests that slow subscriber doesn't block publisher.
+func TestMemoryBusSlowSubscriber(t *testing.T) {
+	b := getMemoryBus(t)
+	queue := make(chan bus.RawMessage)
+	require.NoError(t, b.SubscribeStr("memory-slow", queue))
+	defer b.Unsubscribe("memory-slow")
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 2*memoryQueueSize; i++ {
+			b.PublishStr("memory-slow", NewDeviceCommandMessage("dev1", enums.CmdOn, nil))
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "publisher is blocked")
+	}
+}
@@ -19,6 +19,7 @@ type IMasterMessageParserProvider interface {
 	GetDiscoveryMessageChan() chan *DiscoveryMessage
 	GetDeviceUpdateMessageChan() chan *DeviceUpdateMessage
 	GetEntityLoadStatueMessageChan() chan *EntityLoadStatusMessage
+	GetWorkerLeaveMessageChan() chan *WorkerLeaveMessage
 }
 
 // IWorkerMessageParserProvider describes messages parser for worker.
@@ -40,6 +41,7 @@ type messageParser struct {
 	discoveryMessageChan        chan *DiscoveryMessage
 	deviceUpdateMessageChan     chan *DeviceUpdateMessage
 	entityLoadStatusMessageChan chan *EntityLoadStatusMessage
+	workerLeaveMessageChan      chan *WorkerLeaveMessage
 }
 
 // NewWorkerMessageParser constructs parser for worker.
@@ -59,6 +61,7 @@ func NewMasterMessageParser(logger common.ILoggerProvider) IMasterMessageParserP
 		discoveryMessageChan:        make(chan *DiscoveryMessage, 5),
 		deviceUpdateMessageChan:     make(chan *DeviceUpdateMessage, 50),
 		entityLoadStatusMessageChan: make(chan *EntityLoadStatusMessage, 50),
+		workerLeaveMessageChan:      make(chan *WorkerLeaveMessage, 5),
 		isWorker:                    false,
 	}
 }
@@ -87,6 +90,11 @@ func (w *messageParser) GetEntityLoadStatueMessageChan() chan *EntityLoadStatusM
 	return w.entityLoadStatusMessageChan
 }
 
+// GetWorkerLeaveMessageChan returns channel used for worker leave callbacks.
+func (w *messageParser) GetWorkerLeaveMessageChan() chan *WorkerLeaveMessage {
+	return w.workerLeaveMessageChan
+}
+
 // ProcessIncomingMessage parses incoming service bus message.
 func (w *messageParser) ProcessIncomingMessage(r *bus.RawMessage) {
 	var err error
@@ -125,6 +133,20 @@ func (w *messageParser) processWorkerMessage(b *MessageWithType, r *bus.RawMessa
 		if err == nil {
 			w.deviceCommandsChan <- &d
 		}
+	case bus.MsgDeviceBatchCommand:
+		// Batch is split, so worker processes every command the same way as a single one.
+		var d DeviceBatchCommandMessage
+		err = json.Unmarshal(r.Body, &d)
+		if err == nil {
+			for _, v := range d.Commands {
+				w.deviceCommandsChan <- &DeviceCommandMessage{
+					MessageWithType: d.MessageWithType,
+					DeviceID:        v.DeviceID,
+					Command:         v.Command,
+					Payload:         v.Payload,
+				}
+			}
+		}
 	default:
 		w.logger.Warn("Received unknown message type", "type", b.Type.String(),
 			common.LogSystemToken, logSystem)
@@ -158,6 +180,12 @@ func (w *messageParser) processServerMessage(b *MessageWithType, r *bus.RawMessa
 		if err == nil {
 			w.entityLoadStatusMessageChan <- &m
 		}
+	case bus.MsgWorkerLeave:
+		var m WorkerLeaveMessage
+		err := json.Unmarshal(r.Body, &m)
+		if err == nil {
+			w.workerLeaveMessageChan <- &m
+		}
 	default:
 		w.logger.Warn("Received unknown message type", "type", b.Type.String(),
 			common.LogSystemToken, logSystem)
@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go-home.io/x/server/mocks"
 	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/plugins/device/enums"
 	"go-home.io/x/server/utils"
 )
 
@@ -122,6 +124,18 @@ func TestWorkerServerParser(t *testing.T) {
 			cmd:    true,
 			err:    "device command",
 		},
+		{
+			msg:    fmt.Sprintf(`{"mt": "device_batch_command",  "st": %d, "c": [{"i": "d1", "c": "on"}]}`, utils.TimeNow()),
+			assign: false,
+			cmd:    true,
+			err:    "device batch command",
+		},
+		{
+			msg:    fmt.Sprintf(`{"mt": "device_batch_command",  "st": %d, "c": []}`, utils.TimeNow()),
+			assign: false,
+			cmd:    false,
+			err:    "empty device batch command",
+		},
 		{
 			msg:    fmt.Sprintf(`{"mt": "ping",  "st": %d}`, utils.TimeNow()),
 			assign: false,
@@ -145,3 +159,32 @@ func TestWorkerServerParser(t *testing.T) {
 		assert.Equal(t, v.assign, assign, "assignment %s", v.err)
 	}
 }
+
+// Tests that batch command is split into single commands.
+func TestWorkerBatchCommand(t *testing.T) {
+	p := NewWorkerMessageParser(mocks.FakeNewLogger(nil))
+	msg := NewDeviceBatchCommandMessage([]*DeviceCommand{
+		{DeviceID: "d1", Command: enums.CmdOn},
+		{DeviceID: "d2", Command: enums.CmdSetBrightness, Payload: map[string]interface{}{"value": 10.0}},
+	})
+	b, _ := json.Marshal(msg)
+
+	p.ProcessIncomingMessage(&bus.RawMessage{Body: b})
+	cmd1 := <-p.GetDeviceCommandMessageChan()
+	cmd2 := <-p.GetDeviceCommandMessageChan()
+
+	assert.Equal(t, "d1", cmd1.DeviceID)
+	assert.Equal(t, enums.CmdOn, cmd1.Command)
+	assert.Equal(t, "d2", cmd2.DeviceID)
+	assert.Equal(t, 10.0, cmd2.Payload["value"])
+}
+
+// Tests worker leave message.
+func TestMasterWorkerLeave(t *testing.T) {
+	p := NewMasterMessageParser(mocks.FakeNewLogger(nil))
+	b, _ := json.Marshal(NewWorkerLeaveMessage("worker1"))
+
+	p.ProcessIncomingMessage(&bus.RawMessage{Body: b})
+	msg := <-p.GetWorkerLeaveMessageChan()
+	assert.Equal(t, "worker1", msg.NodeID)
+}
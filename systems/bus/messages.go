@@ -12,6 +12,16 @@ type MessageWithType struct {
 	SendTime int64           `json:"st"`
 }
 
+// ITypedMessage describes message with known type.
+type ITypedMessage interface {
+	GetType() bus.MessageType
+}
+
+// GetType returns message type.
+func (m MessageWithType) GetType() bus.MessageType {
+	return m.Type
+}
+
 // KeyValue helper type for key-value pair.
 type KeyValue struct {
 	Key   string `json:"k"`
@@ -27,6 +37,12 @@ type DiscoveryMessage struct {
 	MaxDevices   int               `json:"m"`
 }
 
+// WorkerLeaveMessage used by worker to notify master about graceful shutdown.
+type WorkerLeaveMessage struct {
+	MessageWithType
+	NodeID string `json:"n"`
+}
+
 // DeviceAssignment type with single device assignment.
 type DeviceAssignment struct {
 	Plugin string           `json:"p"`
@@ -73,6 +89,19 @@ type DeviceCommandMessage struct {
 	Payload  map[string]interface{} `json:"p"`
 }
 
+// DeviceCommand has single device command data.
+type DeviceCommand struct {
+	DeviceID string                 `json:"i"`
+	Command  enums.Command          `json:"c"`
+	Payload  map[string]interface{} `json:"p"`
+}
+
+// DeviceBatchCommandMessage used by server to invoke multiple devices commands on a worker.
+type DeviceBatchCommandMessage struct {
+	MessageWithType
+	Commands []*DeviceCommand `json:"c"`
+}
+
 // NewDiscoveryMessage constructs discovery message.
 func NewDiscoveryMessage(nodeID string, firstStart bool, properties map[string]string,
 	maxDevices int) *DiscoveryMessage {
@@ -130,6 +159,17 @@ func NewDeviceCommandMessage(deviceID string, command enums.Command,
 	}
 }
 
+// NewDeviceBatchCommandMessage constructs multiple devices commands message.
+func NewDeviceBatchCommandMessage(commands []*DeviceCommand) *DeviceBatchCommandMessage {
+	return &DeviceBatchCommandMessage{
+		MessageWithType: MessageWithType{
+			Type:     bus.MsgDeviceBatchCommand,
+			SendTime: utils.TimeNow(),
+		},
+		Commands: commands,
+	}
+}
+
 // NewEntityLoadStatusMessage constructs entity load message.
 func NewEntityLoadStatusMessage(entityName string, nodeID string, isSuccess bool) *EntityLoadStatusMessage {
 	return &EntityLoadStatusMessage{
@@ -142,3 +182,14 @@ func NewEntityLoadStatusMessage(entityName string, nodeID string, isSuccess bool
 		NodeID:    nodeID,
 	}
 }
+
+// NewWorkerLeaveMessage constructs worker leave message.
+func NewWorkerLeaveMessage(nodeID string) *WorkerLeaveMessage {
+	return &WorkerLeaveMessage{
+		MessageWithType: MessageWithType{
+			Type:     bus.MsgWorkerLeave,
+			SendTime: utils.TimeNow(),
+		},
+		NodeID: nodeID,
+	}
+}
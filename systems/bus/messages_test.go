@@ -40,6 +40,13 @@ func TestNewDeviceCommandMessage(t *testing.T) {
 	assert.Equal(t, 1, len(m.Payload))
 }
 
+// Tests device batch command ctor.
+func TestNewDeviceBatchCommandMessage(t *testing.T) {
+	m := NewDeviceBatchCommandMessage([]*DeviceCommand{{DeviceID: "test", Command: enums.CmdOn}})
+	checkTime(t, m.SendTime)
+	assert.Equal(t, 1, len(m.Commands))
+}
+
 // Tests entity load ctor.
 func TestNewEntityLoadStatusMessage(t *testing.T) {
 	m := NewEntityLoadStatusMessage("test", "test_node", true)
@@ -48,3 +55,10 @@ func TestNewEntityLoadStatusMessage(t *testing.T) {
 	assert.Equal(t, "test_node", m.NodeID, "node")
 	assert.True(t, m.IsSuccess, "success")
 }
+
+// Tests worker leave ctor.
+func TestNewWorkerLeaveMessage(t *testing.T) {
+	m := NewWorkerLeaveMessage("test_node")
+	checkTime(t, m.SendTime)
+	assert.Equal(t, "test_node", m.NodeID, "node")
+}
@@ -0,0 +1,226 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+	"gopkg.in/yaml.v2"
+)
+
+// Built-in MQTT service bus provider name.
+const mqttProvider = "mqtt"
+
+// MQTT service bus settings.
+// Every channel is mapped to <prefix>/<channel> topic.
+// Client ID defaults to the node ID, so master and workers never collide.
+type mqttSettings struct {
+	Broker               string `yaml:"broker" validate:"required"`
+	ClientID             string `yaml:"clientID"`
+	Username             string `yaml:"username"`
+	Password             string `yaml:"password"`
+	Prefix               string `yaml:"prefix" default:"go-home/bus"`
+	QoS                  int    `yaml:"qos" validate:"gte=0,lte=2"`
+	KeepAlive            int    `yaml:"keepAlive" default:"30" validate:"gte=1"`
+	ConnectTimeout       int    `yaml:"connectTimeout" default:"10" validate:"gte=1"`
+	MaxReconnectInterval int    `yaml:"maxReconnectInterval" default:"60" validate:"gte=1"`
+	ConnectRetries       int    `yaml:"connectRetries" default:"5" validate:"gte=0"`
+	ConnectRetryInterval int    `yaml:"connectRetryInterval" default:"10" validate:"gte=1"`
+}
+
+// Built-in service bus, backed by MQTT broker.
+// Client re-connects automatically and restores all active subscriptions.
+type mqttBus struct {
+	sync.Mutex
+	settings      *mqttSettings
+	logger        common.ILoggerProvider
+	client        mqtt.Client
+	subscriptions map[string]*mqttSubscription
+}
+
+// Active channel subscription.
+type mqttSubscription struct {
+	queue chan bus.RawMessage
+	stop  chan bool
+}
+
+// Creates a new MQTT service bus.
+func newMQTTBus(rawConfig []byte, validator providers.IValidatorProvider) (*mqttBus, error) {
+	settings := &mqttSettings{}
+	err := yaml.Unmarshal(rawConfig, settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !validator.Validate(settings) {
+		return nil, &ErrInvalidMQTTConfig{}
+	}
+
+	return &mqttBus{
+		settings:      settings,
+		subscriptions: make(map[string]*mqttSubscription),
+	}, nil
+}
+
+// Init connects to the broker.
+func (m *mqttBus) Init(data *bus.InitDataServiceBus) error {
+	m.logger = data.Logger
+	if "" == m.settings.ClientID {
+		m.settings.ClientID = data.NodeID
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(m.settings.Broker).
+		SetClientID(m.settings.ClientID).
+		SetUsername(m.settings.Username).
+		SetPassword(m.settings.Password).
+		SetKeepAlive(time.Duration(m.settings.KeepAlive) * time.Second).
+		SetConnectTimeout(time.Duration(m.settings.ConnectTimeout) * time.Second).
+		SetMaxReconnectInterval(time.Duration(m.settings.MaxReconnectInterval) * time.Second).
+		SetAutoReconnect(true).
+		SetCleanSession(true).
+		SetOnConnectHandler(m.onConnect).
+		SetConnectionLostHandler(m.onConnectionLost)
+
+	m.client = mqtt.NewClient(opts)
+	return m.connect()
+}
+
+// Connects to the broker.
+// Client re-connects automatically only after the first successful connection,
+// so initial attempts are retried here.
+func (m *mqttBus) connect() error {
+	for attempt := 0; ; attempt++ {
+		err := m.wait(m.client.Connect())
+		if nil == err {
+			return nil
+		}
+
+		if attempt >= m.settings.ConnectRetries {
+			return err
+		}
+
+		m.logger.Error("Failed to connect to MQTT broker, retrying", err)
+		time.Sleep(time.Duration(m.settings.ConnectRetryInterval) * time.Second)
+	}
+}
+
+// Subscribe allows to subscribe to the channel.
+func (m *mqttBus) Subscribe(channel string, queue chan bus.RawMessage) error {
+	m.Lock()
+	if old, ok := m.subscriptions[channel]; ok {
+		close(old.stop)
+	}
+
+	sub := &mqttSubscription{queue: queue, stop: make(chan bool)}
+	m.subscriptions[channel] = sub
+	m.Unlock()
+
+	return m.subscribe(channel, sub)
+}
+
+// Unsubscribe removes channel subscription.
+func (m *mqttBus) Unsubscribe(channel string) {
+	m.Lock()
+	sub, ok := m.subscriptions[channel]
+	if ok {
+		close(sub.stop)
+		delete(m.subscriptions, channel)
+	}
+	m.Unlock()
+
+	if !ok {
+		return
+	}
+
+	token := m.client.Unsubscribe(m.topic(channel))
+	if m.wait(token) != nil {
+		m.logger.Warn("Failed to unsubscribe from MQTT topic", common.LogChannelToken, channel)
+	}
+}
+
+// Publish sends messages to the channel.
+// Messages are sent one by one, keeping the order.
+func (m *mqttBus) Publish(channel string, messages ...interface{}) {
+	for _, v := range messages {
+		data, err := json.Marshal(v)
+		if err != nil {
+			m.logger.Error("Failed to marshal message", err, common.LogChannelToken, channel)
+			continue
+		}
+
+		token := m.client.Publish(m.topic(channel), m.qos(), false, data)
+		if err := m.wait(token); err != nil {
+			m.logger.Error("Failed to publish message", err, common.LogChannelToken, channel)
+		}
+	}
+}
+
+// Ping checks whether connection to the broker is open.
+func (m *mqttBus) Ping() error {
+	if nil == m.client || !m.client.IsConnectionOpen() {
+		return &ErrMQTTNotConnected{}
+	}
+
+	return nil
+}
+
+// Restores subscriptions, since clean session drops them on the broker side.
+func (m *mqttBus) onConnect(mqtt.Client) {
+	m.logger.Info("Connected to MQTT broker")
+
+	m.Lock()
+	subs := make(map[string]*mqttSubscription, len(m.subscriptions))
+	for k, v := range m.subscriptions {
+		subs[k] = v
+	}
+	m.Unlock()
+
+	for channel, sub := range subs {
+		if err := m.subscribe(channel, sub); err != nil {
+			m.logger.Error("Failed to restore subscription", err, common.LogChannelToken, channel)
+		}
+	}
+}
+
+// Logs lost connection, client re-connects automatically.
+func (m *mqttBus) onConnectionLost(_ mqtt.Client, err error) {
+	m.logger.Warn("Lost connection to MQTT broker", common.LogErrorToken, err.Error())
+}
+
+// Subscribes to the channel topic.
+func (m *mqttBus) subscribe(channel string, sub *mqttSubscription) error {
+	token := m.client.Subscribe(m.topic(channel), m.qos(), func(_ mqtt.Client, msg mqtt.Message) {
+		select {
+		case sub.queue <- bus.RawMessage{Body: msg.Payload()}:
+		case <-sub.stop:
+		}
+	})
+
+	return m.wait(token)
+}
+
+// Waits for the broker operation.
+func (m *mqttBus) wait(token mqtt.Token) error {
+	if !token.WaitTimeout(time.Duration(m.settings.ConnectTimeout) * time.Second) {
+		return &ErrMQTTNotConnected{}
+	}
+
+	return token.Error()
+}
+
+// Returns channel topic.
+func (m *mqttBus) topic(channel string) string {
+	return fmt.Sprintf("%s/%s", m.settings.Prefix, channel)
+}
+
+// Returns configured QoS.
+func (m *mqttBus) qos() byte {
+	return byte(m.settings.QoS)
+}
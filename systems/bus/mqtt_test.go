@@ -0,0 +1,148 @@
+package bus
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+)
+
+// Returns MQTT bus, connected to the fake broker.
+func getMQTTBus(t *testing.T, broker mocks.IFakeMQTTBroker, nodeID string) providers.IBusProvider {
+	b, err := NewServiceBusProvider(&ConstructBus{
+		Provider:  mqttProvider,
+		RawConfig: []byte("broker: " + broker.Address() + "\nqos: 1"),
+		Logger:    mocks.FakeNewLogger(nil),
+		Validator: utils.NewValidator(mocks.FakeNewLogger(nil)),
+		NodeID:    nodeID,
+	})
+	require.NoError(t, err)
+	return b
+}
+
+// Returns next received message or nil.
+func receive(queue chan bus.RawMessage) *DeviceCommandMessage {
+	select {
+	case msg := <-queue:
+		m := &DeviceCommandMessage{}
+		if json.Unmarshal(msg.Body, m) != nil {
+			return nil
+		}
+
+		return m
+	case <-time.After(2 * time.Second):
+		return nil
+	}
+}
+
+// Tests messages routing between nodes.
+func TestMQTTBusPublish(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	defer broker.Close()
+
+	master := getMQTTBus(t, broker, "master")
+	worker := getMQTTBus(t, broker, "worker")
+	assert.NoError(t, master.Ping())
+
+	queue := make(chan bus.RawMessage, 10)
+	require.NoError(t, worker.SubscribeToWorker("w1", queue))
+
+	master.PublishToWorker("w1", NewDeviceCommandMessage("dev1", enums.CmdOn, nil),
+		NewDeviceCommandMessage("dev1", enums.CmdOff, nil))
+	msg := receive(queue)
+	require.NotNil(t, msg, "first")
+	assert.Equal(t, enums.CmdOn, msg.Command)
+	msg = receive(queue)
+	require.NotNil(t, msg, "second")
+	assert.Equal(t, enums.CmdOff, msg.Command)
+
+	published := broker.Messages("go-home/bus/worker-w1")
+	require.Equal(t, 2, len(published))
+	assert.Equal(t, byte(1), published[0].QoS)
+
+	worker.Unsubscribe("worker-w1")
+	master.PublishToWorker("w1", NewDeviceCommandMessage("dev1", enums.CmdOn, nil))
+	assert.Nil(t, receive(queue), "unsubscribed")
+}
+
+// Tests subscriptions restore after connection loss.
+func TestMQTTBusReconnect(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	defer broker.Close()
+
+	master := getMQTTBus(t, broker, "master")
+	worker := getMQTTBus(t, broker, "worker")
+
+	queue := make(chan bus.RawMessage, 10)
+	require.NoError(t, master.Subscribe(bus.ChDiscovery, queue))
+
+	broker.DropClients()
+	reconnected := false
+	for i := 0; i < 100 && !reconnected; i++ {
+		time.Sleep(50 * time.Millisecond)
+		reconnected = 2 == broker.Clients() && nil == master.Ping() && nil == worker.Ping()
+	}
+
+	require.True(t, reconnected, "not reconnected")
+	time.Sleep(100 * time.Millisecond)
+
+	worker.Publish(bus.ChDiscovery, NewDeviceCommandMessage("dev1", enums.CmdToggle, nil))
+	msg := receive(queue)
+	require.NotNil(t, msg)
+	assert.Equal(t, enums.CmdToggle, msg.Command)
+}
+
+// Tests that initial connection is retried.
+func TestMQTTBusConnectRetry(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	address := broker.Address()
+	broker.Close()
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		broker = mocks.FakeNewMQTTBrokerAt(strings.TrimPrefix(address, "tcp://"))
+	}()
+
+	b, err := NewServiceBusProvider(&ConstructBus{
+		Provider:  mqttProvider,
+		RawConfig: []byte("broker: " + address + "\nconnectTimeout: 1\nconnectRetries: 3\nconnectRetryInterval: 1"),
+		Logger:    mocks.FakeNewLogger(nil),
+		Validator: utils.NewValidator(mocks.FakeNewLogger(nil)),
+		NodeID:    "node",
+	})
+	require.NoError(t, err)
+	defer broker.Close()
+	assert.NoError(t, b.Ping())
+}
+
+// Tests invalid MQTT settings.
+func TestMQTTBusErrors(t *testing.T) {
+	broker := mocks.FakeNewMQTTBroker()
+	address := broker.Address()
+	broker.Close()
+
+	data := []string{
+		"qos: 1",
+		"broker: " + address + "\nqos: 3",
+		"broker: " + address + "\nconnectTimeout: 1\nconnectRetries: 1\nconnectRetryInterval: 1",
+	}
+
+	for _, v := range data {
+		_, err := NewServiceBusProvider(&ConstructBus{
+			Provider:  mqttProvider,
+			RawConfig: []byte(v),
+			Logger:    mocks.FakeNewLogger(nil),
+			Validator: utils.NewValidator(mocks.FakeNewLogger(nil)),
+			NodeID:    "node",
+		})
+		assert.Error(t, err, v)
+	}
+}
@@ -3,17 +3,21 @@ package bus
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/pkg/errors"
 	"go-home.io/x/server/plugins/bus"
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/metrics"
 )
 
 const (
 	// Logs representation.
 	logSystem = "service_bus"
+	// Metrics representation of messages without type.
+	msgTypeOther = "other"
 )
 
 // ConstructBus holds values required for a new service bus provider.
@@ -24,16 +28,37 @@ type ConstructBus struct {
 	Logger    common.ILoggerProvider
 	NodeID    string
 	Secret    common.ISecretProvider
+	Validator providers.IValidatorProvider
 }
 
 // Service bus provider.
 type provider struct {
-	bus bus.IServiceBus
+	sync.Mutex
+	bus           bus.IServiceBus
+	subscriptions map[string]*subscription
+}
+
+// Active subscription.
+// Messages are forwarded through the internal queue, so they can be counted by channel.
+type subscription struct {
+	stop chan bool
+	done chan bool
 }
 
 // NewServiceBusProvider constructs a new service bus provider.
+// MQTT and in-memory buses are built-in, other providers are loaded as plugins.
 func NewServiceBusProvider(ctor *ConstructBus) (providers.IBusProvider, error) {
-	p := provider{}
+	p := provider{
+		subscriptions: make(map[string]*subscription),
+	}
+
+	switch ctor.Provider {
+	case mqttProvider:
+		return loadMQTT(ctor, &p)
+	case MemoryProvider:
+		p.bus = newMemoryBus(ctor.Logger)
+		return &p, nil
+	}
 
 	pluginLoadRequest := &providers.PluginLoadRequest{
 		ExpectedType:   bus.TypeServiceBus,
@@ -56,13 +81,51 @@ func NewServiceBusProvider(ctor *ConstructBus) (providers.IBusProvider, error) {
 	return &p, nil
 }
 
+// Loads built-in MQTT bus.
+func loadMQTT(ctor *ConstructBus, p *provider) (providers.IBusProvider, error) {
+	b, err := newMQTTBus(ctor.RawConfig, ctor.Validator)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.Init(&bus.InitDataServiceBus{
+		NodeID: ctor.NodeID,
+		Logger: ctor.Logger,
+		Secret: ctor.Secret,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "mqtt connect failed")
+	}
+
+	p.bus = b
+	return p, nil
+}
+
 // Subscribe allows to subscribe to the incoming messages.
 func (s *provider) Subscribe(channel bus.ChannelName, queue chan bus.RawMessage) error {
 	return s.SubscribeStr(channel.String(), queue)
 }
 
+// SubscribeStr allows to subscribe to the incoming messages of any channel.
 func (s *provider) SubscribeStr(channel string, queue chan bus.RawMessage) error {
-	return s.bus.Subscribe(channel, queue)
+	s.Lock()
+	defer s.Unlock()
+
+	in := make(chan bus.RawMessage, cap(queue))
+	err := s.bus.Subscribe(channel, in)
+	if err != nil {
+		return err
+	}
+
+	s.stopSubscription(channel)
+	sub := &subscription{
+		stop: make(chan bool),
+		done: make(chan bool),
+	}
+
+	s.subscriptions[channel] = sub
+	go sub.forward(channel, in, queue)
+	return nil
 }
 
 // SubscribeToWorker is a syntax sugar around worker channels.
@@ -73,6 +136,40 @@ func (s *provider) SubscribeToWorker(workerName string, queue chan bus.RawMessag
 // Unsubscribe removes bus subscription.
 func (s *provider) Unsubscribe(channel string) {
 	s.bus.Unsubscribe(channel)
+
+	s.Lock()
+	defer s.Unlock()
+	s.stopSubscription(channel)
+}
+
+// Stops forwarding of channel messages. Caller should hold the lock.
+func (s *provider) stopSubscription(channel string) {
+	sub, ok := s.subscriptions[channel]
+	if !ok {
+		return
+	}
+
+	close(sub.stop)
+	<-sub.done
+	delete(s.subscriptions, channel)
+}
+
+// Forwards incoming messages to the subscriber's queue.
+func (sub *subscription) forward(channel string, in chan bus.RawMessage, queue chan bus.RawMessage) {
+	defer close(sub.done)
+	for {
+		select {
+		case msg := <-in:
+			metrics.BusMessages.Inc("in", channel, getRawMessageType(&msg))
+			select {
+			case queue <- msg:
+			case <-sub.stop:
+				return
+			}
+		case <-sub.stop:
+			return
+		}
+	}
 }
 
 // Publish allows to send a new message.
@@ -80,7 +177,17 @@ func (s *provider) Publish(channel bus.ChannelName, messages ...interface{}) {
 	s.PublishStr(channel.String(), messages...)
 }
 
+// PublishStr allows to send a new message to any channel.
 func (s *provider) PublishStr(channel string, messages ...interface{}) {
+	for _, v := range messages {
+		msgType := msgTypeOther
+		if m, ok := v.(ITypedMessage); ok {
+			msgType = m.GetType().String()
+		}
+
+		metrics.BusMessages.Inc("out", channel, msgType)
+	}
+
 	s.bus.Publish(channel, messages...)
 }
 
@@ -1,12 +1,16 @@
 package bus
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go-home.io/x/server/mocks"
 	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/systems/metrics"
 )
 
 // Fake bus plugin.
@@ -16,6 +20,7 @@ type fakePlugin struct {
 	unSub bool
 	pub   bool
 	ping  bool
+	queue chan bus.RawMessage
 }
 
 func (f *fakePlugin) Init(*bus.InitDataServiceBus) error {
@@ -24,6 +29,7 @@ func (f *fakePlugin) Init(*bus.InitDataServiceBus) error {
 
 func (f *fakePlugin) Subscribe(channel string, queue chan bus.RawMessage) error {
 	f.sub = true
+	f.queue = queue
 	return nil
 }
 
@@ -104,3 +110,45 @@ func TestServiceBus(t *testing.T) {
 	p.Unsubscribe("test")
 	assert.True(t, f.unSub, "unsubscribe")
 }
+
+// Tests messages forwarding and metrics.
+func TestServiceBusMetrics(t *testing.T) {
+	f := &fakePlugin{}
+	p, err := NewServiceBusProvider(&ConstructBus{
+		Loader: mocks.FakeNewPluginLoader(f),
+		Logger: mocks.FakeNewLogger(nil),
+	})
+	require.NoError(t, err)
+
+	queue := make(chan bus.RawMessage, 5)
+	require.NoError(t, p.SubscribeStr("metrics-test", queue))
+
+	body, _ := json.Marshal(NewWorkerLeaveMessage("worker"))
+	f.queue <- bus.RawMessage{Body: body}
+	f.queue <- bus.RawMessage{Body: []byte(`{"st": 1}`)}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-queue:
+		case <-time.After(time.Second):
+			require.Fail(t, "message was not forwarded")
+		}
+	}
+
+	p.PublishStr("metrics-test", NewWorkerLeaveMessage("worker"), "raw")
+	p.Unsubscribe("metrics-test")
+	f.queue <- bus.RawMessage{Body: body}
+	select {
+	case <-queue:
+		assert.Fail(t, "message was forwarded after unsubscribe")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, metrics.Write(buf))
+	out := buf.String()
+	assert.Contains(t, out, `gohome_bus_messages_total{direction="in",channel="metrics-test",type="worker_leave"} 1`)
+	assert.Contains(t, out, `gohome_bus_messages_total{direction="in",channel="metrics-test",type="other"} 1`)
+	assert.Contains(t, out, `gohome_bus_messages_total{direction="out",channel="metrics-test",type="worker_leave"} 1`)
+	assert.Contains(t, out, `gohome_bus_messages_total{direction="out",channel="metrics-test",type="other"} 1`)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/systems/metrics"
 	"go-home.io/x/server/utils"
 )
 
@@ -15,8 +16,23 @@ func parseRawMessage(r *bus.RawMessage) (*MessageWithType, error) {
 	}
 
 	if utils.TimeNow()-b.SendTime > bus.MsgTTLSeconds {
+		metrics.BusMessagesDiscarded.Inc(b.Type.String())
 		return nil, &ErrOldMessage{}
 	}
 
 	return &b, nil
 }
+
+// Returns message type for metrics.
+// Messages without type, e.g. extended API messages, are reported as other.
+func getRawMessageType(r *bus.RawMessage) string {
+	var b struct {
+		Type *bus.MessageType `json:"mt"`
+	}
+
+	if err := json.Unmarshal(r.Body, &b); err != nil || nil == b.Type {
+		return msgTypeOther
+	}
+
+	return b.Type.String()
+}
@@ -12,7 +12,8 @@ import (
 )
 
 var (
-	excludedDevices = []enums.DeviceType{enums.DevUnknown, enums.DevHub, enums.DevGroup, enums.DevTrigger}
+	excludedDevices = []enums.DeviceType{enums.DevUnknown, enums.DevHub, enums.DevGroup, enums.DevTrigger,
+		enums.DevScene, enums.DevMode, enums.DevHelper, enums.DevAlarm, enums.DevPerson}
 )
 
 // Tests that all device types are known.
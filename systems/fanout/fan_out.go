@@ -7,6 +7,7 @@ import (
 
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems/metrics"
 	"go-home.io/x/server/utils"
 )
 
@@ -14,6 +15,8 @@ import (
 type provider struct {
 	device  sync.Mutex
 	trigger sync.Mutex
+	// Guards subscribers maps changes, so metrics don't wait for blocked broadcasts.
+	subs sync.RWMutex
 
 	inDeviceUpdates  chan *common.MsgDeviceUpdate
 	outDeviceUpdates map[int64]chan *common.MsgDeviceUpdate
@@ -34,6 +37,7 @@ func NewFanOut() providers.IInternalFanOutProvider {
 		trigger: sync.Mutex{},
 	}
 
+	metrics.AddCollector("fanout", p.collectMetrics)
 	go p.internalCycle()
 	return p
 }
@@ -45,7 +49,9 @@ func (p *provider) SubscribeDeviceUpdates() (int64, chan *common.MsgDeviceUpdate
 
 	c := make(chan *common.MsgDeviceUpdate, 10)
 	rnd := p.getID()
+	p.subs.Lock()
 	p.outDeviceUpdates[rnd] = c
+	p.subs.Unlock()
 	return rnd, c
 }
 
@@ -61,7 +67,9 @@ func (p *provider) UnSubscribeDeviceUpdates(id int64) {
 	}
 
 	close(c)
+	p.subs.Lock()
 	delete(p.outDeviceUpdates, id)
+	p.subs.Unlock()
 }
 
 // ChannelInDeviceUpdates returns input channel for the device updates.
@@ -76,7 +84,9 @@ func (p *provider) SubscribeTriggerUpdates() (int64, chan string) {
 
 	c := make(chan string, 10)
 	rnd := p.getID()
+	p.subs.Lock()
 	p.outTriggerUpdates[rnd] = c
+	p.subs.Unlock()
 	return rnd, c
 }
 
@@ -92,7 +102,9 @@ func (p *provider) UnSubscribeTriggerUpdates(id int64) {
 	}
 
 	close(c)
+	p.subs.Lock()
 	delete(p.outTriggerUpdates, id)
+	p.subs.Unlock()
 }
 
 // ChannelInTriggerUpdates returns input channel for the triggers updates.
@@ -135,3 +147,36 @@ func (p *provider) triggerUpdates(update string) {
 		v <- update
 	}
 }
+
+// Updates fan-out metrics.
+func (p *provider) collectMetrics() {
+	p.subs.RLock()
+	defer p.subs.RUnlock()
+
+	depths := make([]int, 0, len(p.outDeviceUpdates))
+	for _, v := range p.outDeviceUpdates {
+		depths = append(depths, len(v))
+	}
+	setQueueMetrics("device", depths)
+
+	depths = make([]int, 0, len(p.outTriggerUpdates))
+	for _, v := range p.outTriggerUpdates {
+		depths = append(depths, len(v))
+	}
+	setQueueMetrics("trigger", depths)
+}
+
+// Sets subscribers and queues metrics for the stream.
+func setQueueMetrics(stream string, depths []int) {
+	total, max := 0, 0
+	for _, v := range depths {
+		total += v
+		if v > max {
+			max = v
+		}
+	}
+
+	metrics.FanOutSubscribers.Set(float64(len(depths)), stream)
+	metrics.FanOutQueueDepth.Set(float64(total), stream)
+	metrics.FanOutQueueMaxDepth.Set(float64(max), stream)
+}
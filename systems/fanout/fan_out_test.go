@@ -1,11 +1,14 @@
 package fanout
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/systems/metrics"
 )
 
 // Tests devices updates channels.
@@ -121,3 +124,26 @@ func TestTriggerUpdates(t *testing.T) {
 	assert.True(t, d2Exited, "exit channel 2")
 
 }
+
+// Tests queues metrics.
+func TestQueueMetrics(t *testing.T) {
+	fo := NewFanOut()
+	fo.SubscribeDeviceUpdates()
+	fo.SubscribeDeviceUpdates()
+	id, _ := fo.SubscribeTriggerUpdates()
+
+	fo.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{}
+	fo.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{}
+	fo.ChannelInTriggerUpdates() <- "trigger"
+	time.Sleep(500 * time.Millisecond)
+	fo.UnSubscribeTriggerUpdates(id)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, metrics.Write(buf))
+	out := buf.String()
+	assert.Contains(t, out, `gohome_fanout_subscribers{stream="device"} 2`)
+	assert.Contains(t, out, `gohome_fanout_queue_depth{stream="device"} 4`)
+	assert.Contains(t, out, `gohome_fanout_queue_max_depth{stream="device"} 2`)
+	assert.Contains(t, out, `gohome_fanout_subscribers{stream="trigger"} 0`)
+	assert.Contains(t, out, `gohome_fanout_queue_depth{stream="trigger"} 0`)
+}
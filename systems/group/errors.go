@@ -0,0 +1,23 @@
+package group
+
+import "fmt"
+
+// ErrEmptySelector defines selector without any condition.
+type ErrEmptySelector struct {
+}
+
+// Error formats output.
+func (*ErrEmptySelector) Error() string {
+	return "selector has no conditions"
+}
+
+// ErrNestingCycle defines groups cycle.
+type ErrNestingCycle struct {
+	Parent string
+	Child  string
+}
+
+// Error formats output.
+func (e *ErrNestingCycle) Error() string {
+	return fmt.Sprintf("group %s can't contain %s since it creates a cycle", e.Parent, e.Child)
+}
@@ -23,10 +23,13 @@ type provider struct {
 
 	internalID string
 
-	devicesExp  []glob.Glob
-	updatesChan chan *common.MsgDeviceUpdate
-	logger      common.ILoggerProvider
-	server      providers.IServerProvider
+	devicesExp     []glob.Glob
+	groupsExp      []glob.Glob
+	selectors      []*selector
+	excludedGroups []string
+	updatesChan    chan *common.MsgDeviceUpdate
+	logger         common.ILoggerProvider
+	server         providers.IServerProvider
 
 	devices   []*groupDevice
 	unmatched []string
@@ -38,8 +41,10 @@ type provider struct {
 
 // Groups settings.
 type settings struct {
-	Name    string   `yaml:"name"`
-	Devices []string `yaml:"devices"`
+	Name      string              `yaml:"name"`
+	Devices   []string            `yaml:"devices"`
+	Groups    []string            `yaml:"groups"`
+	Selectors []*selectorSettings `yaml:"selectors"`
 }
 
 // Single group device.
@@ -80,6 +85,8 @@ func NewGroupProvider(ctor *ConstructGroup) (providers.IGroupProvider, error) {
 	provider := &provider{
 		logger:     log,
 		devicesExp: make([]glob.Glob, 0),
+		groupsExp:  make([]glob.Glob, 0),
+		selectors:  make([]*selector, 0),
 		internalID: getID(settings.Name),
 		Name:       settings.Name,
 		devices:    make([]*groupDevice, 0),
@@ -99,6 +106,26 @@ func NewGroupProvider(ctor *ConstructGroup) (providers.IGroupProvider, error) {
 		provider.devicesExp = append(provider.devicesExp, exp)
 	}
 
+	for _, v := range settings.Groups {
+		exp, err := glob.Compile(v)
+		if err != nil {
+			provider.logger.Error("Failed to compile nested group regexp, skipping", err)
+			continue
+		}
+
+		provider.groupsExp = append(provider.groupsExp, exp)
+	}
+
+	for _, v := range settings.Selectors {
+		sel, err := newSelector(v)
+		if err != nil {
+			provider.logger.Error("Failed to parse group selector, skipping", err)
+			continue
+		}
+
+		provider.selectors = append(provider.selectors, sel)
+	}
+
 	fanOut := ctor.Settings.FanOut()
 	_, provider.updatesChan = fanOut.SubscribeDeviceUpdates()
 	go provider.deviceUpdates()
@@ -124,6 +151,43 @@ func (p *provider) Devices() []string {
 	return ids
 }
 
+// Nests checks whether group is configured to contain another group.
+func (p *provider) Nests(groupID string) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if groupID == p.internalID || helpers.SliceContainsString(p.excludedGroups, groupID) {
+		return false
+	}
+
+	for _, v := range p.groupsExp {
+		if v.Match(groupID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExcludeNested prevents group from containing another group.
+// Used for breaking nesting cycles.
+func (p *provider) ExcludeNested(groupID string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if helpers.SliceContainsString(p.excludedGroups, groupID) {
+		return
+	}
+
+	p.excludedGroups = append(p.excludedGroups, groupID)
+	for i, v := range p.devices {
+		if v.ID == groupID {
+			p.devices = append(p.devices[:i], p.devices[i+1:]...)
+			break
+		}
+	}
+}
+
 // InvokeCommand invokes commands on all group's devices.
 func (p *provider) InvokeCommand(cmd enums.Command, props map[string]interface{}) {
 	p.Lock()
@@ -155,31 +219,31 @@ func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
 	}
 
 	kd := p.server.GetDevice(msg.ID)
-	if nil == kd || kd.Type == enums.DevGroup {
+	if nil == kd {
 		return
 	}
 
-	found := false
-	for _, v := range p.devices {
+	found := -1
+	for i, v := range p.devices {
 		if v.ID == msg.ID {
-			copyState(v.State, msg.State)
-			v.Commands = kd.Commands
-			found = true
+			found = i
 			break
 		}
 	}
 
-	if !found {
-		matched := false
-		for _, v := range p.devicesExp {
-			if v.Match(msg.ID) {
-				matched = true
-				break
+	switch {
+	case found >= 0 && !p.isStatic(kd) && !p.isMatched(msg.ID, kd):
+		p.logger.Debug("Removing device from the group", common.LogIDToken, msg.ID)
+		p.devices = append(p.devices[:found], p.devices[found+1:]...)
+	case found >= 0:
+		copyState(p.devices[found].State, msg.State)
+		p.devices[found].Commands = kd.Commands
+	default:
+		if !p.isMatched(msg.ID, kd) {
+			if p.isStatic(kd) {
+				p.unmatched = append(p.unmatched, msg.ID)
 			}
-		}
 
-		if !matched {
-			p.unmatched = append(p.unmatched, msg.ID)
 			return
 		}
 
@@ -188,6 +252,7 @@ func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
 			return
 		}
 
+		p.logger.Debug("Adding device to the group", common.LogIDToken, msg.ID)
 		p.devices = append(p.devices, &groupDevice{
 			ID:       msg.ID,
 			Commands: kd.Commands,
@@ -208,13 +273,53 @@ func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
 	})
 }
 
+// Checks whether device belongs to the group.
+// Nested groups are matched only by groups expressions,
+// regular devices are matched either by ID or by dynamic selectors.
+func (p *provider) isMatched(deviceID string, kd *providers.KnownDevice) bool {
+	if kd.Type == enums.DevGroup {
+		if helpers.SliceContainsString(p.excludedGroups, deviceID) {
+			return false
+		}
+
+		for _, v := range p.groupsExp {
+			if v.Match(deviceID) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, v := range p.devicesExp {
+		if v.Match(deviceID) {
+			return true
+		}
+	}
+
+	for _, v := range p.selectors {
+		if v.Match(kd) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks whether device match result can't change over time.
+// Dynamic selectors depend on locations and properties, so such
+// devices have to be re-evaluated on every update.
+func (p *provider) isStatic(kd *providers.KnownDevice) bool {
+	return kd.Type == enums.DevGroup || 0 == len(p.selectors)
+}
+
 // Updates group state.
 func (p *provider) updateGroupState() {
+	p.State = make(map[string]interface{})
 	if 0 == len(p.devices) {
 		return
 	}
 
-	p.State = make(map[string]interface{})
 	for k, s := range p.devices[0].State {
 		found := true
 
@@ -237,6 +342,9 @@ func (p *provider) updateGroupState() {
 // Updates available commands.
 func (p *provider) updateGroupCommands() {
 	p.Commands = make([]string, 0)
+	if 0 == len(p.devices) {
+		return
+	}
 
 	for _, c := range p.devices[0].Commands {
 		found := true
@@ -1,6 +1,7 @@
 package group
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -144,3 +145,172 @@ devices: devices:
 	_, err := NewGroupProvider(ctor)
 	assert.Error(t, err)
 }
+
+// Creates a new group with the provided config.
+func newTestGroup(t *testing.T, config string, srv mocks.IFakeServer,
+	s providers.ISettingsProvider) providers.IGroupProvider {
+	ctor := &ConstructGroup{
+		Settings:  s,
+		Server:    srv.(providers.IServerProvider),
+		RawConfig: []byte(config),
+	}
+
+	g, err := NewGroupProvider(ctor)
+	assert.NoError(t, err)
+	return g
+}
+
+// Tests dynamic selectors.
+func TestSelectors(t *testing.T) {
+	var config = `
+system: device
+provider: group
+name: downstairs lights
+selectors:
+  - type: light
+    location: down*
+  - property: scenes
+    worker: worker-2
+`
+	s := mocks.FakeNewSettings(nil, false, nil, nil)
+	srv := mocks.FakeNewServer(nil)
+	g := newTestGroup(t, config, srv, s)
+
+	srv.AddDeviceWithID("light1", &providers.KnownDevice{
+		Type:      enums.DevLight,
+		Worker:    "worker-1",
+		Locations: []string{"downstairs"},
+	})
+	srv.AddDeviceWithID("light2", &providers.KnownDevice{
+		Type:      enums.DevLight,
+		Worker:    "worker-1",
+		Locations: []string{"upstairs"},
+	})
+	srv.AddDeviceWithID("light3", &providers.KnownDevice{
+		Type:       enums.DevLight,
+		Worker:     "worker-2",
+		Properties: []string{enums.PropScenes.String()},
+	})
+	srv.AddDeviceWithID("switch1", &providers.KnownDevice{
+		Type:      enums.DevSwitch,
+		Worker:    "worker-1",
+		Locations: []string{"downstairs"},
+	})
+
+	for _, v := range []string{"light1", "light2", "light3", "switch1"} {
+		s.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: v, FirstSeen: true}
+	}
+
+	time.Sleep(1 * time.Second)
+	devices := g.Devices()
+	assert.Equal(t, 2, len(devices), "wrong number of devices")
+	assert.Contains(t, devices, "light1")
+	assert.Contains(t, devices, "light3")
+
+	srv.AddDeviceWithID("light2", &providers.KnownDevice{
+		Type:      enums.DevLight,
+		Worker:    "worker-1",
+		Locations: []string{"downstairs"},
+	})
+	s.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: "light2"}
+
+	time.Sleep(1 * time.Second)
+	assert.Contains(t, g.Devices(), "light2", "device was not re-evaluated")
+
+	srv.AddDeviceWithID("light1", &providers.KnownDevice{
+		Type:      enums.DevLight,
+		Worker:    "worker-1",
+		Locations: []string{"upstairs"},
+	})
+	s.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: "light1"}
+
+	time.Sleep(1 * time.Second)
+	assert.NotContains(t, g.Devices(), "light1", "device was not removed")
+	assert.Equal(t, 2, len(g.Devices()), "wrong number of devices after removal")
+}
+
+// Tests wrong selectors.
+func TestWrongSelectors(t *testing.T) {
+	data := []*selectorSettings{
+		nil,
+		{},
+		{Type: "wrong"},
+		{Property: "wrong"},
+		{Location: "[a"},
+		{Worker: "[a"},
+	}
+
+	for _, v := range data {
+		_, err := newSelector(v)
+		assert.Error(t, err, "%+v", v)
+	}
+}
+
+// Tests nested groups.
+func TestNestedGroups(t *testing.T) {
+	var config = `
+system: device
+provider: group
+name: all lights
+devices:
+  - light1
+groups:
+  - group.*
+`
+	s := mocks.FakeNewSettings(nil, false, nil, nil)
+	srv := mocks.FakeNewServer(nil)
+	g := newTestGroup(t, config, srv, s)
+
+	srv.AddDeviceWithID("group.kitchen", &providers.KnownDevice{Type: enums.DevGroup})
+	srv.AddDeviceWithID("group.all_lights", &providers.KnownDevice{Type: enums.DevGroup})
+	srv.AddDeviceWithID("light1", &providers.KnownDevice{Type: enums.DevLight})
+	srv.AddDeviceWithID("group_light", &providers.KnownDevice{Type: enums.DevLight})
+
+	for _, v := range []string{"group.kitchen", "group.all_lights", "light1", "group_light"} {
+		s.FanOut().ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{ID: v, FirstSeen: true}
+	}
+
+	time.Sleep(1 * time.Second)
+	devices := g.Devices()
+	assert.Equal(t, 2, len(devices), "wrong number of devices")
+	assert.Contains(t, devices, "group.kitchen")
+	assert.Contains(t, devices, "light1")
+	assert.False(t, g.Nests("group.all_lights"), "group nests itself")
+	assert.True(t, g.Nests("group.kitchen"))
+
+	g.ExcludeNested("group.kitchen")
+	assert.False(t, g.Nests("group.kitchen"), "group was not excluded")
+	assert.Equal(t, []string{"light1"}, g.Devices(), "nested group was not removed")
+}
+
+// Tests nesting cycles detection.
+func TestNestingCycles(t *testing.T) {
+	var format = `
+system: device
+provider: group
+name: %s
+groups:
+  - %s
+`
+	s := mocks.FakeNewSettings(nil, false, nil, nil)
+	srv := mocks.FakeNewServer(nil)
+
+	groups := map[string]providers.IGroupProvider{
+		"group.a": newTestGroup(t, fmt.Sprintf(format, "a", "group.b"), srv, s),
+		"group.b": newTestGroup(t, fmt.Sprintf(format, "b", "group.c"), srv, s),
+		"group.c": newTestGroup(t, fmt.Sprintf(format, "c", "group.a"), srv, s),
+		"group.d": newTestGroup(t, fmt.Sprintf(format, "d", "group.[ab]"), srv, s),
+	}
+
+	errors := 0
+	BreakNestingCycles(groups, mocks.FakeNewLogger(func(string) {
+		errors++
+	}))
+
+	assert.Equal(t, 1, errors, "wrong number of cycles")
+	assert.True(t, groups["group.a"].Nests("group.b"))
+	assert.True(t, groups["group.b"].Nests("group.c"))
+	assert.False(t, groups["group.c"].Nests("group.a"), "cycle was not broken")
+	assert.True(t, groups["group.d"].Nests("group.a"))
+	assert.True(t, groups["group.d"].Nests("group.b"))
+}
@@ -0,0 +1,54 @@
+package group
+
+import (
+	"sort"
+
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+)
+
+// Nesting traversal states.
+const (
+	nodeNew = iota
+	nodeInProgress
+	nodeDone
+)
+
+// BreakNestingCycles validates groups hierarchy and removes
+// nested groups which are creating cycles.
+func BreakNestingCycles(groups map[string]providers.IGroupProvider, logger common.ILoggerProvider) {
+	ids := make([]string, 0, len(groups))
+	for k := range groups {
+		ids = append(ids, k)
+	}
+
+	sort.Strings(ids)
+	states := make(map[string]int, len(ids))
+
+	var visit func(string)
+	visit = func(parent string) {
+		states[parent] = nodeInProgress
+		for _, child := range ids {
+			if !groups[parent].Nests(child) {
+				continue
+			}
+
+			switch states[child] {
+			case nodeInProgress:
+				logger.Error("Nested group creates a cycle, ignoring",
+					&ErrNestingCycle{Parent: parent, Child: child}, common.LogIDToken, parent)
+				groups[parent].ExcludeNested(child)
+			case nodeNew:
+				visit(child)
+			}
+		}
+
+		states[parent] = nodeDone
+	}
+
+	for _, v := range ids {
+		if nodeNew == states[v] {
+			visit(v)
+		}
+	}
+}
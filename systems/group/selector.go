@@ -0,0 +1,107 @@
+package group
+
+import (
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+)
+
+// Dynamic group membership settings.
+// All defined fields must match.
+type selectorSettings struct {
+	Type     string `yaml:"type"`
+	Location string `yaml:"location"`
+	Property string `yaml:"property"`
+	Worker   string `yaml:"worker"`
+}
+
+// Pre-compiled dynamic selector.
+type selector struct {
+	deviceType enums.DeviceType
+	property   enums.Property
+	location   glob.Glob
+	worker     glob.Glob
+
+	checkType     bool
+	checkProperty bool
+}
+
+// Creates a new selector from the settings.
+func newSelector(settings *selectorSettings) (*selector, error) {
+	if nil == settings || ("" == settings.Type && "" == settings.Location &&
+		"" == settings.Property && "" == settings.Worker) {
+		return nil, &ErrEmptySelector{}
+	}
+
+	sel := &selector{}
+
+	if "" != settings.Type {
+		t, err := enums.DeviceTypeString(settings.Type)
+		if err != nil {
+			return nil, errors.Wrap(err, "unknown device type")
+		}
+
+		sel.deviceType = t
+		sel.checkType = true
+	}
+
+	if "" != settings.Property {
+		prop, err := enums.PropertyString(settings.Property)
+		if err != nil {
+			return nil, errors.Wrap(err, "unknown property")
+		}
+
+		sel.property = prop
+		sel.checkProperty = true
+	}
+
+	var err error
+	if "" != settings.Location {
+		sel.location, err = glob.Compile(settings.Location)
+		if err != nil {
+			return nil, errors.Wrap(err, "location regexp compile failed")
+		}
+	}
+
+	if "" != settings.Worker {
+		sel.worker, err = glob.Compile(settings.Worker)
+		if err != nil {
+			return nil, errors.Wrap(err, "worker regexp compile failed")
+		}
+	}
+
+	return sel, nil
+}
+
+// Match checks whether device satisfies the selector.
+func (s *selector) Match(kd *providers.KnownDevice) bool {
+	if s.checkType && kd.Type != s.deviceType {
+		return false
+	}
+
+	if s.checkProperty && !helpers.SliceContainsString(kd.Properties, s.property.String()) {
+		return false
+	}
+
+	if nil != s.worker && !s.worker.Match(kd.Worker) {
+		return false
+	}
+
+	if nil != s.location {
+		found := false
+		for _, v := range kd.Locations {
+			if s.location.Match(v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,43 @@
+package helper
+
+import "fmt"
+
+// ErrInvalidSettings defines invalid helper settings error.
+type ErrInvalidSettings struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrInvalidSettings) Error() string {
+	return fmt.Sprintf("helper %s has invalid settings", e.Name)
+}
+
+// ErrUnknownType defines unknown helper type error.
+type ErrUnknownType struct {
+	Type string
+}
+
+// Error formats output.
+func (e *ErrUnknownType) Error() string {
+	return fmt.Sprintf("helper type %s is unknown", e.Type)
+}
+
+// ErrUnsupportedCommand defines unsupported command error.
+type ErrUnsupportedCommand struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnsupportedCommand) Error() string {
+	return fmt.Sprintf("command %s is not supported", e.Name)
+}
+
+// ErrInvalidValue defines invalid helper value error.
+type ErrInvalidValue struct {
+	Value string
+}
+
+// Error formats output.
+func (e *ErrInvalidValue) Error() string {
+	return fmt.Sprintf("value %s is invalid", e.Value)
+}
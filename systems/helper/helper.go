@@ -0,0 +1,409 @@
+// Package helper contains input helper entities provider.
+package helper
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Commands supported by each helper type.
+var supportedCommands = map[helperType][]enums.Command{
+	helperBoolean: {enums.CmdOn, enums.CmdOff, enums.CmdToggle, enums.CmdSetValue},
+	helperNumber:  {enums.CmdSetValue, enums.CmdIncrement, enums.CmdDecrement},
+	helperSelect:  {enums.CmdSetValue, enums.CmdIncrement, enums.CmdDecrement},
+	helperText:    {enums.CmdSetValue},
+	helperTimer:   {enums.CmdStart, enums.CmdPause, enums.CmdCancel},
+}
+
+// Implements input helper provider.
+type provider struct {
+	sync.Mutex
+
+	internalID string
+	name       string
+	kind       helperType
+	settings   *settings
+	state      *state
+
+	timer      *time.Timer
+	deadline   time.Time
+	generation int
+
+	logger common.ILoggerProvider
+	server providers.IServerProvider
+	store  providers.IPersistentStoreProvider
+}
+
+// ConstructHelper has data required for instantiating a new input helper.
+type ConstructHelper struct {
+	RawConfig []byte
+	Settings  providers.ISettingsProvider
+	Server    providers.IServerProvider
+	Store     providers.IPersistentStoreProvider
+}
+
+// NewHelperProvider creates a new input helper provider.
+func NewHelperProvider(ctor *ConstructHelper) (providers.IHelperProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Settings.SystemLogger().Error("Failed to load helper", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !ctor.Settings.Validator().Validate(settings) {
+		return nil, &ErrInvalidSettings{Name: settings.Name}
+	}
+
+	kind, err := helperTypeString(settings.Type)
+	if err != nil {
+		return nil, &ErrUnknownType{Type: settings.Type}
+	}
+
+	p := &provider{
+		internalID: getID(settings.Name),
+		name:       settings.Name,
+		kind:       kind,
+		settings:   settings,
+		server:     ctor.Server,
+		store:      ctor.Store,
+	}
+
+	p.state, err = p.initialState()
+	if err != nil {
+		return nil, err
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Settings.PluginLogger(),
+		Provider:     systems.SysDevice.String(),
+		System:       "helper",
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   p.internalID,
+		},
+	}
+	p.logger = logger.NewPluginLogger(logCtor)
+
+	p.restore()
+	p.pushState()
+	return p, nil
+}
+
+// ID returns helper device ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// InvokeCommand invokes helper command.
+func (p *provider) InvokeCommand(cmd enums.Command, data map[string]interface{}) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if !enums.SliceContainsCommand(supportedCommands[p.kind], cmd) {
+		return &ErrUnsupportedCommand{Name: cmd.String()}
+	}
+
+	var err error
+	switch p.kind {
+	case helperBoolean:
+		err = p.invokeBoolean(cmd, data)
+	case helperNumber:
+		err = p.invokeNumber(cmd, data)
+	case helperSelect:
+		err = p.invokeSelect(cmd, data)
+	case helperText:
+		err = p.invokeText(data)
+	case helperTimer:
+		err = p.invokeTimer(cmd, data)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	p.save()
+	p.pushState()
+	return nil
+}
+
+// Builds initial state from settings.
+func (p *provider) initialState() (*state, error) {
+	s := &state{}
+	switch p.kind {
+	case helperBoolean:
+		if nil != p.settings.Initial {
+			on, ok := p.settings.Initial.(bool)
+			if !ok {
+				return nil, &ErrInvalidSettings{Name: p.settings.Name}
+			}
+
+			s.On = on
+		}
+	case helperNumber:
+		if nil != p.settings.Min && nil != p.settings.Max && *p.settings.Min > *p.settings.Max {
+			return nil, &ErrInvalidSettings{Name: p.settings.Name}
+		}
+
+		if nil != p.settings.Initial {
+			val, ok := toFloat(p.settings.Initial)
+			if !ok || !p.inRange(val) {
+				return nil, &ErrInvalidSettings{Name: p.settings.Name}
+			}
+
+			s.Value = val
+		} else {
+			s.Value = p.clamp(0)
+		}
+	case helperSelect:
+		if 0 == len(p.settings.Options) {
+			return nil, &ErrInvalidSettings{Name: p.settings.Name}
+		}
+
+		s.Option = p.settings.Options[0]
+		if nil != p.settings.Initial {
+			option, ok := p.settings.Initial.(string)
+			if !ok || !helpers.SliceContainsString(p.settings.Options, option) {
+				return nil, &ErrInvalidSettings{Name: p.settings.Name}
+			}
+
+			s.Option = option
+		}
+	case helperText:
+		if nil != p.settings.Initial {
+			text, ok := p.settings.Initial.(string)
+			if !ok || len(text) > p.settings.MaxLength {
+				return nil, &ErrInvalidSettings{Name: p.settings.Name}
+			}
+
+			s.Text = text
+		}
+	case helperTimer:
+		s.Status = timerIdle
+	}
+
+	return s, nil
+}
+
+// Restores persisted state, ignoring values which no longer match settings.
+func (p *provider) restore() {
+	stored := &state{}
+	if !p.store.Load(p.internalID, stored) {
+		return
+	}
+
+	switch p.kind {
+	case helperBoolean:
+		p.state.On = stored.On
+	case helperNumber:
+		if p.inRange(stored.Value) {
+			p.state.Value = stored.Value
+		}
+	case helperSelect:
+		if helpers.SliceContainsString(p.settings.Options, stored.Option) {
+			p.state.Option = stored.Option
+		}
+	case helperText:
+		if len(stored.Text) <= p.settings.MaxLength {
+			p.state.Text = stored.Text
+		}
+	case helperTimer:
+		p.restoreTimer(stored)
+	}
+}
+
+// Processes boolean commands.
+func (p *provider) invokeBoolean(cmd enums.Command, data map[string]interface{}) error {
+	switch cmd {
+	case enums.CmdOn:
+		p.state.On = true
+	case enums.CmdOff:
+		p.state.On = false
+	case enums.CmdToggle:
+		p.state.On = !p.state.On
+	default:
+		on, ok := getValue(data).(bool)
+		if !ok {
+			return &ErrInvalidValue{Value: fmt.Sprintf("%v", getValue(data))}
+		}
+
+		p.state.On = on
+	}
+
+	return nil
+}
+
+// Processes number commands.
+// Increment and decrement are clamped to the configured range.
+func (p *provider) invokeNumber(cmd enums.Command, data map[string]interface{}) error {
+	if enums.CmdSetValue == cmd {
+		val, ok := toFloat(getValue(data))
+		if !ok || !p.inRange(val) {
+			return &ErrInvalidValue{Value: fmt.Sprintf("%v", getValue(data))}
+		}
+
+		p.state.Value = val
+		return nil
+	}
+
+	step := p.settings.Step
+	if nil != getValue(data) {
+		val, ok := toFloat(getValue(data))
+		if !ok {
+			return &ErrInvalidValue{Value: fmt.Sprintf("%v", getValue(data))}
+		}
+
+		step = val
+	}
+
+	if enums.CmdDecrement == cmd {
+		step = -step
+	}
+
+	p.state.Value = p.clamp(p.state.Value + step)
+	return nil
+}
+
+// Processes select commands.
+// Increment and decrement cycle through options.
+func (p *provider) invokeSelect(cmd enums.Command, data map[string]interface{}) error {
+	if enums.CmdSetValue == cmd {
+		option, ok := getValue(data).(string)
+		if !ok || !helpers.SliceContainsString(p.settings.Options, option) {
+			return &ErrInvalidValue{Value: fmt.Sprintf("%v", getValue(data))}
+		}
+
+		p.state.Option = option
+		return nil
+	}
+
+	index := 0
+	for i, v := range p.settings.Options {
+		if v == p.state.Option {
+			index = i
+			break
+		}
+	}
+
+	shift := 1
+	if enums.CmdDecrement == cmd {
+		shift = -1
+	}
+
+	total := len(p.settings.Options)
+	p.state.Option = p.settings.Options[(index+shift+total)%total]
+	return nil
+}
+
+// Processes text commands.
+func (p *provider) invokeText(data map[string]interface{}) error {
+	text, ok := getValue(data).(string)
+	if !ok || len(text) > p.settings.MaxLength {
+		return &ErrInvalidValue{Value: fmt.Sprintf("%v", getValue(data))}
+	}
+
+	p.state.Text = text
+	return nil
+}
+
+// Checks whether value is within configured range.
+func (p *provider) inRange(val float64) bool {
+	return p.clamp(val) == val
+}
+
+// Fits value into configured range.
+func (p *provider) clamp(val float64) float64 {
+	if nil != p.settings.Min {
+		val = math.Max(val, *p.settings.Min)
+	}
+
+	if nil != p.settings.Max {
+		val = math.Min(val, *p.settings.Max)
+	}
+
+	return val
+}
+
+// Persists current state.
+func (p *provider) save() {
+	p.store.Save(p.internalID, p.state)
+}
+
+// Pushes helper device state.
+func (p *provider) pushState() {
+	state := map[string]interface{}{
+		enums.PropHelperType.String(): p.kind.String(),
+	}
+
+	switch p.kind {
+	case helperBoolean:
+		state[enums.PropOn.String()] = p.state.On
+	case helperNumber:
+		state[enums.PropValue.String()] = p.state.Value
+	case helperSelect:
+		state[enums.PropOption.String()] = p.state.Option
+		state[enums.PropOptions.String()] = p.settings.Options
+	case helperText:
+		state[enums.PropText.String()] = p.state.Text
+	case helperTimer:
+		state[enums.PropTimerStatus.String()] = p.state.Status
+		state[enums.PropRemaining.String()] = p.state.Remaining
+		state[enums.PropFinishesAt.String()] = p.state.FinishesAt
+		state[enums.PropDuration.String()] = p.settings.Duration
+	}
+
+	commands := make([]string, 0)
+	for _, v := range supportedCommands[p.kind] {
+		commands = append(commands, v.String())
+	}
+
+	p.server.PushMasterDeviceUpdate(&providers.MasterDeviceUpdate{
+		Type:     enums.DevHelper,
+		Name:     p.name,
+		ID:       p.internalID,
+		Commands: commands,
+		State:    state,
+	})
+}
+
+// Returns command value.
+func getValue(data map[string]interface{}) interface{} {
+	if nil == data {
+		return nil
+	}
+
+	return data["value"]
+}
+
+// Converts numeric value into float.
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("helper.%s", utils.NormalizeDeviceName(name))
+}
@@ -0,0 +1,207 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+// Creates a new helper provider.
+func getHelper(t *testing.T, config string, srv mocks.IFakeServer,
+	store providers.IPersistentStoreProvider) providers.IHelperProvider {
+	ctor := &ConstructHelper{
+		RawConfig: []byte(config),
+		Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+		Server:    srv.(providers.IServerProvider),
+		Store:     store,
+	}
+
+	h, err := NewHelperProvider(ctor)
+	require.NoError(t, err)
+	return h
+}
+
+// Returns last pushed property.
+func lastState(srv mocks.IFakeServer, prop enums.Property) interface{} {
+	return srv.GetLastMasterUpdate().State[prop.String()]
+}
+
+// Tests boolean helper.
+func TestBoolean(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	h := getHelper(t, "name: guest mode\ntype: boolean", srv, mocks.FakeNewPersistentStore())
+
+	assert.Equal(t, "helper.guest_mode", h.ID())
+	assert.Equal(t, enums.DevHelper, srv.GetLastMasterUpdate().Type)
+	assert.Equal(t, "boolean", lastState(srv, enums.PropHelperType))
+	assert.Equal(t, false, lastState(srv, enums.PropOn))
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdToggle, nil))
+	assert.Equal(t, true, lastState(srv, enums.PropOn))
+	assert.NoError(t, h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": false}))
+	assert.Equal(t, false, lastState(srv, enums.PropOn))
+
+	_, ok := h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": "on"}).(*ErrInvalidValue)
+	assert.True(t, ok, "wrong value")
+	_, ok = h.InvokeCommand(enums.CmdStart, nil).(*ErrUnsupportedCommand)
+	assert.True(t, ok, "unsupported command")
+}
+
+// Tests number helper.
+func TestNumber(t *testing.T) {
+	config := `
+name: counter
+type: number
+min: 0
+max: 10
+step: 2
+initial: 5
+`
+	srv := mocks.FakeNewServer(nil)
+	h := getHelper(t, config, srv, mocks.FakeNewPersistentStore())
+	assert.Equal(t, 5.0, lastState(srv, enums.PropValue))
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdIncrement, nil))
+	assert.Equal(t, 7.0, lastState(srv, enums.PropValue))
+	assert.NoError(t, h.InvokeCommand(enums.CmdIncrement, map[string]interface{}{"value": 5}))
+	assert.Equal(t, 10.0, lastState(srv, enums.PropValue), "clamped")
+	assert.NoError(t, h.InvokeCommand(enums.CmdDecrement, nil))
+	assert.Equal(t, 8.0, lastState(srv, enums.PropValue))
+	assert.NoError(t, h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": 1.5}))
+	assert.Equal(t, 1.5, lastState(srv, enums.PropValue))
+
+	_, ok := h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": 11}).(*ErrInvalidValue)
+	assert.True(t, ok, "out of range")
+}
+
+// Tests select helper.
+func TestSelect(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	h := getHelper(t, "name: scene\ntype: select\noptions: [ day, evening, night ]",
+		srv, mocks.FakeNewPersistentStore())
+	assert.Equal(t, "day", lastState(srv, enums.PropOption))
+	assert.Equal(t, []string{"day", "evening", "night"}, lastState(srv, enums.PropOptions))
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdDecrement, nil))
+	assert.Equal(t, "night", lastState(srv, enums.PropOption), "cycled")
+	assert.NoError(t, h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": "evening"}))
+	assert.Equal(t, "evening", lastState(srv, enums.PropOption))
+
+	_, ok := h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": "morning"}).(*ErrInvalidValue)
+	assert.True(t, ok, "unknown option")
+}
+
+// Tests text helper.
+func TestText(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	h := getHelper(t, "name: note\ntype: text\nmaxLength: 5", srv, mocks.FakeNewPersistentStore())
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": "hello"}))
+	assert.Equal(t, "hello", lastState(srv, enums.PropText))
+
+	_, ok := h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": "too long"}).(*ErrInvalidValue)
+	assert.True(t, ok, "too long")
+}
+
+// Tests timer helper.
+func TestTimer(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	h := getHelper(t, "name: fan\ntype: timer\nduration: 1", srv, mocks.FakeNewPersistentStore())
+	assert.Equal(t, timerIdle, lastState(srv, enums.PropTimerStatus))
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdStart, nil))
+	assert.Equal(t, timerActive, lastState(srv, enums.PropTimerStatus))
+	assert.Equal(t, 1, lastState(srv, enums.PropRemaining))
+	assert.InDelta(t, time.Now().Add(time.Second).Unix(), lastState(srv, enums.PropFinishesAt), 1)
+
+	time.Sleep(1200 * time.Millisecond)
+	assert.Equal(t, timerIdle, lastState(srv, enums.PropTimerStatus), "finished")
+	assert.Equal(t, int64(0), lastState(srv, enums.PropFinishesAt), "finished")
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdStart, map[string]interface{}{"value": 10}))
+	assert.NoError(t, h.InvokeCommand(enums.CmdPause, nil))
+	assert.Equal(t, timerPaused, lastState(srv, enums.PropTimerStatus))
+	assert.Equal(t, 10, lastState(srv, enums.PropRemaining))
+	assert.Equal(t, int64(0), lastState(srv, enums.PropFinishesAt), "paused")
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdStart, nil))
+	assert.Equal(t, timerActive, lastState(srv, enums.PropTimerStatus), "resumed")
+	assert.Equal(t, 10, lastState(srv, enums.PropRemaining))
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdCancel, nil))
+	assert.Equal(t, timerIdle, lastState(srv, enums.PropTimerStatus))
+	assert.Equal(t, 0, lastState(srv, enums.PropRemaining))
+}
+
+// Tests that cancelled timer doesn't fire.
+func TestTimerCancel(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	h := getHelper(t, "name: fan\ntype: timer\nduration: 1", srv, mocks.FakeNewPersistentStore())
+
+	assert.NoError(t, h.InvokeCommand(enums.CmdStart, nil))
+	assert.NoError(t, h.InvokeCommand(enums.CmdCancel, nil))
+	assert.NoError(t, h.InvokeCommand(enums.CmdStart, map[string]interface{}{"value": 3}))
+
+	time.Sleep(1200 * time.Millisecond)
+	assert.Equal(t, timerActive, lastState(srv, enums.PropTimerStatus))
+}
+
+// Tests persistence.
+func TestPersistence(t *testing.T) {
+	store := mocks.FakeNewPersistentStore()
+	h := getHelper(t, "name: counter\ntype: number", mocks.FakeNewServer(nil), store)
+	require.NoError(t, h.InvokeCommand(enums.CmdSetValue, map[string]interface{}{"value": 42}))
+
+	srv := mocks.FakeNewServer(nil)
+	getHelper(t, "name: counter\ntype: number", srv, store)
+	assert.Equal(t, 42.0, lastState(srv, enums.PropValue), "restored")
+
+	srv = mocks.FakeNewServer(nil)
+	getHelper(t, "name: counter\ntype: number\nmax: 10", srv, store)
+	assert.Equal(t, 0.0, lastState(srv, enums.PropValue), "stored value is out of range")
+
+	h = getHelper(t, "name: fan\ntype: timer", mocks.FakeNewServer(nil), store)
+	require.NoError(t, h.InvokeCommand(enums.CmdStart, map[string]interface{}{"value": 100}))
+
+	srv = mocks.FakeNewServer(nil)
+	getHelper(t, "name: fan\ntype: timer", srv, store)
+	assert.Equal(t, timerActive, lastState(srv, enums.PropTimerStatus), "timer restored")
+	assert.InDelta(t, 100, lastState(srv, enums.PropRemaining), 1)
+
+	store.Save("helper.fan", &state{Status: timerActive, FinishesAt: time.Now().Add(-time.Minute).Unix()})
+	srv = mocks.FakeNewServer(nil)
+	getHelper(t, "name: fan\ntype: timer", srv, store)
+	assert.Equal(t, timerIdle, lastState(srv, enums.PropTimerStatus), "timer finished while down")
+}
+
+// Tests wrong config.
+func TestWrongSettings(t *testing.T) {
+	data := []string{
+		"ad",
+		"name: h1",
+		"name: h1\ntype: unknown",
+		"name: h1\ntype: boolean\ninitial: 1",
+		"name: h1\ntype: number\nmin: 10\nmax: 1",
+		"name: h1\ntype: number\nmax: 1\ninitial: 5",
+		"name: h1\ntype: select",
+		"name: h1\ntype: select\noptions: [ a ]\ninitial: b",
+		"name: h1\ntype: text\nmaxLength: 1\ninitial: ab",
+	}
+
+	for _, v := range data {
+		ctor := &ConstructHelper{
+			RawConfig: []byte(v),
+			Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+			Server:    mocks.FakeNewServer(nil).(providers.IServerProvider),
+			Store:     mocks.FakeNewPersistentStore(),
+		}
+
+		_, err := NewHelperProvider(ctor)
+		assert.Error(t, err, v)
+	}
+}
@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go-home.io/x/server/plugins/device/enums"
+)
+
+// Processes timer commands.
+// Start without a value resumes paused timer or starts it with the configured duration.
+func (p *provider) invokeTimer(cmd enums.Command, data map[string]interface{}) error {
+	switch cmd {
+	case enums.CmdStart:
+		seconds := p.settings.Duration
+		if nil != getValue(data) {
+			val, ok := toFloat(getValue(data))
+			if !ok {
+				return &ErrInvalidValue{Value: fmt.Sprintf("%v", getValue(data))}
+			}
+
+			seconds = int(val)
+		} else if timerPaused == p.state.Status {
+			seconds = p.state.Remaining
+		}
+
+		if seconds <= 0 {
+			return &ErrInvalidValue{Value: fmt.Sprintf("%d", seconds)}
+		}
+
+		p.startTimer(time.Duration(seconds) * time.Second)
+	case enums.CmdPause:
+		if timerActive != p.state.Status {
+			return nil
+		}
+
+		remaining := p.remaining()
+		p.stopTimer()
+		p.state.Status = timerPaused
+		p.state.Remaining = remaining
+	default:
+		p.stopTimer()
+		p.state.Status = timerIdle
+		p.state.Remaining = 0
+	}
+
+	return nil
+}
+
+// Restores persisted timer.
+// Timer which finished while master was down becomes idle.
+func (p *provider) restoreTimer(stored *state) {
+	switch stored.Status {
+	case timerPaused:
+		p.state.Status = timerPaused
+		p.state.Remaining = stored.Remaining
+	case timerActive:
+		left := time.Until(time.Unix(stored.FinishesAt, 0))
+		if left <= 0 {
+			p.logger.Info("Timer finished while master was down")
+			p.save()
+			return
+		}
+
+		p.startTimer(left)
+	}
+}
+
+// Starts countdown.
+func (p *provider) startTimer(duration time.Duration) {
+	p.stopTimer()
+
+	p.deadline = time.Now().Add(duration)
+	p.state.Status = timerActive
+	p.state.Remaining = p.remaining()
+	p.state.FinishesAt = p.deadline.Unix()
+
+	generation := p.generation
+	p.timer = time.AfterFunc(duration, func() {
+		p.finishTimer(generation)
+	})
+}
+
+// Stops countdown.
+func (p *provider) stopTimer() {
+	p.generation++
+	p.state.FinishesAt = 0
+	if nil != p.timer {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// Marks timer as finished.
+// Generation prevents outdated callbacks from resetting restarted timer.
+func (p *provider) finishTimer(generation int) {
+	p.Lock()
+	defer p.Unlock()
+
+	if generation != p.generation {
+		return
+	}
+
+	p.timer = nil
+	p.state.Status = timerIdle
+	p.state.Remaining = 0
+	p.state.FinishesAt = 0
+	p.logger.Info("Timer finished")
+
+	p.save()
+	p.pushState()
+}
+
+// Returns remaining seconds.
+func (p *provider) remaining() int {
+	return int(math.Ceil(time.Until(p.deadline).Seconds()))
+}
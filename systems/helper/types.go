@@ -0,0 +1,52 @@
+//go:generate enumer -type=helperType -transform=kebab -trimprefix=helper -json -text -yaml
+
+package helper
+
+// helperType describes known input helper types.
+type helperType int
+
+const (
+	// helperBoolean describes on/off flag.
+	helperBoolean helperType = iota
+	// helperNumber describes numeric value.
+	helperNumber
+	// helperSelect describes one of the pre-defined options.
+	helperSelect
+	// helperText describes free text value.
+	helperText
+	// helperTimer describes countdown timer.
+	helperTimer
+)
+
+const (
+	// Timer is not running.
+	timerIdle = "idle"
+	// Timer is counting down.
+	timerActive = "active"
+	// Timer is paused.
+	timerPaused = "paused"
+)
+
+// Helper settings.
+type settings struct {
+	Name      string      `yaml:"name" validate:"required"`
+	Type      string      `yaml:"type" validate:"required"`
+	Initial   interface{} `yaml:"initial"`
+	Min       *float64    `yaml:"min"`
+	Max       *float64    `yaml:"max"`
+	Step      float64     `yaml:"step" validate:"gt=0" default:"1"`
+	Options   []string    `yaml:"options"`
+	MaxLength int         `yaml:"maxLength" validate:"gt=0" default:"255"`
+	Duration  int         `yaml:"duration" validate:"gte=0"`
+}
+
+// Persisted helper state.
+type state struct {
+	On         bool    `yaml:"on,omitempty"`
+	Value      float64 `yaml:"value,omitempty"`
+	Option     string  `yaml:"option,omitempty"`
+	Text       string  `yaml:"text,omitempty"`
+	Status     string  `yaml:"status,omitempty"`
+	Remaining  int     `yaml:"remaining,omitempty"`
+	FinishesAt int64   `yaml:"finishesAt,omitempty"`
+}
@@ -0,0 +1,44 @@
+package metrics
+
+// Known metrics.
+var (
+	// BusMessages counts service bus messages.
+	BusMessages = NewCounter("gohome_bus_messages_total",
+		"Service bus messages by direction, channel and type.", "direction", "channel", "type")
+	// BusMessagesDiscarded counts messages discarded because of TTL.
+	BusMessagesDiscarded = NewCounter("gohome_bus_messages_discarded_total",
+		"Service bus messages discarded because they are too old.", "type")
+
+	// FanOutSubscribers shows number of fan-out subscribers.
+	FanOutSubscribers = NewGauge("gohome_fanout_subscribers",
+		"Number of internal fan-out subscribers.", "stream")
+	// FanOutQueueDepth shows total number of queued fan-out messages.
+	FanOutQueueDepth = NewGauge("gohome_fanout_queue_depth",
+		"Total number of messages queued for internal fan-out subscribers.", "stream")
+	// FanOutQueueMaxDepth shows the longest fan-out subscriber queue.
+	FanOutQueueMaxDepth = NewGauge("gohome_fanout_queue_max_depth",
+		"The longest internal fan-out subscriber queue.", "stream")
+
+	// DeviceUpdates counts device state updates.
+	DeviceUpdates = NewCounter("gohome_device_updates_total",
+		"Device state updates by device type.", "type")
+	// DeviceCommandDuration tracks device command execution time.
+	DeviceCommandDuration = NewHistogram("gohome_device_command_duration_seconds",
+		"Device command execution time.", "command")
+
+	// PluginLoadDuration tracks plugin load time.
+	PluginLoadDuration = NewHistogram("gohome_plugin_load_duration_seconds",
+		"Plugin load time, including download.", "system", "provider", "status")
+
+	// StorageWriteDuration tracks state storage write time.
+	StorageWriteDuration = NewHistogram("gohome_storage_write_duration_seconds",
+		"State storage write time.", "operation")
+
+	// TriggerFired counts fired triggers.
+	TriggerFired = NewCounter("gohome_trigger_fired_total",
+		"Triggers which invoked their actions.", "trigger")
+
+	// AuthFailures counts failed API authentications.
+	AuthFailures = NewCounter("gohome_auth_failures_total",
+		"Failed API authentication attempts.", "reason")
+)
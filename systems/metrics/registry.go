@@ -0,0 +1,288 @@
+// Package metrics contains internal metrics registry.
+// Metrics are exposed in Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	// ContentType is Prometheus text format content type.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Default histogram buckets, in seconds.
+var defaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry of all known metrics.
+var registry = &metricsRegistry{
+	families:   make(map[string]*family),
+	collectors: make(map[string]func()),
+}
+
+// Metrics registry.
+type metricsRegistry struct {
+	sync.Mutex
+	families   map[string]*family
+	collectors map[string]func()
+}
+
+// Single metric with all label values.
+type family struct {
+	sync.Mutex
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// Single metric value.
+type series struct {
+	labels  []string
+	value   float64
+	counts  []uint64
+	sum     float64
+	samples uint64
+}
+
+// Counter is a monotonically increasing metric.
+type Counter struct {
+	f *family
+}
+
+// Gauge is a metric which can go up and down.
+type Gauge struct {
+	f *family
+}
+
+// Histogram samples durations into buckets.
+type Histogram struct {
+	f *family
+}
+
+// Registers a new metric family.
+func newFamily(name string, help string, typ string, labels []string) *family {
+	f := &family{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.families[name] = f
+	return f
+}
+
+// Returns series for label values. Caller should hold the lock.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d labels", f.name, len(f.labels)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), values...)}
+		if typeHistogram == f.typ {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// NewCounter registers a new counter.
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return &Counter{f: newFamily(name, help, typeCounter, labels)}
+}
+
+// Inc increments counter by one.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increments counter by the value.
+func (c *Counter) Add(value float64, values ...string) {
+	c.f.Lock()
+	defer c.f.Unlock()
+	c.f.get(values).value += value
+}
+
+// NewGauge registers a new gauge.
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{f: newFamily(name, help, typeGauge, labels)}
+}
+
+// Set sets gauge value.
+func (g *Gauge) Set(value float64, values ...string) {
+	g.f.Lock()
+	defer g.f.Unlock()
+	g.f.get(values).value = value
+}
+
+// NewHistogram registers a new histogram with default buckets.
+func NewHistogram(name string, help string, labels ...string) *Histogram {
+	f := newFamily(name, help, typeHistogram, labels)
+	f.buckets = defaultBuckets
+	return &Histogram{f: f}
+}
+
+// Observe adds a new sample.
+func (h *Histogram) Observe(value float64, values ...string) {
+	h.f.Lock()
+	defer h.f.Unlock()
+
+	s := h.f.get(values)
+	s.sum += value
+	s.samples++
+	for i, v := range h.f.buckets {
+		if value <= v {
+			s.counts[i]++
+		}
+	}
+}
+
+// Since adds duration from the start time as a new sample.
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// AddCollector registers function which is invoked before every metrics export.
+// It's used for metrics which are cheaper to calculate on demand, e.g. queue depths.
+// Collector with the same name is replaced.
+func AddCollector(name string, collect func()) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.collectors[name] = collect
+}
+
+// Write exports all metrics in Prometheus text format.
+func Write(w io.Writer) error {
+	registry.Lock()
+	collectors := make([]func(), 0, len(registry.collectors))
+	for _, v := range registry.collectors {
+		collectors = append(collectors, v)
+	}
+
+	families := make([]*family, 0, len(registry.families))
+	for _, v := range registry.families {
+		families = append(families, v)
+	}
+	registry.Unlock()
+
+	for _, v := range collectors {
+		v()
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	buf := bufio.NewWriter(w)
+	for _, v := range families {
+		v.write(buf)
+	}
+
+	return buf.Flush()
+}
+
+// Handler returns HTTP handler exporting metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", ContentType)
+		Write(writer) // nolint: gosec, errcheck
+	})
+}
+
+// Writes single metric family.
+func (f *family) write(w *bufio.Writer) {
+	f.Lock()
+	defer f.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.series[k]
+		if typeHistogram != f.typ {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, f.formatLabels(s.labels, "", ""), formatValue(s.value))
+			continue
+		}
+
+		for i, b := range f.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(s.labels, "le", formatValue(b)), s.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(s.labels, "le", "+Inf"), s.samples)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.formatLabels(s.labels, "", ""), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.formatLabels(s.labels, "", ""), s.samples)
+	}
+}
+
+// Formats labels with optional extra label.
+func (f *family) formatLabels(values []string, extraName string, extraValue string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, f.labels[i], escapeLabel(v)))
+	}
+
+	if "" != extraName {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+
+	if 0 == len(pairs) {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Formats float value.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Escapes help text.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// Escapes label value.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns exported metrics.
+func export(t *testing.T) string {
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf))
+	return buf.String()
+}
+
+// Tests counters and gauges export.
+func TestCounterAndGauge(t *testing.T) {
+	c := NewCounter("test_counter_total", "Test\ncounter.", "name")
+	c.Inc("a")
+	c.Add(2.5, "a")
+	c.Inc(`b"\`)
+
+	g := NewGauge("test_gauge", "Test gauge.")
+	g.Set(3)
+	g.Set(-1)
+
+	out := export(t)
+	assert.Contains(t, out, "# HELP test_counter_total Test\\ncounter.\n# TYPE test_counter_total counter\n")
+	assert.Contains(t, out, `test_counter_total{name="a"} 3.5`+"\n")
+	assert.Contains(t, out, `test_counter_total{name="b\"\\"} 1`+"\n")
+	assert.Contains(t, out, "# TYPE test_gauge gauge\ntest_gauge -1\n")
+	assert.True(t, strings.Index(out, "test_counter_total") < strings.Index(out, "test_gauge"), "sorted")
+
+	assert.Panics(t, func() {
+		c.Inc()
+	}, "wrong labels")
+}
+
+// Tests histograms export.
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("test_duration_seconds", "Test histogram.", "op")
+	h.Observe(0.003, "write")
+	h.Observe(0.2, "write")
+	h.Observe(20, "write")
+
+	out := export(t)
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="write",le="0.005"} 1`)
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="write",le="0.25"} 2`)
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="write",le="10"} 2`)
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="write",le="+Inf"} 3`)
+	assert.Contains(t, out, `test_duration_seconds_sum{op="write"} 20.203`)
+	assert.Contains(t, out, `test_duration_seconds_count{op="write"} 3`)
+}
+
+// Tests collectors and HTTP handler.
+func TestCollectorsAndHandler(t *testing.T) {
+	g := NewGauge("test_collected", "Test collected gauge.")
+	value := 0.0
+	AddCollector("test", func() {
+		value++
+		g.Set(value)
+	})
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "test_collected 1\n")
+	assert.Contains(t, export(t), "test_collected 2\n")
+}
@@ -0,0 +1,34 @@
+package mode
+
+import "fmt"
+
+// ErrUnknownMode defines unknown mode error.
+type ErrUnknownMode struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnknownMode) Error() string {
+	return fmt.Sprintf("mode %s is unknown", e.Name)
+}
+
+// ErrForbiddenTransition defines not allowed transition error.
+type ErrForbiddenTransition struct {
+	From string
+	To   string
+}
+
+// Error formats output.
+func (e *ErrForbiddenTransition) Error() string {
+	return fmt.Sprintf("transition from %s to %s is not allowed", e.From, e.To)
+}
+
+// ErrUnsupportedCommand defines unsupported command error.
+type ErrUnsupportedCommand struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnsupportedCommand) Error() string {
+	return fmt.Sprintf("command %s is not supported", e.Name)
+}
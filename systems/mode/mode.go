@@ -0,0 +1,198 @@
+// Package mode contains global home mode provider.
+package mode
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Default mode name.
+const defaultName = "home"
+
+// Default modes, used if nothing is configured.
+var defaultStates = []string{"home", "away", "night", "vacation"}
+
+// Implements home mode provider.
+type provider struct {
+	sync.Mutex
+
+	internalID  string
+	name        string
+	states      []string
+	transitions map[string][]string
+	current     string
+
+	logger common.ILoggerProvider
+	server providers.IServerProvider
+	store  providers.IPersistentStoreProvider
+}
+
+// Mode settings.
+type settings struct {
+	Name        string              `yaml:"name"`
+	Initial     string              `yaml:"initial"`
+	States      []string            `yaml:"states"`
+	Transitions map[string][]string `yaml:"transitions"`
+}
+
+// ConstructMode has data required for instantiating a new mode provider.
+// Empty RawConfig means default settings.
+type ConstructMode struct {
+	RawConfig []byte
+	Settings  providers.ISettingsProvider
+	Server    providers.IServerProvider
+	Store     providers.IPersistentStoreProvider
+}
+
+// NewModeProvider creates a new home mode provider.
+func NewModeProvider(ctor *ConstructMode) (providers.IModeProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Settings.SystemLogger().Error("Failed to load home mode", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if "" == settings.Name {
+		settings.Name = defaultName
+	}
+
+	if 0 == len(settings.States) {
+		settings.States = defaultStates
+	}
+
+	if "" == settings.Initial {
+		settings.Initial = settings.States[0]
+	}
+
+	if !helpers.SliceContainsString(settings.States, settings.Initial) {
+		return nil, &ErrUnknownMode{Name: settings.Initial}
+	}
+
+	for from, to := range settings.Transitions {
+		for _, v := range append([]string{from}, to...) {
+			if !helpers.SliceContainsString(settings.States, v) {
+				return nil, &ErrUnknownMode{Name: v}
+			}
+		}
+	}
+
+	p := &provider{
+		internalID:  getID(settings.Name),
+		name:        settings.Name,
+		states:      settings.States,
+		transitions: settings.Transitions,
+		current:     settings.Initial,
+		server:      ctor.Server,
+		store:       ctor.Store,
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Settings.PluginLogger(),
+		Provider:     systems.SysDevice.String(),
+		System:       "mode",
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   p.internalID,
+		},
+	}
+	p.logger = logger.NewPluginLogger(logCtor)
+
+	stored := ""
+	if p.store.Load(p.internalID, &stored) {
+		if helpers.SliceContainsString(p.states, stored) {
+			p.current = stored
+		} else {
+			p.logger.Warn("Stored mode is unknown, using initial", common.LogNameToken, stored)
+		}
+	}
+
+	p.pushState()
+	return p, nil
+}
+
+// ID returns mode device ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// Current returns current mode.
+func (p *provider) Current() string {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.current
+}
+
+// States returns all configured modes.
+func (p *provider) States() []string {
+	return p.states
+}
+
+// SetMode changes current mode, validating transition.
+func (p *provider) SetMode(mode string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if !helpers.SliceContainsString(p.states, mode) {
+		return &ErrUnknownMode{Name: mode}
+	}
+
+	if mode == p.current {
+		return nil
+	}
+
+	allowed, ok := p.transitions[p.current]
+	if ok && !helpers.SliceContainsString(allowed, mode) {
+		return &ErrForbiddenTransition{From: p.current, To: mode}
+	}
+
+	p.logger.Info(fmt.Sprintf("Changing mode from %s to %s", p.current, mode))
+	p.current = mode
+	p.store.Save(p.internalID, mode)
+	p.pushState()
+	return nil
+}
+
+// InvokeCommand invokes mode command.
+func (p *provider) InvokeCommand(cmd enums.Command, data map[string]interface{}) error {
+	if cmd != enums.CmdSetMode {
+		return &ErrUnsupportedCommand{Name: cmd.String()}
+	}
+
+	mode, ok := data["value"].(string)
+	if !ok {
+		return &ErrUnknownMode{Name: fmt.Sprintf("%v", data["value"])}
+	}
+
+	return p.SetMode(mode)
+}
+
+// Pushes mode device state.
+func (p *provider) pushState() {
+	p.server.PushMasterDeviceUpdate(&providers.MasterDeviceUpdate{
+		Type:     enums.DevMode,
+		Name:     p.name,
+		ID:       p.internalID,
+		Commands: []string{enums.CmdSetMode.String()},
+		State: map[string]interface{}{
+			enums.PropMode.String():  p.current,
+			enums.PropModes.String(): p.states,
+		},
+	})
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("mode.%s", utils.NormalizeDeviceName(name))
+}
@@ -0,0 +1,114 @@
+package mode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+// Creates a new mode provider.
+func getMode(t *testing.T, config string, srv mocks.IFakeServer,
+	store providers.IPersistentStoreProvider) providers.IModeProvider {
+	ctor := &ConstructMode{
+		RawConfig: []byte(config),
+		Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+		Server:    srv.(providers.IServerProvider),
+		Store:     store,
+	}
+
+	m, err := NewModeProvider(ctor)
+	require.NoError(t, err)
+	return m
+}
+
+// Tests default settings.
+func TestDefaultMode(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	m := getMode(t, "", srv, mocks.FakeNewPersistentStore())
+
+	assert.Equal(t, "mode.home", m.ID())
+	assert.Equal(t, "home", m.Current())
+	assert.Equal(t, defaultStates, m.States())
+
+	update := srv.GetLastMasterUpdate()
+	assert.Equal(t, enums.DevMode, update.Type)
+	assert.Equal(t, "home", update.State[enums.PropMode.String()])
+
+	assert.NoError(t, m.InvokeCommand(enums.CmdSetMode, map[string]interface{}{"value": "night"}))
+	assert.Equal(t, "night", m.Current())
+	assert.Equal(t, "night", srv.GetLastMasterUpdate().State[enums.PropMode.String()])
+}
+
+// Tests transitions.
+func TestTransitions(t *testing.T) {
+	config := `
+name: house
+initial: away
+states: [ home, away, vacation ]
+transitions:
+  vacation: [ home ]
+`
+	m := getMode(t, config, mocks.FakeNewServer(nil), mocks.FakeNewPersistentStore())
+	assert.Equal(t, "mode.house", m.ID())
+	assert.Equal(t, "away", m.Current())
+
+	assert.NoError(t, m.SetMode("vacation"), "away -> vacation")
+
+	_, ok := m.SetMode("away").(*ErrForbiddenTransition)
+	assert.True(t, ok, "vacation -> away")
+
+	_, ok = m.SetMode("night").(*ErrUnknownMode)
+	assert.True(t, ok, "unknown mode")
+
+	assert.NoError(t, m.SetMode("home"), "vacation -> home")
+	assert.Equal(t, "home", m.Current())
+}
+
+// Tests persistence.
+func TestPersistence(t *testing.T) {
+	store := mocks.FakeNewPersistentStore()
+	m := getMode(t, "", mocks.FakeNewServer(nil), store)
+	require.NoError(t, m.SetMode("away"))
+
+	m = getMode(t, "", mocks.FakeNewServer(nil), store)
+	assert.Equal(t, "away", m.Current(), "restored")
+
+	m = getMode(t, "states: [ a, b ]", mocks.FakeNewServer(nil), store)
+	assert.Equal(t, "a", m.Current(), "stored mode is unknown")
+}
+
+// Tests wrong commands.
+func TestWrongCommands(t *testing.T) {
+	m := getMode(t, "", mocks.FakeNewServer(nil), mocks.FakeNewPersistentStore())
+
+	_, ok := m.InvokeCommand(enums.CmdOn, nil).(*ErrUnsupportedCommand)
+	assert.True(t, ok, "unsupported command")
+
+	_, ok = m.InvokeCommand(enums.CmdSetMode, map[string]interface{}{"value": 1}).(*ErrUnknownMode)
+	assert.True(t, ok, "wrong value")
+}
+
+// Tests wrong config.
+func TestWrongSettings(t *testing.T) {
+	data := []string{
+		"ad",
+		"initial: wrong",
+		"transitions: { home: [ wrong ] }",
+	}
+
+	for _, v := range data {
+		ctor := &ConstructMode{
+			RawConfig: []byte(v),
+			Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+			Server:    mocks.FakeNewServer(nil).(providers.IServerProvider),
+			Store:     mocks.FakeNewPersistentStore(),
+		}
+
+		_, err := NewModeProvider(ctor)
+		assert.Error(t, err, v)
+	}
+}
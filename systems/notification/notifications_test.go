@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go-home.io/x/server/mocks"
 	"go-home.io/x/server/plugins/notification"
+	"go-home.io/x/server/providers"
 )
 
 type fakePlugin struct {
@@ -72,4 +73,29 @@ func TestMessage(t *testing.T) {
 
 	p.Message("test")
 	assert.Equal(t, 1, f.called, "wrong invoke count")
-}
\ No newline at end of file
+}
+
+// Tests that messages are sent only in configured modes.
+func TestMessageModes(t *testing.T) {
+	f := &fakePlugin{}
+	srv := mocks.FakeNewServer(nil)
+
+	ctor := &ConstructNotification{
+		Name:      "test id",
+		Loader:    mocks.FakeNewPluginLoader(f),
+		RawConfig: []byte("modes: [away, vacation]"),
+		Logger:    mocks.FakeNewLogger(nil),
+		Server:    srv.(providers.IServerProvider),
+	}
+
+	p, _ := NewNotificationProvider(ctor)
+	assert.NotNil(t, p, "failed to create provider")
+
+	srv.SetHomeMode("home")
+	p.Message("test")
+	assert.Equal(t, 0, f.called, "home")
+
+	srv.SetHomeMode("away")
+	p.Message("test")
+	assert.Equal(t, 1, f.called, "away")
+}
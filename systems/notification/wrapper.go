@@ -6,11 +6,13 @@ import (
 
 	"github.com/pkg/errors"
 	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/helpers"
 	"go-home.io/x/server/plugins/notification"
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
 	"go-home.io/x/server/systems/logger"
 	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
 )
 
 // ConstructNotification defines notifications provider constructor.
@@ -21,6 +23,7 @@ type ConstructNotification struct {
 	RawConfig []byte
 	Logger    common.ILoggerProvider
 	Secret    common.ISecretProvider
+	Server    providers.IServerProvider
 }
 
 // Notification provider wrapper.
@@ -28,12 +31,20 @@ type provider struct {
 	id     string
 	logger common.ILoggerProvider
 	plugin notification.INotification
+	server providers.IServerProvider
+	modes  []string
+}
+
+// Generic notification settings.
+type settings struct {
+	Modes []string `yaml:"modes"`
 }
 
 // NewNotificationProvider creates a new notification provider.
 func NewNotificationProvider(ctor *ConstructNotification) (providers.INotificationProvider, error) {
 	p := &provider{
-		id: fmt.Sprintf("%s.%s", utils.NormalizeDeviceName(ctor.Name), systems.SysNotification.String()),
+		id:     fmt.Sprintf("%s.%s", utils.NormalizeDeviceName(ctor.Name), systems.SysNotification.String()),
+		server: ctor.Server,
 	}
 
 	loggerCtor := &logger.ConstructPluginLogger{
@@ -46,6 +57,11 @@ func NewNotificationProvider(ctor *ConstructNotification) (providers.INotificati
 	l := logger.NewPluginLogger(loggerCtor)
 	p.logger = l
 
+	s := &settings{}
+	if err := yaml.Unmarshal(ctor.RawConfig, s); err == nil {
+		p.modes = s.Modes
+	}
+
 	pluginLoadRequest := &providers.PluginLoadRequest{
 		ExpectedType:   notification.TypeNotification,
 		SystemType:     systems.SysNotification,
@@ -72,8 +88,20 @@ func (p *provider) GetID() string {
 	return p.id
 }
 
+// Unload releases plugin resources, if it's supported.
+func (p *provider) Unload() {
+	if u, ok := p.plugin.(common.IUnloadable); ok {
+		u.Unload()
+	}
+}
+
 // Message sends the message through a plugin.
 func (p *provider) Message(msg string) {
+	if len(p.modes) > 0 && (nil == p.server || !helpers.SliceContainsString(p.modes, p.server.GetHomeMode())) {
+		p.logger.Debug("Skipping notification since home mode is not active: " + msg)
+		return
+	}
+
 	p.logger.Debug("Sending a notification: " + msg)
 
 	err := p.plugin.Message(msg)
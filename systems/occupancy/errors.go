@@ -0,0 +1,13 @@
+package occupancy
+
+import "fmt"
+
+// ErrInvalidSettings defines occupancy settings validation error.
+type ErrInvalidSettings struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrInvalidSettings) Error() string {
+	return fmt.Sprintf("occupancy %s has invalid settings", e.Name)
+}
@@ -0,0 +1,337 @@
+// Package occupancy contains location occupancy provider.
+package occupancy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Implements occupancy provider.
+type provider struct {
+	sync.Mutex
+
+	internalID string
+	name       string
+	location   string
+
+	devicesExp  []glob.Glob
+	timeout     time.Duration
+	doorTimeout time.Duration
+	updatesChan chan *common.MsgDeviceUpdate
+	updatesSub  int64
+	fanOut      providers.IInternalFanOutProvider
+	logger      common.ILoggerProvider
+	server      providers.IServerProvider
+
+	sensors  map[string]*sensor
+	sealed   bool
+	occupied bool
+	vacantAt time.Time
+	timer    *time.Timer
+	unloaded bool
+}
+
+// Occupancy settings.
+type settings struct {
+	Name        string   `yaml:"name"`
+	Location    string   `yaml:"location"`
+	Devices     []string `yaml:"devices"`
+	Timeout     int      `yaml:"timeout" validate:"gte=0" default:"300"`
+	DoorTimeout int      `yaml:"doorTimeout" validate:"gte=0" default:"60"`
+}
+
+// Single tracked sensor.
+type sensor struct {
+	Type enums.SensorType
+	On   bool
+}
+
+// ConstructOccupancy has data required for instantiating a new occupancy provider.
+type ConstructOccupancy struct {
+	RawConfig []byte
+	Settings  providers.ISettingsProvider
+	Server    providers.IServerProvider
+}
+
+// NewOccupancyProvider creates a new occupancy provider.
+func NewOccupancyProvider(ctor *ConstructOccupancy) (providers.IOccupancyProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Settings.SystemLogger().Error("Failed to load occupancy", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !ctor.Settings.Validator().Validate(settings) {
+		return nil, &ErrInvalidSettings{Name: settings.Name}
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Settings.PluginLogger(),
+		Provider:     systems.SysDevice.String(),
+		System:       "occupancy",
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   getID(settings.Name),
+		},
+	}
+
+	provider := &provider{
+		internalID:  getID(settings.Name),
+		name:        settings.Name,
+		location:    settings.Location,
+		devicesExp:  make([]glob.Glob, 0),
+		timeout:     time.Duration(settings.Timeout) * time.Second,
+		doorTimeout: time.Duration(settings.DoorTimeout) * time.Second,
+		logger:      logger.NewPluginLogger(logCtor),
+		server:      ctor.Server,
+		fanOut:      ctor.Settings.FanOut(),
+		sensors:     make(map[string]*sensor),
+	}
+
+	for _, v := range settings.Devices {
+		exp, err := glob.Compile(v)
+		if err != nil {
+			provider.logger.Error("Failed to compile occupancy regexp, skipping", err)
+			continue
+		}
+
+		provider.devicesExp = append(provider.devicesExp, exp)
+	}
+
+	provider.pushState()
+
+	provider.updatesSub, provider.updatesChan = provider.fanOut.SubscribeDeviceUpdates()
+	go provider.deviceUpdates()
+
+	return provider, nil
+}
+
+// ID returns occupancy sensor ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// Location returns tracked location name.
+func (p *provider) Location() string {
+	return p.location
+}
+
+// IsOccupied returns current occupancy state.
+func (p *provider) IsOccupied() bool {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.occupied
+}
+
+// Unload stops listening for devices updates and cancels vacancy timer.
+func (p *provider) Unload() {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.unloaded {
+		return
+	}
+
+	p.unloaded = true
+	p.fanOut.UnSubscribeDeviceUpdates(p.updatesSub)
+	if nil != p.timer {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// Subscribes for devices updates.
+// Updates are processed in order, so sensor state transitions are not mixed up.
+func (p *provider) deviceUpdates() {
+	for msg := range p.updatesChan {
+		p.processDeviceUpdates(msg)
+	}
+}
+
+// Processes devices updates.
+// Motion and presence sensors keep location occupied while they are on
+// and for the timeout afterwards. Door contact change marks location as
+// occupied for the door timeout, unless motion is detected after the doors
+// are closed: in this case location stays occupied until a door opens.
+func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.unloaded || msg.ID == p.internalID {
+		return
+	}
+
+	kd := p.server.GetDevice(msg.ID)
+	if nil == kd || kd.Type != enums.DevSensor {
+		return
+	}
+
+	if !p.isMatched(msg.ID, kd) {
+		delete(p.sensors, msg.ID)
+		return
+	}
+
+	s, ok := p.sensors[msg.ID]
+	if !ok {
+		s = &sensor{Type: enums.SenGeneric}
+		p.sensors[msg.ID] = s
+	}
+
+	if t, ok := parseSensorType(msg.State[enums.PropSensorType]); ok {
+		s.Type = t
+	}
+
+	on, ok := msg.State[enums.PropOn].(bool)
+	if !ok {
+		return
+	}
+
+	prev := s.On
+	s.On = on
+	now := time.Now()
+
+	switch s.Type {
+	case enums.SenMotion, enums.SenPresence:
+		if on && s.Type == enums.SenMotion {
+			p.sealed = p.allDoorsClosed()
+		}
+
+		if !on && prev {
+			p.vacantAt = now.Add(p.timeout)
+		}
+	case enums.SenContact:
+		if on == prev {
+			return
+		}
+
+		p.sealed = false
+		p.vacantAt = now.Add(p.doorTimeout)
+	default:
+		return
+	}
+
+	p.update(now)
+}
+
+// Checks whether sensor belongs to the location.
+func (p *provider) isMatched(deviceID string, kd *providers.KnownDevice) bool {
+	if "" != p.location && helpers.SliceContainsString(kd.Locations, p.location) {
+		return true
+	}
+
+	for _, v := range p.devicesExp {
+		if v.Match(deviceID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks whether location has door sensors and all of them are closed.
+func (p *provider) allDoorsClosed() bool {
+	found := false
+	for _, v := range p.sensors {
+		if v.Type != enums.SenContact {
+			continue
+		}
+
+		if v.On {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
+
+// Re-calculates occupancy state and schedules vacancy check.
+func (p *provider) update(now time.Time) {
+	occupied := p.sealed || now.Before(p.vacantAt)
+	for _, v := range p.sensors {
+		if v.On && (v.Type == enums.SenMotion || v.Type == enums.SenPresence) {
+			occupied = true
+			break
+		}
+	}
+
+	if nil != p.timer {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	if now.Before(p.vacantAt) {
+		p.timer = time.AfterFunc(p.vacantAt.Sub(now), p.onTimer)
+	}
+
+	if occupied == p.occupied {
+		return
+	}
+
+	p.logger.Debug(fmt.Sprintf("Occupancy changed to %t", occupied))
+	p.occupied = occupied
+	p.pushState()
+}
+
+// Processes vacancy timer.
+func (p *provider) onTimer() {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.unloaded {
+		return
+	}
+
+	p.update(time.Now())
+}
+
+// Pushes virtual sensor state.
+func (p *provider) pushState() {
+	p.server.PushMasterDeviceUpdate(&providers.MasterDeviceUpdate{
+		Type:     enums.DevSensor,
+		Name:     p.name,
+		ID:       p.internalID,
+		Commands: make([]string, 0),
+		State: map[string]interface{}{
+			enums.PropOn.String():         p.occupied,
+			enums.PropSensorType.String(): enums.SenOccupancy.String(),
+		},
+	})
+}
+
+// Converts sensor type received from the update.
+func parseSensorType(raw interface{}) (enums.SensorType, bool) {
+	switch v := raw.(type) {
+	case enums.SensorType:
+		return v, true
+	case string:
+		t, err := enums.SensorTypeString(v)
+		return t, nil == err
+	case float64:
+		return enums.SensorType(int(v)), true
+	case int:
+		return enums.SensorType(v), true
+	}
+
+	return enums.SenGeneric, false
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("occupancy.%s", utils.NormalizeDeviceName(name))
+}
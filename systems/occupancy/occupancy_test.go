@@ -0,0 +1,183 @@
+package occupancy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+type occSuite struct {
+	suite.Suite
+
+	f    providers.IInternalFanOutProvider
+	prov providers.IOccupancyProvider
+	srv  mocks.IFakeServer
+}
+
+func (o *occSuite) SetupTest() {
+	var config = `
+system: ui
+provider: occupancy
+name: bedroom occupancy
+location: bedroom
+timeout: 1
+doorTimeout: 1
+devices:
+  - extra*
+`
+	s := mocks.FakeNewSettings(nil, false, nil, nil)
+	o.f = s.FanOut()
+	o.srv = mocks.FakeNewServer(nil)
+
+	for _, v := range []string{"motion1", "presence1", "door1"} {
+		o.srv.AddDeviceWithID(v, &providers.KnownDevice{
+			Type:      enums.DevSensor,
+			Locations: []string{"bedroom", "upstairs"},
+		})
+	}
+
+	o.srv.AddDeviceWithID("extra1", &providers.KnownDevice{Type: enums.DevSensor})
+	o.srv.AddDeviceWithID("motion2", &providers.KnownDevice{
+		Type:      enums.DevSensor,
+		Locations: []string{"kitchen"},
+	})
+
+	ctor := &ConstructOccupancy{
+		Settings:  s,
+		Server:    o.srv.(providers.IServerProvider),
+		RawConfig: []byte(config),
+	}
+
+	o.prov, _ = NewOccupancyProvider(ctor)
+}
+
+func (o *occSuite) TearDownTest() {
+	o.prov.Unload()
+}
+
+// Sends sensor update.
+func (o *occSuite) send(id string, sensorType enums.SensorType, on bool) {
+	o.f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:   id,
+		Type: enums.DevSensor,
+		State: map[enums.Property]interface{}{
+			enums.PropOn:         on,
+			enums.PropSensorType: sensorType.String(),
+		},
+	}
+
+	time.Sleep(200 * time.Millisecond)
+}
+
+// Tests initial state.
+func (o *occSuite) TestInitialState() {
+	assert.Equal(o.T(), "occupancy.bedroom_occupancy", o.prov.ID())
+	assert.Equal(o.T(), "bedroom", o.prov.Location())
+	assert.False(o.T(), o.prov.IsOccupied())
+
+	update := o.srv.GetLastMasterUpdate()
+	assert.Equal(o.T(), enums.DevSensor, update.Type)
+	assert.Equal(o.T(), false, update.State[enums.PropOn.String()])
+	assert.Equal(o.T(), enums.SenOccupancy.String(), update.State[enums.PropSensorType.String()])
+}
+
+// Tests motion timeout.
+func (o *occSuite) TestMotion() {
+	o.send("motion1", enums.SenMotion, true)
+	assert.True(o.T(), o.prov.IsOccupied(), "motion on")
+	assert.Equal(o.T(), true, o.srv.GetLastMasterUpdate().State[enums.PropOn.String()], "pushed")
+
+	o.send("motion1", enums.SenMotion, false)
+	assert.True(o.T(), o.prov.IsOccupied(), "motion off")
+
+	time.Sleep(1 * time.Second)
+	assert.False(o.T(), o.prov.IsOccupied(), "timeout")
+	assert.Equal(o.T(), false, o.srv.GetLastMasterUpdate().State[enums.PropOn.String()], "pushed")
+}
+
+// Tests presence sensor.
+func (o *occSuite) TestPresence() {
+	o.send("presence1", enums.SenPresence, true)
+	time.Sleep(1200 * time.Millisecond)
+	assert.True(o.T(), o.prov.IsOccupied(), "presence on")
+
+	o.send("presence1", enums.SenPresence, false)
+	time.Sleep(1200 * time.Millisecond)
+	assert.False(o.T(), o.prov.IsOccupied(), "presence off")
+}
+
+// Tests that sensors from other locations and non-sensors are ignored.
+func (o *occSuite) TestOtherLocation() {
+	o.send("motion2", enums.SenMotion, true)
+	assert.False(o.T(), o.prov.IsOccupied(), "other location")
+
+	o.srv.AddDeviceWithID("light1", &providers.KnownDevice{
+		Type:      enums.DevLight,
+		Locations: []string{"bedroom"},
+	})
+	o.send("light1", enums.SenMotion, true)
+	assert.False(o.T(), o.prov.IsOccupied(), "light")
+
+	o.send("extra1", enums.SenMotion, true)
+	assert.True(o.T(), o.prov.IsOccupied(), "explicit device")
+}
+
+// Tests that motion after doors are closed keeps location occupied.
+func (o *occSuite) TestDoorSeal() {
+	o.send("door1", enums.SenContact, false)
+	o.send("motion1", enums.SenMotion, true)
+	o.send("motion1", enums.SenMotion, false)
+
+	time.Sleep(1200 * time.Millisecond)
+	assert.True(o.T(), o.prov.IsOccupied(), "sealed")
+
+	o.send("door1", enums.SenContact, true)
+	assert.True(o.T(), o.prov.IsOccupied(), "door opened")
+
+	time.Sleep(1200 * time.Millisecond)
+	assert.False(o.T(), o.prov.IsOccupied(), "left")
+}
+
+// Tests door without following motion.
+func (o *occSuite) TestDoorOnly() {
+	o.send("door1", enums.SenContact, true)
+	assert.True(o.T(), o.prov.IsOccupied(), "door opened")
+
+	o.send("door1", enums.SenContact, false)
+	time.Sleep(1200 * time.Millisecond)
+	assert.False(o.T(), o.prov.IsOccupied(), "nobody entered")
+}
+
+// Tests that unloaded provider ignores updates and timers.
+func (o *occSuite) TestUnload() {
+	o.send("door1", enums.SenContact, true)
+	assert.True(o.T(), o.prov.IsOccupied(), "door opened")
+
+	o.prov.Unload()
+	time.Sleep(1200 * time.Millisecond)
+	assert.True(o.T(), o.prov.IsOccupied(), "timer stopped")
+}
+
+// Tests occupancy provider.
+func TestOccupancyProvider(t *testing.T) {
+	suite.Run(t, new(occSuite))
+}
+
+// Tests wrong config.
+func TestWrongSettings(t *testing.T) {
+	s := mocks.FakeNewSettings(nil, false, nil, nil)
+	ctor := &ConstructOccupancy{
+		Settings:  s,
+		Server:    mocks.FakeNewServer(nil).(providers.IServerProvider),
+		RawConfig: []byte(`ad`),
+	}
+
+	_, err := NewOccupancyProvider(ctor)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package person
+
+import "fmt"
+
+// ErrInvalidSettings defines invalid person settings error.
+type ErrInvalidSettings struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrInvalidSettings) Error() string {
+	return fmt.Sprintf("person %s has invalid settings", e.Name)
+}
+
+// ErrUnknownZone defines unknown zone error.
+type ErrUnknownZone struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnknownZone) Error() string {
+	return fmt.Sprintf("zone %s is unknown", e.Name)
+}
+
+// ErrInvalidLocation defines invalid location report error.
+type ErrInvalidLocation struct {
+}
+
+// Error formats output.
+func (e *ErrInvalidLocation) Error() string {
+	return "location should contain either zone or latitude and longitude"
+}
+
+// ErrUnsupportedCommand defines unsupported command error.
+type ErrUnsupportedCommand struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrUnsupportedCommand) Error() string {
+	return fmt.Sprintf("command %s is not supported", e.Name)
+}
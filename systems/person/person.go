@@ -0,0 +1,361 @@
+// Package person contains presence tracking provider.
+package person
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Person is at home.
+	presenceHome = "home"
+	// Person is outside of all known zones.
+	presenceAway = "away"
+	// Default zone radius in meters.
+	defaultRadius = 100
+)
+
+// Implements person provider.
+type provider struct {
+	sync.Mutex
+
+	internalID string
+	name       string
+	users      []string
+	devicesExp []glob.Glob
+	zones      map[string]*zone
+
+	trackers map[string]bool
+	state    *state
+
+	updatesChan chan *common.MsgDeviceUpdate
+	logger      common.ILoggerProvider
+	server      providers.IServerProvider
+	store       providers.IPersistentStoreProvider
+}
+
+// Person settings.
+type settings struct {
+	Name    string           `yaml:"name" validate:"required"`
+	Users   []string         `yaml:"users"`
+	Devices []string         `yaml:"devices"`
+	Zones   map[string]*zone `yaml:"zones"`
+}
+
+// Persisted person state.
+type state struct {
+	Presence  string   `yaml:"presence"`
+	LastEvent string   `yaml:"lastEvent"`
+	LastSeen  int64    `yaml:"lastSeen"`
+	Latitude  *float64 `yaml:"latitude,omitempty"`
+	Longitude *float64 `yaml:"longitude,omitempty"`
+}
+
+// ConstructPerson has data required for instantiating a new person.
+type ConstructPerson struct {
+	RawConfig []byte
+	Settings  providers.ISettingsProvider
+	Server    providers.IServerProvider
+	Store     providers.IPersistentStoreProvider
+}
+
+// NewPersonProvider creates a new person provider.
+// Person is at home if any of the network trackers is on,
+// otherwise the latest report from any source wins.
+func NewPersonProvider(ctor *ConstructPerson) (providers.IPersonProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Settings.SystemLogger().Error("Failed to load person", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !ctor.Settings.Validator().Validate(settings) {
+		return nil, &ErrInvalidSettings{Name: settings.Name}
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Settings.PluginLogger(),
+		Provider:     systems.SysDevice.String(),
+		System:       "person",
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   getID(settings.Name),
+		},
+	}
+
+	p := &provider{
+		internalID: getID(settings.Name),
+		name:       settings.Name,
+		users:      settings.Users,
+		devicesExp: make([]glob.Glob, 0),
+		zones:      make(map[string]*zone),
+		trackers:   make(map[string]bool),
+		state:      &state{Presence: presenceAway, LastEvent: presenceAway},
+		logger:     logger.NewPluginLogger(logCtor),
+		server:     ctor.Server,
+		store:      ctor.Store,
+	}
+
+	for k, v := range settings.Zones {
+		if nil == v || v.Radius < 0 || presenceAway == k {
+			return nil, &ErrInvalidSettings{Name: settings.Name}
+		}
+
+		if 0 == v.Radius {
+			v.Radius = defaultRadius
+		}
+
+		p.zones[k] = v
+	}
+
+	for _, v := range settings.Devices {
+		exp, err := glob.Compile(v)
+		if err != nil {
+			p.logger.Error("Failed to compile person tracker regexp, skipping", err)
+			continue
+		}
+
+		p.devicesExp = append(p.devicesExp, exp)
+	}
+
+	p.store.Load(p.internalID, p.state)
+	p.pushState()
+
+	_, p.updatesChan = ctor.Settings.FanOut().SubscribeDeviceUpdates()
+	go p.deviceUpdates()
+
+	return p, nil
+}
+
+// ID returns person device ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// Presence returns current presence: home, away or zone name.
+func (p *provider) Presence() string {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.state.Presence
+}
+
+// LastSeen returns timestamp of the latest report.
+func (p *provider) LastSeen() int64 {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.state.LastSeen
+}
+
+// InvokeCommand processes location reports.
+// Location contains either zone name or latitude and longitude.
+func (p *provider) InvokeCommand(cmd enums.Command, data map[string]interface{}) error {
+	if cmd != enums.CmdSetLocation {
+		return &ErrUnsupportedCommand{Name: cmd.String()}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if z, ok := data["zone"].(string); ok {
+		if _, ok := p.zones[z]; !ok && presenceHome != z && presenceAway != z {
+			return &ErrUnknownZone{Name: z}
+		}
+
+		p.report(z, nil, nil)
+		return nil
+	}
+
+	lat, okLat := toFloat(data["latitude"])
+	lon, okLon := toFloat(data["longitude"])
+	if !okLat || !okLon {
+		return &ErrInvalidLocation{}
+	}
+
+	p.report(p.getZone(lat, lon), &lat, &lon)
+	return nil
+}
+
+// Subscribes for devices updates.
+func (p *provider) deviceUpdates() {
+	for msg := range p.updatesChan {
+		go p.processDeviceUpdates(msg)
+	}
+}
+
+// Processes sensors reporting user and network trackers.
+// First seen tracker updates only refresh trackers state, since they are not actual reports.
+func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
+	if msg.Type != enums.DevSensor {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if user, ok := msg.State[enums.PropUser].(string); ok && p.isUser(user) {
+		p.logger.Debug("Person reported by sensor", common.LogIDToken, msg.ID)
+		p.report(presenceHome, nil, nil)
+		return
+	}
+
+	on, ok := msg.State[enums.PropOn].(bool)
+	if !ok || !p.isTracker(msg.ID) {
+		return
+	}
+
+	p.trackers[msg.ID] = on
+	if msg.FirstSeen {
+		p.update(false)
+		return
+	}
+
+	event := presenceAway
+	if on {
+		event = presenceHome
+	}
+
+	p.report(event, nil, nil)
+}
+
+// Stores the latest report and re-evaluates presence.
+// Repeated reports only refresh last seen timestamp.
+func (p *provider) report(event string, latitude *float64, longitude *float64) {
+	changed := event != p.state.LastEvent || !isSameCoordinate(latitude, p.state.Latitude) ||
+		!isSameCoordinate(longitude, p.state.Longitude)
+
+	p.state.LastEvent = event
+	p.state.LastSeen = utils.TimeNow()
+	p.state.Latitude = latitude
+	p.state.Longitude = longitude
+	p.update(changed)
+}
+
+// Evaluates presence, persists and pushes it if anything changed.
+func (p *provider) update(changed bool) {
+	presence := p.state.LastEvent
+	for _, v := range p.trackers {
+		if v {
+			presence = presenceHome
+			break
+		}
+	}
+
+	if presence == p.state.Presence && !changed {
+		return
+	}
+
+	if presence != p.state.Presence {
+		p.logger.Info(fmt.Sprintf("Presence changed from %s to %s", p.state.Presence, presence))
+	}
+
+	p.state.Presence = presence
+	p.store.Save(p.internalID, p.state)
+	p.pushState()
+}
+
+// Returns zone name for the coordinates.
+func (p *provider) getZone(latitude float64, longitude float64) string {
+	if z, ok := p.zones[presenceHome]; ok && z.contains(latitude, longitude) {
+		return presenceHome
+	}
+
+	names := make([]string, 0)
+	for k := range p.zones {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+	for _, v := range names {
+		if p.zones[v].contains(latitude, longitude) {
+			return v
+		}
+	}
+
+	return presenceAway
+}
+
+// Checks whether sensor reported user belongs to the person.
+func (p *provider) isUser(user string) bool {
+	for _, v := range p.users {
+		if strings.EqualFold(v, user) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks whether device is a network tracker of the person.
+func (p *provider) isTracker(deviceID string) bool {
+	for _, v := range p.devicesExp {
+		if v.Match(deviceID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Pushes person device state.
+func (p *provider) pushState() {
+	state := map[string]interface{}{
+		enums.PropPresence.String():  p.state.Presence,
+		enums.PropLastSeen.String():  p.state.LastSeen,
+		enums.PropLatitude.String():  nil,
+		enums.PropLongitude.String(): nil,
+	}
+
+	if nil != p.state.Latitude && nil != p.state.Longitude {
+		state[enums.PropLatitude.String()] = *p.state.Latitude
+		state[enums.PropLongitude.String()] = *p.state.Longitude
+	}
+
+	p.server.PushMasterDeviceUpdate(&providers.MasterDeviceUpdate{
+		Type:     enums.DevPerson,
+		Name:     p.name,
+		ID:       p.internalID,
+		Commands: []string{enums.CmdSetLocation.String()},
+		State:    state,
+	})
+}
+
+// Converts numeric value into float.
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+// Compares optional coordinates.
+func isSameCoordinate(x *float64, y *float64) bool {
+	if nil == x || nil == y {
+		return x == y
+	}
+
+	return *x == *y
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("person.%s", utils.NormalizeDeviceName(name))
+}
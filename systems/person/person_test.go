@@ -0,0 +1,200 @@
+package person
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+const config = `
+name: John
+users: [ john ]
+devices: [ phone_john* ]
+zones:
+  home:
+    latitude: 47.6062
+    longitude: -122.3321
+  work:
+    latitude: 47.6205
+    longitude: -122.3493
+    radius: 500
+`
+
+type prSuite struct {
+	suite.Suite
+
+	f     providers.IInternalFanOutProvider
+	srv   mocks.IFakeServer
+	store providers.IPersistentStoreProvider
+	prov  providers.IPersonProvider
+}
+
+func (s *prSuite) SetupTest() {
+	s.srv = mocks.FakeNewServer(nil)
+	s.store = mocks.FakeNewPersistentStore()
+	s.prov = s.getPerson()
+}
+
+// Creates a new person.
+func (s *prSuite) getPerson() providers.IPersonProvider {
+	settings := mocks.FakeNewSettings(nil, false, nil, nil)
+	s.f = settings.FanOut()
+
+	ctor := &ConstructPerson{
+		Settings:  settings,
+		Server:    s.srv.(providers.IServerProvider),
+		Store:     s.store,
+		RawConfig: []byte(config),
+	}
+
+	p, err := NewPersonProvider(ctor)
+	require.NoError(s.T(), err)
+	return p
+}
+
+// Sends sensor update.
+func (s *prSuite) send(id string, firstSeen bool, state map[enums.Property]interface{}) {
+	s.f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:        id,
+		Type:      enums.DevSensor,
+		State:     state,
+		FirstSeen: firstSeen,
+	}
+
+	time.Sleep(200 * time.Millisecond)
+}
+
+// Tests registration.
+func (s *prSuite) TestRegistration() {
+	assert.Equal(s.T(), "person.john", s.prov.ID())
+	assert.Equal(s.T(), "away", s.prov.Presence())
+	assert.Equal(s.T(), int64(0), s.prov.LastSeen())
+
+	update := s.srv.GetLastMasterUpdate()
+	assert.Equal(s.T(), enums.DevPerson, update.Type)
+	assert.Equal(s.T(), "away", update.State[enums.PropPresence.String()])
+}
+
+// Tests network trackers.
+func (s *prSuite) TestTrackers() {
+	s.send("phone_john_wifi", true, map[enums.Property]interface{}{enums.PropOn: true})
+	assert.Equal(s.T(), "home", s.prov.Presence())
+	assert.Equal(s.T(), int64(0), s.prov.LastSeen(), "first seen is not a report")
+
+	s.send("phone_john_bt", false, map[enums.Property]interface{}{enums.PropOn: false})
+	assert.Equal(s.T(), "home", s.prov.Presence(), "another tracker is on")
+	assert.NotEqual(s.T(), int64(0), s.prov.LastSeen())
+
+	s.send("phone_john_wifi", false, map[enums.Property]interface{}{enums.PropOn: false})
+	assert.Equal(s.T(), "away", s.prov.Presence())
+
+	s.send("phone_jane", false, map[enums.Property]interface{}{enums.PropOn: true})
+	assert.Equal(s.T(), "away", s.prov.Presence(), "other person")
+}
+
+// Tests sensors reporting user.
+func (s *prSuite) TestUserSensor() {
+	s.send("lock", false, map[enums.Property]interface{}{enums.PropUser: "jane"})
+	assert.Equal(s.T(), "away", s.prov.Presence())
+
+	s.send("lock", false, map[enums.Property]interface{}{enums.PropUser: "John"})
+	assert.Equal(s.T(), "home", s.prov.Presence())
+}
+
+// Tests location reports.
+func (s *prSuite) TestLocation() {
+	assert.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation,
+		map[string]interface{}{"latitude": 47.6210, "longitude": -122.3490}))
+	assert.Equal(s.T(), "work", s.prov.Presence())
+	assert.Equal(s.T(), 47.6210, s.srv.GetLastMasterUpdate().State[enums.PropLatitude.String()])
+
+	assert.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation,
+		map[string]interface{}{"latitude": 47.6063, "longitude": -122.3322}))
+	assert.Equal(s.T(), "home", s.prov.Presence())
+
+	assert.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation,
+		map[string]interface{}{"latitude": 40, "longitude": -100}))
+	assert.Equal(s.T(), "away", s.prov.Presence())
+
+	assert.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation, map[string]interface{}{"zone": "work"}))
+	assert.Equal(s.T(), "work", s.prov.Presence())
+	assert.Nil(s.T(), s.srv.GetLastMasterUpdate().State[enums.PropLatitude.String()])
+
+	_, ok := s.prov.InvokeCommand(enums.CmdSetLocation, map[string]interface{}{"zone": "gym"}).(*ErrUnknownZone)
+	assert.True(s.T(), ok, "unknown zone")
+	_, ok = s.prov.InvokeCommand(enums.CmdSetLocation, map[string]interface{}{"latitude": 1}).(*ErrInvalidLocation)
+	assert.True(s.T(), ok, "no longitude")
+	_, ok = s.prov.InvokeCommand(enums.CmdOn, nil).(*ErrUnsupportedCommand)
+	assert.True(s.T(), ok, "unsupported")
+
+	s.send("phone_john", false, map[enums.Property]interface{}{enums.PropOn: true})
+	assert.Equal(s.T(), "home", s.prov.Presence(), "tracker wins")
+}
+
+// Tests that repeated reports are not pushed.
+func (s *prSuite) TestRepeatedReport() {
+	require.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation, map[string]interface{}{"zone": "work"}))
+	update := s.srv.GetLastMasterUpdate()
+
+	require.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation, map[string]interface{}{"zone": "work"}))
+	assert.True(s.T(), update == s.srv.GetLastMasterUpdate(), "same zone")
+
+	require.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation,
+		map[string]interface{}{"latitude": 47.6210, "longitude": -122.3490}))
+	assert.False(s.T(), update == s.srv.GetLastMasterUpdate(), "coordinates")
+	update = s.srv.GetLastMasterUpdate()
+
+	require.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation,
+		map[string]interface{}{"latitude": 47.6210, "longitude": -122.3490}))
+	assert.True(s.T(), update == s.srv.GetLastMasterUpdate(), "same coordinates")
+}
+
+// Tests persisted state.
+func (s *prSuite) TestPersistence() {
+	require.NoError(s.T(), s.prov.InvokeCommand(enums.CmdSetLocation, map[string]interface{}{"zone": "work"}))
+	seen := s.prov.LastSeen()
+
+	p := s.getPerson()
+	assert.Equal(s.T(), "work", p.Presence())
+	assert.Equal(s.T(), seen, p.LastSeen())
+}
+
+// Tests person provider.
+func TestPersonProvider(t *testing.T) {
+	suite.Run(t, new(prSuite))
+}
+
+// Tests distance calculation.
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 0, distance(10, 10, 10, 10), 0.001)
+	assert.InDelta(t, 111195, distance(0, 0, 1, 0), 1)
+	assert.InDelta(t, 3935746, distance(40.7128, -74.0060, 34.0522, -118.2437), 1000)
+}
+
+// Tests wrong config.
+func TestWrongSettings(t *testing.T) {
+	data := []string{
+		"ad",
+		"name: p1\nzones: { work: { radius: -1 } }",
+		"name: p1\nzones: { away: { radius: 10 } }",
+	}
+
+	for _, v := range data {
+		ctor := &ConstructPerson{
+			RawConfig: []byte(v),
+			Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+			Server:    mocks.FakeNewServer(nil).(providers.IServerProvider),
+			Store:     mocks.FakeNewPersistentStore(),
+		}
+
+		_, err := NewPersonProvider(ctor)
+		assert.Error(t, err, v)
+	}
+}
@@ -0,0 +1,29 @@
+package person
+
+import "math"
+
+// Earth radius in meters.
+const earthRadius = 6371000
+
+// Named circular zone.
+type zone struct {
+	Latitude  float64 `yaml:"latitude"`
+	Longitude float64 `yaml:"longitude"`
+	Radius    float64 `yaml:"radius" validate:"gt=0"`
+}
+
+// Checks whether coordinates are within the zone.
+func (z *zone) contains(latitude float64, longitude float64) bool {
+	return distance(z.Latitude, z.Longitude, latitude, longitude) <= z.Radius
+}
+
+// Calculates great-circle distance in meters using haversine formula.
+func distance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,56 @@
+package scene
+
+import (
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+)
+
+// Single device command required for restoring the state.
+type sceneCommand struct {
+	cmd  enums.Command
+	data map[string]interface{}
+}
+
+// Converts device state into the list of commands.
+// Only commands supported by the device are returned.
+// Turned off device receives only transition time and off commands,
+// since changing other properties might turn it back on.
+func getCommands(state map[enums.Property]interface{}, supported []string) []*sceneCommand {
+	result := make([]*sceneCommand, 0)
+	add := func(cmd enums.Command, data map[string]interface{}) {
+		if !helpers.SliceContainsString(supported, cmd.String()) {
+			return
+		}
+
+		result = append(result, &sceneCommand{cmd: cmd, data: data})
+	}
+
+	if v, ok := state[enums.PropTransitionTime].(common.Int); ok {
+		add(enums.CmdSetTransitionTime, map[string]interface{}{"value": v.Value})
+	}
+
+	on, hasOn := state[enums.PropOn].(bool)
+	if hasOn && !on {
+		add(enums.CmdOff, make(map[string]interface{}))
+		return result
+	}
+
+	if hasOn {
+		add(enums.CmdOn, make(map[string]interface{}))
+	}
+
+	if v, ok := state[enums.PropColor].(common.Color); ok {
+		add(enums.CmdSetColor, map[string]interface{}{"r": v.R, "g": v.G, "b": v.B})
+	}
+
+	if v, ok := state[enums.PropBrightness].(common.Percent); ok {
+		add(enums.CmdSetBrightness, map[string]interface{}{"value": v.Value})
+	}
+
+	if v, ok := state[enums.PropFanSpeed].(common.Percent); ok {
+		add(enums.CmdSetFanSpeed, map[string]interface{}{"value": v.Value})
+	}
+
+	return result
+}
@@ -0,0 +1,302 @@
+// Package scene contains server-side scenes provider.
+package scene
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/plugins/helpers"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Properties restored by the scene.
+var sceneProperties = []enums.Property{enums.PropOn, enums.PropColor, enums.PropBrightness,
+	enums.PropFanSpeed, enums.PropTransitionTime}
+
+// Implements scene provider.
+type provider struct {
+	sync.Mutex
+
+	internalID string
+	name       string
+
+	devicesExp  []glob.Glob
+	updatesChan chan *common.MsgDeviceUpdate
+	logger      common.ILoggerProvider
+	server      providers.IServerProvider
+	store       providers.IPersistentStoreProvider
+
+	current  map[string]map[enums.Property]interface{}
+	states   map[string]map[enums.Property]interface{}
+	snapshot map[string]map[enums.Property]interface{}
+}
+
+// Scene settings.
+type settings struct {
+	Name    string                            `yaml:"name"`
+	Devices []string                          `yaml:"devices"`
+	States  map[string]map[string]interface{} `yaml:"states"`
+}
+
+// ConstructScene has data required for instantiating a new scene.
+type ConstructScene struct {
+	RawConfig []byte
+	Settings  providers.ISettingsProvider
+	Server    providers.IServerProvider
+	Store     providers.IPersistentStoreProvider
+}
+
+// NewSceneProvider creates a new scene provider.
+func NewSceneProvider(ctor *ConstructScene) (providers.ISceneProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Settings.SystemLogger().Error("Failed to load scene", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Settings.PluginLogger(),
+		Provider:     systems.SysDevice.String(),
+		System:       "scene",
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   getID(settings.Name),
+		},
+	}
+
+	provider := &provider{
+		internalID: getID(settings.Name),
+		name:       settings.Name,
+		devicesExp: make([]glob.Glob, 0),
+		logger:     logger.NewPluginLogger(logCtor),
+		server:     ctor.Server,
+		store:      ctor.Store,
+		current:    make(map[string]map[enums.Property]interface{}),
+		states:     make(map[string]map[enums.Property]interface{}),
+	}
+
+	for _, v := range settings.Devices {
+		exp, err := glob.Compile(v)
+		if err != nil {
+			provider.logger.Error("Failed to compile scene regexp, skipping", err)
+			continue
+		}
+
+		provider.devicesExp = append(provider.devicesExp, exp)
+	}
+
+	states := settings.States
+	stored := make(map[string]map[string]interface{})
+	if provider.store.Load(provider.internalID, &stored) {
+		provider.logger.Debug("Using captured scene states")
+		states = stored
+	}
+
+	for id, v := range states {
+		provider.states[id] = provider.parseState(id, v)
+	}
+
+	provider.server.PushMasterDeviceUpdate(&providers.MasterDeviceUpdate{
+		Type:     enums.DevScene,
+		Name:     provider.name,
+		ID:       provider.internalID,
+		Commands: []string{enums.CmdActivate.String(), enums.CmdSnapshot.String(), enums.CmdRestore.String()},
+		State:    make(map[string]interface{}),
+	})
+
+	_, provider.updatesChan = ctor.Settings.FanOut().SubscribeDeviceUpdates()
+	go provider.deviceUpdates()
+
+	return provider, nil
+}
+
+// ID returns scene internal ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// Devices returns list of devices, affected by the scene.
+func (p *provider) Devices() []string {
+	p.Lock()
+	defer p.Unlock()
+
+	ids := make([]string, 0)
+	for k := range p.states {
+		ids = append(ids, k)
+	}
+
+	for k := range p.current {
+		if _, ok := p.states[k]; !ok {
+			ids = append(ids, k)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// InvokeCommand invokes scene command.
+func (p *provider) InvokeCommand(cmd enums.Command, _ map[string]interface{}) {
+	p.Lock()
+	defer p.Unlock()
+
+	switch cmd {
+	case enums.CmdActivate:
+		p.apply(p.states)
+	case enums.CmdSnapshot:
+		p.snapshot = p.copyCurrent()
+	case enums.CmdRestore:
+		if nil == p.snapshot {
+			p.logger.Warn("Snapshot is not captured, nothing to restore")
+			return
+		}
+
+		p.apply(p.snapshot)
+		p.snapshot = nil
+	default:
+		p.logger.Warn("Received unsupported scene command", common.LogDeviceCommandToken, cmd.String())
+	}
+}
+
+// Capture replaces scene states with the current state of its devices.
+func (p *provider) Capture() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.states = p.copyCurrent()
+	p.store.Save(p.internalID, getRawStates(p.states))
+	p.logger.Info("Captured scene from the current devices state")
+}
+
+// Subscribes for devices updates.
+func (p *provider) deviceUpdates() {
+	for msg := range p.updatesChan {
+		go p.processDeviceUpdates(msg)
+	}
+}
+
+// Keeps track of the current devices state.
+func (p *provider) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
+	p.Lock()
+	defer p.Unlock()
+
+	if msg.ID == p.internalID || msg.Type == enums.DevGroup || msg.Type == enums.DevScene {
+		return
+	}
+
+	state, ok := p.current[msg.ID]
+	if !ok {
+		if !p.isMatched(msg.ID) {
+			return
+		}
+
+		state = make(map[enums.Property]interface{})
+		p.current[msg.ID] = state
+	}
+
+	for _, v := range sceneProperties {
+		if val, ok := msg.State[v]; ok {
+			state[v] = val
+		}
+	}
+}
+
+// Checks whether device is a part of the scene.
+func (p *provider) isMatched(deviceID string) bool {
+	if _, ok := p.states[deviceID]; ok {
+		return true
+	}
+
+	for _, v := range p.devicesExp {
+		if v.Match(deviceID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns copy of the current devices state.
+func (p *provider) copyCurrent() map[string]map[enums.Property]interface{} {
+	result := make(map[string]map[enums.Property]interface{})
+	for id, state := range p.current {
+		result[id] = make(map[enums.Property]interface{})
+		for k, v := range state {
+			result[id][k] = v
+		}
+	}
+
+	return result
+}
+
+// Applies devices states by issuing commands on their workers.
+func (p *provider) apply(states map[string]map[enums.Property]interface{}) {
+	for id, state := range states {
+		kd := p.server.GetDevice(id)
+		if nil == kd {
+			p.logger.Warn("Scene device is unknown, skipping", common.LogIDToken, id)
+			continue
+		}
+
+		exp, err := glob.Compile(id)
+		if err != nil {
+			continue
+		}
+
+		for _, v := range getCommands(state, kd.Commands) {
+			p.server.InternalCommandInvokeDeviceCommand(exp, v.cmd, v.data)
+		}
+	}
+}
+
+// Converts configured state into internal properties.
+func (p *provider) parseState(deviceID string, raw map[string]interface{}) map[enums.Property]interface{} {
+	state := make(map[enums.Property]interface{})
+	for k, v := range raw {
+		prop, err := enums.PropertyString(k)
+		if err != nil || !enums.SliceContainsProperty(sceneProperties, prop) {
+			p.logger.Warn("Unsupported scene property, skipping", common.LogIDToken, deviceID,
+				common.LogDevicePropertyToken, k)
+			continue
+		}
+
+		val, err := helpers.PropertyFixYaml(v, prop)
+		if err != nil {
+			p.logger.Error("Failed to convert scene property", err, common.LogIDToken, deviceID,
+				common.LogDevicePropertyToken, k)
+			continue
+		}
+
+		state[prop] = val
+	}
+
+	return state
+}
+
+// Converts states into the config representation, so they can be persisted.
+func getRawStates(states map[string]map[enums.Property]interface{}) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	for id, state := range states {
+		result[id] = make(map[string]interface{})
+		for k, v := range state {
+			result[id][k.String()] = helpers.PlainValueProperty(v, k)
+		}
+	}
+
+	return result
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("scene.%s", utils.NormalizeDeviceName(name))
+}
@@ -0,0 +1,211 @@
+package scene
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+)
+
+type scSuite struct {
+	suite.Suite
+
+	f     providers.IInternalFanOutProvider
+	prov  providers.ISceneProvider
+	srv   mocks.IFakeServer
+	store providers.IPersistentStoreProvider
+	ctor  *ConstructScene
+}
+
+func (s *scSuite) SetupTest() {
+	var config = `
+system: device
+provider: scene
+name: movie night
+devices:
+  - light*
+states:
+  light1:
+    on: true
+    brightness: 30
+    color:
+      r: 10
+      g: 20
+      b: 30
+  switch1:
+    on: false
+  light2:
+    wrong: true
+`
+	settings := mocks.FakeNewSettings(nil, false, nil, nil)
+	s.f = settings.FanOut()
+	s.srv = mocks.FakeNewServer(nil)
+
+	lightCommands := []string{enums.CmdOn.String(), enums.CmdOff.String(),
+		enums.CmdSetBrightness.String(), enums.CmdSetColor.String()}
+	s.srv.AddDeviceWithID("light1", &providers.KnownDevice{Type: enums.DevLight, Commands: lightCommands})
+	s.srv.AddDeviceWithID("light2", &providers.KnownDevice{Type: enums.DevLight, Commands: lightCommands})
+	s.srv.AddDeviceWithID("switch1", &providers.KnownDevice{
+		Type:     enums.DevSwitch,
+		Commands: []string{enums.CmdOn.String(), enums.CmdOff.String()},
+	})
+
+	s.store = mocks.FakeNewPersistentStore()
+	s.ctor = &ConstructScene{
+		Settings:  settings,
+		Server:    s.srv.(providers.IServerProvider),
+		Store:     s.store,
+		RawConfig: []byte(config),
+	}
+
+	s.prov, _ = NewSceneProvider(s.ctor)
+}
+
+// Sends device update.
+func (s *scSuite) send(id string, state map[enums.Property]interface{}) {
+	s.f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:    id,
+		Type:  enums.DevLight,
+		State: state,
+	}
+
+	time.Sleep(200 * time.Millisecond)
+}
+
+// Tests scene device registration.
+func (s *scSuite) TestRegistration() {
+	assert.Equal(s.T(), "scene.movie_night", s.prov.ID())
+
+	update := s.srv.GetLastMasterUpdate()
+	assert.Equal(s.T(), enums.DevScene, update.Type)
+	assert.Equal(s.T(), "movie night", update.Name)
+	assert.Equal(s.T(), 3, len(update.Commands))
+	assert.Equal(s.T(), []string{"light1", "light2", "switch1"}, s.prov.Devices())
+}
+
+// Tests activation of configured states.
+func (s *scSuite) TestActivate() {
+	s.prov.InvokeCommand(enums.CmdActivate, nil)
+	assert.ElementsMatch(s.T(), []enums.Command{enums.CmdOn, enums.CmdSetColor, enums.CmdSetBrightness,
+		enums.CmdOff}, s.srv.GetInvokedCommands())
+}
+
+// Tests temporary snapshot and restore.
+func (s *scSuite) TestSnapshotRestore() {
+	s.send("light3", map[enums.Property]interface{}{
+		enums.PropOn:         true,
+		enums.PropBrightness: common.Percent{Value: 50},
+		enums.PropPower:      10.0,
+	})
+	s.send("other", map[enums.Property]interface{}{enums.PropOn: true})
+	s.srv.AddDeviceWithID("light3", &providers.KnownDevice{
+		Type:     enums.DevLight,
+		Commands: []string{enums.CmdOn.String(), enums.CmdOff.String(), enums.CmdSetBrightness.String()},
+	})
+
+	s.prov.InvokeCommand(enums.CmdRestore, nil)
+	assert.Equal(s.T(), 0, len(s.srv.GetInvokedCommands()), "no snapshot")
+
+	s.prov.InvokeCommand(enums.CmdSnapshot, nil)
+	s.send("light3", map[enums.Property]interface{}{enums.PropOn: false})
+
+	s.prov.InvokeCommand(enums.CmdRestore, nil)
+	assert.Equal(s.T(), []enums.Command{enums.CmdOn, enums.CmdSetBrightness}, s.srv.GetInvokedCommands())
+
+	s.prov.InvokeCommand(enums.CmdRestore, nil)
+	assert.Equal(s.T(), 2, len(s.srv.GetInvokedCommands()), "snapshot is cleared")
+}
+
+// Tests capturing scene from the current state.
+func (s *scSuite) TestCapture() {
+	s.send("light1", map[enums.Property]interface{}{enums.PropOn: false})
+	s.prov.Capture()
+	assert.Equal(s.T(), []string{"light1"}, s.prov.Devices())
+
+	s.prov.InvokeCommand(enums.CmdActivate, nil)
+	assert.Equal(s.T(), []enums.Command{enums.CmdOff}, s.srv.GetInvokedCommands())
+}
+
+// Tests that captured states survive restart.
+func (s *scSuite) TestCapturePersisted() {
+	s.send("light3", map[enums.Property]interface{}{
+		enums.PropOn:         true,
+		enums.PropBrightness: common.Percent{Value: 50},
+		enums.PropColor:      common.Color{R: 1, G: 2, B: 3},
+	})
+	s.prov.Capture()
+
+	prov, err := NewSceneProvider(s.ctor)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"light3"}, prov.Devices())
+
+	s.srv.AddDeviceWithID("light3", &providers.KnownDevice{
+		Type: enums.DevLight,
+		Commands: []string{enums.CmdOn.String(), enums.CmdOff.String(),
+			enums.CmdSetBrightness.String(), enums.CmdSetColor.String()},
+	})
+	prov.InvokeCommand(enums.CmdActivate, nil)
+	assert.ElementsMatch(s.T(), []enums.Command{enums.CmdOn, enums.CmdSetColor, enums.CmdSetBrightness},
+		s.srv.GetInvokedCommands())
+}
+
+// Tests scene provider.
+func TestSceneProvider(t *testing.T) {
+	suite.Run(t, new(scSuite))
+}
+
+// Tests commands conversion.
+func TestGetCommands(t *testing.T) {
+	all := []string{enums.CmdOn.String(), enums.CmdOff.String(), enums.CmdSetTransitionTime.String(),
+		enums.CmdSetColor.String(), enums.CmdSetBrightness.String(), enums.CmdSetFanSpeed.String()}
+	data := []struct {
+		state     map[enums.Property]interface{}
+		supported []string
+		gold      []enums.Command
+	}{
+		{
+			state: map[enums.Property]interface{}{enums.PropOn: false,
+				enums.PropBrightness: common.Percent{Value: 10}, enums.PropTransitionTime: common.Int{Value: 1}},
+			supported: all,
+			gold:      []enums.Command{enums.CmdSetTransitionTime, enums.CmdOff},
+		},
+		{
+			state: map[enums.Property]interface{}{enums.PropOn: true,
+				enums.PropFanSpeed: common.Percent{Value: 10}, enums.PropColor: common.Color{R: 1}},
+			supported: all,
+			gold:      []enums.Command{enums.CmdOn, enums.CmdSetColor, enums.CmdSetFanSpeed},
+		},
+		{
+			state:     map[enums.Property]interface{}{enums.PropBrightness: common.Percent{Value: 10}},
+			supported: []string{enums.CmdOn.String()},
+			gold:      []enums.Command{},
+		},
+	}
+
+	for i, v := range data {
+		cmds := make([]enums.Command, 0)
+		for _, c := range getCommands(v.state, v.supported) {
+			cmds = append(cmds, c.cmd)
+		}
+
+		assert.Equal(t, v.gold, cmds, "case %d", i)
+	}
+}
+
+// Tests wrong config.
+func TestWrongSettings(t *testing.T) {
+	ctor := &ConstructScene{
+		Settings:  mocks.FakeNewSettings(nil, false, nil, nil),
+		Server:    mocks.FakeNewServer(nil).(providers.IServerProvider),
+		Store:     mocks.FakeNewPersistentStore(),
+		RawConfig: []byte(`ad`),
+	}
+
+	_, err := NewSceneProvider(ctor)
+	assert.Error(t, err)
+}
@@ -1,12 +1,16 @@
 package security
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/patrickmn/go-cache"
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/plugins/user"
 	"go-home.io/x/server/utils"
@@ -14,10 +18,12 @@ import (
 )
 
 // Implements default user provider.
+// Successfully verified credentials are cached, so bcrypt doesn't run on every request.
 type basicAuthProvider struct {
 	logger          common.ILoggerProvider
 	secret          common.ISecretProvider
 	presetPasswords map[string]string
+	verified        *cache.Cache
 }
 
 // Init load regular htpsswds file.
@@ -25,6 +31,7 @@ type basicAuthProvider struct {
 func (b *basicAuthProvider) Init(data *user.InitDataUserStorage) error {
 	b.logger = data.Logger
 	b.secret = data.Secret
+	b.verified = cache.New(5*time.Minute, 10*time.Minute)
 
 	possibleFile := fmt.Sprintf("%s/_users", utils.GetDefaultConfigsDir())
 
@@ -57,15 +64,23 @@ func (b *basicAuthProvider) Authorize(headers map[string][]string) (username str
 		return "", &ErrCorruptedHeader{Header: string(payload)}
 	}
 
+	sum := sha256.Sum256(payload)
+	key := hex.EncodeToString(sum[:])
+	if _, ok := b.verified.Get(key); ok {
+		return pair[0], nil
+	}
+
 	pwd, ok := b.presetPasswords[pair[0]]
 	if ok && bcrypt.CompareHashAndPassword([]byte(pwd), []byte(pair[1])) == nil {
 		b.logger.Debug("Found user in _users file", "user", pair[0])
+		b.verified.Set(key, true, cache.DefaultExpiration)
 		return pair[0], nil
 	}
 
 	pwd, err = b.secret.Get(pair[0])
 	if err == nil && pwd == pair[1] {
 		b.logger.Debug("Found user in secret store", "user", pair[0])
+		b.verified.Set(key, true, cache.DefaultExpiration)
 		return pair[0], nil
 	}
 
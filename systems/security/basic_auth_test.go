@@ -154,3 +154,21 @@ func TestIncorrectHeader(t *testing.T) {
 	assert.Error(t, err)
 	assert.NotEqual(t, "user1", usr)
 }
+
+// Tests that verified credentials are cached.
+func TestVerifiedCache(t *testing.T) {
+	prov := getProvider(t, nil, getFileRecord("user1", "123"))
+	defer cleanup(t)
+
+	usr, err := prov.Authorize(getAuthHeader("user1", "123"))
+	require.NoError(t, err)
+	assert.Equal(t, "user1", usr)
+
+	prov.presetPasswords = make(map[string]string)
+	usr, err = prov.Authorize(getAuthHeader("user1", "123"))
+	require.NoError(t, err, "cached")
+	assert.Equal(t, "user1", usr)
+
+	_, err = prov.Authorize(getAuthHeader("user1", "1234"))
+	assert.Error(t, err, "wrong password")
+}
@@ -39,3 +39,81 @@ type ErrUserNotFound struct {
 func (e *ErrUserNotFound) Error() string {
 	return fmt.Sprintf("user %s not found", e.User)
 }
+
+// ErrTokenNotFound defines unknown API token.
+type ErrTokenNotFound struct {
+	ID string
+}
+
+// Error formats output.
+func (e *ErrTokenNotFound) Error() string {
+	return fmt.Sprintf("token %s not found", e.ID)
+}
+
+// ErrTokenExpired defines expired API token.
+type ErrTokenExpired struct {
+	ID string
+}
+
+// Error formats output.
+func (e *ErrTokenExpired) Error() string {
+	return fmt.Sprintf("token %s is expired", e.ID)
+}
+
+// ErrInvalidTokenRequest defines incorrect API token request.
+type ErrInvalidTokenRequest struct {
+	Reason string
+}
+
+// Error formats output.
+func (e *ErrInvalidTokenRequest) Error() string {
+	return fmt.Sprintf("invalid token request: %s", e.Reason)
+}
+
+// ErrTokenManagement defines attempt to manage tokens using API token authentication.
+type ErrTokenManagement struct {
+}
+
+// Error formats output.
+func (*ErrTokenManagement) Error() string {
+	return "tokens can't be managed with token authentication"
+}
+
+// ErrNoBearerToken defines absence of bearer token header.
+type ErrNoBearerToken struct {
+}
+
+// Error formats output.
+func (*ErrNoBearerToken) Error() string {
+	return "bearer token not found"
+}
+
+// ErrInvalidJWT defines JWT which failed validation.
+type ErrInvalidJWT struct {
+	Reason string
+}
+
+// Error formats output.
+func (e *ErrInvalidJWT) Error() string {
+	return fmt.Sprintf("invalid token: %s", e.Reason)
+}
+
+// ErrUnknownJWTKey defines JWT signed with unknown key.
+type ErrUnknownJWTKey struct {
+	ID string
+}
+
+// Error formats output.
+func (e *ErrUnknownJWTKey) Error() string {
+	return fmt.Sprintf("signing key %s not found", e.ID)
+}
+
+// ErrInvalidJWTSettings defines incorrect JWT provider settings.
+type ErrInvalidJWTSettings struct {
+	Reason string
+}
+
+// Error formats output.
+func (e *ErrInvalidJWTSettings) Error() string {
+	return fmt.Sprintf("invalid jwt settings: %s", e.Reason)
+}
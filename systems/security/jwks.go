@@ -0,0 +1,210 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Minimal period between JWKS reloads caused by unknown key ID.
+	jwksMinReloadPeriod = time.Minute
+	// JWKS URL request timeout.
+	jwksRequestTimeout = 10 * time.Second
+)
+
+// Single JSON web key.
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// JSON web keys set.
+type jwks struct {
+	Keys []*jwk `json:"keys"`
+}
+
+// Signing keys loaded from JWKS file or URL.
+type jwksKeys struct {
+	file            string
+	url             string
+	refreshInterval time.Duration
+
+	keys       map[string]crypto.PublicKey
+	loaded     time.Time
+	lastReload time.Time
+}
+
+// Constructs a new keys set.
+func newJWKSKeys(file string, url string, refreshInterval time.Duration) *jwksKeys {
+	return &jwksKeys{
+		file:            file,
+		url:             url,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]crypto.PublicKey),
+	}
+}
+
+// Returns key by ID.
+// Keys are re-loaded if they are outdated or key is unknown.
+func (k *jwksKeys) get(keyID string) (crypto.PublicKey, error) {
+	if "" != k.url && time.Since(k.loaded) > k.refreshInterval {
+		k.load() // nolint: gosec, errcheck
+	}
+
+	key, ok := k.find(keyID)
+	if !ok && time.Since(k.lastReload) > jwksMinReloadPeriod {
+		k.load() // nolint: gosec, errcheck
+		key, ok = k.find(keyID)
+	}
+
+	if !ok {
+		return nil, &ErrUnknownJWTKey{ID: keyID}
+	}
+
+	return key, nil
+}
+
+// Looks up a key. Token without key ID is allowed if there's only one key.
+func (k *jwksKeys) find(keyID string) (crypto.PublicKey, bool) {
+	key, ok := k.keys[keyID]
+	if !ok && "" == keyID && 1 == len(k.keys) {
+		for _, v := range k.keys {
+			return v, true
+		}
+	}
+
+	return key, ok
+}
+
+// Loads keys. Previous keys are kept if load fails.
+func (k *jwksKeys) load() error {
+	k.lastReload = time.Now()
+
+	data, err := k.read()
+	if err != nil {
+		return errors.Wrap(err, "jwks read failed")
+	}
+
+	set := &jwks{}
+	err = json.Unmarshal(data, set)
+	if err != nil {
+		return errors.Wrap(err, "jwks un-marshal failed")
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, v := range set.Keys {
+		if "" != v.Use && "sig" != v.Use {
+			continue
+		}
+
+		key, err := parseJWK(v)
+		if err != nil {
+			continue
+		}
+
+		keys[v.KeyID] = key
+	}
+
+	if 0 == len(keys) {
+		return &ErrInvalidJWTSettings{Reason: "jwks doesn't have supported keys"}
+	}
+
+	k.keys = keys
+	k.loaded = time.Now()
+	return nil
+}
+
+// Reads JWKS from the file or URL.
+func (k *jwksKeys) read() ([]byte, error) {
+	if "" != k.file {
+		return ioutil.ReadFile(k.file)
+	}
+
+	client := &http.Client{Timeout: jwksRequestTimeout}
+	resp, err := client.Get(k.url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close() // nolint: errcheck
+	if http.StatusOK != resp.StatusCode {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Converts JSON web key into public key.
+func parseJWK(key *jwk) (crypto.PublicKey, error) {
+	switch key.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(key.E)
+		if err != nil || !e.IsInt64() {
+			return nil, &ErrInvalidJWTSettings{Reason: "incorrect rsa exponent"}
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, &ErrInvalidJWTSettings{Reason: fmt.Sprintf("unsupported curve %s", key.Curve)}
+		}
+
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, &ErrInvalidJWTSettings{Reason: "ec point is not on the curve"}
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, &ErrInvalidJWTSettings{Reason: fmt.Sprintf("unsupported key type %s", key.KeyType)}
+}
+
+// Decodes base64 encoded big integer.
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
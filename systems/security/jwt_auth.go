@@ -0,0 +1,268 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creasty/defaults"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/user"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported JWT signature algorithms.
+var jwtAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// JWT user provider settings.
+type jwtSettings struct {
+	JWKSFile        string `yaml:"jwksFile"`
+	JWKSURL         string `yaml:"jwksUrl"`
+	Issuer          string `yaml:"issuer"`
+	Audience        string `yaml:"audience"`
+	UsernameClaim   string `yaml:"usernameClaim" default:"sub"`
+	GroupsClaim     string `yaml:"groupsClaim" default:"groups"`
+	Leeway          int    `yaml:"leeway" default:"60"`
+	RefreshInterval int    `yaml:"refreshInterval" default:"3600"`
+}
+
+// JWT header.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Implements built-in OIDC/JWT user provider.
+// Validates bearer tokens against JWKS, loaded either from a file or URL.
+type jwtAuthProvider struct {
+	sync.Mutex
+	logger   common.ILoggerProvider
+	settings *jwtSettings
+	keys     *jwksKeys
+}
+
+// Init validates settings and loads signing keys.
+func (j *jwtAuthProvider) Init(data *user.InitDataUserStorage) error {
+	j.logger = data.Logger
+	j.settings = &jwtSettings{}
+
+	err := yaml.Unmarshal(data.RawConfig, j.settings)
+	if err != nil {
+		return err
+	}
+
+	err = defaults.Set(j.settings)
+	if err != nil {
+		return err
+	}
+
+	if ("" == j.settings.JWKSFile) == ("" == j.settings.JWKSURL) {
+		return &ErrInvalidJWTSettings{Reason: "exactly one of jwksFile or jwksUrl is required"}
+	}
+
+	j.keys = newJWKSKeys(j.settings.JWKSFile, j.settings.JWKSURL,
+		time.Duration(j.settings.RefreshInterval)*time.Second)
+	return j.keys.load()
+}
+
+// Authorize validates bearer JWT and returns user name.
+func (j *jwtAuthProvider) Authorize(headers map[string][]string) (username string, err error) {
+	username, _, err = j.AuthorizeWithGroups(headers)
+	return username, err
+}
+
+// AuthorizeWithGroups validates bearer JWT and returns user name and groups.
+func (j *jwtAuthProvider) AuthorizeWithGroups(headers map[string][]string) (string, []string, error) {
+	token := getBearerToken(headers)
+	if "" == token {
+		j.logger.Warn("No Bearer token found")
+		return "", nil, &ErrNoBearerToken{}
+	}
+
+	claims, err := j.validate(token)
+	if err != nil {
+		j.logger.Warn("JWT is invalid", "reason", err.Error())
+		return "", nil, err
+	}
+
+	username, ok := getClaim(claims, j.settings.UsernameClaim).(string)
+	if !ok || "" == username {
+		j.logger.Warn("JWT doesn't have username claim", "claim", j.settings.UsernameClaim)
+		return "", nil, &ErrInvalidJWT{Reason: "username claim not found"}
+	}
+
+	groups := make([]string, 0)
+	switch v := getClaim(claims, j.settings.GroupsClaim).(type) {
+	case string:
+		groups = append(groups, v)
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	j.logger.Debug("Found user in JWT", "user", username)
+	return username, groups, nil
+}
+
+// Validates token signature and standard claims.
+func (j *jwtAuthProvider) validate(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if 3 != len(parts) {
+		return nil, &ErrInvalidJWT{Reason: "malformed token"}
+	}
+
+	header := &jwtHeader{}
+	if nil != decodeJWTPart(parts[0], header) {
+		return nil, &ErrInvalidJWT{Reason: "malformed header"}
+	}
+
+	hash, ok := jwtAlgorithms[header.Algorithm]
+	if !ok {
+		return nil, &ErrInvalidJWT{Reason: fmt.Sprintf("unsupported algorithm %s", header.Algorithm)}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, &ErrInvalidJWT{Reason: "malformed signature"}
+	}
+
+	j.Lock()
+	key, err := j.keys.get(header.KeyID)
+	j.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1])) // nolint: gosec, errcheck
+	if !verifyJWTSignature(header.Algorithm, key, hash, h.Sum(nil), signature) {
+		return nil, &ErrInvalidJWT{Reason: "signature verification failed"}
+	}
+
+	claims := make(map[string]interface{})
+	if nil != decodeJWTPart(parts[1], &claims) {
+		return nil, &ErrInvalidJWT{Reason: "malformed claims"}
+	}
+
+	return claims, j.validateClaims(claims)
+}
+
+// Validates expiration, issuer and audience.
+func (j *jwtAuthProvider) validateClaims(claims map[string]interface{}) error {
+	now := float64(time.Now().Unix())
+	leeway := float64(j.settings.Leeway)
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || exp+leeway < now {
+		return &ErrInvalidJWT{Reason: "token is expired"}
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && nbf-leeway > now {
+		return &ErrInvalidJWT{Reason: "token is not valid yet"}
+	}
+
+	if "" != j.settings.Issuer && claims["iss"] != j.settings.Issuer {
+		return &ErrInvalidJWT{Reason: "wrong issuer"}
+	}
+
+	if "" == j.settings.Audience {
+		return nil
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		if aud == j.settings.Audience {
+			return nil
+		}
+	case []interface{}:
+		for _, v := range aud {
+			if v == j.settings.Audience {
+				return nil
+			}
+		}
+	}
+
+	return &ErrInvalidJWT{Reason: "wrong audience"}
+}
+
+// Verifies signature with the key matching algorithm family.
+func verifyJWTSignature(alg string, key crypto.PublicKey, hash crypto.Hash, digest []byte, signature []byte) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "RS") {
+			return nil == rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		}
+
+		if strings.HasPrefix(alg, "PS") {
+			return nil == rsa.VerifyPSS(k, hash, digest, signature, nil)
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || 2*size != len(signature) || k.Curve.Params().BitSize != ecBitSize(alg) {
+			return false
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+
+	return false
+}
+
+// Returns curve size required by ECDSA algorithm.
+func ecBitSize(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	default:
+		return 521
+	}
+}
+
+// Decodes base64 JSON part of the token.
+func decodeJWTPart(part string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+// Returns claim value. Nested claims are separated with dots, e.g. realm_access.roles.
+func getClaim(claims map[string]interface{}, name string) interface{} {
+	var value interface{} = claims
+	for _, v := range strings.Split(name, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		value = m[v]
+	}
+
+	return value
+}
@@ -0,0 +1,351 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/user"
+	"go-home.io/x/server/providers"
+)
+
+// Locally generated signing keys.
+type jwtTestKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+// Generates signing keys.
+func getJWTTestKeys(t *testing.T) *jwtTestKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &jwtTestKeys{rsa: rsaKey, ec: ecKey}
+}
+
+// Returns JWKS with public keys.
+func (k *jwtTestKeys) jwks() []byte {
+	enc := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	set := &jwks{
+		Keys: []*jwk{
+			{KeyType: "RSA", KeyID: "rsa", Use: "sig", N: enc(k.rsa.N), E: enc(big.NewInt(int64(k.rsa.E)))},
+			{KeyType: "EC", KeyID: "ec", Curve: "P-256", X: enc(k.ec.X), Y: enc(k.ec.Y)},
+			{KeyType: "oct", KeyID: "hmac"},
+		},
+	}
+
+	data, _ := json.Marshal(set)
+	return data
+}
+
+// Signs claims.
+func (k *jwtTestKeys) sign(t *testing.T, alg string, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(input)) // nolint: gosec, errcheck
+	hash := digest.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, hash)
+	case "PS256":
+		signature, err = rsa.SignPSS(rand.Reader, k.rsa, crypto.SHA256, hash, nil)
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, hash)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		signature = []byte("signature")
+	}
+
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Writes JWKS file.
+func writeJWKS(t *testing.T, keys *jwtTestKeys) string {
+	dir, err := ioutil.TempDir("", "jwks")
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "jwks.json")
+	require.NoError(t, ioutil.WriteFile(file, keys.jwks(), 0600))
+	return file
+}
+
+// Returns initialized JWT provider.
+func getJWTProvider(t *testing.T, config string) *jwtAuthProvider {
+	prov := &jwtAuthProvider{}
+	err := prov.Init(&user.InitDataUserStorage{
+		Logger:    mocks.FakeNewLogger(nil),
+		RawConfig: []byte(config),
+	})
+	require.NoError(t, err)
+	return prov
+}
+
+// Returns default claims.
+func getJWTClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":    "usr1",
+		"iss":    "https://idp",
+		"aud":    []string{"go-home", "other"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"admins", "users"},
+	}
+}
+
+// Tests JWT validation.
+func TestJWTValidation(t *testing.T) {
+	keys := getJWTTestKeys(t)
+	file := writeJWKS(t, keys)
+	defer os.RemoveAll(filepath.Dir(file)) // nolint: errcheck
+
+	prov := getJWTProvider(t, fmt.Sprintf("jwksFile: %s\nissuer: https://idp\naudience: go-home", file))
+
+	for _, v := range []string{"RS256", "PS256", "ES256"} {
+		kid := "rsa"
+		if "ES256" == v {
+			kid = "ec"
+		}
+
+		usr, groups, err := prov.AuthorizeWithGroups(getBearerHeader(keys.sign(t, v, kid, getJWTClaims())))
+		require.NoError(t, err, v)
+		assert.Equal(t, "usr1", usr, v)
+		assert.Equal(t, []string{"admins", "users"}, groups, v)
+	}
+
+	wrong := map[string]func(map[string]interface{}) (string, string){
+		"expired": func(c map[string]interface{}) (string, string) {
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return "RS256", "rsa"
+		},
+		"no exp": func(c map[string]interface{}) (string, string) {
+			delete(c, "exp")
+			return "RS256", "rsa"
+		},
+		"not before": func(c map[string]interface{}) (string, string) {
+			c["nbf"] = time.Now().Add(time.Hour).Unix()
+			return "RS256", "rsa"
+		},
+		"issuer": func(c map[string]interface{}) (string, string) {
+			c["iss"] = "https://wrong"
+			return "RS256", "rsa"
+		},
+		"audience": func(c map[string]interface{}) (string, string) {
+			c["aud"] = "wrong"
+			return "RS256", "rsa"
+		},
+		"no user": func(c map[string]interface{}) (string, string) {
+			delete(c, "sub")
+			return "RS256", "rsa"
+		},
+		"unknown key": func(c map[string]interface{}) (string, string) {
+			return "RS256", "wrong"
+		},
+		"wrong key type": func(c map[string]interface{}) (string, string) {
+			return "RS256", "ec"
+		},
+		"none": func(c map[string]interface{}) (string, string) {
+			return "none", "rsa"
+		},
+		"hmac": func(c map[string]interface{}) (string, string) {
+			return "HS256", "hmac"
+		},
+	}
+
+	for k, v := range wrong {
+		claims := getJWTClaims()
+		alg, kid := v(claims)
+		_, err := prov.Authorize(getBearerHeader(keys.sign(t, alg, kid, claims)))
+		assert.Error(t, err, k)
+	}
+
+	token := keys.sign(t, "RS256", "rsa", getJWTClaims())
+	_, err := prov.Authorize(getBearerHeader(token + "a"))
+	assert.Error(t, err, "tampered")
+	_, err = prov.Authorize(nil)
+	assert.IsType(t, &ErrNoBearerToken{}, err, "no header")
+	_, err = prov.Authorize(getBearerHeader("a.b"))
+	assert.Error(t, err, "malformed")
+}
+
+// Tests custom and nested claims.
+func TestJWTClaimsMapping(t *testing.T) {
+	keys := getJWTTestKeys(t)
+	file := writeJWKS(t, keys)
+	defer os.RemoveAll(filepath.Dir(file)) // nolint: errcheck
+
+	prov := getJWTProvider(t, fmt.Sprintf(
+		"jwksFile: %s\nusernameClaim: preferred_username\ngroupsClaim: realm_access.roles", file))
+
+	claims := getJWTClaims()
+	claims["preferred_username"] = "john"
+	claims["realm_access"] = map[string]interface{}{"roles": []string{"family"}}
+
+	usr, groups, err := prov.AuthorizeWithGroups(getBearerHeader(keys.sign(t, "ES256", "ec", claims)))
+	require.NoError(t, err)
+	assert.Equal(t, "john", usr)
+	assert.Equal(t, []string{"family"}, groups)
+}
+
+// Tests JWKS loading from URL and reload on unknown key.
+func TestJWKSURL(t *testing.T) {
+	keys := getJWTTestKeys(t)
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+		writer.Write(keys.jwks()) // nolint: gosec, errcheck
+	}))
+	defer ts.Close()
+
+	prov := getJWTProvider(t, "jwksUrl: "+ts.URL)
+	_, err := prov.Authorize(getBearerHeader(keys.sign(t, "RS256", "rsa", getJWTClaims())))
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	prov.keys.lastReload = time.Now().Add(-2 * jwksMinReloadPeriod)
+	_, err = prov.Authorize(getBearerHeader(keys.sign(t, "RS256", "new", getJWTClaims())))
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls, "reloaded")
+
+	_, err = prov.Authorize(getBearerHeader(keys.sign(t, "RS256", "new", getJWTClaims())))
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls, "throttled")
+}
+
+// Tests wrong JWT provider settings.
+func TestJWTWrongSettings(t *testing.T) {
+	input := []string{
+		"",
+		"jwksFile: a\njwksUrl: b",
+		"jwksFile: /wrong/file",
+		"jwksUrl: http://127.0.0.1:1/",
+		"wrong",
+	}
+
+	for _, v := range input {
+		prov := &jwtAuthProvider{}
+		err := prov.Init(&user.InitDataUserStorage{
+			Logger:    mocks.FakeNewLogger(nil),
+			RawConfig: []byte(v),
+		})
+		assert.Error(t, err, v)
+	}
+}
+
+// Tests roles assignment through JWT groups.
+func TestJWTGroupsRoles(t *testing.T) {
+	keys := getJWTTestKeys(t)
+	file := writeJWKS(t, keys)
+	defer os.RemoveAll(filepath.Dir(file)) // nolint: errcheck
+
+	fallback := false
+	ctor := &ConstructSecurityProvider{
+		PluginLogger: mocks.FakeNewLogger(func(s string) {
+			if "Loading default user storage" == s {
+				fallback = true
+			}
+		}),
+		UserProvider:  "jwt",
+		UserRawConfig: []byte("jwksFile: " + file),
+		Roles: []*providers.SecRole{
+			{
+				Name: "admins",
+				Rules: []providers.SecRoleRule{
+					{
+						System:    "*",
+						StrVerb:   []string{"*"},
+						Resources: []string{"*"},
+					},
+				},
+				Groups: []string{"admin*"},
+			},
+			{
+				Name: "sensors",
+				Rules: []providers.SecRoleRule{
+					{
+						System:    providers.SecSystemDevice.String(),
+						StrVerb:   []string{providers.SecVerbGet.String()},
+						Resources: []string{"sensor.*"},
+					},
+				},
+				Users: []string{"usr2"},
+			},
+		},
+	}
+
+	prov := NewSecurityProvider(ctor)
+	assert.False(t, fallback)
+
+	usr, err := prov.GetUser(getBearerHeader(keys.sign(t, "RS256", "rsa", getJWTClaims())))
+	require.NoError(t, err)
+	assert.True(t, usr.Workers(), "admin")
+
+	claims := getJWTClaims()
+	claims["sub"] = "usr2"
+	claims["groups"] = "guests"
+	usr, err = prov.GetUser(getBearerHeader(keys.sign(t, "RS256", "rsa", claims)))
+	require.NoError(t, err)
+	assert.False(t, usr.Workers(), "not admin")
+	assert.True(t, usr.DeviceGet("sensor.1"), "user role")
+
+	claims["groups"] = []string{"admins"}
+	usr, err = prov.GetUser(getBearerHeader(keys.sign(t, "RS256", "rsa", claims)))
+	require.NoError(t, err)
+	assert.True(t, usr.Workers(), "groups are not cached per user")
+	assert.Equal(t, []string{"admins"}, usr.Groups())
+
+	_, value, err := prov.CreateToken(usr, &providers.APITokenRequest{Name: "full"})
+	require.NoError(t, err)
+	usr, err = prov.GetUser(getBearerHeader(value))
+	require.NoError(t, err)
+	assert.True(t, usr.Workers(), "token keeps creator groups")
+
+	_, err = prov.GetUser(getAuthHeader("usr1", "pwd"))
+	assert.Error(t, err, "basic auth")
+
+	ctor.UserRawConfig = []byte("jwksFile: /wrong")
+	NewSecurityProvider(ctor)
+	assert.True(t, fallback, "fallback")
+}
+
+// Tests EC keys validation.
+func TestJWKCurvePoint(t *testing.T) {
+	k := getJWTTestKeys(t)
+	enc := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	_, err := parseJWK(&jwk{KeyType: "EC", Curve: "P-256", X: enc(k.ec.X), Y: enc(k.ec.Y)})
+	assert.NoError(t, err)
+
+	_, err = parseJWK(&jwk{KeyType: "EC", Curve: "P-256", X: enc(k.ec.X), Y: enc(big.NewInt(1))})
+	assert.Error(t, err, "not on curve")
+}
@@ -2,6 +2,9 @@
 package security
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,6 +27,13 @@ type provider struct {
 	secret      common.ISecretProvider
 	roles       []*bakedRole
 	cache       *cache.Cache
+	store       providers.IPersistentStoreProvider
+	tokens      map[string]*providers.APIToken
+	scopes      map[string]map[providers.SecSystem][]*providers.BakedRule
+	tokensSaved int64
+	tokensDirty bool
+
+	tokensSaveMutex sync.Mutex
 }
 
 // ConstructSecurityProvider has all data required for a new security provider.
@@ -34,13 +44,15 @@ type ConstructSecurityProvider struct {
 	Roles         []*providers.SecRole
 	UserRawConfig []byte
 	UserProvider  string
+	Store         providers.IPersistentStoreProvider
 }
 
 // Helper type for pre-baked role.
 type bakedRole struct {
-	Name  string
-	Rules []*providers.BakedRule
-	Users []glob.Glob
+	Name   string
+	Rules  []*providers.BakedRule
+	Users  []glob.Glob
+	Groups []glob.Glob
 }
 
 // NewSecurityProvider constructs new security provider.
@@ -52,9 +64,11 @@ func NewSecurityProvider(ctor *ConstructSecurityProvider) providers.ISecurityPro
 		secret:      ctor.Secret,
 		roles:       make([]*bakedRole, 0),
 		cache:       cache.New(5*time.Minute, 10*time.Minute),
+		store:       ctor.Store,
 	}
 
 	prov.processRoles(ctor.Roles)
+	prov.loadTokens()
 	return prov
 }
 
@@ -65,6 +79,10 @@ func loadUserStorage(ctor *ConstructSecurityProvider) (user.IUserStorage, common
 		return loadBasicAuthStorage(ctor)
 	}
 
+	if "jwt" == ctor.UserProvider {
+		return loadJWTStorage(ctor)
+	}
+
 	loggerCtor := &logger.ConstructPluginLogger{
 		SystemLogger: ctor.PluginLogger,
 		Provider:     ctor.UserProvider,
@@ -96,6 +114,32 @@ func loadUserStorage(ctor *ConstructSecurityProvider) (user.IUserStorage, common
 	return storage.(user.IUserStorage), loggerProvider
 }
 
+// Loads built-in JWT provider.
+// If settings are incorrect, falls back to default file system provider.
+func loadJWTStorage(ctor *ConstructSecurityProvider) (user.IUserStorage, common.ILoggerProvider) {
+	loggerCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.PluginLogger,
+		Provider:     ctor.UserProvider,
+		System:       systems.SysSecurity.String(),
+	}
+
+	loggerProvider := logger.NewPluginLogger(loggerCtor)
+	initData := &user.InitDataUserStorage{
+		Secret:    ctor.Secret,
+		Logger:    loggerProvider,
+		RawConfig: ctor.UserRawConfig,
+	}
+
+	prov := &jwtAuthProvider{}
+	err := prov.Init(initData)
+	if err != nil {
+		loggerProvider.Error("Failed to load user storage, defaulting to basic", err)
+		return loadBasicAuthStorage(ctor)
+	}
+
+	return prov, loggerProvider
+}
+
 // Loads default file system provider.
 //noinspection GoUnhandledErrorResult
 func loadBasicAuthStorage(ctor *ConstructSecurityProvider) (user.IUserStorage, common.ILoggerProvider) {
@@ -119,35 +163,76 @@ func loadBasicAuthStorage(ctor *ConstructSecurityProvider) (user.IUserStorage, c
 }
 
 // GetUser returns found user with allowed roles if any.
+// API tokens are validated before falling back to the user storage.
 func (p *provider) GetUser(headers map[string][]string) (providers.IAuthenticatedUser, error) {
+	if token := getBearerToken(headers); strings.HasPrefix(token, tokenPrefix) {
+		p.Lock()
+		usr, err := p.getTokenUser(token)
+		p.Unlock()
+		p.saveTokens()
+		if err != nil {
+			return nil, errors.Wrap(err, "auth failed")
+		}
+
+		return usr, nil
+	}
+
 	p.Lock()
 	defer p.Unlock()
 
-	usr, err := p.userStorage.Authorize(headers)
+	usr, groups, err := p.authorize(headers)
 	if err != nil {
 		return nil, errors.Wrap(err, "auth failed")
 	}
 
-	authData, ok := p.cache.Get(usr)
+	return p.getRoleUser(usr, groups), nil
+}
+
+// GetUserByName returns user authenticated by external means, e.g. with client certificate.
+func (p *provider) GetUserByName(name string) (providers.IAuthenticatedUser, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if "" == name {
+		return nil, &ErrUserNotFound{User: name}
+	}
+
+	return p.getRoleUser(name, nil), nil
+}
+
+// Validates headers with user storage.
+// Groups are returned only if storage supports them.
+func (p *provider) authorize(headers map[string][]string) (string, []string, error) {
+	if storage, ok := p.userStorage.(user.IGroupsUserStorage); ok {
+		return storage.AuthorizeWithGroups(headers)
+	}
+
+	usr, err := p.userStorage.Authorize(headers)
+	return usr, nil, err
+}
+
+// Returns user with rules from all roles matching either user name or groups.
+func (p *provider) getRoleUser(usr string, groups []string) *AuthenticatedUser {
+	cacheKey := usr
+	if 0 != len(groups) {
+		sorted := append([]string{}, groups...)
+		sort.Strings(sorted)
+		cacheKey = fmt.Sprintf("%s\x00%s", usr, strings.Join(sorted, "\x00"))
+	}
+
+	authData, ok := p.cache.Get(cacheKey)
 	if ok {
-		return authData.(*AuthenticatedUser), nil
+		return authData.(*AuthenticatedUser)
 	}
 
 	authUser := &AuthenticatedUser{
-		Username: usr,
-		Rules:    make(map[providers.SecSystem][]*providers.BakedRule),
+		Username:   usr,
+		Rules:      make(map[providers.SecSystem][]*providers.BakedRule),
+		UserGroups: groups,
 	}
 
 	for _, v := range p.roles {
-		found := false
-		for _, u := range v.Users {
-			if u.Match(usr) {
-				found = true
-				break
-			}
-		}
-
-		if found {
+		if v.matches(usr, groups) {
 			for _, r := range v.Rules {
 				_, ok := authUser.Rules[r.System]
 				if !ok {
@@ -159,9 +244,9 @@ func (p *provider) GetUser(headers map[string][]string) (providers.IAuthenticate
 		}
 	}
 
-	p.cache.Set(usr, authUser, cache.DefaultExpiration)
+	p.cache.Set(cacheKey, authUser, cache.DefaultExpiration)
 
-	return authUser, nil
+	return authUser
 }
 
 // Processes configured roles and pre-complies regexps.
@@ -169,9 +254,10 @@ func (p *provider) processRoles(roles []*providers.SecRole) {
 	p.roles = make([]*bakedRole, 0)
 	for _, v := range roles {
 		role := &bakedRole{
-			Name:  v.Name,
-			Users: make([]glob.Glob, 0),
-			Rules: make([]*providers.BakedRule, 0),
+			Name:   v.Name,
+			Users:  make([]glob.Glob, 0),
+			Groups: make([]glob.Glob, 0),
+			Rules:  make([]*providers.BakedRule, 0),
 		}
 
 		for _, o := range v.Users {
@@ -186,7 +272,18 @@ func (p *provider) processRoles(roles []*providers.SecRole) {
 			role.Users = append(role.Users, reg)
 		}
 
-		if 0 == len(role.Users) {
+		for _, o := range v.Groups {
+			reg, err := glob.Compile(o)
+			if err != nil {
+				p.logger.Warn("Failed to compile role's group regexp", "regexp", o,
+					common.LogRoleNameToken, v.Name)
+				continue
+			}
+
+			role.Groups = append(role.Groups, reg)
+		}
+
+		if 0 == len(role.Users) && 0 == len(role.Groups) {
 			p.logger.Warn("Skipping role since users are empty", common.LogRoleNameToken, v.Name)
 			continue
 		}
@@ -210,6 +307,25 @@ func (p *provider) processRoles(roles []*providers.SecRole) {
 	}
 }
 
+// Checks whether role is assigned to the user directly or through one of groups.
+func (r *bakedRole) matches(usr string, groups []string) bool {
+	for _, u := range r.Users {
+		if u.Match(usr) {
+			return true
+		}
+	}
+
+	for _, g := range r.Groups {
+		for _, v := range groups {
+			if g.Match(v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // Processing config's role rules.
 func (p *provider) processRule(rule *providers.SecRoleRule, roleName string) *providers.BakedRule {
 	system, err := getSystem(rule.System)
@@ -264,6 +380,8 @@ func (p *provider) prepareVerbs(rule *providers.SecRoleRule, baked *providers.Ba
 			baked.Get = true
 			baked.Command = true
 			baked.History = true
+			baked.Arm = true
+			baked.Disarm = true
 			return
 		case providers.SecVerbGet:
 			baked.Get = true
@@ -271,6 +389,10 @@ func (p *provider) prepareVerbs(rule *providers.SecRoleRule, baked *providers.Ba
 			baked.Command = true
 		case providers.SecVerbHistory:
 			baked.History = true
+		case providers.SecVerbArm:
+			baked.Arm = true
+		case providers.SecVerbDisarm:
+			baked.Disarm = true
 		}
 	}
 }
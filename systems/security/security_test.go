@@ -210,3 +210,47 @@ func TestCorrectProcessing(t *testing.T) {
 	require.NoError(t, err)
 	checkAllAllowed(t, user)
 }
+
+// Tests alarm rules processing.
+func TestAlarmRulesProcessing(t *testing.T) {
+	ctor := &ConstructSecurityProvider{
+		PluginLogger: mocks.FakeNewLogger(nil),
+		UserProvider: "test",
+		Loader:       mocks.FakeNewPluginLoader(mocks.FakeNewUserStorage("test")),
+		Roles: []*providers.SecRole{
+			{
+				Name: "1",
+				Rules: []providers.SecRoleRule{
+					{
+						System:    providers.SecSystemAlarm.String(),
+						StrVerb:   []string{providers.SecVerbArm.String()},
+						Resources: []string{"alarm.*"},
+					},
+				},
+				Users: []string{"test"},
+			},
+		},
+	}
+
+	user, err := NewSecurityProvider(ctor).GetUser(nil)
+	require.NoError(t, err)
+	assert.True(t, user.AlarmArm("alarm.house"), "arm")
+	assert.False(t, user.AlarmDisarm("alarm.house"), "disarm")
+	assert.False(t, user.DeviceCommand("alarm.house"), "command")
+}
+
+// Tests users authenticated by name.
+func TestGetUserByName(t *testing.T) {
+	prov := getFakeProvider("")
+	usr, err := prov.GetUserByName("usr1")
+	require.NoError(t, err)
+	assert.Equal(t, "usr1", usr.Name())
+	assert.Equal(t, 1, len(usr.(*AuthenticatedUser).Rules))
+
+	usr, err = prov.GetUserByName("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(usr.(*AuthenticatedUser).Rules))
+
+	_, err = prov.GetUserByName("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,294 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+)
+
+const (
+	// Prefix of API tokens. Distinguishes them from other bearer tokens.
+	tokenPrefix = "ght_"
+	// Persistent store key for API tokens.
+	tokensStoreKey = "security.tokens"
+	// Minimal period in seconds between persisting tokens' last usage time.
+	tokensUsageFlushPeriod = 60
+)
+
+// CreateToken creates a new API token for the user.
+// Returns created token and its value, which is not stored anywhere.
+func (p *provider) CreateToken(user providers.IAuthenticatedUser,
+	req *providers.APITokenRequest) (*providers.APIToken, string, error) {
+	defer p.saveTokens()
+	p.Lock()
+	defer p.Unlock()
+
+	if "" != user.TokenID() {
+		return nil, "", &ErrTokenManagement{}
+	}
+
+	if "" == req.Name {
+		return nil, "", &ErrInvalidTokenRequest{Reason: "name is required"}
+	}
+
+	now := time.Now().Unix()
+	if 0 != req.Expires && req.Expires <= now {
+		return nil, "", &ErrInvalidTokenRequest{Reason: "expiration time is in the past"}
+	}
+
+	scope, err := p.bakeTokenRules(req.Rules)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := &providers.APIToken{
+		ID:      id,
+		Name:    req.Name,
+		User:    user.Name(),
+		Groups:  user.Groups(),
+		Hash:    hashTokenSecret(secret),
+		Rules:   req.Rules,
+		Created: now,
+		Expires: req.Expires,
+	}
+
+	if nil == token.Rules {
+		token.Rules = make([]providers.SecRoleRule, 0)
+	}
+
+	p.tokens[id] = token
+	p.scopes[id] = scope
+	p.tokensDirty = true
+
+	p.logger.Info("Created API token", common.LogIDToken, id, common.LogUserNameToken, token.User)
+	return token, fmt.Sprintf("%s%s_%s", tokenPrefix, id, secret), nil
+}
+
+// GetTokens returns all user's API tokens.
+func (p *provider) GetTokens(user providers.IAuthenticatedUser) ([]*providers.APIToken, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if "" != user.TokenID() {
+		return nil, &ErrTokenManagement{}
+	}
+
+	result := make([]*providers.APIToken, 0)
+	for _, v := range p.tokens {
+		if v.User == user.Name() {
+			result = append(result, v)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Created == result[j].Created {
+			return result[i].ID < result[j].ID
+		}
+
+		return result[i].Created < result[j].Created
+	})
+
+	return result, nil
+}
+
+// RevokeToken removes user's API token.
+func (p *provider) RevokeToken(user providers.IAuthenticatedUser, tokenID string) error {
+	defer p.saveTokens()
+	p.Lock()
+	defer p.Unlock()
+
+	if "" != user.TokenID() {
+		return &ErrTokenManagement{}
+	}
+
+	token, ok := p.tokens[tokenID]
+	if !ok || token.User != user.Name() {
+		return &ErrTokenNotFound{ID: tokenID}
+	}
+
+	delete(p.tokens, tokenID)
+	delete(p.scopes, tokenID)
+	p.tokensDirty = true
+
+	p.logger.Info("Revoked API token", common.LogIDToken, tokenID, common.LogUserNameToken, token.User)
+	return nil
+}
+
+// Authenticates user with API token.
+// Must be called under the lock, changed usage time is persisted by saveTokens.
+func (p *provider) getTokenUser(value string) (*AuthenticatedUser, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, tokenPrefix), "_", 2)
+	token, ok := p.tokens[parts[0]]
+	if !ok || 2 != len(parts) ||
+		1 != subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hashTokenSecret(parts[1]))) {
+		p.logger.Warn("Unknown API token", common.LogIDToken, parts[0])
+		return nil, &ErrTokenNotFound{ID: parts[0]}
+	}
+
+	now := time.Now().Unix()
+	if 0 != token.Expires && token.Expires <= now {
+		p.logger.Warn("Expired API token", common.LogIDToken, token.ID, common.LogUserNameToken, token.User)
+		return nil, &ErrTokenExpired{ID: token.ID}
+	}
+
+	p.logger.Debug("Authorized with API token", common.LogIDToken, token.ID, common.LogUserNameToken, token.User)
+	firstUse := 0 == token.LastUsed
+	token.LastUsed = now
+	if firstUse || now-p.tokensSaved >= tokensUsageFlushPeriod {
+		p.tokensDirty = true
+	}
+
+	usr := p.getRoleUser(token.User, token.Groups)
+	return &AuthenticatedUser{
+		Username:   usr.Username,
+		Rules:      usr.Rules,
+		Scope:      p.scopes[token.ID],
+		Token:      token.ID,
+		UserGroups: usr.UserGroups,
+	}, nil
+}
+
+// Loads stored API tokens, skipping expired ones.
+func (p *provider) loadTokens() {
+	p.tokens = make(map[string]*providers.APIToken)
+	p.scopes = make(map[string]map[providers.SecSystem][]*providers.BakedRule)
+	if nil == p.store {
+		return
+	}
+
+	stored := make([]*providers.APIToken, 0)
+	if !p.store.Load(tokensStoreKey, &stored) {
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, v := range stored {
+		if 0 != v.Expires && v.Expires <= now {
+			continue
+		}
+
+		scope, err := p.bakeTokenRules(v.Rules)
+		if err != nil {
+			p.logger.Error("Skipping corrupted API token", err, common.LogIDToken, v.ID)
+			continue
+		}
+
+		p.tokens[v.ID] = v
+		p.scopes[v.ID] = scope
+	}
+}
+
+// Persists API tokens if they were changed.
+// Must be called outside of the lock, so store I/O doesn't block authentication.
+func (p *provider) saveTokens() {
+	p.tokensSaveMutex.Lock()
+	defer p.tokensSaveMutex.Unlock()
+
+	p.Lock()
+	if !p.tokensDirty {
+		p.Unlock()
+		return
+	}
+
+	p.tokensDirty = false
+	p.tokensSaved = time.Now().Unix()
+	data := make([]providers.APIToken, 0, len(p.tokens))
+	for _, v := range p.tokens {
+		data = append(data, *v)
+	}
+
+	p.Unlock()
+
+	if nil == p.store {
+		return
+	}
+
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].ID < data[j].ID
+	})
+
+	p.store.Save(tokensStoreKey, data)
+}
+
+// Validates and pre-compiles token rules.
+// Unlike roles, token with incorrect rules is rejected.
+func (p *provider) bakeTokenRules(rules []providers.SecRoleRule) (map[providers.SecSystem][]*providers.BakedRule, error) {
+	if 0 == len(rules) {
+		return nil, nil
+	}
+
+	scope := make(map[providers.SecSystem][]*providers.BakedRule)
+	for _, v := range rules {
+		v := v
+		if _, err := getSystem(v.System); err != nil {
+			return nil, &ErrInvalidTokenRequest{Reason: fmt.Sprintf("unknown system %s", v.System)}
+		}
+
+		if 0 == len(v.Resources) || 0 == len(v.StrVerb) {
+			return nil, &ErrInvalidTokenRequest{Reason: "resources and verbs are required"}
+		}
+
+		for _, r := range v.Resources {
+			if _, err := glob.Compile(r); err != nil {
+				return nil, &ErrInvalidTokenRequest{Reason: fmt.Sprintf("incorrect resource %s", r)}
+			}
+		}
+
+		for _, r := range v.StrVerb {
+			if _, err := getVerb(r); err != nil {
+				return nil, &ErrInvalidTokenRequest{Reason: fmt.Sprintf("unknown verb %s", r)}
+			}
+		}
+
+		rule := p.processRule(&v, "token")
+		scope[rule.System] = append(scope[rule.System], rule)
+	}
+
+	return scope, nil
+}
+
+// Returns hash of the token secret.
+// Secrets are random, so plain SHA-256 is enough.
+func hashTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generates random hex string.
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Returns bearer token from headers.
+func getBearerToken(headers map[string][]string) string {
+	auth := strings.SplitN(getAuth(headers), " ", 2)
+	if 2 != len(auth) || "Bearer" != auth[0] {
+		return ""
+	}
+
+	return auth[1]
+}
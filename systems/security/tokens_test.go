@@ -0,0 +1,178 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/providers"
+)
+
+// Returns security provider with persistent store.
+func getTokensProvider(store providers.IPersistentStoreProvider) providers.ISecurityProvider {
+	ctor := &ConstructSecurityProvider{
+		PluginLogger: mocks.FakeNewLogger(nil),
+		UserProvider: "test",
+		Loader:       mocks.FakeNewPluginLoader(mocks.FakeNewUserStorage("usr1")),
+		Store:        store,
+		Roles: []*providers.SecRole{
+			{
+				Name: "1",
+				Rules: []providers.SecRoleRule{
+					{
+						System:    providers.SecSystemDevice.String(),
+						StrVerb:   []string{providers.SecVerbGet.String(), providers.SecVerbCommand.String()},
+						Resources: []string{"sensor.*", "light.*"},
+					},
+				},
+				Users: []string{"usr1"},
+			},
+		},
+	}
+
+	return NewSecurityProvider(ctor)
+}
+
+// Returns bearer header.
+func getBearerHeader(token string) map[string][]string {
+	return map[string][]string{"Authorization": {"Bearer " + token}}
+}
+
+// Tests scoped token authentication.
+func TestTokenScope(t *testing.T) {
+	prov := getTokensProvider(nil)
+	usr, err := prov.GetUser(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", usr.TokenID())
+
+	token, value, err := prov.CreateToken(usr, &providers.APITokenRequest{
+		Name: "read-only",
+		Rules: []providers.SecRoleRule{
+			{
+				System:    providers.SecSystemDevice.String(),
+				StrVerb:   []string{providers.SecVerbGet.String()},
+				Resources: []string{"sensor.*", "switch.*"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "usr1", token.User)
+	assert.Contains(t, value, tokenPrefix+token.ID)
+	assert.NotContains(t, token.Hash, value)
+
+	tokenUsr, err := prov.GetUser(getBearerHeader(value))
+	require.NoError(t, err)
+	assert.Equal(t, "usr1", tokenUsr.Name())
+	assert.Equal(t, token.ID, tokenUsr.TokenID())
+	assert.True(t, tokenUsr.DeviceGet("sensor.1"), "allowed")
+	assert.False(t, tokenUsr.DeviceCommand("sensor.1"), "verb not in scope")
+	assert.False(t, tokenUsr.DeviceGet("light.1"), "resource not in scope")
+	assert.False(t, tokenUsr.DeviceGet("switch.1"), "resource not in role")
+	assert.True(t, usr.DeviceCommand("sensor.1"), "user is not affected")
+
+	_, err = prov.GetUser(getBearerHeader(value + "1"))
+	assert.Error(t, err, "wrong secret")
+	_, err = prov.GetUser(getBearerHeader(tokenPrefix + "wrong"))
+	assert.Error(t, err, "wrong format")
+}
+
+// Tests token without rules.
+func TestTokenWithoutScope(t *testing.T) {
+	prov := getTokensProvider(nil)
+	usr, _ := prov.GetUser(nil)
+	_, value, err := prov.CreateToken(usr, &providers.APITokenRequest{Name: "full"})
+	require.NoError(t, err)
+
+	tokenUsr, err := prov.GetUser(getBearerHeader(value))
+	require.NoError(t, err)
+	assert.True(t, tokenUsr.DeviceCommand("light.1"))
+	assert.False(t, tokenUsr.DeviceGet("switch.1"))
+}
+
+// Tests tokens management.
+func TestTokensManagement(t *testing.T) {
+	store := mocks.FakeNewPersistentStore()
+	prov := getTokensProvider(store)
+	usr, _ := prov.GetUser(nil)
+
+	t1, v1, err := prov.CreateToken(usr, &providers.APITokenRequest{Name: "1"})
+	require.NoError(t, err)
+	t2, v2, err := prov.CreateToken(usr, &providers.APITokenRequest{Name: "2",
+		Expires: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+
+	tokenUsr, err := prov.GetUser(getBearerHeader(v1))
+	require.NoError(t, err)
+	_, _, err = prov.CreateToken(tokenUsr, &providers.APITokenRequest{Name: "3"})
+	assert.IsType(t, &ErrTokenManagement{}, err, "create with token")
+	_, err = prov.GetTokens(tokenUsr)
+	assert.IsType(t, &ErrTokenManagement{}, err, "list with token")
+	assert.IsType(t, &ErrTokenManagement{}, prov.RevokeToken(tokenUsr, t2.ID), "revoke with token")
+
+	reloaded := getTokensProvider(store)
+	tokens, err := reloaded.GetTokens(usr)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(tokens), "reloaded")
+	assert.NotEqual(t, int64(0), tokens[0].LastUsed+tokens[1].LastUsed, "usage")
+
+	_, err = reloaded.GetUser(getBearerHeader(v2))
+	require.NoError(t, err, "reloaded token")
+
+	assert.IsType(t, &ErrTokenNotFound{}, reloaded.RevokeToken(usr, "wrong"))
+	require.NoError(t, reloaded.RevokeToken(usr, t1.ID))
+	_, err = reloaded.GetUser(getBearerHeader(v1))
+	assert.Error(t, err, "revoked")
+
+	tokens, _ = getTokensProvider(store).GetTokens(usr)
+	require.Equal(t, 1, len(tokens), "revoked")
+	assert.Equal(t, t2.ID, tokens[0].ID)
+}
+
+// Tests expired tokens.
+func TestTokenExpired(t *testing.T) {
+	store := mocks.FakeNewPersistentStore()
+	prov := getTokensProvider(store)
+	usr, _ := prov.GetUser(nil)
+
+	token, value, err := prov.CreateToken(usr, &providers.APITokenRequest{Name: "1",
+		Expires: time.Now().Add(time.Second).Unix()})
+	require.NoError(t, err)
+
+	time.Sleep(1100 * time.Millisecond)
+	_, err = prov.GetUser(getBearerHeader(value))
+	require.Error(t, err)
+
+	tokens, _ := getTokensProvider(store).GetTokens(usr)
+	assert.Equal(t, 0, len(tokens), "expired are not loaded")
+	assert.NotEmpty(t, token.ID)
+}
+
+// Tests incorrect token requests.
+func TestWrongTokenRequests(t *testing.T) {
+	prov := getTokensProvider(nil)
+	usr, _ := prov.GetUser(nil)
+
+	input := map[string]*providers.APITokenRequest{
+		"no name": {},
+		"expired": {Name: "1", Expires: 1},
+		"system": {Name: "1", Rules: []providers.SecRoleRule{
+			{System: "wrong", StrVerb: []string{"get"}, Resources: []string{"*"}}}},
+		"verb": {Name: "1", Rules: []providers.SecRoleRule{
+			{System: "device", StrVerb: []string{"wrong"}, Resources: []string{"*"}}}},
+		"resource": {Name: "1", Rules: []providers.SecRoleRule{
+			{System: "device", StrVerb: []string{"get"}, Resources: []string{"["}}}},
+		"no resources": {Name: "1", Rules: []providers.SecRoleRule{
+			{System: "device", StrVerb: []string{"get"}}}},
+	}
+
+	for k, v := range input {
+		_, _, err := prov.CreateToken(usr, v)
+		assert.IsType(t, &ErrInvalidTokenRequest{}, err, k)
+	}
+
+	tokens, err := prov.GetTokens(usr)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(tokens))
+}
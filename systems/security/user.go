@@ -3,9 +3,13 @@ package security
 import "go-home.io/x/server/providers"
 
 // AuthenticatedUser has data with authenticated user, returned by user store.
+// If user is authenticated with API token, Scope additionally restricts Rules.
 type AuthenticatedUser struct {
-	Username string
-	Rules    map[providers.SecSystem][]*providers.BakedRule
+	Username   string
+	Rules      map[providers.SecSystem][]*providers.BakedRule
+	Scope      map[providers.SecSystem][]*providers.BakedRule
+	Token      string
+	UserGroups []string
 }
 
 // Name returns the user name.
@@ -13,6 +17,16 @@ func (u *AuthenticatedUser) Name() string {
 	return u.Username
 }
 
+// TokenID returns ID of the API token used for authentication, if any.
+func (u *AuthenticatedUser) TokenID() string {
+	return u.Token
+}
+
+// Groups returns groups the user belongs to.
+func (u *AuthenticatedUser) Groups() []string {
+	return u.UserGroups
+}
+
 // TriggerGet get verifies whether user is allowed to get a trigger.
 func (u *AuthenticatedUser) TriggerGet(triggerID string) bool {
 	return u.verifyEntity(providers.SecSystemTrigger, providers.SecVerbGet, triggerID)
@@ -53,9 +67,34 @@ func (u *AuthenticatedUser) Logs() bool {
 	return u.verifyEntity(providers.SecSystemCore, providers.SecVerbGet, "logs")
 }
 
+// Audit verifies whether user is allowed to query audit log.
+func (u *AuthenticatedUser) Audit() bool {
+	return u.verifyEntity(providers.SecSystemCore, providers.SecVerbGet, "audit")
+}
+
+// AlarmArm verifies whether user is allowed to arm an alarm panel.
+func (u *AuthenticatedUser) AlarmArm(alarmID string) bool {
+	return u.verifyEntity(providers.SecSystemAlarm, providers.SecVerbArm, alarmID)
+}
+
+// AlarmDisarm verifies whether user is allowed to disarm an alarm panel.
+func (u *AuthenticatedUser) AlarmDisarm(alarmID string) bool {
+	return u.verifyEntity(providers.SecSystemAlarm, providers.SecVerbDisarm, alarmID)
+}
+
 // Verifies access.
 func (u *AuthenticatedUser) verifyEntity(system providers.SecSystem, verb providers.SecVerb, entityID string) bool {
-	for k, v := range u.Rules {
+	if !u.verifyRules(u.Rules, system, verb, entityID) {
+		return false
+	}
+
+	return nil == u.Scope || u.verifyRules(u.Scope, system, verb, entityID)
+}
+
+// Verifies access against the rules set.
+func (u *AuthenticatedUser) verifyRules(rules map[providers.SecSystem][]*providers.BakedRule,
+	system providers.SecSystem, verb providers.SecVerb, entityID string) bool {
+	for k, v := range rules {
 		if k == providers.SecSystemAll || k == system {
 			if u.isAllowed(v, verb, entityID) {
 				return true
@@ -83,8 +122,16 @@ func (u *AuthenticatedUser) isAllowed(rules []*providers.BakedRule, verb provide
 			if !v.History {
 				continue
 			}
+		case providers.SecVerbArm:
+			if !v.Arm {
+				continue
+			}
+		case providers.SecVerbDisarm:
+			if !v.Disarm {
+				continue
+			}
 		default:
-			if !v.Get && !v.Command && !v.History {
+			if !v.Get && !v.Command && !v.History && !v.Arm && !v.Disarm {
 				continue
 			}
 		}
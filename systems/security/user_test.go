@@ -20,6 +20,7 @@ func checkAllAllowed(t *testing.T, user providers.IAuthenticatedUser) {
 	assert.True(t, user.Workers(), "workers")
 	assert.True(t, user.Entities(), "entities")
 	assert.True(t, user.Entities(), "logs")
+	assert.True(t, user.Audit(), "audit")
 }
 
 // Tests whether baked rules are interpreted correctly to allow operations.
@@ -322,4 +323,35 @@ func TestLogsForbidden(t *testing.T) {
 	}
 
 	assert.False(t, user.Logs())
+	assert.False(t, user.Audit())
+}
+
+// Tests alarm verbs.
+func TestAlarmVerbs(t *testing.T) {
+	user := &AuthenticatedUser{
+		Rules: map[providers.SecSystem][]*providers.BakedRule{
+			providers.SecSystemAlarm: {
+				{
+					Arm: true,
+					Resources: []glob.Glob{
+						compileRegexp("alarm.*"),
+					},
+				},
+			},
+			providers.SecSystemDevice: {
+				{
+					Get:     true,
+					Command: true,
+					Disarm:  true,
+					Resources: []glob.Glob{
+						compileRegexp("*"),
+					},
+				},
+			},
+		},
+	}
+
+	assert.True(t, user.AlarmArm("alarm.house"), "arm")
+	assert.False(t, user.AlarmArm("house"), "arm other")
+	assert.False(t, user.AlarmDisarm("alarm.house"), "disarm from other system")
 }
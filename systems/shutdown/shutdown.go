@@ -0,0 +1,150 @@
+// Package shutdown contains graceful shutdown coordinator.
+package shutdown
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+)
+
+const (
+	// Logger system representation.
+	logSystem = "shutdown"
+	// Default timeout of a single shutdown step.
+	defaultStepTimeout = 10 * time.Second
+)
+
+// ConstructShutdown has data required for a new shutdown coordinator.
+type ConstructShutdown struct {
+	Logger      common.ILoggerProvider
+	StepTimeout time.Duration
+}
+
+// Single shutdown step.
+type step struct {
+	name string
+	run  func(context.Context)
+}
+
+// Shutdown coordinator implementation.
+// Steps are executed one by one in the order they were added,
+// every step has its own deadline.
+type provider struct {
+	sync.Mutex
+	logger      common.ILoggerProvider
+	stepTimeout time.Duration
+	steps       []*step
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+	done    chan bool
+	signals chan os.Signal
+	exit    func(int)
+}
+
+// NewShutdownProvider constructs a new shutdown coordinator.
+func NewShutdownProvider(ctor *ConstructShutdown) providers.IShutdownProvider {
+	p := &provider{
+		logger:      ctor.Logger,
+		stepTimeout: ctor.StepTimeout,
+		steps:       make([]*step, 0),
+		done:        make(chan bool),
+		signals:     make(chan os.Signal, 2),
+		exit:        os.Exit,
+	}
+
+	if p.stepTimeout <= 0 {
+		p.stepTimeout = defaultStepTimeout
+	}
+
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
+}
+
+// Context returns context which is cancelled once shutdown starts.
+func (p *provider) Context() context.Context {
+	return p.ctx
+}
+
+// Add registers a new shutdown step.
+func (p *provider) Add(name string, run func(context.Context)) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.steps = append(p.steps, &step{name: name, run: run})
+}
+
+// Shutdown executes all registered steps.
+// Concurrent calls are waiting for the first one to complete.
+func (p *provider) Shutdown() {
+	p.once.Do(func() {
+		p.cancel()
+
+		p.Lock()
+		steps := make([]*step, len(p.steps))
+		copy(steps, p.steps)
+		p.Unlock()
+
+		for _, v := range steps {
+			p.runStep(v)
+		}
+
+		p.logger.Info("Shutdown completed", common.LogSystemToken, logSystem)
+		close(p.done)
+	})
+
+	<-p.done
+}
+
+// Wait blocks until stop signal is received and shutdown is completed.
+// Second signal terminates the process immediately.
+func (p *provider) Wait() {
+	signal.Notify(p.signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(p.signals)
+
+	select {
+	case <-p.signals:
+		p.logger.Info("Received stop command, shutting down", common.LogSystemToken, logSystem)
+		go p.Shutdown()
+	case <-p.ctx.Done():
+	}
+
+	for {
+		select {
+		case <-p.signals:
+			p.logger.Warn("Received second stop command, exiting immediately", common.LogSystemToken, logSystem)
+			p.exit(1)
+			return
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Executes a single step with the deadline.
+func (p *provider) runStep(s *step) {
+	p.logger.Debug("Executing shutdown step", common.LogSystemToken, logSystem, common.LogNameToken, s.name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.stepTimeout)
+	defer cancel()
+
+	finished := make(chan bool)
+	go func() {
+		defer close(finished)
+		s.run(ctx)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		p.logger.Warn("Shutdown step didn't finish in time", common.LogSystemToken, logSystem,
+			common.LogNameToken, s.name)
+	}
+}
@@ -0,0 +1,107 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go-home.io/x/server/mocks"
+)
+
+// Returns coordinator with short steps timeout.
+func getFakeProvider(logs *[]string) *provider {
+	mtx := sync.Mutex{}
+	return NewShutdownProvider(&ConstructShutdown{
+		Logger: mocks.FakeNewLogger(func(s string) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			*logs = append(*logs, s)
+		}),
+		StepTimeout: 100 * time.Millisecond,
+	}).(*provider)
+}
+
+// Tests steps order and timeouts.
+func TestShutdownSteps(t *testing.T) {
+	logs := make([]string, 0)
+	p := getFakeProvider(&logs)
+	order := make([]string, 0)
+
+	p.Add("first", func(ctx context.Context) {
+		order = append(order, "first")
+	})
+	p.Add("hung", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+	})
+	p.Add("last", func(ctx context.Context) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "deadline")
+		assert.NoError(t, ctx.Err(), "own deadline")
+		order = append(order, "last")
+	})
+
+	assert.NoError(t, p.Context().Err())
+	p.Shutdown()
+	p.Shutdown()
+
+	assert.Error(t, p.Context().Err(), "cancelled")
+	assert.Equal(t, []string{"first", "last"}, order)
+	assert.Contains(t, logs, "Shutdown step didn't finish in time")
+	assert.Contains(t, logs, "Shutdown completed")
+}
+
+// Tests signals processing.
+func TestShutdownSignals(t *testing.T) {
+	logs := make([]string, 0)
+	p := getFakeProvider(&logs)
+	exitCode := -1
+	p.exit = func(code int) {
+		exitCode = code
+	}
+
+	release := make(chan bool)
+	p.Add("slow", func(ctx context.Context) {
+		<-release
+	})
+
+	finished := make(chan bool)
+	go func() {
+		p.Wait()
+		close(finished)
+	}()
+
+	p.signals <- os.Interrupt
+	p.signals <- os.Interrupt
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		assert.Fail(t, "wait didn't return")
+	}
+
+	close(release)
+	assert.Equal(t, 1, exitCode, "forced exit")
+}
+
+// Tests that Wait returns after direct shutdown.
+func TestShutdownWithoutSignal(t *testing.T) {
+	logs := make([]string, 0)
+	p := getFakeProvider(&logs)
+
+	finished := make(chan bool)
+	go func() {
+		p.Wait()
+		close(finished)
+	}()
+
+	p.Shutdown()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		assert.Fail(t, "wait didn't return")
+	}
+}
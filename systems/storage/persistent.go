@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// File-based persistent store.
+// All entries are kept in a single yaml file.
+type fileStore struct {
+	sync.Mutex
+	location string
+	logger   common.ILoggerProvider
+	data     map[string]interface{}
+}
+
+// NewPersistentStore creates a new persistent store, located in configs directory.
+func NewPersistentStore(logger common.ILoggerProvider) providers.IPersistentStoreProvider {
+	return NewFilePersistentStore(fmt.Sprintf("%s/_state.yaml", utils.GetDefaultConfigsDir()), logger)
+}
+
+// NewFilePersistentStore creates a new persistent store with a custom location.
+func NewFilePersistentStore(location string, logger common.ILoggerProvider) providers.IPersistentStoreProvider {
+	s := &fileStore{
+		location: location,
+		logger:   logger,
+		data:     make(map[string]interface{}),
+	}
+
+	fileData, err := ioutil.ReadFile(location)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("Failed to read persistent state file", err, common.LogFileToken, location)
+		}
+
+		return s
+	}
+
+	err = yaml.Unmarshal(fileData, &s.data)
+	if err != nil {
+		logger.Error("Failed to unmarshal persistent state file. Be aware that it will be rewritten", err,
+			common.LogFileToken, location)
+		s.data = make(map[string]interface{})
+	}
+
+	return s
+}
+
+// Load populates target with a stored value.
+// Returns false if value doesn't exist.
+func (s *fileStore) Load(key string, target interface{}) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.data[key]
+	if !ok {
+		return false
+	}
+
+	tmp, err := yaml.Marshal(v)
+	if err != nil {
+		return false
+	}
+
+	err = yaml.Unmarshal(tmp, target)
+	if err != nil {
+		s.logger.Error("Failed to load persistent value", err, common.LogIDToken, key)
+		return false
+	}
+
+	return true
+}
+
+// Save stores a copy of the value and flushes the file.
+// File is replaced atomically, so a crash during write never leaves it truncated.
+func (s *fileStore) Save(key string, data interface{}) {
+	value, err := yaml.Marshal(data)
+	if err != nil {
+		s.logger.Error("Failed to marshal persistent value", err, common.LogIDToken, key)
+		return
+	}
+
+	var stored interface{}
+	err = yaml.Unmarshal(value, &stored)
+	if err != nil {
+		s.logger.Error("Failed to copy persistent value", err, common.LogIDToken, key)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.data[key] = stored
+	fileData, err := yaml.Marshal(s.data)
+	if err != nil {
+		s.logger.Error("Failed to marshal persistent state", err, common.LogIDToken, key)
+		return
+	}
+
+	err = s.write(fileData)
+	if err != nil {
+		s.logger.Error("Failed to write persistent state file", err, common.LogFileToken, s.location)
+	}
+}
+
+// Writes data into a temp file and replaces the state file with it.
+func (s *fileStore) write(fileData []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(s.location), filepath.Base(s.location))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(fileData)
+	if nil == err {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); nil == err {
+		err = closeErr
+	}
+
+	if nil == err {
+		err = os.Rename(f.Name(), s.location)
+	}
+
+	if err != nil {
+		os.Remove(f.Name()) // nolint: errcheck, gosec
+	}
+
+	return err
+}
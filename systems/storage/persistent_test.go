@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+)
+
+type persistentValue struct {
+	Name  string `yaml:"name"`
+	Value int    `yaml:"value"`
+}
+
+// Tests persistent store round trip.
+func TestPersistentStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gohome")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	location := fmt.Sprintf("%s/_state.yaml", dir)
+	s := NewFilePersistentStore(location, mocks.FakeNewLogger(nil))
+
+	target := &persistentValue{}
+	assert.False(t, s.Load("test", target), "empty store")
+
+	value := &persistentValue{Name: "test", Value: 10}
+	s.Save("test", value)
+	s.Save("other", "value")
+	value.Value = 20
+	require.True(t, s.Load("test", target), "copy")
+	assert.Equal(t, 10, target.Value, "stored value is a copy")
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(files), "temp files")
+
+	s = NewFilePersistentStore(location, mocks.FakeNewLogger(nil))
+	require.True(t, s.Load("test", target), "reloaded")
+	assert.Equal(t, "test", target.Name)
+	assert.Equal(t, 10, target.Value)
+
+	str := ""
+	require.True(t, s.Load("other", &str), "string")
+	assert.Equal(t, "value", str)
+}
+
+// Tests corrupted file.
+func TestPersistentStoreCorrupted(t *testing.T) {
+	f, err := ioutil.TempFile("", "gohome")
+	require.NoError(t, err)
+	defer os.Remove(f.Name()) // nolint: errcheck
+
+	_, err = f.WriteString("{{")
+	require.NoError(t, err)
+	f.Close() // nolint: errcheck, gosec
+
+	s := NewFilePersistentStore(f.Name(), mocks.FakeNewLogger(nil))
+	str := ""
+	assert.False(t, s.Load("test", &str))
+}
@@ -3,6 +3,7 @@ package storage
 
 import (
 	"sync"
+	"time"
 
 	"github.com/gobwas/glob"
 	"go-home.io/x/server/plugins/common"
@@ -11,6 +12,7 @@ import (
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
 	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/systems/metrics"
 	"gopkg.in/yaml.v2"
 )
 
@@ -20,6 +22,12 @@ type provider struct {
 	plugin   storage.IStorage
 	logger   common.ILoggerProvider
 	settings *settings
+
+	pendingLock sync.Mutex
+	pending     sync.WaitGroup
+	closed      bool
+
+	isConfigured bool
 }
 
 // Provider settings.
@@ -96,8 +104,9 @@ func NewStorageProvider(ctor *ConstructStorage) providers.IStorageProvider {
 	}
 
 	prov := &provider{
-		logger:   log,
-		settings: settings,
+		logger:       log,
+		settings:     settings,
+		isConfigured: true,
 	}
 
 	pluginInterface, err := ctor.Loader.LoadPlugin(pluginLoadRequest)
@@ -112,6 +121,10 @@ func NewStorageProvider(ctor *ConstructStorage) providers.IStorageProvider {
 
 // State stores a new state entry.
 func (s *provider) State(msg *common.MsgDeviceUpdate) {
+	if !s.addPending() {
+		return
+	}
+
 	go s.processDeviceUpdate(msg)
 }
 
@@ -121,9 +134,55 @@ func (s *provider) Heartbeat(deviceID string) {
 		return
 	}
 
+	if !s.addPending() {
+		return
+	}
+
 	go s.processHeartbeat(deviceID)
 }
 
+// Flush waits for pending entries and flushes plugin, if it's supported.
+// Entries received after flush are ignored.
+func (s *provider) Flush() {
+	s.pendingLock.Lock()
+	s.closed = true
+	s.pendingLock.Unlock()
+
+	s.pending.Wait()
+
+	s.Lock()
+	defer s.Unlock()
+	if f, ok := s.plugin.(storage.IFlushableStorage); ok {
+		s.logger.Debug("Flushing storage")
+		f.Flush()
+	}
+}
+
+// Registers a new pending entry.
+// Returns false if storage is already flushed.
+func (s *provider) addPending() bool {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.pending.Add(1)
+	return true
+}
+
+// GetSpecs returns storage state.
+func (s *provider) GetSpecs() *providers.StorageSpecs {
+	s.Lock()
+	defer s.Unlock()
+
+	return &providers.StorageSpecs{
+		IsConfigured: s.isConfigured,
+		IsLoaded:     nil != s.plugin,
+	}
+}
+
 // History returns device state history for the past 24 hrs.
 func (s *provider) History(deviceID string) map[enums.Property]map[int64]interface{} {
 	s.Lock()
@@ -164,6 +223,7 @@ func (s *provider) History(deviceID string) map[enums.Property]map[int64]interfa
 
 // Processes device update message.
 func (s *provider) processDeviceUpdate(msg *common.MsgDeviceUpdate) {
+	defer s.pending.Done()
 	s.Lock()
 	defer s.Unlock()
 	if nil == s.plugin || !s.needToSave(msg.Type, msg.ID) {
@@ -188,11 +248,14 @@ func (s *provider) processDeviceUpdate(msg *common.MsgDeviceUpdate) {
 		data[k.String()] = d
 	}
 
+	start := time.Now()
 	s.plugin.State(msg.ID, data)
+	metrics.StorageWriteDuration.Since(start, "state")
 }
 
 // Processes device heartbeat event.
 func (s *provider) processHeartbeat(deviceID string) {
+	defer s.pending.Done()
 	s.Lock()
 	defer s.Unlock()
 
@@ -200,7 +263,9 @@ func (s *provider) processHeartbeat(deviceID string) {
 		return
 	}
 
+	start := time.Now()
 	s.plugin.Heartbeat(deviceID)
+	metrics.StorageWriteDuration.Since(start, "heartbeat")
 }
 
 func (s *provider) needToSave(deviceType enums.DeviceType, deviceID string) bool {
@@ -10,6 +10,7 @@ import (
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/plugins/device/enums"
 	"go-home.io/x/server/plugins/storage"
+	"go-home.io/x/server/providers"
 )
 
 // Fake plugin.
@@ -49,6 +50,7 @@ func TestNewEmptyStorageProvider(t *testing.T) {
 	p.History("test")
 	p.Heartbeat("test")
 	p.State(nil)
+	assert.Equal(t, &providers.StorageSpecs{}, p.GetSpecs(), "specs")
 }
 
 // Tests that failed plugin is not causing panic.
@@ -70,6 +72,7 @@ storeHeartbeat: true`),
 	p.History("test")
 	p.Heartbeat("test")
 	p.State(nil)
+	assert.Equal(t, &providers.StorageSpecs{IsConfigured: true}, p.GetSpecs(), "specs")
 }
 
 // Test correct invokes.
@@ -84,6 +87,7 @@ func TestNewStorageProvider(t *testing.T) {
 	}
 
 	p := NewStorageProvider(ctor)
+	assert.Equal(t, &providers.StorageSpecs{IsConfigured: true, IsLoaded: true}, p.GetSpecs(), "specs")
 
 	update := &common.MsgDeviceUpdate{
 		State:     map[enums.Property]interface{}{enums.PropOn: true},
@@ -139,6 +143,7 @@ exclude:
 	}
 
 	p := NewStorageProvider(ctor)
+	assert.Equal(t, &providers.StorageSpecs{IsConfigured: true, IsLoaded: true}, p.GetSpecs(), "specs")
 
 	update := &common.MsgDeviceUpdate{
 		State:     map[enums.Property]interface{}{enums.PropOn: true},
@@ -165,6 +170,7 @@ func TestIgnores(t *testing.T) {
 	}
 
 	p := NewStorageProvider(ctor)
+	assert.Equal(t, &providers.StorageSpecs{IsConfigured: true, IsLoaded: true}, p.GetSpecs(), "specs")
 
 	update := &common.MsgDeviceUpdate{
 		State:     map[enums.Property]interface{}{enums.PropOn: true},
@@ -193,6 +199,7 @@ include:
 	}
 
 	p := NewStorageProvider(ctor)
+	assert.Equal(t, &providers.StorageSpecs{IsConfigured: true, IsLoaded: true}, p.GetSpecs(), "specs")
 
 	update := &common.MsgDeviceUpdate{
 		State:     map[enums.Property]interface{}{enums.PropOn: true},
@@ -206,3 +213,42 @@ include:
 	time.Sleep(1 * time.Second)
 	assert.Equal(t, 1, pl.invokes)
 }
+
+// Fake plugin with flush support.
+type fakeFlushPlugin struct {
+	fakePlugin
+	flushedAfter int
+}
+
+func (f *fakeFlushPlugin) Flush() {
+	f.flushedAfter = f.invokes
+}
+
+// Tests that flush waits for pending entries.
+func TestFlush(t *testing.T) {
+	pl := &fakeFlushPlugin{flushedAfter: -1}
+	ctor := &ConstructStorage{
+		PluginLogger: mocks.FakeNewLogger(nil),
+		RawConfig:    []byte("storeHeartbeat: true"),
+		Loader:       mocks.FakeNewPluginLoader(pl),
+		Provider:     "test",
+		Secret:       mocks.FakeNewSecretStore(nil, true),
+	}
+
+	p := NewStorageProvider(ctor)
+	p.Heartbeat("test")
+	p.State(&common.MsgDeviceUpdate{
+		State: map[enums.Property]interface{}{enums.PropOn: true},
+		ID:    "test",
+		Type:  enums.DevWeather,
+	})
+
+	p.Flush()
+	assert.Equal(t, 2, pl.flushedAfter)
+
+	p.Heartbeat("test")
+	p.Flush()
+	assert.Equal(t, 2, pl.flushedAfter, "after flush")
+
+	NewEmptyStorageProvider().Flush()
+}
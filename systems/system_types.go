@@ -30,4 +30,8 @@ const (
 	SysStorage
 	// SysNotification describes push-type notifications system.
 	SysNotification
+	// SysAudit describes user actions audit log system.
+	SysAudit
+	// SysWebhook describes outbound webhooks system.
+	SysWebhook
 )
@@ -1,6 +1,5 @@
 // Code generated by "enumer -type=SystemType -transform=kebab -trimprefix=Sys -json -text -yaml"; DO NOT EDIT.
 
-//
 package systems
 
 import (
@@ -8,9 +7,9 @@ import (
 	"fmt"
 )
 
-const _SystemTypeName = "go-homeloggerbusdevicesecretconfigsecuritytriggerapiuistoragenotification"
+const _SystemTypeName = "go-homeloggerbusdevicesecretconfigsecuritytriggerapiuistoragenotificationauditwebhook"
 
-var _SystemTypeIndex = [...]uint8{0, 7, 13, 16, 22, 28, 34, 42, 49, 52, 54, 61, 73}
+var _SystemTypeIndex = [...]uint8{0, 7, 13, 16, 22, 28, 34, 42, 49, 52, 54, 61, 73, 78, 85}
 
 func (i SystemType) String() string {
 	if i < 0 || i >= SystemType(len(_SystemTypeIndex)-1) {
@@ -19,7 +18,7 @@ func (i SystemType) String() string {
 	return _SystemTypeName[_SystemTypeIndex[i]:_SystemTypeIndex[i+1]]
 }
 
-var _SystemTypeValues = []SystemType{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+var _SystemTypeValues = []SystemType{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}
 
 var _SystemTypeNameToValueMap = map[string]SystemType{
 	_SystemTypeName[0:7]:   0,
@@ -34,6 +33,8 @@ var _SystemTypeNameToValueMap = map[string]SystemType{
 	_SystemTypeName[52:54]: 9,
 	_SystemTypeName[54:61]: 10,
 	_SystemTypeName[61:73]: 11,
+	_SystemTypeName[73:78]: 12,
+	_SystemTypeName[78:85]: 13,
 }
 
 // SystemTypeString retrieves an enum value from the enum constants string name.
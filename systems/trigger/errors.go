@@ -17,3 +17,39 @@ type ErrInvalidActionConfig struct {
 func (*ErrInvalidActionConfig) Error() string {
 	return "invalid action config"
 }
+
+// ErrInvalidHookConfig defines invalid webhook trigger config.
+type ErrInvalidHookConfig struct {
+}
+
+// Error formats output.
+func (*ErrInvalidHookConfig) Error() string {
+	return "invalid webhook trigger config"
+}
+
+// ErrNotHook defines call of a trigger, which doesn't accept inbound calls.
+type ErrNotHook struct {
+}
+
+// Error formats output.
+func (*ErrNotHook) Error() string {
+	return "trigger is not a webhook"
+}
+
+// ErrHookUnauthorized defines inbound call with wrong secret or signature.
+type ErrHookUnauthorized struct {
+}
+
+// Error formats output.
+func (*ErrHookUnauthorized) Error() string {
+	return "wrong secret or signature"
+}
+
+// ErrHookBadPayload defines inbound call with invalid JSON body.
+type ErrHookBadPayload struct {
+}
+
+// Error formats output.
+func (*ErrHookBadPayload) Error() string {
+	return "body is not a valid JSON"
+}
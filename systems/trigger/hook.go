@@ -0,0 +1,176 @@
+package trigger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	pluginTrigger "go-home.io/x/server/plugins/trigger"
+	"go-home.io/x/server/providers"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Built-in inbound webhook trigger provider name.
+	hookProvider = "webhook"
+	// Header with a shared secret.
+	hookSecretHeader = "X-Go-Home-Secret"
+	// Query parameter with a shared secret.
+	hookSecretParam = "secret"
+	// Generated hook ID length in bytes.
+	hookIDLength = 16
+)
+
+// Inbound webhook trigger settings.
+// If hook ID is not set, random one is generated and preserved between restarts.
+type hookSettings struct {
+	HookID          string `yaml:"hookID" validate:"omitempty,min=16"`
+	Secret          string `yaml:"secret"`
+	HMACSecret      string `yaml:"hmacSecret"`
+	SignatureHeader string `yaml:"signatureHeader" default:"X-Go-Home-Signature"`
+}
+
+// Built-in trigger, fired by inbound HTTP calls.
+type hookTrigger struct {
+	id        string
+	settings  *hookSettings
+	triggered chan interface{}
+}
+
+// Creates a new inbound webhook trigger.
+func newHookTrigger(ctor *ConstructTrigger, triggerID string, log common.ILoggerProvider) (*hookTrigger, error) {
+	settings := &hookSettings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !ctor.Validator.Validate(settings) {
+		return nil, &ErrInvalidHookConfig{}
+	}
+
+	h := &hookTrigger{
+		id:       settings.HookID,
+		settings: settings,
+	}
+
+	if "" == h.id {
+		h.id, err = getHookID(ctor.Store, triggerID)
+		if err != nil {
+			return nil, errors.Wrap(err, "hook id generation failed")
+		}
+	}
+
+	log.Info(fmt.Sprintf("Webhook trigger is available at /pub/hook/%s", h.id))
+	return h, nil
+}
+
+// Init saves trigger callback channel.
+func (h *hookTrigger) Init(data *pluginTrigger.InitDataTrigger) error {
+	h.triggered = data.Triggered
+	return nil
+}
+
+// Validates inbound call and fires the trigger.
+func (h *hookTrigger) fire(header http.Header, query map[string][]string, body []byte) error {
+	if !h.isAuthorized(header, query, body) {
+		return &ErrHookUnauthorized{}
+	}
+
+	payload, err := getHookPayload(query, body)
+	if err != nil {
+		return err
+	}
+
+	h.triggered <- payload
+	return nil
+}
+
+// Checks shared secret and body signature, if configured.
+func (h *hookTrigger) isAuthorized(header http.Header, query map[string][]string, body []byte) bool {
+	if "" != h.settings.Secret {
+		secret := header.Get(hookSecretHeader)
+		if "" == secret && 0 != len(query[hookSecretParam]) {
+			secret = query[hookSecretParam][0]
+		}
+
+		if 1 != subtle.ConstantTimeCompare([]byte(secret), []byte(h.settings.Secret)) {
+			return false
+		}
+	}
+
+	if "" != h.settings.HMACSecret {
+		signature := strings.TrimPrefix(header.Get(h.settings.SignatureHeader), "sha256=")
+		received, err := hex.DecodeString(signature)
+		if err != nil {
+			return false
+		}
+
+		mac := hmac.New(sha256.New, []byte(h.settings.HMACSecret))
+		mac.Write(body) // nolint: gosec, errcheck
+		if !hmac.Equal(received, mac.Sum(nil)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns parsed JSON body.
+// If body is empty, query parameters are used instead.
+func getHookPayload(query map[string][]string, body []byte) (interface{}, error) {
+	if 0 != len(strings.TrimSpace(string(body))) {
+		var payload interface{}
+		err := json.Unmarshal(body, &payload)
+		if err != nil {
+			return nil, &ErrHookBadPayload{}
+		}
+
+		return payload, nil
+	}
+
+	payload := make(map[string]interface{})
+	for k, v := range query {
+		if hookSecretParam == k || 0 == len(v) {
+			continue
+		}
+
+		if 1 == len(v) {
+			payload[k] = v[0]
+		} else {
+			payload[k] = v
+		}
+	}
+
+	return payload, nil
+}
+
+// Returns preserved hook ID or generates a new one.
+func getHookID(store providers.IPersistentStoreProvider, triggerID string) (string, error) {
+	key := fmt.Sprintf("hook.%s", triggerID)
+	id := ""
+	if nil != store && store.Load(key, &id) && "" != id {
+		return id, nil
+	}
+
+	data := make([]byte, hookIDLength)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+
+	id = hex.EncodeToString(data)
+	if nil != store {
+		store.Save(key, id)
+	}
+
+	return id, nil
+}
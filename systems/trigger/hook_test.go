@@ -0,0 +1,183 @@
+package trigger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+)
+
+// Returns webhook trigger constructor.
+func getHookCtor(config string, srv mocks.IFakeServer, store providers.IPersistentStoreProvider) *ConstructTrigger {
+	return &ConstructTrigger{
+		Logger:    mocks.FakeNewLogger(nil),
+		Validator: utils.NewValidator(mocks.FakeNewLogger(nil)),
+		Loader:    mocks.FakeNewPluginLoader(nil),
+		Secret:    mocks.FakeNewSecretStore(nil, false),
+		FanOut:    mocks.FakeNewFanOut(),
+		Storage:   mocks.FakeNewStorage(),
+		Store:     store,
+		Provider:  hookProvider,
+		Name:      "hook",
+		Server:    srv.(providers.IServerProvider),
+		Timezone:  getUTC(),
+		RawConfig: []byte(config),
+	}
+}
+
+// Returns body signature.
+func signHook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body) // nolint: gosec, errcheck
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Tests webhook trigger secrets validation and payload templates.
+func TestHookInvoke(t *testing.T) {
+	srv := mocks.FakeNewServer(nil)
+	tr, err := NewTrigger(getHookCtor(`
+secret: shared
+hmacSecret: signed
+actions:
+    - system: notification
+      entity: hub
+      message: "{{ .Name }}: {{ .Payload.msg }}"
+    - system: device
+      entity: light
+      command: set-brightness
+      args: "{{ .Payload.level }}"
+`, srv, mocks.FakeNewPersistentStore()))
+	require.NoError(t, err)
+
+	hook := tr.(providers.IHookTriggerProvider)
+	body := []byte(`{"msg": "hello", "level": 42}`)
+	signature := signHook("signed", body)
+
+	header := http.Header{}
+	header.Set(hookSecretHeader, "wrong")
+	header.Set("X-Go-Home-Signature", signature)
+	assert.IsType(t, &ErrHookUnauthorized{}, hook.Hook(header, nil, body), "wrong secret")
+
+	header.Set(hookSecretHeader, "shared")
+	assert.IsType(t, &ErrHookUnauthorized{}, hook.Hook(header, nil, []byte(`{}`)), "wrong signature")
+	assert.IsType(t, &ErrHookBadPayload{}, hook.Hook(http.Header{"X-Go-Home-Signature": {signHook("signed", body[1:])}},
+		map[string][]string{hookSecretParam: {"shared"}}, body[1:]), "secret in query")
+
+	require.NoError(t, hook.Hook(header, nil, body))
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Equal(t, []string{"hook: hello"}, srv.GetNotifications())
+	assert.Equal(t, []enums.Command{enums.CmdSetBrightness}, srv.GetInvokedCommands())
+	assert.True(t, tr.GetLastTriggeredTime() > 0)
+}
+
+// Tests hook ID generation and persistence.
+func TestHookID(t *testing.T) {
+	config := `
+actions:
+    - system: notification
+      entity: hub
+`
+	store := mocks.FakeNewPersistentStore()
+	tr, err := NewTrigger(getHookCtor(config, mocks.FakeNewServer(nil), store))
+	require.NoError(t, err)
+	id := tr.(providers.IHookTriggerProvider).HookID()
+	assert.Len(t, id, 2*hookIDLength)
+
+	tr, err = NewTrigger(getHookCtor(config, mocks.FakeNewServer(nil), store))
+	require.NoError(t, err)
+	assert.Equal(t, id, tr.(providers.IHookTriggerProvider).HookID(), "not preserved")
+
+	tr, err = NewTrigger(getHookCtor(config+"hookID: 0123456789abcdef", mocks.FakeNewServer(nil), store))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", tr.(providers.IHookTriggerProvider).HookID(), "configured")
+
+	_, err = NewTrigger(getHookCtor(config+"hookID: short", mocks.FakeNewServer(nil), store))
+	assert.Error(t, err, "short id")
+}
+
+// Tests that plugin triggers don't accept inbound calls.
+func TestNotHook(t *testing.T) {
+	w := &wrapper{logger: mocks.FakeNewLogger(nil)}
+	assert.Equal(t, "", w.HookID())
+	assert.IsType(t, &ErrNotHook{}, w.Hook(http.Header{}, nil, nil))
+}
+
+// Tests payload parsing.
+func TestHookPayload(t *testing.T) {
+	payload, err := getHookPayload(map[string][]string{
+		"state":         {"on"},
+		"tags":          {"a", "b"},
+		hookSecretParam: {"secret"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"state": "on", "tags": []string{"a", "b"}}, payload)
+
+	payload, err = getHookPayload(nil, []byte(`[1, 2]`))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, payload)
+
+	_, err = getHookPayload(nil, []byte(`{`))
+	assert.IsType(t, &ErrHookBadPayload{}, err)
+}
+
+// Tests device action arguments templates.
+func TestDeviceArgsTemplate(t *testing.T) {
+	w := &wrapper{logger: mocks.FakeNewLogger(nil), validator: utils.NewValidator(mocks.FakeNewLogger(nil))}
+	w.deviceActions = make([]*triggerActionDevice, 0)
+	w.loadDeviceAction(map[string]interface{}{
+		"system":  "device",
+		"entity":  "light",
+		"command": "set-color",
+		"args":    map[interface{}]interface{}{"r": "{{ .Payload.r }}", "g": 5, "b": "{{ .Name }}"},
+	})
+	w.loadDeviceAction(map[string]interface{}{
+		"system":  "device",
+		"entity":  "light",
+		"command": "set-color",
+		"args":    map[interface{}]interface{}{"r": "{{ .Payload.r "},
+	})
+	require.Len(t, w.deviceActions, 1, "invalid template was loaded")
+
+	args, err := w.getDeviceArgs(w.deviceActions[0], &actionTemplateData{
+		Name:    "7",
+		Payload: map[string]interface{}{"r": 10},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"r": 10, "g": 5, "b": 7}, args)
+
+	_, err = w.getDeviceArgs(w.deviceActions[0], &actionTemplateData{Payload: map[string]interface{}{"r": "x"}})
+	assert.Error(t, err, "wrong value")
+}
+
+// Tests that rendered arguments are converted only into scalars.
+func TestCoerceScalar(t *testing.T) {
+	data := map[string]interface{}{
+		"10":           10,
+		" 1.5 ":        1.5,
+		"true":         true,
+		"False":        false,
+		"t":            "t",
+		"NaN":          "NaN",
+		"inf":          "inf",
+		"text":         "text",
+		"":             "",
+		"{ a: 1 }":     "{ a: 1 }",
+		"[ 1, 2 ]":     "[ 1, 2 ]",
+		"&a x":         "&a x",
+		"!!binary eA=": "!!binary eA=",
+	}
+
+	for k, v := range data {
+		assert.Equal(t, v, coerceScalar(k), k)
+	}
+}
@@ -142,6 +142,65 @@ func TestOutsideOfActiveWindowInvokes(t *testing.T) {
 	}
 }
 
+// Tests that trigger fires only in configured home modes.
+func TestModeInvokes(t *testing.T) {
+	called := 0
+	srv := mocks.FakeNewServer(func() {
+		called++
+	})
+	w := wrapper{
+		logger:        mocks.FakeNewLogger(nil),
+		server:        srv.(providers.IServerProvider),
+		deviceActions: []*triggerActionDevice{{}},
+		timezone:      getUTC(),
+		fanOut:        mocks.FakeNewFanOut(),
+		storage:       mocks.FakeNewStorage(),
+		modes:         []string{"away", "night"},
+	}
+
+	data := map[string]int{
+		"":      0,
+		"home":  0,
+		"away":  1,
+		"night": 1,
+	}
+
+	for k, v := range data {
+		called = 0
+		srv.SetHomeMode(k)
+		w.triggered(nil)
+		assert.Equal(t, v, called, "mode %s", k)
+	}
+}
+
+// Tests that trigger respects persons presence.
+func TestPresenceInvokes(t *testing.T) {
+	called := 0
+	srv := mocks.FakeNewServer(func() {
+		called++
+	})
+	w := wrapper{
+		logger:        mocks.FakeNewLogger(nil),
+		server:        srv.(providers.IServerProvider),
+		deviceActions: []*triggerActionDevice{{}},
+		timezone:      getUTC(),
+		fanOut:        mocks.FakeNewFanOut(),
+		storage:       mocks.FakeNewStorage(),
+		presence:      map[string]string{"person.john": "away", "person.jane": "work"},
+	}
+
+	w.triggered(nil)
+	assert.Equal(t, 0, called, "unknown")
+
+	srv.SetPersonPresence("person.john", "away")
+	w.triggered(nil)
+	assert.Equal(t, 0, called, "one matched")
+
+	srv.SetPersonPresence("person.jane", "work")
+	w.triggered(nil)
+	assert.Equal(t, 1, called, "all matched")
+}
+
 type wdSuite struct {
 	suite.Suite
 
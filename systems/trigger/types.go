@@ -3,6 +3,8 @@
 package trigger
 
 import (
+	"text/template"
+
 	"github.com/gobwas/glob"
 	"go-home.io/x/server/plugins/device/enums"
 )
@@ -33,6 +35,7 @@ type triggerActionDevice struct {
 	prepArgs   map[string]interface{}
 	prepEntity glob.Glob
 	cmd        enums.Command
+	templated  bool
 }
 
 // Notification action.
@@ -41,10 +44,20 @@ type triggerActionNotification struct {
 	Message string `yaml:"message"`
 
 	prepEntity glob.Glob
+	messageTpl *template.Template
+}
+
+// Data available in actions templates.
+type actionTemplateData struct {
+	ID      string
+	Name    string
+	Payload interface{}
 }
 
 // Trigger config.
 type trigger struct {
 	Actions   []map[string]interface{} `yaml:"actions" validate:"gt=0"`
 	ActiveHrs string                   `yaml:"activeHrs"`
+	Modes     []string                 `yaml:"modes"`
+	Presence  map[string]string        `yaml:"presence"`
 }
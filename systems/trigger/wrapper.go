@@ -2,8 +2,14 @@
 package trigger
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/gobwas/glob"
@@ -15,6 +21,7 @@ import (
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
 	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/systems/metrics"
 	"go-home.io/x/server/utils"
 	"gopkg.in/yaml.v2"
 )
@@ -22,6 +29,7 @@ import (
 // Describes trigger wrapper object.
 type wrapper struct {
 	trigger     pluginTrigger.ITrigger
+	hook        *hookTrigger
 	logger      common.ILoggerProvider
 	validator   providers.IValidatorProvider
 	ID          string
@@ -41,6 +49,11 @@ type wrapper struct {
 	activeWindow bool
 	from         int
 	to           int
+	modes        []string
+	presence     map[string]string
+
+	unloadMutex sync.RWMutex
+	isUnloaded  bool
 }
 
 // ConstructTrigger has data required to create a new trigger.
@@ -55,10 +68,12 @@ type ConstructTrigger struct {
 	FanOut    providers.IInternalFanOutProvider
 	Server    providers.IServerProvider
 	Storage   providers.IStorageProvider
+	Store     providers.IPersistentStoreProvider
 	Timezone  *time.Location
 }
 
 // NewTrigger creates a new trigger.
+// Webhook provider is built-in and doesn't require a plugin.
 func NewTrigger(ctor *ConstructTrigger) (providers.ITriggerProvider, error) {
 	triggerID := fmt.Sprintf("%s.%s", utils.NormalizeDeviceName(ctor.Name), systems.SysTrigger.String())
 
@@ -86,6 +101,8 @@ func NewTrigger(ctor *ConstructTrigger) (providers.ITriggerProvider, error) {
 		timezone:  ctor.Timezone,
 		fanOut:    ctor.FanOut,
 		storage:   ctor.Storage,
+		modes:     cfg.Modes,
+		presence:  cfg.Presence,
 	}
 	err = w.loadActions(cfg.Actions)
 	if err != nil {
@@ -113,7 +130,13 @@ func NewTrigger(ctor *ConstructTrigger) (providers.ITriggerProvider, error) {
 		InitData:       initData,
 	}
 
-	plugin, err := ctor.Loader.LoadPlugin(request)
+	var plugin interface{}
+	if hookProvider == ctor.Provider {
+		plugin, err = w.loadHook(ctor, initData)
+	} else {
+		plugin, err = ctor.Loader.LoadPlugin(request)
+	}
+
 	if err != nil {
 		log.Error("Failed to load trigger provider", err)
 		return nil, errors.Wrap(err, "plugin load failed")
@@ -138,6 +161,61 @@ func (w *wrapper) GetLastTriggeredTime() int64 {
 	return w.triggeredAt
 }
 
+// HookID returns inbound webhook ID.
+// Empty string is returned if trigger is not a webhook.
+func (w *wrapper) HookID() string {
+	if nil == w.hook {
+		return ""
+	}
+
+	return w.hook.id
+}
+
+// Hook fires the trigger from an inbound webhook call.
+func (w *wrapper) Hook(header http.Header, query map[string][]string, body []byte) error {
+	if nil == w.hook {
+		return &ErrNotHook{}
+	}
+
+	err := w.hook.fire(header, query, body)
+	if err != nil {
+		w.logger.Warn("Rejected webhook call", "error", err.Error())
+	}
+
+	return err
+}
+
+// Loads built-in webhook trigger.
+func (w *wrapper) loadHook(ctor *ConstructTrigger, initData *pluginTrigger.InitDataTrigger) (interface{}, error) {
+	h, err := newHookTrigger(ctor, w.ID, w.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.Init(initData)
+	if err != nil {
+		return nil, err
+	}
+
+	w.hook = h
+	return h, nil
+}
+
+// Unload stops processing of trigger events.
+func (w *wrapper) Unload() {
+	w.unloadMutex.Lock()
+	defer w.unloadMutex.Unlock()
+
+	if w.isUnloaded {
+		return
+	}
+
+	w.isUnloaded = true
+	if u, ok := w.trigger.(common.IUnloadable); ok {
+		u.Unload()
+	}
+}
+
 // Loads all trigger actions.
 func (w *wrapper) loadActions(data []map[string]interface{}) error {
 	w.deviceActions = make([]*triggerActionDevice, 0)
@@ -193,28 +271,84 @@ func (w *wrapper) loadDeviceAction(data map[string]interface{}) {
 		return
 	}
 
-	action.Args, err = helpers.CommandPropertyFixYaml(action.Args, action.cmd)
+	action.templated = false
+	_, err = walkArgs(action.Args, func(arg string) (interface{}, error) {
+		action.templated = true
+		return template.New("arg").Parse(arg)
+	})
 	if err != nil {
-		w.logger.Error("Failed to validate action properties", err)
+		w.logger.Error("Failed to parse device action template", err)
 		return
 	}
 
-	action.prepArgs = make(map[string]interface{})
-	if nil != action.Args {
-		tmp, err := yaml.Marshal(action.Args)
+	if !action.templated {
+		action.prepArgs, err = prepareArgs(action.Args, action.cmd)
 		if err != nil {
-			w.logger.Error("Failed to parse device action: marshal error", err)
+			w.logger.Error("Failed to validate action properties", err)
 			return
 		}
+	}
+
+	w.deviceActions = append(w.deviceActions, action)
+}
 
-		err = yaml.Unmarshal(tmp, action.prepArgs)
+// Returns device action arguments.
+// Templated arguments are rendered with trigger payload.
+func (w *wrapper) getDeviceArgs(action *triggerActionDevice, data *actionTemplateData) (map[string]interface{}, error) {
+	if !action.templated {
+		return action.prepArgs, nil
+	}
+
+	args, err := walkArgs(action.Args, func(arg string) (interface{}, error) {
+		rendered, err := renderTemplate(template.New("arg"), arg, data)
 		if err != nil {
-			w.logger.Error("Failed to parse device action: un-marshal error", err)
-			return
+			return nil, err
 		}
+
+		return coerceScalar(rendered), nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	w.deviceActions = append(w.deviceActions, action)
+	return prepareArgs(args, action.cmd)
+}
+
+// Converts rendered template argument into a number or a boolean.
+// Payload-derived text is never parsed as a structure, anything else is passed as a string.
+func coerceScalar(rendered string) interface{} {
+	value := strings.TrimSpace(rendered)
+	if i, err := strconv.Atoi(value); nil == err {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(value, 64); nil == err && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+
+	// Single letter booleans are skipped, since they are more likely a text.
+	if b, err := strconv.ParseBool(value); nil == err && len(value) > 1 {
+		return b
+	}
+
+	return rendered
+}
+
+// Returns notification message.
+// Templated message is rendered with trigger payload.
+func (w *wrapper) getNotificationMessage(action *triggerActionNotification, data *actionTemplateData) string {
+	if nil == action.messageTpl {
+		return action.Message
+	}
+
+	buf := &bytes.Buffer{}
+	err := action.messageTpl.Execute(buf, data)
+	if err != nil {
+		w.logger.Error("Failed to render notification message", err)
+		return action.Message
+	}
+
+	return buf.String()
 }
 
 // Loads notification action.
@@ -235,6 +369,14 @@ func (w *wrapper) loadNotificationAction(data map[string]interface{}) {
 		action.Message = fmt.Sprintf("go-home trigger %s[%s] went on", w.name, w.ID)
 	}
 
+	if strings.Contains(action.Message, "{{") {
+		action.messageTpl, err = template.New("message").Parse(action.Message)
+		if err != nil {
+			w.logger.Error("Failed to parse notification message template", err)
+			return
+		}
+	}
+
 	w.notificationActions = append(w.notificationActions, action)
 }
 
@@ -274,13 +416,31 @@ func (w *wrapper) processTriggers() {
 }
 
 // Processes actual event.
-// TODO: Remove nolint after scripts implementation.
-func (w *wrapper) triggered(msg interface{}) { //nolint:unparam
+// Message is passed to actions templates as a payload.
+func (w *wrapper) triggered(msg interface{}) {
+	w.unloadMutex.RLock()
+	defer w.unloadMutex.RUnlock()
+	if w.isUnloaded {
+		w.logger.Debug("Triggered but trigger is unloaded")
+		return
+	}
+
 	if !w.isInActiveTimeWindow() {
 		w.logger.Debug("Triggered but outside of active window")
 		return
 	}
 
+	if !w.isInActiveMode() {
+		w.logger.Debug("Triggered but home mode is not active")
+		return
+	}
+
+	if !w.isPresenceMatched() {
+		w.logger.Debug("Triggered but persons presence doesn't match")
+		return
+	}
+
+	metrics.TriggerFired.Inc(w.ID)
 	w.storage.State(&common.MsgDeviceUpdate{
 		ID:        w.ID,
 		Name:      w.name,
@@ -292,18 +452,46 @@ func (w *wrapper) triggered(msg interface{}) { //nolint:unparam
 	w.triggeredAt = utils.TimeNow()
 	w.fanOut.ChannelInTriggerUpdates() <- w.ID
 
+	data := &actionTemplateData{ID: w.ID, Name: w.name, Payload: msg}
 	for _, v := range w.deviceActions {
+		args, err := w.getDeviceArgs(v, data)
+		if err != nil {
+			w.logger.Error("Failed to render trigger device action", err,
+				"target_id", v.Entity, common.LogDeviceCommandToken, v.Command)
+			continue
+		}
+
 		w.logger.Info("Invoking trigger device action",
 			"target_id", v.Entity, common.LogDeviceCommandToken, v.Command)
-		w.server.InternalCommandInvokeDeviceCommand(v.prepEntity, v.cmd, v.prepArgs)
+		w.server.InternalCommandInvokeDeviceCommand(v.prepEntity, v.cmd, args)
 	}
 
 	for _, v := range w.notificationActions {
 		w.logger.Info("Sending notification action", "target_id", v.Entity)
-		w.server.SendNotificationCommand(v.prepEntity, v.Message)
+		w.server.SendNotificationCommand(v.prepEntity, w.getNotificationMessage(v, data))
 	}
 }
 
+// Determines whether current home mode allows trigger to fire.
+func (w *wrapper) isInActiveMode() bool {
+	if 0 == len(w.modes) {
+		return true
+	}
+
+	return helpers.SliceContainsString(w.modes, w.server.GetHomeMode())
+}
+
+// Determines whether all persons have required presence.
+func (w *wrapper) isPresenceMatched() bool {
+	for k, v := range w.presence {
+		if w.server.GetPersonPresence(k) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Determines whether local time is within operation hours.
 func (w *wrapper) isInActiveTimeWindow() bool {
 	if !w.activeWindow {
@@ -346,3 +534,90 @@ func (w *wrapper) loadActiveWindow(window string) {
 		w.activeWindow = true
 	}
 }
+
+// Converts device action arguments into the command format.
+func prepareArgs(args interface{}, cmd enums.Command) (map[string]interface{}, error) {
+	args, err := helpers.CommandPropertyFixYaml(args, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	if nil == args {
+		return result, nil
+	}
+
+	tmp, err := yaml.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(tmp, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Renders text template.
+func renderTemplate(tpl *template.Template, text string, data interface{}) (string, error) {
+	tpl, err := tpl.Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	err = tpl.Execute(buf, data)
+	return buf.String(), err
+}
+
+// Walks through arguments and replaces templated strings with a callback result.
+func walkArgs(x interface{}, callback func(string) (interface{}, error)) (interface{}, error) {
+	switch v := x.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+
+		return callback(v)
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(v))
+		for k, item := range v {
+			r, err := walkArgs(item, callback)
+			if err != nil {
+				return nil, err
+			}
+
+			result[k] = r
+		}
+
+		return result, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			r, err := walkArgs(item, callback)
+			if err != nil {
+				return nil, err
+			}
+
+			result[k] = r
+		}
+
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := walkArgs(item, callback)
+			if err != nil {
+				return nil, err
+			}
+
+			result[i] = r
+		}
+
+		return result, nil
+	}
+
+	return x, nil
+}
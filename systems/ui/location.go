@@ -18,6 +18,7 @@ type location struct {
 
 	name      string
 	icon      string
+	parent    string
 	devices   []string
 	unmatched []string
 
@@ -43,10 +44,17 @@ func (l *location) Icon() string {
 	return l.icon
 }
 
-// Groups settings.
+// Parent returns parent location name.
+// Empty string is returned for the top-level locations.
+func (l *location) Parent() string {
+	return l.parent
+}
+
+// Location settings.
 type locationSettings struct {
 	Name    string   `yaml:"name"`
 	Icon    string   `yaml:"icon"`
+	Parent  string   `yaml:"parent"`
 	Devices []string `yaml:"devices"`
 }
 
@@ -72,6 +80,7 @@ func NewLocationProvider(ctor *ConstructLocation) (providers.ILocationProvider,
 		name:       settings.Name,
 		devicesExp: make([]glob.Glob, 0),
 		icon:       settings.Icon,
+		parent:     settings.Parent,
 	}
 
 	for _, v := range settings.Devices {
@@ -97,11 +106,13 @@ func (l *location) deviceUpdates() {
 }
 
 // Processes devices update messages.
+// Any update is processed, so devices which were seen before
+// location has been created are matched as well.
 func (l *location) processDeviceUpdates(msg *common.MsgDeviceUpdate) {
 	l.Lock()
 	defer l.Unlock()
 
-	if !msg.FirstSeen || helpers.SliceContainsString(l.unmatched, msg.ID) ||
+	if helpers.SliceContainsString(l.unmatched, msg.ID) ||
 		helpers.SliceContainsString(l.devices, msg.ID) {
 		return
 	}
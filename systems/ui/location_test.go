@@ -24,6 +24,7 @@ func (g *grSuite) SetupTest() {
 system: ui
 provider: location
 name: cabinet loc
+parent: upstairs
 devices:
   - device1
   - device*
@@ -40,14 +41,14 @@ devices:
 	g.prov, _ = NewLocationProvider(ctor)
 }
 
-// Tests empty device message.
+// Tests that not first-seen device is matched as well.
 func (g *grSuite) TestEmptyDevice() {
 	g.f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
 		ID: "device1",
 	}
 
 	time.Sleep(1 * time.Second)
-	assert.Equal(g.T(), 0, len(g.prov.Devices()))
+	assert.Equal(g.T(), 1, len(g.prov.Devices()))
 }
 
 // Tests double invoke.
@@ -89,6 +90,11 @@ func (g *grSuite) TestID() {
 	assert.Equal(g.T(), "cabinet loc", g.prov.ID())
 }
 
+// Tests parent.
+func (g *grSuite) TestParent() {
+	assert.Equal(g.T(), "upstairs", g.prov.Parent())
+}
+
 // Tests location provider.
 func TestGroupProvider(t *testing.T) {
 	suite.Run(t, new(grSuite))
@@ -0,0 +1,32 @@
+package webhook
+
+import "fmt"
+
+// ErrInvalidSettings defines webhook settings validation error.
+type ErrInvalidSettings struct {
+	Name string
+}
+
+// Error formats output.
+func (e *ErrInvalidSettings) Error() string {
+	return fmt.Sprintf("webhook %s has invalid settings", e.Name)
+}
+
+// ErrInvalidBody defines error when rendered body is not a valid JSON.
+type ErrInvalidBody struct {
+}
+
+// Error formats output.
+func (*ErrInvalidBody) Error() string {
+	return "rendered body is not a valid JSON"
+}
+
+// ErrBadResponse defines non-successful endpoint response.
+type ErrBadResponse struct {
+	Code int
+}
+
+// Error formats output.
+func (e *ErrBadResponse) Error() string {
+	return fmt.Sprintf("endpoint responded with %d", e.Code)
+}
@@ -0,0 +1,550 @@
+// Package webhook contains outbound webhooks dispatcher.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/logger"
+	"go-home.io/x/server/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Device update event.
+	eventDevice = "device"
+	// Trigger fired event.
+	eventTrigger = "trigger"
+	// Header with event type.
+	headerEvent = "X-Go-Home-Event"
+	// How often changed queue is persisted.
+	persistInterval = 5 * time.Second
+)
+
+// Webhook provider.
+type provider struct {
+	sync.Mutex
+
+	internalID string
+	settings   *settings
+	client     *http.Client
+	body       *template.Template
+	devicesExp []glob.Glob
+	triggerExp []glob.Glob
+	properties []enums.Property
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	logger common.ILoggerProvider
+	fanOut providers.IInternalFanOutProvider
+	store  providers.IPersistentStoreProvider
+
+	deviceSubID  int64
+	triggerSubID int64
+	wake         chan bool
+	stop         chan bool
+	stopOnce     sync.Once
+
+	queue  []*delivery
+	status *providers.WebhookStatus
+
+	saveMutex sync.Mutex
+	dirty     bool
+}
+
+// Webhook settings.
+// All durations are in seconds.
+type settings struct {
+	Name            string            `yaml:"name"`
+	URL             string            `yaml:"url" validate:"required,url"`
+	Method          string            `yaml:"method" validate:"oneof=POST PUT PATCH" default:"POST"`
+	Devices         []string          `yaml:"devices"`
+	Triggers        []string          `yaml:"triggers"`
+	Properties      []string          `yaml:"properties"`
+	Body            string            `yaml:"body"`
+	Headers         map[string]string `yaml:"headers"`
+	Secret          string            `yaml:"secret"`
+	SignatureHeader string            `yaml:"signatureHeader" default:"X-Go-Home-Signature"`
+	Timeout         int               `yaml:"timeout" validate:"gte=1" default:"10"`
+	Retries         int               `yaml:"retries" validate:"gte=0" default:"5"`
+	Backoff         int               `yaml:"backoff" validate:"gte=1" default:"2"`
+	MaxBackoff      int               `yaml:"maxBackoff" validate:"gte=1" default:"300"`
+	QueueSize       int               `yaml:"queueSize" validate:"gte=1,lte=10000" default:"100"`
+}
+
+// Single queued delivery.
+type delivery struct {
+	Event    string `yaml:"event"`
+	Target   string `yaml:"target"`
+	Body     string `yaml:"body"`
+	Time     int64  `yaml:"time"`
+	Attempts int    `yaml:"attempts"`
+}
+
+// Data available in the body template.
+type templateData struct {
+	Event string                 `json:"event"`
+	ID    string                 `json:"id"`
+	Name  string                 `json:"name,omitempty"`
+	Type  string                 `json:"type,omitempty"`
+	State map[string]interface{} `json:"state,omitempty"`
+	Time  int64                  `json:"time"`
+}
+
+// ConstructWebhook has data required for instantiating a new webhook.
+type ConstructWebhook struct {
+	RawConfig []byte
+	Logger    common.ILoggerProvider
+	Validator providers.IValidatorProvider
+	FanOut    providers.IInternalFanOutProvider
+	Store     providers.IPersistentStoreProvider
+}
+
+// NewWebhookProvider creates a new webhook provider.
+func NewWebhookProvider(ctor *ConstructWebhook) (providers.IWebhookProvider, error) {
+	settings := &settings{}
+	err := yaml.Unmarshal(ctor.RawConfig, settings)
+	if err != nil {
+		ctor.Logger.Error("Failed to load webhook", err)
+		return nil, errors.Wrap(err, "yaml un-marshal failed")
+	}
+
+	if !ctor.Validator.Validate(settings) {
+		return nil, &ErrInvalidSettings{Name: settings.Name}
+	}
+
+	logCtor := &logger.ConstructPluginLogger{
+		SystemLogger: ctor.Logger,
+		Provider:     "http",
+		System:       systems.SysWebhook.String(),
+		ExtraFields: map[string]string{
+			common.LogNameToken: settings.Name,
+			common.LogIDToken:   getID(settings.Name),
+		},
+	}
+
+	provider := &provider{
+		internalID: getID(settings.Name),
+		settings:   settings,
+		client:     &http.Client{Timeout: time.Duration(settings.Timeout) * time.Second},
+		properties: make([]enums.Property, 0),
+		backoff:    time.Duration(settings.Backoff) * time.Second,
+		maxBackoff: time.Duration(settings.MaxBackoff) * time.Second,
+		logger:     logger.NewPluginLogger(logCtor),
+		fanOut:     ctor.FanOut,
+		store:      ctor.Store,
+		wake:       make(chan bool, 1),
+		stop:       make(chan bool),
+		queue:      make([]*delivery, 0),
+		status: &providers.WebhookStatus{
+			ID:  getID(settings.Name),
+			URL: settings.URL,
+		},
+	}
+
+	provider.devicesExp = provider.compileGlobs(settings.Devices)
+	provider.triggerExp = provider.compileGlobs(settings.Triggers)
+
+	if "" != settings.Body {
+		provider.body, err = compileBody(settings.Body)
+		if err != nil {
+			provider.logger.Error("Failed to parse webhook body template", err)
+			return nil, &ErrInvalidSettings{Name: settings.Name}
+		}
+	}
+
+	for _, v := range settings.Properties {
+		prop, err := enums.PropertyString(v)
+		if err != nil {
+			provider.logger.Warn("Unknown property, skipping", common.LogDevicePropertyToken, v)
+			continue
+		}
+
+		provider.properties = append(provider.properties, prop)
+	}
+
+	provider.store.Load(provider.internalID, &provider.queue)
+	if len(provider.queue) > settings.QueueSize {
+		provider.queue = provider.queue[len(provider.queue)-settings.QueueSize:]
+	}
+
+	provider.status.Queued = len(provider.queue)
+
+	if 0 != len(provider.devicesExp) {
+		var updates chan *common.MsgDeviceUpdate
+		provider.deviceSubID, updates = provider.fanOut.SubscribeDeviceUpdates()
+		go provider.deviceUpdates(updates)
+	}
+
+	if 0 != len(provider.triggerExp) {
+		var updates chan string
+		provider.triggerSubID, updates = provider.fanOut.SubscribeTriggerUpdates()
+		go provider.triggerUpdates(updates)
+	}
+
+	go provider.deliveryCycle()
+	go provider.persistCycle()
+	provider.notify()
+
+	return provider, nil
+}
+
+// ID returns webhook ID.
+func (p *provider) ID() string {
+	return p.internalID
+}
+
+// Status returns current delivery status.
+func (p *provider) Status() *providers.WebhookStatus {
+	p.Lock()
+	defer p.Unlock()
+
+	status := *p.status
+	return &status
+}
+
+// Unload stops the dispatcher and preserves undelivered events.
+func (p *provider) Unload() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		if 0 != len(p.devicesExp) {
+			p.fanOut.UnSubscribeDeviceUpdates(p.deviceSubID)
+		}
+
+		if 0 != len(p.triggerExp) {
+			p.fanOut.UnSubscribeTriggerUpdates(p.triggerSubID)
+		}
+
+		p.saveQueue()
+	})
+}
+
+// Listens for devices updates.
+func (p *provider) deviceUpdates(updates chan *common.MsgDeviceUpdate) {
+	for msg := range updates {
+		if !isMatched(p.devicesExp, msg.ID) {
+			continue
+		}
+
+		data := &templateData{
+			Event: eventDevice,
+			ID:    msg.ID,
+			Name:  msg.Name,
+			Type:  msg.Type.String(),
+			State: p.filterState(msg.State),
+			Time:  utils.TimeNow(),
+		}
+
+		if 0 == len(data.State) {
+			continue
+		}
+
+		p.enqueue(data)
+	}
+}
+
+// Listens for triggers updates.
+func (p *provider) triggerUpdates(updates chan string) {
+	for id := range updates {
+		if !isMatched(p.triggerExp, id) {
+			continue
+		}
+
+		p.enqueue(&templateData{
+			Event: eventTrigger,
+			ID:    id,
+			Time:  utils.TimeNow(),
+		})
+	}
+}
+
+// Returns device state with only configured properties.
+func (p *provider) filterState(state map[enums.Property]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range state {
+		if 0 != len(p.properties) && !enums.SliceContainsProperty(p.properties, k) {
+			continue
+		}
+
+		result[k.String()] = v
+	}
+
+	return result
+}
+
+// Renders the body and adds it to the queue.
+// Oldest delivery is dropped if queue is full.
+func (p *provider) enqueue(data *templateData) {
+	body, err := p.render(data)
+	if err != nil {
+		p.logger.Error("Failed to render webhook body", err, common.LogIDToken, data.ID)
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.queue) >= p.settings.QueueSize {
+		p.queue = p.queue[1:]
+		p.status.Dropped++
+		p.logger.Warn("Webhook queue is full, dropping oldest event")
+	}
+
+	p.queue = append(p.queue, &delivery{
+		Event:  data.Event,
+		Target: data.ID,
+		Body:   body,
+		Time:   data.Time,
+	})
+
+	p.status.Queued = len(p.queue)
+	p.dirty = true
+	p.notify()
+}
+
+// Renders delivery body.
+func (p *provider) render(data *templateData) (string, error) {
+	if nil == p.body {
+		d, err := json.Marshal(data)
+		return string(d), err
+	}
+
+	buf := &bytes.Buffer{}
+	err := p.body.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		return "", &ErrInvalidBody{}
+	}
+
+	return buf.String(), nil
+}
+
+// Wakes up delivery cycle.
+func (p *provider) notify() {
+	select {
+	case p.wake <- true:
+	default:
+	}
+}
+
+// Delivers queued events one by one, preserving the order.
+func (p *provider) deliveryCycle() {
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.wake:
+		}
+
+		for p.deliverNext() {
+		}
+	}
+}
+
+// Delivers the oldest queued event.
+// Returns false if queue is empty or dispatcher is stopped.
+func (p *provider) deliverNext() bool {
+	p.Lock()
+	if 0 == len(p.queue) {
+		p.Unlock()
+		return false
+	}
+
+	d := p.queue[0]
+	p.Unlock()
+
+	err := p.send(d)
+
+	p.Lock()
+	d.Attempts++
+	if nil == err {
+		p.status.Delivered++
+		p.status.LastSuccess = utils.TimeNow()
+		p.remove(d)
+		p.Unlock()
+		return true
+	}
+
+	p.status.LastFailure = utils.TimeNow()
+	p.status.LastError = err.Error()
+	if d.Attempts > p.settings.Retries {
+		p.logger.Error("Failed to deliver webhook, giving up", err, common.LogIDToken, d.Target)
+		p.status.Failed++
+		p.remove(d)
+		p.Unlock()
+		return true
+	}
+
+	wait := p.getBackoff(d.Attempts)
+	p.Unlock()
+
+	p.logger.Warn("Failed to deliver webhook, will retry", common.LogIDToken, d.Target,
+		"error", err.Error(), "retry_in", wait.String())
+
+	select {
+	case <-p.stop:
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
+// Removes delivery from the queue head, unless it was already dropped.
+func (p *provider) remove(d *delivery) {
+	if 0 == len(p.queue) || p.queue[0] != d {
+		return
+	}
+
+	p.queue = p.queue[1:]
+	p.status.Queued = len(p.queue)
+	p.dirty = true
+}
+
+// Sends a single delivery.
+func (p *provider) send(d *delivery) error {
+	req, err := http.NewRequest(p.settings.Method, p.settings.URL, bytes.NewBufferString(d.Body))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range p.settings.Headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerEvent, d.Event)
+	if "" != p.settings.Secret {
+		req.Header.Set(p.settings.SignatureHeader, sign(p.settings.Secret, d.Body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &ErrBadResponse{Code: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// Returns exponential retry delay.
+func (p *provider) getBackoff(attempts int) time.Duration {
+	wait := p.backoff
+	for i := 1; i < attempts && wait < p.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > p.maxBackoff {
+		wait = p.maxBackoff
+	}
+
+	return wait
+}
+
+// Periodically persists changed queue, so store is not hit on every event.
+func (p *provider) persistCycle() {
+	ticker := time.NewTicker(persistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.saveQueue()
+		}
+	}
+}
+
+// Persists queue snapshot if it was changed.
+// Store is accessed outside of the provider lock.
+func (p *provider) saveQueue() {
+	p.saveMutex.Lock()
+	defer p.saveMutex.Unlock()
+
+	p.Lock()
+	if !p.dirty {
+		p.Unlock()
+		return
+	}
+
+	queue := make([]delivery, len(p.queue))
+	for i, v := range p.queue {
+		queue[i] = *v
+	}
+
+	p.dirty = false
+	p.Unlock()
+
+	p.store.Save(p.internalID, queue)
+}
+
+// Returns HMAC-SHA256 signature of the body.
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body)) // nolint: gosec, errcheck
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Parses body template.
+func compileBody(body string) (*template.Template, error) {
+	return template.New("body").Funcs(template.FuncMap{"json": toJSON}).Parse(body)
+}
+
+// Template function converting value to JSON.
+func toJSON(v interface{}) (string, error) {
+	d, err := json.Marshal(v)
+	return string(d), err
+}
+
+// Compiles globs, skipping invalid.
+func (p *provider) compileGlobs(patterns []string) []glob.Glob {
+	result := make([]glob.Glob, 0)
+	for _, v := range patterns {
+		exp, err := glob.Compile(v)
+		if err != nil {
+			p.logger.Error("Failed to compile webhook regexp, skipping", err)
+			continue
+		}
+
+		result = append(result, exp)
+	}
+
+	return result
+}
+
+// Checks whether ID matches any of globs.
+func isMatched(exps []glob.Glob, id string) bool {
+	for _, v := range exps {
+		if v.Match(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Converts ID.
+func getID(name string) string {
+	return fmt.Sprintf("webhook.%s", utils.NormalizeDeviceName(name))
+}
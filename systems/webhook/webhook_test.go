@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-home.io/x/server/mocks"
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/plugins/device/enums"
+	"go-home.io/x/server/providers"
+	"go-home.io/x/server/utils"
+)
+
+// Fake webhook endpoint.
+type endpoint struct {
+	sync.Mutex
+	server   *httptest.Server
+	bodies   []string
+	headers  []http.Header
+	statuses []int
+}
+
+// Creates a new endpoint which responds with statuses one by one, repeating the last one.
+func newEndpoint(statuses ...int) *endpoint {
+	e := &endpoint{statuses: statuses}
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.Lock()
+		defer e.Unlock()
+
+		data, _ := ioutil.ReadAll(r.Body)
+		e.bodies = append(e.bodies, string(data))
+		e.headers = append(e.headers, r.Header)
+
+		status := e.statuses[0]
+		if len(e.statuses) > 1 {
+			e.statuses = e.statuses[1:]
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	return e
+}
+
+// Returns number of received requests.
+func (e *endpoint) count() int {
+	e.Lock()
+	defer e.Unlock()
+
+	return len(e.bodies)
+}
+
+// Creates a new webhook.
+func getWebhook(t *testing.T, config string, fanOut providers.IInternalFanOutProvider,
+	store providers.IPersistentStoreProvider) providers.IWebhookProvider {
+	ctor := &ConstructWebhook{
+		RawConfig: []byte(config),
+		Logger:    mocks.FakeNewLogger(nil),
+		Validator: utils.NewValidator(mocks.FakeNewLogger(nil)),
+		FanOut:    fanOut,
+		Store:     store,
+	}
+
+	w, err := NewWebhookProvider(ctor)
+	if err != nil {
+		t.Fatal("failed to create webhook", err)
+	}
+
+	return w
+}
+
+// Waits until condition is true.
+func waitFor(t *testing.T, cond func() bool) {
+	for i := 0; i < 300; i++ {
+		if cond() {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("condition is not met")
+}
+
+// Tests device updates delivery with a custom body, property filter and signature.
+func TestDeviceDelivery(t *testing.T) {
+	e := newEndpoint(http.StatusOK)
+	defer e.server.Close()
+
+	config := fmt.Sprintf(`
+system: webhook
+name: lights
+url: %s
+devices:
+  - light.*
+properties:
+  - on
+secret: secret
+body: '{"light": "{{ .ID }}", "on": {{ json (index .State "on") }}}'
+`, e.server.URL)
+
+	f := mocks.FakeNewFanOut()
+	w := getWebhook(t, config, f, mocks.FakeNewPersistentStore())
+	defer w.Unload()
+
+	f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:    "sensor.door",
+		State: map[enums.Property]interface{}{enums.PropOn: true},
+	}
+	f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:    "light.bulb",
+		State: map[enums.Property]interface{}{enums.PropBrightness: 10},
+	}
+	f.ChannelInDeviceUpdates() <- &common.MsgDeviceUpdate{
+		ID:    "light.bulb",
+		Type:  enums.DevLight,
+		State: map[enums.Property]interface{}{enums.PropOn: true, enums.PropBrightness: 10},
+	}
+
+	waitFor(t, func() bool { return w.Status().Delivered == 1 })
+	time.Sleep(50 * time.Millisecond)
+
+	if 1 != e.count() {
+		t.Fatalf("wrong number of deliveries: %d", e.count())
+	}
+
+	body := `{"light": "light.bulb", "on": true}`
+	if e.bodies[0] != body {
+		t.Errorf("wrong body: %s", e.bodies[0])
+	}
+
+	if e.headers[0].Get("X-Go-Home-Signature") != sign("secret", body) {
+		t.Error("wrong signature")
+	}
+
+	if e.headers[0].Get(headerEvent) != eventDevice {
+		t.Error("wrong event header")
+	}
+
+	if 0 != w.Status().Queued || "webhook.lights" != w.ID() {
+		t.Error("wrong status")
+	}
+}
+
+// Tests triggers delivery with a default body.
+func TestTriggerDelivery(t *testing.T) {
+	e := newEndpoint(http.StatusNoContent)
+	defer e.server.Close()
+
+	config := fmt.Sprintf(`
+name: triggers
+url: %s
+triggers:
+  - "*"
+`, e.server.URL)
+
+	f := mocks.FakeNewFanOut()
+	w := getWebhook(t, config, f, mocks.FakeNewPersistentStore())
+	defer w.Unload()
+
+	f.ChannelInTriggerUpdates() <- "trigger1"
+	waitFor(t, func() bool { return w.Status().Delivered == 1 && 1 == e.count() })
+
+	data := &templateData{}
+	err := json.Unmarshal([]byte(e.bodies[0]), data)
+	if err != nil || data.Event != eventTrigger || data.ID != "trigger1" || 0 == data.Time {
+		t.Errorf("wrong body: %s", e.bodies[0])
+	}
+
+	if "" != e.headers[0].Get("X-Go-Home-Signature") {
+		t.Error("signature without a secret")
+	}
+}
+
+// Tests retries.
+func TestRetry(t *testing.T) {
+	e := newEndpoint(http.StatusInternalServerError, http.StatusOK)
+	defer e.server.Close()
+
+	config := fmt.Sprintf(`
+name: retry
+url: %s
+triggers:
+  - trigger1
+retries: 1
+backoff: 1
+`, e.server.URL)
+
+	f := mocks.FakeNewFanOut()
+	w := getWebhook(t, config, f, mocks.FakeNewPersistentStore())
+	defer w.Unload()
+
+	f.ChannelInTriggerUpdates() <- "trigger1"
+	waitFor(t, func() bool { return w.Status().Delivered == 1 })
+
+	status := w.Status()
+	if 2 != e.count() || 0 != status.Failed || "" == status.LastError || 0 == status.LastFailure {
+		t.Errorf("wrong status: %+v", status)
+	}
+}
+
+// Tests giving up after retries.
+func TestGiveUp(t *testing.T) {
+	e := newEndpoint(http.StatusBadGateway)
+	defer e.server.Close()
+
+	config := fmt.Sprintf(`
+name: fail
+url: %s
+triggers:
+  - trigger1
+retries: 1
+backoff: 1
+`, e.server.URL)
+
+	f := mocks.FakeNewFanOut()
+	w := getWebhook(t, config, f, mocks.FakeNewPersistentStore())
+	defer w.Unload()
+
+	f.ChannelInTriggerUpdates() <- "trigger1"
+	waitFor(t, func() bool { return w.Status().Failed == 1 })
+
+	status := w.Status()
+	if 2 != e.count() || 0 != status.Queued || 0 != status.Delivered || (&ErrBadResponse{Code: 502}).Error() != status.LastError {
+		t.Errorf("wrong status: %+v", status)
+	}
+}
+
+// Tests bounded queue persistence between restarts.
+func TestQueuePersistence(t *testing.T) {
+	e := newEndpoint(http.StatusInternalServerError)
+	defer e.server.Close()
+
+	config := fmt.Sprintf(`
+name: queue
+url: %s
+triggers:
+  - "*"
+queueSize: 2
+backoff: 300
+`, e.server.URL)
+
+	f := mocks.FakeNewFanOut()
+	store := mocks.FakeNewPersistentStore()
+	w := getWebhook(t, config, f, store)
+
+	for _, v := range []string{"trigger1", "trigger2", "trigger3"} {
+		w.(*provider).enqueue(&templateData{Event: eventTrigger, ID: v})
+	}
+
+	var saved []*delivery
+	if store.Load("webhook.queue", &saved) {
+		t.Error("queue was saved on enqueue")
+	}
+
+	status := w.Status()
+	w.Unload()
+	if 2 != status.Queued || 1 != status.Dropped {
+		t.Fatalf("wrong status: %+v", status)
+	}
+
+	if !store.Load("webhook.queue", &saved) || 2 != len(saved) {
+		t.Fatal("queue was not saved on unload")
+	}
+
+	w = getWebhook(t, config, f, store)
+	defer w.Unload()
+
+	queue := w.(*provider).queue
+	if 2 != w.Status().Queued || "trigger2" != queue[0].Target || "trigger3" != queue[1].Target {
+		t.Error("queue was not restored")
+	}
+}
+
+// Tests invalid configs.
+func TestInvalidConfig(t *testing.T) {
+	configs := []string{
+		"name: test",
+		"name: test\nurl: http://localhost\nmethod: GET",
+		"name: test\nurl: http://localhost\nbody: '{{ .ID '",
+		"name: [",
+	}
+
+	for _, v := range configs {
+		_, err := NewWebhookProvider(&ConstructWebhook{
+			RawConfig: []byte(v),
+			Logger:    mocks.FakeNewLogger(nil),
+			Validator: utils.NewValidator(mocks.FakeNewLogger(nil)),
+			FanOut:    mocks.FakeNewFanOut(),
+			Store:     mocks.FakeNewPersistentStore(),
+		})
+
+		if nil == err {
+			t.Errorf("config didn't fail: %s", v)
+		}
+	}
+}
+
+// Tests rendering of a template which produces invalid JSON.
+func TestInvalidBody(t *testing.T) {
+	p := &provider{}
+	p.body, _ = compileBody("{{ .ID }}")
+	_, err := p.render(&templateData{ID: "test"})
+	if nil == err {
+		t.Fail()
+	}
+}
+
+// Tests exponential backoff.
+func TestBackoff(t *testing.T) {
+	p := &provider{backoff: 2 * time.Second, maxBackoff: 10 * time.Second}
+	expected := map[int]time.Duration{1: 2 * time.Second, 2: 4 * time.Second, 3: 8 * time.Second,
+		4: 10 * time.Second, 30: 10 * time.Second}
+	for k, v := range expected {
+		if p.getBackoff(k) != v {
+			t.Errorf("wrong backoff for %d attempts", k)
+		}
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems"
+	"go-home.io/x/server/systems/metrics"
 	"gopkg.in/yaml.v2"
 )
 
@@ -84,6 +85,20 @@ func (l *pluginLoader) UpdateLogger(logger common.ILoggerProvider) {
 // Returns main interface implementation which should be casted to package interface.
 //noinspection GoUnhandledErrorResult
 func (l *pluginLoader) LoadPlugin(request *providers.PluginLoadRequest) (interface{}, error) {
+	start := time.Now()
+	loaded, err := l.load(request)
+
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+
+	metrics.PluginLoadDuration.Since(start, request.SystemType.String(), request.PluginProvider, status)
+	return loaded, err
+}
+
+// Loads plugin from cache or from the .so file.
+func (l *pluginLoader) load(request *providers.PluginLoadRequest) (interface{}, error) {
 	pKey := getPluginKey(request.SystemType, request.PluginProvider)
 	if method, ok := l.loadedPlugins[pKey]; ok {
 		l.logger.Info("Loading plugin from cache", common.LogSystemToken, logSystem, logPluginToken, pKey)
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-home.io/x/server/plugins/common"
+	"go-home.io/x/server/systems/metrics"
+)
+
+// Starts optional metrics listener.
+// Worker has no other HTTP endpoints, so metrics are served only if port is configured.
+func (w *GoHomeWorker) startMetrics() {
+	port := w.Settings.WorkerSettings().MetricsPort
+	if 0 == port {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+
+	go func() {
+		w.Logger.Info("Starting metrics listener", common.LogSystemToken, logSystem, "port", strconv.Itoa(port))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			w.Logger.Error("Failed to start metrics listener", err, common.LogSystemToken, logSystem)
+		}
+	}()
+
+	w.shutdown.Add("metrics", func(ctx context.Context) {
+		srv.Shutdown(ctx) // nolint: gosec, errcheck
+	})
+}
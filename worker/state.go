@@ -14,6 +14,7 @@ import (
 	"go-home.io/x/server/systems/api"
 	"go-home.io/x/server/systems/bus"
 	"go-home.io/x/server/systems/device"
+	"go-home.io/x/server/systems/metrics"
 	"go-home.io/x/server/utils"
 )
 
@@ -23,6 +24,8 @@ type IWorkerStateProvider interface {
 	DevicesAssignmentMessage(*bus.DeviceAssignmentMessage)
 	// Processing device command message.
 	DevicesCommandMessage(*bus.DeviceCommandMessage)
+	// Unloading all devices and APIs.
+	Unload()
 }
 
 var (
@@ -114,7 +117,51 @@ func (w *workerState) DevicesCommandMessage(msg *bus.DeviceCommandMessage) {
 		return
 	}
 
+	start := time.Now()
 	wrapper.InvokeCommand(msg.Command, msg.Payload)
+	metrics.DeviceCommandDuration.Since(start, msg.Command.String())
+}
+
+// Unload synchronously unloads all APIs and then all devices.
+// Used during shutdown, so unload timeout is not fatal.
+func (w *workerState) Unload() {
+	w.mutex.Lock()
+	w.dictMutex.Lock()
+
+	w.Logger.Info("Unloading all devices", common.LogSystemToken, logSystem)
+	w.lastAssignment = make([]string, 0)
+	w.failedDevices = nil
+	w.failedCount = 0
+
+	apis := make([]providers.ILoadedProvider, 0, len(w.extendedAPIs))
+	for k, v := range w.extendedAPIs {
+		apis = append(apis, v)
+		delete(w.extendedAPIs, k)
+	}
+
+	devices := make([]providers.ILoadedProvider, 0, len(w.devices))
+	for k, v := range w.devices {
+		devices = append(devices, v)
+		delete(w.devices, k)
+	}
+
+	w.dictMutex.Unlock()
+	w.mutex.Unlock()
+
+	for _, v := range [][]providers.ILoadedProvider{apis, devices} {
+		wg := sync.WaitGroup{}
+		wg.Add(len(v))
+		for _, p := range v {
+			go func(pr providers.ILoadedProvider) {
+				defer wg.Done()
+				pr.Unload()
+			}(p)
+		}
+
+		if !waitWithTimeout(&wg, deviceUnloadTimeout) {
+			w.Logger.Warn("Failed to unload some providers in time", common.LogSystemToken, logSystem)
+		}
+	}
 }
 
 // Periodic checks to determine whether master is active.
@@ -2,15 +2,15 @@
 package worker
 
 import (
-	"os"
-	"os/signal"
+	"context"
+	"fmt"
 	"strconv"
-	"syscall"
 
 	busPlugin "go-home.io/x/server/plugins/bus"
 	"go-home.io/x/server/plugins/common"
 	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems/bus"
+	"go-home.io/x/server/systems/shutdown"
 )
 
 const (
@@ -26,7 +26,9 @@ type GoHomeWorker struct {
 
 	workerChan chan busPlugin.RawMessage
 
-	state IWorkerStateProvider
+	state        IWorkerStateProvider
+	shutdown     providers.IShutdownProvider
+	discoveryJob int
 }
 
 // NewWorker constructs a go-home worker.
@@ -40,7 +42,8 @@ func NewWorker(settings providers.ISettingsProvider) (*GoHomeWorker, error) {
 
 		workerChan: make(chan busPlugin.RawMessage, 20),
 
-		state: newWorkerState(settings),
+		state:    newWorkerState(settings),
+		shutdown: shutdown.NewShutdownProvider(&shutdown.ConstructShutdown{Logger: settings.SystemLogger()}),
 	}
 
 	return &worker, nil
@@ -52,7 +55,7 @@ func (w *GoHomeWorker) Start() {
 	w.busStart()
 
 	w.sendDiscovery(true)
-	_, err := w.Settings.Cron().AddFunc("@every 1m", func() {
+	job, err := w.Settings.Cron().AddFunc("@every 1m", func() {
 		w.sendDiscovery(false)
 	})
 
@@ -60,10 +63,35 @@ func (w *GoHomeWorker) Start() {
 		w.Logger.Fatal("Failed to start discovery routine", err)
 	}
 
+	w.discoveryJob = job
+	w.registerShutdown()
+	w.startMetrics()
+
 	w.Logger.Info("Successfully started go-home worker",
 		"max_devices", strconv.Itoa(w.Settings.WorkerSettings().MaxDevices))
 
-	w.busCycle()
+	go w.busCycle()
+	w.shutdown.Wait()
+}
+
+// Registers worker shutdown steps.
+// Worker stops receiving messages, unloads everything and
+// notifies master, so devices are re-balanced without waiting for the stale timeout.
+func (w *GoHomeWorker) registerShutdown() {
+	w.shutdown.Add("bus", func(context.Context) {
+		w.Settings.Cron().RemoveFunc(w.discoveryJob)
+		w.Settings.ServiceBus().Unsubscribe(fmt.Sprintf(busPlugin.ChWorkerFormat, w.Settings.NodeID()))
+	})
+	w.shutdown.Add("devices", func(context.Context) {
+		w.state.Unload()
+	})
+	w.shutdown.Add("storage", func(context.Context) {
+		w.Settings.Storage().Flush()
+	})
+	w.shutdown.Add("leave", func(context.Context) {
+		w.Logger.Info("Notifying master about shutdown", common.LogSystemToken, logSystem)
+		w.Settings.ServiceBus().Publish(busPlugin.ChDiscovery, bus.NewWorkerLeaveMessage(w.Settings.NodeID()))
+	})
 }
 
 // Starting service-bus listeners.
@@ -85,8 +113,6 @@ func (w *GoHomeWorker) sendDiscovery(isFirstStart bool) {
 
 // Processing incoming service-bus messages.
 func (w *GoHomeWorker) busCycle() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	for {
 		select {
 		case msg := <-w.workerChan:
@@ -95,9 +121,8 @@ func (w *GoHomeWorker) busCycle() {
 			w.state.DevicesAssignmentMessage(assign)
 		case cmd := <-w.MessageParser.GetDeviceCommandMessageChan():
 			go w.state.DevicesCommandMessage(cmd)
-		case <-c:
-			w.Logger.Info("Received stop command, exiting", common.LogSystemToken, logSystem)
-			os.Exit(0)
+		case <-w.shutdown.Context().Done():
+			return
 		}
 	}
 }
@@ -2,13 +2,18 @@ package worker
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go-home.io/x/server/mocks"
 	busPlugin "go-home.io/x/server/plugins/bus"
+	"go-home.io/x/server/providers"
 	"go-home.io/x/server/systems/bus"
 	"go-home.io/x/server/utils"
 )
@@ -80,3 +85,84 @@ func (d *dSuite) TestCommand() {
 func TestWorker(t *testing.T) {
 	suite.Run(t, new(dSuite))
 }
+
+// Tests graceful worker shutdown.
+func TestWorkerShutdown(t *testing.T) {
+	settings := mocks.FakeNewSettings(nil, true, nil, nil)
+	left := false
+	settings.(mocks.IFakeSettings).AddSBCallback(func(i ...interface{}) {
+		if m, ok := i[0].(*bus.WorkerLeaveMessage); ok {
+			left = settings.NodeID() == m.NodeID
+		}
+	})
+
+	s := &fakeSwitch{}
+	settings.(mocks.IFakeSettings).AddLoader(s)
+	w, _ := NewWorker(settings)
+
+	stopped := make(chan bool)
+	go func() {
+		w.Start()
+		close(stopped)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	w.workerChan <- busPlugin.RawMessage{Body: []byte(fmt.Sprintf(`
+{ 
+"mt": "device_assignment",
+"d": [
+	{"t": "switch", "n": "test", "a": false}
+],
+"st": %d 
+}
+`, utils.TimeNow()))}
+
+	time.Sleep(1 * time.Second)
+	require.True(t, s.loadCalled, "load")
+
+	w.shutdown.Shutdown()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "worker didn't stop")
+	}
+
+	assert.True(t, s.unloadCalled, "unload")
+	assert.True(t, left, "leave message")
+}
+
+// Settings with configured metrics port.
+type metricsSettings struct {
+	providers.ISettingsProvider
+	port int
+}
+
+func (s *metricsSettings) WorkerSettings() *providers.WorkerSettings {
+	return &providers.WorkerSettings{MetricsPort: s.port}
+}
+
+// Tests worker metrics listener.
+func TestWorkerMetrics(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close() // nolint: errcheck, gosec
+
+	w, _ := NewWorker(&metricsSettings{
+		ISettingsProvider: mocks.FakeNewSettings(nil, true, nil, nil),
+		port:              port,
+	})
+	w.startMetrics()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port))
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close() // nolint: errcheck, gosec
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "# TYPE gohome_bus_messages_total counter")
+
+	w.shutdown.Shutdown()
+	_, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port))
+	assert.Error(t, err, "stopped")
+}